@@ -0,0 +1,112 @@
+// mailpreview 按配置的搜索条件列出各邮箱匹配的邮件，不下载附件、不标记已读
+//
+// 用法：
+//
+//	go run ./cmd/mailpreview -config ./configs/mail_attacher_config.yaml -search ./search.yaml -provider qq -limit 20
+//
+// -search 指定的YAML文件内容为一个search条件（格式同email_attacher.providers[].search），会覆盖所有服务商的搜索条件
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	emailattacher "easyHR/internal/email/email-attacher"
+	"easyHR/internal/email/email-attacher/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+type mainConfig struct {
+	EmailAttacher config.AppConfig `yaml:"email_attacher"`
+}
+
+func main() {
+	configPath := flag.String("config", "./configs/mail_attacher_config.yaml", "配置文件路径")
+	searchPath := flag.String("search", "", "搜索条件YAML文件，覆盖配置中的search")
+	provider := flag.String("provider", "", "只预览指定类型的服务商")
+	limit := flag.Int("limit", 50, "每个服务商最多列出的邮件数，<=0不限")
+	flag.Parse()
+
+	if err := run(*configPath, *searchPath, *provider, *limit); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, searchPath, provider string, limit int) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("加载配置失败：%w", err)
+	}
+
+	if searchPath != "" {
+		search, err := loadSearch(searchPath)
+		if err != nil {
+			return fmt.Errorf("加载搜索条件失败：%w", err)
+		}
+		for i := range cfg.Providers {
+			cfg.Providers[i].Search = search
+		}
+	}
+	if provider != "" {
+		var providers []config.ProviderConfig
+		for _, p := range cfg.Providers {
+			if strings.EqualFold(p.Type, provider) {
+				providers = append(providers, p)
+			}
+		}
+		if len(providers) == 0 {
+			return fmt.Errorf("配置中没有类型为%s的服务商", provider)
+		}
+		cfg.Providers = providers
+	}
+
+	results, err := emailattacher.Preview(cfg, limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, res := range results {
+		fmt.Fprintf(w, "== %s（%s）\n", res.Provider, res.Username)
+		if res.Err != nil {
+			fmt.Fprintf(w, "搜索失败：%v\n\n", res.Err)
+			continue
+		}
+		fmt.Fprintf(w, "匹配%d封\n", len(res.Emails))
+		fmt.Fprintln(w, "UID\t日期\t已读\t发件人\t主题")
+		for _, e := range res.Emails {
+			fmt.Fprintf(w, "%d\t%s\t%t\t%s\t%s\n", e.ID, e.SentAt.Format("2006-01-02 15:04"), e.IsRead, e.From, e.Subject)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func loadConfig(path string) (*config.AppConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg mainConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg.EmailAttacher, nil
+}
+
+func loadSearch(path string) (*config.SearchConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var search config.SearchConfig
+	if err := yaml.Unmarshal(data, &search); err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
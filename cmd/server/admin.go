@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"easyHR/internal/agent"
+	"easyHR/internal/ranking"
+	"easyHR/pkg/logger"
+)
+
+// AdminConfig 管理接口配置，addr为空时不启动
+type AdminConfig struct {
+	Addr  string `yaml:"addr"`  // 监听地址，建议只监听内网，如127.0.0.1:8081
+	Token string `yaml:"token"` // 请求需携带的Bearer令牌，为空时不校验
+}
+
+// agentAdmin 管理接口使用的Agent操作，由agent.AiAgentManager实现
+type agentAdmin interface {
+	RecordHRDecision(ctx context.Context, candidateKey string, advance bool) error
+	ExperimentReport(ctx context.Context) (agent.ExperimentReport, error)
+	RecalibrateScores(ctx context.Context, since time.Time, keys ...agent.ScoreKey) error
+}
+
+// rankingAdmin 管理接口使用的岗位排名查询，由ranking.Ranker实现
+type rankingAdmin interface {
+	Jobs() []string
+	Ranking(jobID string) []ranking.Entry
+	Shortlist(jobID string, n int) []ranking.Entry
+}
+
+// cvImporter 管理接口使用的批量导入，由bulkImporter实现
+type cvImporter interface {
+	Import(dir string) (int, error)
+}
+
+// importRequest POST /cvs/import的请求体
+type importRequest struct {
+	Dir string `json:"dir"` // 相对批量导入根目录的路径，为空时导入整个根目录
+}
+
+// importResponse POST /cvs/import的返回
+type importResponse struct {
+	Files int `json:"files"` // 已提交的文件数
+}
+
+// decisionRequest POST /experiments/decisions的请求体
+type decisionRequest struct {
+	CandidateID string `json:"candidate_id"` // 与分析时的候选人ID一致
+	Advance     bool   `json:"advance"`      // 是否进入面试/录用
+}
+
+// recalibrateRequest POST /scores/recalibrate的请求体，均可省略
+type recalibrateRequest struct {
+	Since time.Time        `json:"since"` // 只使用该时间之后的样本（RFC3339），为空时使用全部样本
+	Keys  []agent.ScoreKey `json:"keys"`  // 要重建的分布，为空时重建所有分布
+}
+
+// newAdminHandler 管理接口：
+//
+//	POST /experiments/decisions 记录HR对候选人的决定，用于评估A/B实验各组与HR决定的一致率
+//	GET  /experiments/report    当前实验各组的对比报告
+//	POST /scores/recalibrate    根据保存的评分样本重建评分分布（如更换模型或提示词后丢弃旧样本）
+//	GET  /rankings              已有排名的岗位ID
+//	GET  /rankings/{job}        岗位的完整排名
+//	GET  /rankings/{job}/shortlist?n=  岗位通过硬性筛选的前n名（面试候选名单），n省略时使用配置的人数
+//	POST /cvs/import            批量导入目录中的简历，入库后按批量导入优先级（低于邮件投递）分析
+func newAdminHandler(a agentAdmin, rankings rankingAdmin, importer cvImporter, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /experiments/decisions", func(w http.ResponseWriter, r *http.Request) {
+		var req decisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "请求体格式错误: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.CandidateID) == "" {
+			http.Error(w, "candidate_id不能为空", http.StatusBadRequest)
+			return
+		}
+		if err := a.RecordHRDecision(r.Context(), req.CandidateID, req.Advance); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /experiments/report", func(w http.ResponseWriter, r *http.Request) {
+		report, err := a.ExperimentReport(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, report)
+	})
+	mux.HandleFunc("POST /scores/recalibrate", func(w http.ResponseWriter, r *http.Request) {
+		var req recalibrateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "请求体格式错误: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := a.RecalibrateScores(r.Context(), req.Since, req.Keys...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /rankings", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, rankings.Jobs())
+	})
+	mux.HandleFunc("GET /rankings/{job}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, rankings.Ranking(r.PathValue("job")))
+	})
+	mux.HandleFunc("GET /rankings/{job}/shortlist", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		if v := r.URL.Query().Get("n"); v != "" {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+				http.Error(w, "n必须是正整数", http.StatusBadRequest)
+				return
+			}
+		}
+		writeJSON(w, rankings.Shortlist(r.PathValue("job"), n))
+	})
+	mux.HandleFunc("POST /cvs/import", func(w http.ResponseWriter, r *http.Request) {
+		var req importRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "请求体格式错误: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := importer.Import(req.Dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		writeJSON(w, importResponse{Files: n})
+	})
+	return requireToken(mux, token)
+}
+
+// requireToken 校验Authorization: Bearer <token>
+func requireToken(next http.Handler, token string) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// startAdminServer 在后台启动管理接口，cfg.Addr为空时返回nil
+func startAdminServer(cfg AdminConfig, handler http.Handler, log logger.LoggerV1) *http.Server {
+	if cfg.Addr == "" {
+		return nil
+	}
+	srv := &http.Server{Addr: cfg.Addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("管理接口启动失败: " + err.Error())
+		}
+	}()
+	log.Info("管理接口已启动: " + cfg.Addr)
+	return srv
+}
+
+// stopAdminServer 关闭管理接口，等待处理中的请求结束
+func stopAdminServer(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easyHR/internal/agent"
+	"easyHR/internal/agent/config"
+	"easyHR/internal/cv"
+	"easyHR/internal/ranking"
+	"easyHR/pkg/logger"
+)
+
+// fakeAdmin 记录管理接口的调用
+type fakeAdmin struct {
+	decisions map[string]bool
+	since     time.Time
+	keys      []agent.ScoreKey
+	calibrate int
+}
+
+func (f *fakeAdmin) RecordHRDecision(ctx context.Context, candidateKey string, advance bool) error {
+	f.decisions[candidateKey] = advance
+	return nil
+}
+
+func (f *fakeAdmin) ExperimentReport(ctx context.Context) (agent.ExperimentReport, error) {
+	return agent.ExperimentReport{Experiment: "prompt-v2", AdvanceScore: 70}, nil
+}
+
+func (f *fakeAdmin) RecalibrateScores(ctx context.Context, since time.Time, keys ...agent.ScoreKey) error {
+	f.calibrate++
+	f.since, f.keys = since, keys
+	return nil
+}
+
+func TestAdminHandler(t *testing.T) {
+	admin := &fakeAdmin{decisions: make(map[string]bool)}
+	ranker := ranking.NewRanker(config.RankingConfig{ShortlistSize: 1})
+	for id, score := range map[string]int{"cand-1": 90, "cand-2": 80} {
+		ranker.Update(id, id+".pdf", &agent.AnalysisResult{
+			JobID:   "backend",
+			Primary: []*agent.Message{{Evaluation: &agent.CandidateEvaluation{MatchScore: score}}},
+		})
+	}
+	root := t.TempDir()
+	for _, name := range []string{"campus/张三-后端.pdf", "campus/2026/李四.docx", "campus/.DS_Store"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	submissions := make(chan cv.Submission, 10)
+	importer := &bulkImporter{root: root, ch: submissions, log: logger.NewNopLogger()}
+	srv := httptest.NewServer(newAdminHandler(admin, ranker, importer, "secret"))
+	defer srv.Close()
+
+	do := func(method, path, body, token string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	if resp := do(http.MethodGet, "/experiments/report", "", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if resp := do(http.MethodPost, "/experiments/decisions", `{"advance":true}`, "secret"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without candidate_id, got %d", resp.StatusCode)
+	}
+	if resp := do(http.MethodPost, "/experiments/decisions", `{"candidate_id":"cand-1","advance":true}`, "secret"); resp.StatusCode != http.StatusNoContent || !admin.decisions["cand-1"] {
+		t.Fatalf("decision not recorded: %d, %v", resp.StatusCode, admin.decisions)
+	}
+
+	resp := do(http.MethodGet, "/experiments/report", "", "secret")
+	var report agent.ExperimentReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || report.Experiment != "prompt-v2" {
+		t.Fatalf("unexpected report %+v, %v", report, err)
+	}
+
+	// 不带请求体时重建所有分布
+	if resp := do(http.MethodPost, "/scores/recalibrate", "", "secret"); resp.StatusCode != http.StatusNoContent || admin.calibrate != 1 || !admin.since.IsZero() || len(admin.keys) != 0 {
+		t.Fatalf("unexpected recalibration %d: %+v", resp.StatusCode, admin)
+	}
+	body := `{"since":"2026-09-01T00:00:00Z","keys":[{"agent_key":"primary-1","job_id":"backend","prompt_version":"v2"}]}`
+	if resp := do(http.MethodPost, "/scores/recalibrate", body, "secret"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	want := agent.ScoreKey{AgentKey: "primary-1", JobID: "backend", PromptVersion: "v2"}
+	if admin.calibrate != 2 || !admin.since.Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)) || len(admin.keys) != 1 || admin.keys[0] != want {
+		t.Fatalf("unexpected recalibration %+v", admin)
+	}
+
+	// 岗位排名与面试候选名单
+	var entries []ranking.Entry
+	if err := json.NewDecoder(do(http.MethodGet, "/rankings/backend", "", "secret").Body).Decode(&entries); err != nil || len(entries) != 2 || entries[0].CandidateID != "cand-1" {
+		t.Fatalf("unexpected ranking %+v, %v", entries, err)
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/rankings/backend/shortlist", "", "secret").Body).Decode(&entries); err != nil || len(entries) != 1 {
+		t.Fatalf("unexpected default shortlist %+v, %v", entries, err)
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/rankings/backend/shortlist?n=2", "", "secret").Body).Decode(&entries); err != nil || len(entries) != 2 {
+		t.Fatalf("unexpected shortlist %+v, %v", entries, err)
+	}
+	if resp := do(http.MethodGet, "/rankings/backend/shortlist?n=x", "", "secret"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid n, got %d", resp.StatusCode)
+	}
+
+	// 批量导入：只能导入根目录内的文件，隐藏文件跳过
+	if resp := do(http.MethodPost, "/cvs/import", `{"dir":"../"}`, "secret"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for dir outside root, got %d", resp.StatusCode)
+	}
+	resp = do(http.MethodPost, "/cvs/import", `{"dir":"campus"}`, "secret")
+	var imported importResponse
+	if err := json.NewDecoder(resp.Body).Decode(&imported); err != nil || resp.StatusCode != http.StatusAccepted || imported.Files != 2 {
+		t.Fatalf("unexpected import %d: %+v, %v", resp.StatusCode, imported, err)
+	}
+	for range imported.Files {
+		if sub := <-submissions; !sub.Bulk || (sub.Subject != "张三-后端" && sub.Subject != "李四") {
+			t.Fatalf("unexpected submission %+v", sub)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"easyHR/internal/cv"
+	"easyHR/pkg/logger"
+)
+
+// errImportDisabled 未配置batch.import_dir时拒绝批量导入
+var errImportDisabled = errors.New("未配置批量导入目录batch.import_dir")
+
+// bulkImporter 将导入目录中的简历提交给CV服务，入库后按批量导入优先级分析
+type bulkImporter struct {
+	root string
+	ch   chan<- cv.Submission
+	log  logger.LoggerV1
+}
+
+// Import 提交root下dir目录（含子目录）中的全部文件，返回文件数；dir为相对root的路径，为空时导入root
+// 文件在后台逐个提交，CV服务队列已满时等待，不阻塞请求；以.开头的文件与目录跳过
+func (b *bulkImporter) Import(dir string) (int, error) {
+	if b.root == "" {
+		return 0, errImportDisabled
+	}
+	if dir != "" && !filepath.IsLocal(dir) {
+		return 0, fmt.Errorf("导入目录%s超出批量导入根目录", dir)
+	}
+	start := filepath.Join(b.root, dir)
+	var files []string
+	err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != start {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	go func() {
+		for _, file := range files {
+			// 没有邮件主题，以文件名（如"张三-后端开发"）代替，用于从标题补全档案
+			b.ch <- cv.Submission{
+				FilePath: file,
+				Subject:  strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+				Bulk:     true,
+			}
+		}
+		b.log.Info(fmt.Sprintf("批量导入提交完成: 目录=%s,文件数=%d", start, len(files)))
+	}()
+	return len(files), nil
+}
@@ -8,13 +8,16 @@ import (
 	"easyHR/internal/email/email-attacher/config"
 	emailattacherdomain "easyHR/internal/email/email-attacher/domain"
 	emailreply "easyHR/internal/email/email-reply"
+	"easyHR/internal/email/mailauth"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/spf13/viper"
@@ -22,7 +25,11 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"easyHR/event/aiagentmanager"
+	"easyHR/event/cvreview"
 	"easyHR/internal/agent"
+	"easyHR/internal/agent/tools"
+	"easyHR/internal/ranking"
+	"easyHR/internal/semantic"
 	"easyHR/pkg/logger"
 	"easyHR/pkg/storage"
 )
@@ -33,6 +40,15 @@ type CVHelperConfig struct {
 	Username   string `yaml:"username"`
 	Password   string `yaml:"password"`
 	Collection string `yaml:"collection"`
+	// CandidateCollection 候选人集合，同一人多次投递的简历作为版本关联到同一候选人
+	CandidateCollection string            `yaml:"candidate_collection"`
+	Identity            cv.IdentityConfig `yaml:"identity"`
+	// Injection 简历隐藏文字与提示词注入扫描，高风险简历转人工审核
+	Injection cv.ScanConfig `yaml:"injection"`
+	// Profile 结构化候选人档案抽取，与简历一同保存，供下游复用
+	Profile cv.ProfileConfig `yaml:"profile"`
+	// Convert Word、图片与压缩包附件转换为PDF或文本后再解析
+	Convert cv.ConvertConfig `yaml:"convert"`
 }
 
 // SMTPConfig 存储SMTP服务器连接参数和认证信息
@@ -44,6 +60,8 @@ type SMTPConfig struct {
 	UseTLS     bool   `yaml:"use_tls"`
 	FromEmail  string `yaml:"from_email"`
 	FromName   string `yaml:"from_name"`
+	// OAuth2 使用OAuth2令牌代替密码登录SMTP（可选）
+	OAuth2 *mailauth.OAuth2Config `yaml:"oauth2"`
 }
 
 type RabbitMQConfig struct {
@@ -58,6 +76,8 @@ type MainConfig struct {
 	SMTPConfig     SMTPConfig          `yaml:"smtp_config"`
 	AgentConfig    config2.AgentConfig `yaml:"agent"`
 	RabbitMQConfig RabbitMQConfig      `yaml:"rabbitmq"`
+	Semantic       semantic.Config     `yaml:"semantic"`
+	Admin          AdminConfig         `yaml:"admin"`
 }
 
 func initLogger() logger.LoggerV1 {
@@ -113,6 +133,7 @@ func main() {
 		UseTLS:     mainCfg.SMTPConfig.UseTLS,
 		FromEmail:  mainCfg.SMTPConfig.FromEmail,
 		FromName:   mainCfg.SMTPConfig.FromName,
+		OAuth2:     mainCfg.SMTPConfig.OAuth2,
 	}
 	if err = emailreply.Init(smtpCfg); err != nil {
 		panic(fmt.Sprintf("Failed to initialize email-reply package: %v", err))
@@ -144,9 +165,47 @@ func main() {
 		isPaused bool
 	)
 
+	// 初始化RabbitMQ及Producer
+	ch, err := initRabbitMQ(mainCfg.RabbitMQConfig)
+	if err != nil {
+		panic(err)
+	}
+	defer ch.Close()
+	producer := aiagentmanager.NewRabbitMQProducer(ch)
+	reviewProducer, err := cvreview.NewRabbitMQProducer(ch)
+	if err != nil {
+		panic(err)
+	}
+
 	// 初始化 CV Service
 	cvService := cv.NewCVService(store, mainCfg.CVHelper.Collection, log)
-	cvChan := make(chan string, 100)
+	cvService.SetIdentityResolver(cv.NewIdentityResolver(
+		cv.NewMongoCandidateRepository(store, mainCfg.CVHelper.CandidateCollection),
+		mainCfg.CVHelper.Identity,
+	))
+	if mainCfg.CVHelper.Injection.Enabled {
+		scanner, err := cv.NewInjectionScanner(mainCfg.CVHelper.Injection)
+		if err != nil {
+			panic(err)
+		}
+		cvService.SetInjectionScanner(scanner)
+	}
+	cvService.SetConverter(cv.NewConverter(mainCfg.CVHelper.Convert))
+	cvService.OnUnsupported(func(sub cv.Submission, file cv.Unsupported) {
+		log.Warn(fmt.Sprintf("无法转换的简历附件: 文件=%s,原因=%s,邮件=%s,发件人=%s", file.Name, file.Reason, sub.Subject, sub.Sender))
+		evt := cvreview.UnsupportedAttachmentEvent{
+			Stage:     cvreview.StageConversion,
+			FileName:  file.Name,
+			Reason:    file.Reason,
+			Subject:   sub.Subject,
+			Sender:    sub.Sender,
+			CreatedAt: time.Now(),
+		}
+		if err := reviewProducer.ProduceUnsupportedAttachmentEvent(evt); err != nil {
+			log.Error("发布不支持附件事件失败: " + err.Error())
+		}
+	})
+	cvChan := make(chan cv.Submission, 100)
 
 	cvService.Run(cvChan, func() {
 		// 当 CVHelper 空闲时回调
@@ -162,26 +221,280 @@ func main() {
 		}
 	})
 
-	// 初始化RabbitMQ及Producer
-	ch, err := initRabbitMQ(mainCfg.RabbitMQConfig)
+	// 初始化 AiAgentManager
+	aiAgentManager := agent.NewAiAgentManager(mainCfg.AgentConfig, producer, log)
+	defer aiAgentManager.Stop()
+
+	// 候选人档案：model为true时由extractor角色的Agent抽取，失败时退化为规则抽取
+	if profileCfg := mainCfg.CVHelper.Profile; profileCfg.Enabled {
+		if profileCfg.Model {
+			cvService.SetProfileExtractor(agent.NewProfileExtractor(aiAgentManager))
+		} else {
+			cvService.SetProfileExtractor(cv.RuleExtractor{})
+		}
+	}
+
+	// 评分分布持久化到MongoDB，用于跨模型/提示词版本的评分标准化
+	scoreRepo, err := agent.NewMongoScoreRepository(map[string]interface{}{
+		"conn_url": mainCfg.CVHelper.MongoURI,
+		"db_name":  mainCfg.CVHelper.Database,
+		"username": mainCfg.CVHelper.Username,
+		"password": mainCfg.CVHelper.Password,
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer ch.Close()
-	producer := aiagentmanager.NewRabbitMQProducer(ch)
+	defer scoreRepo.Close(ctx)
+	aiAgentManager.SetScoreRepository(scoreRepo)
 
-	// 初始化 AiAgentManager
-	aiAgentManager := agent.NewAiAgentManager(mainCfg.AgentConfig, producer, log)
-	defer aiAgentManager.Stop()
+	// A/B实验结果与HR决定持久化，用于按实验组生成对比报告
+	if len(mainCfg.AgentConfig.Experiment.Arms) > 0 {
+		experimentRepo, err := agent.NewMongoExperimentRepository(map[string]interface{}{
+			"conn_url": mainCfg.CVHelper.MongoURI,
+			"db_name":  mainCfg.CVHelper.Database,
+			"username": mainCfg.CVHelper.Username,
+			"password": mainCfg.CVHelper.Password,
+		})
+		if err != nil {
+			panic(err)
+		}
+		defer experimentRepo.Close(ctx)
+		aiAgentManager.SetExperimentRepository(experimentRepo)
+	}
+
+	// 审计记录：持久化每次Agent调用，按配置的保留时长自动清理
+	if auditCfg := mainCfg.AgentConfig.Audit; auditCfg.Enabled {
+		messageRepo, err := agent.NewMongoDBRepository(map[string]interface{}{
+			"conn_url": mainCfg.CVHelper.MongoURI,
+			"db_name":  mainCfg.CVHelper.Database,
+			"username": mainCfg.CVHelper.Username,
+			"password": mainCfg.CVHelper.Password,
+			"col_name": auditCfg.Collection,
+		})
+		if err != nil {
+			panic(err)
+		}
+		defer messageRepo.Close(ctx)
+		if err := messageRepo.SetRetention(ctx, auditCfg.Retention); err != nil {
+			panic(err)
+		}
+		aiAgentManager.SetMessageRepository(messageRepo)
+	}
+
+	// 面试题：评审通过的候选人生成针对性面试题，保存到MongoDB并导出Markdown
+	interviewCfg := mainCfg.AgentConfig.Interview
+	if interviewCfg.Enabled {
+		kitRepo, err := agent.NewMongoInterviewKitRepository(map[string]interface{}{
+			"conn_url": mainCfg.CVHelper.MongoURI,
+			"db_name":  mainCfg.CVHelper.Database,
+			"username": mainCfg.CVHelper.Username,
+			"password": mainCfg.CVHelper.Password,
+			"col_name": interviewCfg.Collection,
+		})
+		if err != nil {
+			panic(err)
+		}
+		defer kitRepo.Close(ctx)
+		aiAgentManager.SetInterviewKitRepository(kitRepo)
+		if interviewCfg.MinScore <= 0 {
+			interviewCfg.MinScore = 70
+		}
+	}
+
+	// 为评审Agent注册工具，工具直接查询本地存储
+	// 历史评估只由审计记录写入，未启用审计时不注册find_past_evaluations；未单独配置集合时使用审计集合
+	if mainCfg.AgentConfig.Tools.Enabled {
+		auditCfg := mainCfg.AgentConfig.Audit
+		toolsCfg := tools.Config{
+			CVCollection:      mainCfg.CVHelper.Collection,
+			MessageCollection: mainCfg.AgentConfig.Tools.MessageCollection,
+			PastEvaluations:   auditCfg.Enabled,
+		}
+		if toolsCfg.MessageCollection == "" {
+			toolsCfg.MessageCollection = auditCfg.Collection
+		}
+		if !auditCfg.Enabled {
+			log.Warn("未启用审计记录，历史评估没有数据来源，不注册find_past_evaluations工具")
+		} else if auditCfg.Collection != "" && toolsCfg.MessageCollection != auditCfg.Collection {
+			log.Warn(fmt.Sprintf("工具查询的消息集合%s与审计集合%s不一致，find_past_evaluations将查不到审计写入的评估", toolsCfg.MessageCollection, auditCfg.Collection))
+		}
+		agentTools, err := tools.DefaultTools(store, toolsCfg)
+		if err != nil {
+			panic(err)
+		}
+		aiAgentManager.RegisterTools(agentTools...)
+	}
+
+	// 按岗位维护候选人排名，每份简历分析结束后增量更新；排名保存到MongoDB，重启后恢复
+	ranker := ranking.NewRanker(mainCfg.AgentConfig.Ranking)
+	rankingRepo := ranking.NewMongoRepository(store, mainCfg.AgentConfig.Ranking.Collection)
+	entries, err := rankingRepo.LoadEntries(ctx)
+	if err != nil {
+		panic(err)
+	}
+	ranker.Restore(entries)
+	ranker.OnChange(func(jobID string, entry ranking.Entry) {
+		if err := rankingRepo.SaveEntry(ctx, entry); err != nil {
+			log.Error(fmt.Sprintf("保存岗位排名失败: 岗位=%s,候选人=%s,错误=%v", jobID, entry.CandidateID, err))
+		}
+	})
+
+	// 面试题在worker之外异步生成，退出时需等待其完成后再关闭面试题存储和Agent
+	var interviewKits sync.WaitGroup
+
+	// 初始化批量分析处理器，邮件附件与批量导入共用同一个有界worker池
+	batchProcessor := agent.NewBatchProcessor(aiAgentManager, mainCfg.AgentConfig.Batch, log)
+	batchProcessor.OnItemDone(func(batch *agent.Batch, item agent.BatchItem) {
+		if item.Job.Run != nil {
+			// 档案抽取等非分析任务
+			return
+		}
+		progress := batch.Progress()
+		log.Info(fmt.Sprintf("简历分析结束: 批次=%s,文件=%s,状态=%s,进度=%d/%d", batch.ID, item.Job.File, item.State, progress.Finished(), progress.Total))
+		if item.Result != nil {
+			entry := ranker.Update(item.Job.CandidateID, item.Job.File, item.Result)
+			log.Info(fmt.Sprintf("岗位排名更新: 岗位=%s,候选人=%s,名次=%d,综合分=%.1f,通过筛选=%t", entry.JobID, entry.CandidateID, entry.Rank, entry.Score, entry.Passed))
+			if eval := primaryEvaluation(item.Result); interviewCfg.Enabled && eval != nil && eval.MatchScore >= interviewCfg.MinScore {
+				interviewKits.Add(1)
+				go func() {
+					defer interviewKits.Done()
+					generateInterviewKit(ctx, aiAgentManager, interviewCfg.ExportDir, item, eval, log)
+				}()
+			}
+		}
+	})
+	batchProcessor.Start()
+	// 批量导入的简历排在邮件投递之后，其余按标题判断紧急/内推优先级
+	priorityOf := func(doc *cv.CV) agent.Priority {
+		if doc.Bulk {
+			return agent.PriorityBulk
+		}
+		return batchProcessor.Classify(doc.Subject)
+	}
+	defer func() {
+		// Stop返回后不会再有新的面试题任务，此时等待已启动的任务即可
+		batchProcessor.Stop()
+		interviewKits.Wait()
+	}()
+
+	// 模型抽取档案较慢，简历先以规则抽取的档案入库，模型抽取与分析共用worker池按优先级执行，完成后更新档案
+	if profileCfg := mainCfg.CVHelper.Profile; profileCfg.Enabled && profileCfg.Model {
+		cvService.SetProfileRunner(func(doc *cv.CV, run func(ctx context.Context) error) error {
+			job := agent.BatchJob{
+				File:     doc.FilePath,
+				Title:    doc.Subject,
+				Priority: priorityOf(doc),
+				Run:      run,
+			}
+			if !doc.CandidateID.IsZero() {
+				job.CandidateID = doc.CandidateID.Hex()
+			}
+			_, err := batchProcessor.Submit(ctx, job)
+			return err
+		})
+	}
+
+	// 本地语义匹配：简历分块向量化，用于相似简历查找和LLM评审前的粗排
+	var semanticSvc *semantic.Service
+	if mainCfg.Semantic.Enabled {
+		embedder, err := semantic.NewEmbedder(mainCfg.Semantic)
+		if err != nil {
+			panic(err)
+		}
+		if semanticSvc, err = semantic.NewService(embedder, mainCfg.Semantic); err != nil {
+			panic(err)
+		}
+		if err := semanticSvc.IndexJobs(ctx); err != nil {
+			panic(err)
+		}
+		// 定期保存新增的向量，退出时保存剩余的改动
+		semanticSvc.Start(func(err error) {
+			log.Error("保存向量索引失败: " + err.Error())
+		})
+		defer func() {
+			if err := semanticSvc.Stop(); err != nil {
+				log.Error("保存向量索引失败: " + err.Error())
+			}
+		}()
+	}
+
+	// 简历入库并关联候选人后提交分析，内容完全相同的重复投递不再重复分析
+	cvService.OnProcessed(func(doc *cv.CV, res *cv.Resolution) {
+		if res != nil && res.Identical {
+			log.Info(fmt.Sprintf("重复投递，跳过分析: 文件=%s,候选人=%s,原简历=%s", doc.FilePath, doc.CandidateID.Hex(), res.DuplicateOf.Hex()))
+			return
+		}
+		if doc.ManualReview {
+			log.Warn(fmt.Sprintf("简历疑似包含提示词注入，转人工审核: 文件=%s,风险分=%d", doc.FilePath, doc.Risk.Score))
+			evt := cvreview.ManualReviewEvent{
+				CVID:      doc.ID.Hex(),
+				FilePath:  doc.FilePath,
+				Subject:   doc.Subject,
+				Sender:    doc.Sender,
+				RiskScore: doc.Risk.Score,
+				Findings:  doc.Risk.KindCounts(),
+				CreatedAt: time.Now(),
+			}
+			if !doc.CandidateID.IsZero() {
+				evt.CandidateID = doc.CandidateID.Hex()
+			}
+			if err := reviewProducer.ProduceManualReviewEvent(evt); err != nil {
+				log.Error("发布人工审核事件失败: " + err.Error())
+			}
+			return
+		}
+		job := agent.BatchJob{
+			File:     doc.FilePath,
+			Title:    doc.Subject,
+			Priority: priorityOf(doc),
+			Language: doc.Language,
+		}
+		if semanticSvc != nil {
+			if err := semanticSvc.IndexCV(ctx, doc.ID.Hex(), doc.Content); err != nil {
+				log.Error("简历向量化失败: " + err.Error())
+			} else if best, weak := semanticSvc.WeakMatch(doc.ID.Hex()); weak && job.Priority == agent.PriorityNormal {
+				// LLM评审前粗排：与所有岗位都不相近的普通投递排到最后评审
+				log.Info(fmt.Sprintf("简历与岗位相似度低，延后评审: 文件=%s,最相近岗位=%s,相似度=%.3f", doc.FilePath, best.DocID, best.Score))
+				job.Priority = agent.PriorityLowMatch
+			} else if best.DocID != "" {
+				log.Info(fmt.Sprintf("简历语义匹配: 文件=%s,最相近岗位=%s,相似度=%.3f", doc.FilePath, best.DocID, best.Score))
+			}
+		}
+		if !doc.CandidateID.IsZero() {
+			job.CandidateID = doc.CandidateID.Hex()
+		}
+		if doc.SanitizedPath != "" {
+			// 隐藏文字已剔除，模型只看到可见文本
+			job.File = doc.SanitizedPath
+		}
+		if doc.Risk != nil {
+			// 只传类型与数量；隐藏文字已剔除时模型看不到，不再提醒
+			job.Suspicious = doc.Risk.ModelSummary(doc.SanitizedPath != "")
+		}
+		if _, err := batchProcessor.Submit(ctx, job); err != nil {
+			log.Error("提交简历分析失败: " + err.Error())
+		}
+	})
 
 	// 注册回调
 	attacher.OnAttachmentDownloaded(func(email emailattacherdomain.Email, att emailattacherdomain.Attachment, savePath string) {
 		log.Info(fmt.Sprintf("附件下载成功: 邮件ID=%s,附件名=%s,路径=%s", strconv.Itoa(int(email.ID)), att.Name, savePath))
+		if app := email.Application; app != nil {
+			log.Info(fmt.Sprintf("正文投递信息: 邮件ID=%d,姓名=%s,电话=%s,学校=%s,岗位=%s,链接数=%d", email.ID, app.Name, app.Phone, app.School, app.Position, len(app.Links)))
+		}
 
+		sub := cv.Submission{
+			FilePath: filepath.Join(savePath, att.Name),
+			Subject:  email.Subject,
+			Sender:   email.From,
+		}
+		if app := email.Application; app != nil {
+			// 正文填写的信息补充到候选人档案，手机号用于识别候选人
+			sub.Application = &cv.Application{Name: app.Name, Phone: app.Phone, School: app.School, Position: app.Position}
+		}
 		// 检查通道是否已满（简单的背压控制）
-		fullPath := filepath.Join(savePath, att.Name)
 		select {
-		case cvChan <- fullPath:
+		case cvChan <- sub:
 			// 成功发送
 		default:
 			// 通道已满，暂停轮询
@@ -195,7 +508,25 @@ func main() {
 
 			// 阻塞发送（确保不丢数据，但会阻塞当前 goroutine，即 poller 的 goroutine）
 			// 注意：这里阻塞会导致 poller 暂停处理后续邮件，这正是我们想要的
-			cvChan <- fullPath
+			cvChan <- sub
+		}
+	})
+
+	attacher.OnUnsupportedAttachment(func(email emailattacherdomain.Email, att emailattacherdomain.Attachment, reason string) {
+		log.Warn(fmt.Sprintf("不支持的附件: 邮件ID=%d,附件名=%s,类型=%s,原因=%s,发件人=%s", email.ID, att.Name, att.ContentType, reason, email.From))
+		evt := cvreview.UnsupportedAttachmentEvent{
+			Stage:       cvreview.StageDownload,
+			Mailbox:     email.Mailbox,
+			EmailID:     uint32(email.ID),
+			FileName:    att.Name,
+			ContentType: att.ContentType,
+			Reason:      reason,
+			Subject:     email.Subject,
+			Sender:      email.From,
+			CreatedAt:   time.Now(),
+		}
+		if err := reviewProducer.ProduceUnsupportedAttachmentEvent(evt); err != nil {
+			log.Error("发布不支持附件事件失败: " + err.Error())
 		}
 	})
 
@@ -209,13 +540,61 @@ func main() {
 	}
 	log.Info("下载器启动成功，开始轮询邮件...")
 
+	// 管理接口：记录HR决定、查看实验报告、重建评分分布、查询岗位排名与面试候选名单、批量导入简历
+	importer := &bulkImporter{root: mainCfg.AgentConfig.Batch.ImportDir, ch: cvChan, log: log}
+	adminSrv := startAdminServer(mainCfg.Admin, newAdminHandler(aiAgentManager, ranker, importer, mainCfg.Admin.Token), log)
+
 	// 监听退出信号
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
 	// 优雅关闭
+	stopAdminServer(adminSrv)
 	attacher.Stop()
 	close(cvChan)
 	log.Info("下载器已停止")
 }
+
+// primaryEvaluation 取主评审中评分最高的评审结果，没有可用结果时返回nil
+func primaryEvaluation(result *agent.AnalysisResult) *agent.CandidateEvaluation {
+	var best *agent.CandidateEvaluation
+	for _, msg := range result.Primary {
+		if msg.Evaluation != nil && (best == nil || msg.Evaluation.MatchScore > best.MatchScore) {
+			best = msg.Evaluation
+		}
+	}
+	return best
+}
+
+// generateInterviewKit 为评审通过的候选人生成面试题，exportDir非空时导出Markdown
+func generateInterviewKit(ctx context.Context, manager *agent.AiAgentManager, exportDir string, item agent.BatchItem, eval *agent.CandidateEvaluation, log logger.LoggerV1) {
+	candidateID := item.Job.CandidateID
+	if candidateID == "" {
+		candidateID = strings.TrimSuffix(filepath.Base(item.Job.File), filepath.Ext(item.Job.File))
+	}
+	ctx = agent.WithLanguage(ctx, item.Job.Language)
+	ctx = agent.WithSuspiciousFindings(ctx, item.Job.Suspicious)
+	kit, err := manager.GenerateInterviewKit(ctx, agent.InterviewRequest{
+		CandidateID: candidateID,
+		JobID:       item.Result.JobID,
+		File:        item.Job.File,
+		Evaluation:  eval,
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("生成面试题失败: 文件=%s,错误=%v", item.Job.File, err))
+		return
+	}
+	log.Info(fmt.Sprintf("面试题已生成: 候选人=%s,岗位=%s", kit.CandidateID, kit.JobID))
+	if exportDir == "" {
+		return
+	}
+	if err := os.MkdirAll(exportDir, 0o755); err != nil {
+		log.Error("创建面试题导出目录失败: " + err.Error())
+		return
+	}
+	path := filepath.Join(exportDir, fmt.Sprintf("%s_%s.md", kit.JobID, kit.CandidateID))
+	if err := os.WriteFile(path, []byte(kit.Markdown()), 0o644); err != nil {
+		log.Error("导出面试题失败: " + err.Error())
+	}
+}
@@ -21,6 +21,21 @@ type ResponseReceivedEvent struct {
 	Output    string
 	CreatedAt time.Time
 	ModelName string
+
+	// 评分及其在该Agent/岗位/提示词版本历史分布中的位置
+	PromptVersion string
+	MatchScore    int
+	Percentile    float64
+	ZScore        float64
+	SampleSize    int64
+
+	// A/B实验分组及本次调用的耗时、token用量与成本
+	Experiment   string
+	Arm          string
+	LatencyMs    int64
+	PromptTokens int
+	OutputTokens int
+	Cost         float64
 }
 
 type RabbitMQProducer struct {
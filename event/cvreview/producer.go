@@ -0,0 +1,99 @@
+package cvreview
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReviewProducer 发布需要人工处理的简历事件
+type ReviewProducer interface {
+	ProduceManualReviewEvent(evt ManualReviewEvent) error
+	ProduceUnsupportedAttachmentEvent(evt UnsupportedAttachmentEvent) error
+}
+
+// ManualReviewEvent 简历疑似包含提示词注入，未提交模型分析，等待人工审核
+// Findings只包含各类可疑内容的数量，不含原文
+type ManualReviewEvent struct {
+	CVID        string
+	CandidateID string
+	FilePath    string
+	Subject     string
+	Sender      string
+	RiskScore   int
+	Findings    map[string]int
+	CreatedAt   time.Time
+}
+
+// 不支持的附件的来源阶段
+const (
+	StageDownload   = "download"   // 邮件下载阶段：类型不在白名单、超过大小上限或未通过安全检查
+	StageConversion = "conversion" // 简历转换阶段：附件无法转换为PDF或文本
+)
+
+// UnsupportedAttachmentEvent 候选人投递的附件无法处理，需要HR联系候选人重新投递或人工查看
+// Mailbox与EmailID只在下载阶段填充
+type UnsupportedAttachmentEvent struct {
+	Stage       string
+	Mailbox     string
+	EmailID     uint32
+	FileName    string
+	ContentType string
+	Reason      string
+	Subject     string
+	Sender      string
+	CreatedAt   time.Time
+}
+
+type RabbitMQProducer struct {
+	ch *amqp.Channel
+}
+
+// 队列均为持久化，消费方（HR工作台等）逐条处理
+const (
+	ManualReviewRequiredTopic  = "cv_manual_review_required" // 人工审核队列
+	UnsupportedAttachmentTopic = "cv_unsupported_attachment" // 不支持的附件队列
+)
+
+// NewRabbitMQProducer 声明持久化的人工审核与不支持附件队列，确保没有消费者在线时事件也不会丢失
+func NewRabbitMQProducer(ch *amqp.Channel) (ReviewProducer, error) {
+	for _, topic := range []string{ManualReviewRequiredTopic, UnsupportedAttachmentTopic} {
+		if _, err := ch.QueueDeclare(topic, true, false, false, false, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &RabbitMQProducer{ch: ch}, nil
+}
+
+func (s *RabbitMQProducer) ProduceManualReviewEvent(evt ManualReviewEvent) error {
+	return s.publish(ManualReviewRequiredTopic, evt)
+}
+
+func (s *RabbitMQProducer) ProduceUnsupportedAttachmentEvent(evt UnsupportedAttachmentEvent) error {
+	return s.publish(UnsupportedAttachmentTopic, evt)
+}
+
+// publish 以持久化消息发布到默认交换机，路由键即队列名
+func (s *RabbitMQProducer) publish(topic string, evt interface{}) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = s.ch.PublishWithContext(ctx,
+		"",    // exchange
+		topic, // routing key
+		false, // mandatory
+		false, // immediate
+		amqp.Publishing{
+			ContentType:  "json/application",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		})
+	log.Printf(" [x] Sent %s\n", body)
+	return err
+}
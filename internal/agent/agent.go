@@ -2,9 +2,14 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
 )
 
 // Agent 封装了与Agent交互的核心逻辑
@@ -14,6 +19,10 @@ type Agent struct {
 	SysMsg    string  // 系统默认提示词，用于指导Agent的行为
 	SessionID string  // 会话ID
 	ModelName string
+	tools     []tool.InvokableTool // 分析过程中可调用的工具
+
+	inputPrice  float64 // 每百万输入token价格
+	outputPrice float64 // 每百万输出token价格
 }
 
 // newAgent newAgent 创建一个新的Agent实例
@@ -26,30 +35,168 @@ func newAgent(model AIModel, sessionID string, role string, sysMsg string) *Agen
 	}
 }
 
+// SetPricing 设置每百万token的输入与输出价格，用于统计分析成本
+func (a *Agent) SetPricing(input, output float64) {
+	a.inputPrice = input
+	a.outputPrice = output
+}
+
+// SetTools 设置Agent在分析过程中可调用的工具
+func (a *Agent) SetTools(tools []tool.InvokableTool) {
+	a.tools = tools
+}
+
 // AddTask 处理新的用户请求
 func (a *Agent) AddTask(ctx context.Context, filePath string, usrPrompt string) (*Message, error) {
+	return a.addTask(ctx, a.SysMsg, filePath, usrPrompt)
+}
+
+// addTask 使用指定的系统提示词处理请求，用于按简历语言替换提示词
+func (a *Agent) addTask(ctx context.Context, sysMsg string, filePath string, usrPrompt string) (*Message, error) {
 	// 构造Review的输入
-	// 使用 a.SysMsg 作为 System prompt
 	// 按照需求传入sysMsg, filePath, usrPrompt，并设置Name以便后续解析
 	schemaMsgs := []*schema.Message{
-		{Role: schema.System, Content: a.SysMsg, Name: "sysMsg"},
+		{Role: schema.System, Content: sysMsg, Name: "sysMsg"},
 		{Role: schema.User, Content: filePath, Name: "filePath"},
 		{Role: schema.User, Content: usrPrompt, Name: "usrPrompt"},
 	}
 
-	// 调用模型生成响应
-	resp, err := a.model.GenerateResponse(ctx, schemaMsgs)
+	// 调用模型生成响应，模型支持工具调用时记录每次调用及结果
+	var resp *schema.Message
+	var toolCalls []ToolCallRecord
+	var err error
+	if tm, ok := a.model.(ToolCallingModel); ok && len(a.tools) > 0 {
+		resp, toolCalls, err = a.generateWithTools(ctx, tm, schemaMsgs)
+	} else {
+		resp, err = a.model.GenerateResponse(ctx, schemaMsgs)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// 创建AI响应消息
-	aiMessage := Message{
-		SessionID: a.SessionID,
-		Role:      a.role,
-		Input:     filePath,
-		Content:   resp.Content,
-		CreatedAt: time.Now(),
+	aiMessage := a.responseMessage(ctx, sysMsg, filePath, usrPrompt, resp)
+	aiMessage.ToolCalls = toolCalls
+	// 解析结构化评估结果，解析失败时保留原始内容
+	var eval CandidateEvaluation
+	if err := json.Unmarshal([]byte(resp.Content), &eval); err == nil {
+		normalizeEvaluation(&eval)
+		aiMessage.Evaluation = &eval
 	}
 	return &aiMessage, nil
 }
+
+// generateStructured 使用指定的系统提示词生成符合outputSchema的结构化结果，用于简历分析以外的任务
+func (a *Agent) generateStructured(ctx context.Context, sysMsg string, filePath string, usrPrompt string, outputSchema *jsonschema.Schema) (*Message, error) {
+	sm, ok := a.model.(StructuredModel)
+	if !ok {
+		return nil, fmt.Errorf("model %s does not support structured output", a.model.GetModelType())
+	}
+	resp, err := sm.GenerateStructured(ctx, []*schema.Message{
+		{Role: schema.System, Content: sysMsg, Name: "sysMsg"},
+		{Role: schema.User, Content: filePath, Name: "filePath"},
+		{Role: schema.User, Content: usrPrompt, Name: "usrPrompt"},
+	}, outputSchema)
+	if err != nil {
+		return nil, err
+	}
+	msg := a.responseMessage(ctx, sysMsg, filePath, usrPrompt, resp)
+	return &msg, nil
+}
+
+// responseMessage 根据模型响应创建消息，记录原始响应、请求参数、token用量与成本
+func (a *Agent) responseMessage(ctx context.Context, sysMsg string, filePath string, usrPrompt string, resp *schema.Message) Message {
+	msg := a.newMessage(ctx, sysMsg, filePath, usrPrompt)
+	msg.Content = resp.Content
+	msg.RawResponse = resp.Content
+	if raw, ok := resp.Extra[extraRawResponse].(string); ok && raw != "" {
+		msg.RawResponse = raw
+	}
+	if params, ok := resp.Extra[extraRequestParam].(map[string]any); ok {
+		msg.Request.Params = params
+	}
+	if resp.ResponseMeta != nil && resp.ResponseMeta.Usage != nil {
+		msg.PromptTokens = resp.ResponseMeta.Usage.PromptTokens
+		msg.CompletionTokens = resp.ResponseMeta.Usage.CompletionTokens
+		msg.Cost = (float64(msg.PromptTokens)*a.inputPrice + float64(msg.CompletionTokens)*a.outputPrice) / 1e6
+	}
+	return msg
+}
+
+// newMessage 创建一条记录本次调用输入与Agent身份的消息
+func (a *Agent) newMessage(ctx context.Context, sysMsg string, filePath string, usrPrompt string) Message {
+	req := &RequestParams{ModelType: a.model.GetModelType(), ModelName: a.ModelName}
+	for _, t := range a.tools {
+		if info, err := t.Info(ctx); err == nil {
+			req.Tools = append(req.Tools, info.Name)
+		}
+	}
+	return Message{
+		SessionID:    a.SessionID,
+		Role:         a.role,
+		AgentKey:     a.Key(),
+		Input:        filePath,
+		SystemPrompt: sysMsg,
+		UserPrompt:   usrPrompt,
+		Request:      req,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// failedMessage 创建一条调用失败的消息，仅用于审计记录
+func (a *Agent) failedMessage(ctx context.Context, sysMsg string, filePath string, usrPrompt string, err error) *Message {
+	msg := a.newMessage(ctx, sysMsg, filePath, usrPrompt)
+	msg.Error = err.Error()
+	return &msg
+}
+
+// Key 返回Agent的稳定标识（角色/模型类型/模型名），不随重启生成的会话ID变化
+func (a *Agent) Key() string {
+	return a.role + "/" + a.model.GetModelType() + "/" + a.ModelName
+}
+
+// generateWithTools 调用支持工具的模型，工具在本地执行，每次调用都记录到返回的调用列表中
+func (a *Agent) generateWithTools(ctx context.Context, tm ToolCallingModel, msgs []*schema.Message) (*schema.Message, []ToolCallRecord, error) {
+	byName := make(map[string]tool.InvokableTool, len(a.tools))
+	infos := make([]*schema.ToolInfo, 0, len(a.tools))
+	for _, t := range a.tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		byName[info.Name] = t
+		infos = append(infos, info)
+	}
+
+	var mu sync.Mutex
+	var records []ToolCallRecord
+	exec := func(ctx context.Context, call schema.ToolCall) (string, error) {
+		record := ToolCallRecord{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+			CreatedAt: time.Now(),
+		}
+		var result string
+		var err error
+		if t, ok := byName[call.Function.Name]; ok {
+			result, err = t.InvokableRun(ctx, call.Function.Arguments)
+		} else {
+			err = fmt.Errorf("unknown tool: %s", call.Function.Name)
+		}
+		record.Result = result
+		if err != nil {
+			record.Error = err.Error()
+		}
+		mu.Lock()
+		records = append(records, record)
+		mu.Unlock()
+		return result, err
+	}
+
+	resp, err := tm.GenerateResponseWithTools(ctx, msgs, infos, exec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, records, nil
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"easyHR/internal/agent/llm"
+	"easyHR/internal/agent/tools"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeToolModel 依次发起预设的工具调用，再返回固定结果
+type fakeToolModel struct {
+	calls []schema.FunctionCall
+}
+
+func (f *fakeToolModel) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
+	return &schema.Message{Role: schema.Assistant, Content: "{}"}, nil
+}
+
+func (f *fakeToolModel) GenerateResponseWithTools(ctx context.Context, messages []*schema.Message, infos []*schema.ToolInfo, exec llm.ToolExecutor) (*schema.Message, error) {
+	for i, c := range f.calls {
+		_, _ = exec(ctx, schema.ToolCall{ID: string(rune('a' + i)), Function: c})
+	}
+	return &schema.Message{Role: schema.Assistant, Content: `{"match_score":90}`}, nil
+}
+
+func (f *fakeToolModel) GetModelType() string {
+	return "fake"
+}
+
+func TestAgent_AddTaskRecordsToolCalls(t *testing.T) {
+	taxonomy, err := tools.DefaultSkillTaxonomy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &fakeToolModel{calls: []schema.FunctionCall{
+		{Name: tools.ToolLookupSkill, Arguments: `{"skill":"golang"}`},
+		{Name: "missing_tool", Arguments: `{}`},
+	}}
+	ag := newAgent(m, "1", "secondary", "sys")
+	ag.SetTools([]tool.InvokableTool{tools.NewSkillLookupTool(taxonomy)})
+
+	msg, err := ag.AddTask(context.Background(), "cv.pdf", "usr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool call records, got %d", len(msg.ToolCalls))
+	}
+	if got := msg.ToolCalls[0]; got.Name != tools.ToolLookupSkill || !strings.Contains(got.Result, `"name":"Go"`) {
+		t.Fatalf("unexpected skill lookup record: %+v", got)
+	}
+	if got := msg.ToolCalls[1]; got.Error == "" {
+		t.Fatalf("expected error for unknown tool, got %+v", got)
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+
+	"easyHR/pkg/logger"
+)
+
+// auditSaveTimeout 单条审计记录的写入超时
+const auditSaveTimeout = 5 * time.Second
+
+// auditInfo 一次分析中所有Agent调用共享的审计信息
+type auditInfo struct {
+	candidateID string
+	jobID       string
+	fileHash    string
+	experiment  string
+	arm         string
+}
+
+// apply 将审计信息写入消息
+func (info auditInfo) apply(msg *Message) {
+	msg.CandidateID = info.candidateID
+	msg.JobID = info.jobID
+	msg.FileHash = info.fileHash
+	msg.Experiment = info.experiment
+	msg.Arm = info.arm
+}
+
+// SetMessageRepository 设置审计记录存储，设置后每次Agent调用都会持久化
+func (a *AiAgentManager) SetMessageRepository(repo MessageRepository) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = repo
+}
+
+// saveAudit 持久化一次Agent调用，失败只记录日志，不影响分析流程
+// 分析被取消时仍需写入，因此不继承ctx的取消
+func (a *AiAgentManager) saveAudit(ctx context.Context, msg Message) {
+	a.mu.RLock()
+	repo := a.messages
+	a.mu.RUnlock()
+	if repo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditSaveTimeout)
+	defer cancel()
+	if _, err := repo.SaveMessage(ctx, msg); err != nil {
+		a.l.Error("failed to save audit message",
+			logger.Field{Key: "session_id", Val: msg.SessionID},
+			logger.Field{Key: "error", Val: err},
+		)
+	}
+}
+
+// candidateIDFromContext 取出ctx中携带的候选人ID，未设置时返回空
+func candidateIDFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(candidateKeyCtx{}).(string)
+	return key
+}
+
+// fileSHA256 计算简历文件的SHA-256，用于审计时确认模型看到的是哪份文件
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
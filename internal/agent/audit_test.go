@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// errModel 总是返回错误的AIModel
+type errModel struct{}
+
+func (errModel) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
+	return nil, errors.New("quota exceeded")
+}
+
+func (errModel) GetModelType() string { return "fake" }
+
+func TestAnalysis_AuditTrail(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cv.pdf")
+	if err := os.WriteFile(file, []byte("resume"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewMemoryMessageRepository()
+	a := newTestManager()
+	a.agentMsgProducer = &recordingProducer{}
+	a.SetMessageRepository(repo)
+	a.scores = NewMemoryScoreRepository()
+	a.secondaryReviewAgents["s1"] = newAgent(errModel{}, "s1", "secondary", SysSecondaryReviewPrompt)
+	a.primaryReviewAgents["p1"] = newAgent(&usageModel{score: 75}, "p1", "primary", SysPrimaryReviewPrompt)
+
+	ctx := WithCandidateKey(context.Background(), "cand-1")
+	if _, err := a.Analysis(ctx, file, "2026校园招聘-后端研发-张三-13900000000"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := repo.GetMessagesByCandidateID(context.Background(), "cand-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(msgs))
+	}
+	for _, m := range msgs {
+		if m.JobID != "SoftWareDeveloper_jobId" || m.FileHash == "" || m.SystemPrompt == "" || m.UserPrompt == "" || m.Request == nil || m.StartedAt.IsZero() {
+			t.Fatalf("incomplete audit record %+v", m)
+		}
+		if !strings.HasSuffix(m.UserPrompt, InjectionNotice) {
+			t.Fatalf("user prompt lacks the injection notice: %q", m.UserPrompt)
+		}
+	}
+	failed := msgs[0]
+	if failed.Role != "secondary" {
+		failed = msgs[1]
+	}
+	if failed.Error != "quota exceeded" || failed.Content != "" {
+		t.Fatalf("expected failed secondary call to be recorded, got %+v", failed)
+	}
+
+	byJob, _ := repo.GetMessagesByJobID(context.Background(), "SoftWareDeveloper_jobId")
+	bySession, _ := repo.GetMessagesBySessionID(context.Background(), "p1")
+	if len(byJob) != 2 || len(bySession) != 1 || bySession[0].RawResponse == "" || bySession[0].Evaluation == nil {
+		t.Fatalf("unexpected query results: job=%d session=%+v", len(byJob), bySession)
+	}
+	// 标准化分数与原始评分一同保存
+	if bySession[0].Normalized == nil || bySession[0].Normalized.Raw != 75 {
+		t.Fatalf("expected normalized score in audit record, got %+v", bySession[0].Normalized)
+	}
+
+	// 保留策略：删除早于截止时间的记录
+	if n, _ := repo.DeleteMessagesBefore(context.Background(), time.Now().Add(time.Minute)); n != 2 {
+		t.Fatalf("expected 2 purged records, got %d", n)
+	}
+}
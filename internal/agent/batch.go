@@ -0,0 +1,440 @@
+package agent
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"easyHR/internal/agent/config"
+	"easyHR/pkg/logger"
+)
+
+// Priority 批量分析任务的优先级，数值越小越先处理
+type Priority int
+
+const (
+	PriorityUrgent   Priority = iota // 紧急岗位
+	PriorityReferral                 // 内推
+	PriorityNormal                   // 普通投递
+	PriorityBulk                     // 批量导入
+	PriorityLowMatch                 // 与所有岗位的语义相似度都偏低，其余简历处理完后再评审
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityUrgent:
+		return "urgent"
+	case PriorityReferral:
+		return "referral"
+	case PriorityNormal:
+		return "normal"
+	case PriorityBulk:
+		return "bulk"
+	case PriorityLowMatch:
+		return "low_match"
+	default:
+		return "priority(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// ItemState 批量任务中单份简历的状态
+type ItemState string
+
+const (
+	ItemPending   ItemState = "pending"
+	ItemRunning   ItemState = "running"
+	ItemSucceeded ItemState = "succeeded"
+	ItemFailed    ItemState = "failed"
+	ItemCancelled ItemState = "cancelled"
+)
+
+// ErrBatchProcessorStopped 批量处理器已停止，不再接受任务
+var ErrBatchProcessorStopped = errors.New("batch processor stopped")
+
+// Analyzer 执行单份简历分析，由AiAgentManager实现
+type Analyzer interface {
+	Analysis(ctx context.Context, file string, title string) (*AnalysisResult, error)
+}
+
+// BatchJob 一份待分析的简历
+type BatchJob struct {
+	ID          string   // 任务ID，为空时自动生成
+	File        string   // 简历文件路径
+	Title       string   // 邮件标题或导入时的标题，格式同Analysis
+	Priority    Priority // 优先级
+	CandidateID string   // 候选人ID，用于排名等按候选人汇总的下游处理
+	Suspicious  []string // 简历中检测到的可疑内容摘要（类型与数量，不含原文），分析时提醒模型不得采信
+	Language    string   // 简历语言（见cv.DetectLanguage），用于选择提示词
+
+	// Run 设置时执行该任务代替简历分析（如档案抽取），与分析任务按同样的优先级排队，共用worker
+	Run func(ctx context.Context) error
+}
+
+// BatchItem 批量任务中单份简历的处理情况
+type BatchItem struct {
+	Job        BatchJob
+	State      ItemState
+	Result     *AnalysisResult
+	Err        string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// BatchProgress 批量任务的汇总进度
+type BatchProgress struct {
+	Total     int
+	Pending   int
+	Running   int
+	Succeeded int
+	Failed    int
+	Cancelled int
+}
+
+// Finished 已结束（成功、失败或取消）的数量
+func (p BatchProgress) Finished() int {
+	return p.Succeeded + p.Failed + p.Cancelled
+}
+
+// Batch 一次提交的批量分析任务
+type Batch struct {
+	ID     string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	items     []*BatchItem
+	remaining int
+	done      chan struct{}
+}
+
+// Cancel 取消批量任务：排队中的简历不再处理，处理中的简历会收到取消信号
+func (b *Batch) Cancel() {
+	b.cancel()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, item := range b.items {
+		if item.State == ItemPending {
+			b.finishLocked(item, ItemCancelled, nil, context.Canceled)
+		}
+	}
+}
+
+// Done 批量任务全部结束时关闭
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// Wait 等待批量任务全部结束
+func (b *Batch) Wait(ctx context.Context) error {
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Items 返回所有简历当前状态的快照
+func (b *Batch) Items() []BatchItem {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := make([]BatchItem, len(b.items))
+	for i, item := range b.items {
+		items[i] = *item
+	}
+	return items
+}
+
+// Progress 返回汇总进度
+func (b *Batch) Progress() BatchProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := BatchProgress{Total: len(b.items)}
+	for _, item := range b.items {
+		switch item.State {
+		case ItemPending:
+			p.Pending++
+		case ItemRunning:
+			p.Running++
+		case ItemSucceeded:
+			p.Succeeded++
+		case ItemFailed:
+			p.Failed++
+		case ItemCancelled:
+			p.Cancelled++
+		}
+	}
+	return p
+}
+
+// start 将排队中的简历标记为处理中，已取消的返回false
+func (b *Batch) start(item *BatchItem) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if item.State != ItemPending {
+		return false
+	}
+	if b.ctx.Err() != nil {
+		b.finishLocked(item, ItemCancelled, nil, b.ctx.Err())
+		return false
+	}
+	item.State = ItemRunning
+	item.StartedAt = time.Now()
+	return true
+}
+
+func (b *Batch) finish(item *BatchItem, state ItemState, result *AnalysisResult, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finishLocked(item, state, result, err)
+}
+
+func (b *Batch) finishLocked(item *BatchItem, state ItemState, result *AnalysisResult, err error) {
+	item.State = state
+	item.Result = result
+	item.FinishedAt = time.Now()
+	if err != nil {
+		item.Err = err.Error()
+	}
+	b.remaining--
+	if b.remaining == 0 {
+		close(b.done)
+	}
+}
+
+// queuedItem 优先队列中的元素
+type queuedItem struct {
+	batch *Batch
+	item  *BatchItem
+	seq   uint64 // 提交顺序，同优先级先进先出
+}
+
+type itemQueue []*queuedItem
+
+func (q itemQueue) Len() int { return len(q) }
+func (q itemQueue) Less(i, j int) bool {
+	if q[i].item.Job.Priority != q[j].item.Job.Priority {
+		return q[i].item.Job.Priority < q[j].item.Job.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q itemQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *itemQueue) Push(x any)   { *q = append(*q, x.(*queuedItem)) }
+func (q *itemQueue) Pop() any {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return it
+}
+
+// ItemCallback 单份简历处理结束时的回调
+type ItemCallback func(batch *Batch, item BatchItem)
+
+// BatchProcessor 有界worker池，按优先级处理所有批量任务中的简历
+// 邮件流水线与批量导入共用同一个处理器，保证紧急与内推简历优先分析
+type BatchProcessor struct {
+	analyzer Analyzer
+	cfg      config.BatchConfig
+	l        logger.LoggerV1
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   itemQueue
+	seq     uint64
+	stopped bool
+	wg      sync.WaitGroup
+
+	batchSeq   atomic.Uint64
+	onItemDone ItemCallback
+}
+
+// NewBatchProcessor 创建批量处理器，需调用Start启动worker
+func NewBatchProcessor(analyzer Analyzer, cfg config.BatchConfig, l logger.LoggerV1) *BatchProcessor {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	p := &BatchProcessor{
+		analyzer: analyzer,
+		cfg:      cfg,
+		l:        l,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// OnItemDone 注册单份简历处理结束的回调
+func (p *BatchProcessor) OnItemDone(cb ItemCallback) {
+	p.onItemDone = cb
+}
+
+// Start 启动worker
+func (p *BatchProcessor) Start() {
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+}
+
+// Stop 停止接收新任务，取消排队中的简历并等待处理中的简历结束
+func (p *BatchProcessor) Stop() {
+	p.mu.Lock()
+	p.stopped = true
+	queued := p.queue
+	p.queue = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	for _, q := range queued {
+		q.batch.mu.Lock()
+		if q.item.State == ItemPending {
+			q.batch.finishLocked(q.item, ItemCancelled, nil, ErrBatchProcessorStopped)
+		}
+		q.batch.mu.Unlock()
+	}
+	p.wg.Wait()
+}
+
+// Submit 提交一批简历，返回可用于查询进度和取消的Batch
+// ctx取消时整个批量任务随之取消
+func (p *BatchProcessor) Submit(ctx context.Context, jobs ...BatchJob) (*Batch, error) {
+	batchID := "batch-" + strconv.FormatUint(p.batchSeq.Add(1), 10)
+	bctx, cancel := context.WithCancel(ctx)
+	b := &Batch{
+		ID:        batchID,
+		ctx:       bctx,
+		cancel:    cancel,
+		remaining: len(jobs),
+		done:      make(chan struct{}),
+	}
+	for i, job := range jobs {
+		if job.ID == "" {
+			job.ID = fmt.Sprintf("%s-%d", batchID, i+1)
+		}
+		b.items = append(b.items, &BatchItem{Job: job, State: ItemPending})
+	}
+	if len(jobs) == 0 {
+		close(b.done)
+		return b, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		cancel()
+		return nil, ErrBatchProcessorStopped
+	}
+	for _, item := range b.items {
+		p.seq++
+		heap.Push(&p.queue, &queuedItem{batch: b, item: item, seq: p.seq})
+	}
+	p.cond.Broadcast()
+
+	// 批量任务被取消时唤醒worker，尽快丢弃排队中的简历
+	go func() {
+		select {
+		case <-bctx.Done():
+			b.Cancel()
+		case <-b.done:
+			cancel()
+		}
+	}()
+	return b, nil
+}
+
+// Classify 根据标题中的关键词判断优先级
+func (p *BatchProcessor) Classify(title string) Priority {
+	lower := strings.ToLower(title)
+	for _, kw := range p.cfg.UrgentKeywords {
+		if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+			return PriorityUrgent
+		}
+	}
+	for _, kw := range p.cfg.ReferralKeywords {
+		if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+			return PriorityReferral
+		}
+	}
+	return PriorityNormal
+}
+
+// QueueLen 当前排队中的简历数
+func (p *BatchProcessor) QueueLen() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queue.Len()
+}
+
+func (p *BatchProcessor) worker() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for p.queue.Len() == 0 && !p.stopped {
+			p.cond.Wait()
+		}
+		if p.stopped {
+			p.mu.Unlock()
+			return
+		}
+		q := heap.Pop(&p.queue).(*queuedItem)
+		p.mu.Unlock()
+
+		if !q.batch.start(q.item) {
+			continue
+		}
+		p.process(q.batch, q.item)
+	}
+}
+
+func (p *BatchProcessor) process(b *Batch, item *BatchItem) {
+	job := item.Job
+	ctx := b.ctx
+	if job.CandidateID != "" {
+		// 实验分组按候选人ID哈希，同一候选人重复投递时进入同一组
+		ctx = WithCandidateKey(ctx, job.CandidateID)
+	}
+	if job.Language != "" {
+		ctx = WithLanguage(ctx, job.Language)
+	}
+	if len(job.Suspicious) > 0 {
+		ctx = WithSuspiciousFindings(ctx, job.Suspicious)
+	}
+	var (
+		result *AnalysisResult
+		err    error
+	)
+	if job.Run != nil {
+		err = job.Run(ctx)
+	} else {
+		result, err = p.analyzer.Analysis(ctx, job.File, job.Title)
+	}
+
+	state := ItemSucceeded
+	switch {
+	case err != nil && b.ctx.Err() != nil:
+		state = ItemCancelled
+	case err != nil:
+		state = ItemFailed
+		p.l.Error("批量分析失败",
+			logger.Field{Key: "batch_id", Val: b.ID},
+			logger.Field{Key: "job_id", Val: job.ID},
+			logger.Field{Key: "file", Val: job.File},
+			logger.Field{Key: "error", Val: err},
+		)
+	}
+	b.finish(item, state, result, err)
+
+	if p.onItemDone != nil {
+		b.mu.Lock()
+		snapshot := *item
+		b.mu.Unlock()
+		p.onItemDone(b, snapshot)
+	}
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"easyHR/internal/agent/config"
+	"easyHR/pkg/logger"
+)
+
+// recordingAnalyzer 记录分析顺序，gate关闭前阻塞
+type recordingAnalyzer struct {
+	mu    sync.Mutex
+	order []string
+	gate  chan struct{}
+}
+
+func (r *recordingAnalyzer) Analysis(ctx context.Context, file string, title string) (*AnalysisResult, error) {
+	select {
+	case <-r.gate:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	r.mu.Lock()
+	r.order = append(r.order, file)
+	r.mu.Unlock()
+	return &AnalysisResult{}, nil
+}
+
+func TestBatchProcessor_Priority(t *testing.T) {
+	an := &recordingAnalyzer{gate: make(chan struct{})}
+	p := NewBatchProcessor(an, config.BatchConfig{Workers: 1}, logger.NewNopLogger())
+
+	// worker启动前提交，保证按优先级出队
+	bulk, err := p.Submit(context.Background(),
+		BatchJob{File: "bulk-1", Priority: PriorityBulk},
+		BatchJob{File: "bulk-2", Priority: PriorityBulk},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mail, err := p.Submit(context.Background(),
+		BatchJob{File: "normal", Priority: PriorityNormal},
+		BatchJob{File: "referral", Priority: PriorityReferral},
+		BatchJob{File: "urgent", Priority: PriorityUrgent},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(an.gate)
+	p.Start()
+	defer p.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bulk.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mail.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"urgent", "referral", "normal", "bulk-1", "bulk-2"}
+	for i, f := range want {
+		if an.order[i] != f {
+			t.Fatalf("unexpected order %v, want %v", an.order, want)
+		}
+	}
+	if pr := mail.Progress(); pr.Succeeded != 3 || pr.Total != 3 {
+		t.Fatalf("unexpected progress %+v", pr)
+	}
+}
+
+func TestBatchProcessor_Run(t *testing.T) {
+	an := &recordingAnalyzer{gate: make(chan struct{})}
+	close(an.gate)
+	p := NewBatchProcessor(an, config.BatchConfig{Workers: 1}, logger.NewNopLogger())
+
+	// 自定义任务与分析任务按同样的优先级排队，不调用Analyzer
+	var ran []string
+	b, err := p.Submit(context.Background(),
+		BatchJob{File: "normal", Priority: PriorityNormal},
+		BatchJob{File: "profile", Priority: PriorityUrgent, Run: func(ctx context.Context) error {
+			ran = append(ran, "profile")
+			return nil
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Start()
+	defer p.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || len(an.order) != 1 || an.order[0] != "normal" {
+		t.Fatalf("unexpected runs %v, analyses %v", ran, an.order)
+	}
+	if items := b.Items(); items[1].State != ItemSucceeded || items[1].Result != nil {
+		t.Fatalf("unexpected item %+v", items[1])
+	}
+}
+
+func TestBatchProcessor_Cancel(t *testing.T) {
+	an := &recordingAnalyzer{gate: make(chan struct{})}
+	p := NewBatchProcessor(an, config.BatchConfig{Workers: 1}, logger.NewNopLogger())
+	p.Start()
+	defer p.Stop()
+
+	b, err := p.Submit(context.Background(),
+		BatchJob{File: "a"}, BatchJob{File: "b"}, BatchJob{File: "c"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 等待第一份简历开始处理
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Progress().Running == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	b.Cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pr := b.Progress(); pr.Cancelled != 3 {
+		t.Fatalf("expected all items cancelled, got %+v", pr)
+	}
+}
@@ -1,15 +1,133 @@
 package config
 
+import "time"
+
 type AgentConfig struct {
-	PromptDir string        `yaml:"promptDir"`
-	Agents    []AgentDetail `yaml:"providers"`
+	PromptDir  string           `yaml:"promptDir"`
+	Agents     []AgentDetail    `yaml:"providers"`
+	Review     ReviewConfig     `yaml:"review"`
+	Tools      ToolsConfig      `yaml:"tools"`
+	Scoring    ScoringConfig    `yaml:"scoring"`
+	Batch      BatchConfig      `yaml:"batch"`
+	Ranking    RankingConfig    `yaml:"ranking"`
+	Experiment ExperimentConfig `yaml:"experiment"`
+	Audit      AuditConfig      `yaml:"audit"`
+	Language   LanguageConfig   `yaml:"language"`
+	Interview  InterviewConfig  `yaml:"interview"`
+}
+
+// InterviewConfig 面试题生成配置：评审通过的候选人由interviewer角色的Agent生成针对性面试题
+type InterviewConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	MinScore   int    `yaml:"min_score"`  // 主评审评分不低于该值时生成面试题，默认70
+	Collection string `yaml:"collection"` // MongoDB集合，默认interview_kits
+	ExportDir  string `yaml:"export_dir"` // Markdown导出目录，为空时不导出
+}
+
+// LanguageConfig 多语言分析配置：提示词按简历语言选择，文字说明按Output输出
+type LanguageConfig struct {
+	Output string `yaml:"output"` // HR阅读的输出语言：zh或en，为空时与简历语言一致
+}
+
+// AuditConfig 审计记录配置：持久化每次Agent调用的提示词、原始响应、错误与耗时
+type AuditConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Collection string        `yaml:"collection"` // 默认messages，与工具查询历史评估使用同一集合
+	Retention  time.Duration `yaml:"retention"`  // 保留时长，超过后由MongoDB TTL索引自动清理，0表示永久保留
+}
+
+// ExperimentConfig A/B实验配置：按候选人哈希将一定比例的简历分流到实验组
+// 未命中任何实验组的简历进入对照组（control），使用providers中配置的Agent与默认提示词
+type ExperimentConfig struct {
+	Name         string      `yaml:"name"`          // 实验名称，参与分组哈希，修改后候选人会重新分组
+	Arms         []ArmConfig `yaml:"arms"`          // 实验组，分流比例之和不超过100
+	AdvanceScore int         `yaml:"advance_score"` // 主评审评分不低于该值视为推荐面试，用于计算与HR决定的一致率，默认70
+}
+
+// ArmConfig 单个实验组
+type ArmConfig struct {
+	Name      string          `yaml:"name"`
+	Percent   int             `yaml:"percent"`   // 分流比例（0-100）
+	Providers []AgentDetail   `yaml:"providers"` // 实验组使用的Agent，为空时沿用对照组的Agent配置
+	Prompts   PromptOverrides `yaml:"prompts"`
+}
+
+// PromptOverrides 实验组替换的提示词文件路径，为空时使用默认提示词
+type PromptOverrides struct {
+	SysPrimary   string `yaml:"sys_primary"`
+	UsrPrimary   string `yaml:"usr_primary"`
+	SysSecondary string `yaml:"sys_secondary"`
+	UsrSecondary string `yaml:"usr_secondary"`
+}
+
+// RankingConfig 岗位候选人排名配置
+type RankingConfig struct {
+	Weights       RankingWeights            `yaml:"weights"`
+	Filters       RankingFilters            `yaml:"filters"`        // 所有岗位通用的硬性筛选条件
+	Jobs          map[string]RankingFilters `yaml:"jobs"`           // 按岗位ID覆盖的硬性筛选条件
+	TieBreak      []string                  `yaml:"tie_break"`      // 综合分相同时依次比较的字段：primary、consensus、normalized、submitted_at
+	ShortlistSize int                       `yaml:"shortlist_size"` // 面试候选名单人数，默认10
+	Collection    string                    `yaml:"collection"`     // 保存排名的MongoDB集合，默认rankings，重启后据此恢复
+}
+
+// RankingWeights 综合分中各项分数的权重，全部为0时只使用主评审分数
+type RankingWeights struct {
+	Primary    float64 `yaml:"primary"`    // 主评审评分均值
+	Consensus  float64 `yaml:"consensus"`  // 次级评审原始评分均值
+	Normalized float64 `yaml:"normalized"` // 次级评审标准化后的百分位均值
+}
+
+// RankingFilters 硬性筛选条件，未通过的候选人保留在排名中但不进入面试名单
+type RankingFilters struct {
+	MinPrimaryScore float64  `yaml:"min_primary_score"` // 主评审最低评分
+	MinConsensus    float64  `yaml:"min_consensus"`     // 次级评审最低评分均值
+	MaxSpread       float64  `yaml:"max_spread"`        // 评审间最大分歧，0表示不限制
+	RequiredSkills  []string `yaml:"required_skills"`   // 主评审识别出的技能必须包含这些技能
+}
+
+// BatchConfig 批量分析worker池配置
+type BatchConfig struct {
+	Workers          int      `yaml:"workers"`           // 并发分析的简历数，默认4
+	UrgentKeywords   []string `yaml:"urgent_keywords"`   // 标题包含这些关键词的简历按紧急优先级处理
+	ReferralKeywords []string `yaml:"referral_keywords"` // 标题包含这些关键词的简历按内推优先级处理
+	ImportDir        string   `yaml:"import_dir"`        // 批量导入的根目录，管理接口只能导入其中的文件，为空时不开放批量导入
+}
+
+// ScoringConfig 评分标准化配置
+type ScoringConfig struct {
+	MinSamples int `yaml:"min_samples"` // 分布样本数达到该值后才在共识与排名中使用标准化分数，默认20
+}
+
+// ToolsConfig 评审Agent工具调用配置
+type ToolsConfig struct {
+	Enabled           bool   `yaml:"enabled"`            // 是否允许Agent在分析过程中调用工具
+	MessageCollection string `yaml:"message_collection"` // 历史评估所在的集合，为空时使用审计集合；须启用审计，否则不注册历史评估工具
 }
 
 type AgentDetail struct {
-	Role      string `yaml:"role"`
+	Role      string `yaml:"role"` // primary、secondary、interviewer或extractor
 	ModelType string `yaml:"model_type"`
 	ModelName string `yaml:"model_name"`
 	APIKey    string `yaml:"api_key"`
 	BaseURL   string `yaml:"base_url"`
 	SessionID string `yaml:"session_id"`
+
+	// 每百万token价格，用于统计分析成本
+	InputPrice  float64 `yaml:"input_price"`
+	OutputPrice float64 `yaml:"output_price"`
+}
+
+// ReviewConfig 评审流程配置，分别控制Secondary与Primary两个阶段
+type ReviewConfig struct {
+	Secondary StagePolicy `yaml:"secondary"`
+	Primary   StagePolicy `yaml:"primary"`
+}
+
+// StagePolicy 单个评审阶段的等待策略
+// 阶段在以下任一条件满足时结束：成功响应数达到Quorum、阶段超时、所有Agent均已返回
+// 阶段结束时仍未返回的Agent会被取消，并记录为跳过
+type StagePolicy struct {
+	Timeout      time.Duration `yaml:"timeout"`       // 阶段最长等待时间，0表示不限制
+	AgentTimeout time.Duration `yaml:"agent_timeout"` // 单个Agent调用的超时时间，0表示不限制
+	Quorum       int           `yaml:"quorum"`        // 达到该成功响应数即进入下一阶段，0表示等待全部Agent
 }
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"easyHR/pkg/logger"
+)
+
+// defaultMinSamples 分布样本数达到该值后才使用标准化分数
+const defaultMinSamples = 20
+
+// Consensus 同一阶段各评审结果的汇总
+// 分布样本充足时使用标准化分数，避免不同模型/提示词版本的系统性偏差影响结论
+type Consensus struct {
+	Reviewers      int     `json:"reviewers" bson:"reviewers"`
+	MeanScore      float64 `json:"mean_score" bson:"mean_score"`           // 原始评分均值
+	MeanPercentile float64 `json:"mean_percentile" bson:"mean_percentile"` // 百分位均值，未标准化的评审按原始分计
+	MeanZScore     float64 `json:"mean_z_score" bson:"mean_z_score"`
+	Spread         float64 `json:"spread" bson:"spread"`         // 评审间分歧：百分位的标准差
+	Normalized     bool    `json:"normalized" bson:"normalized"` // 是否所有评审都使用了标准化分数
+}
+
+// normalizeScores 将每条评审结果的原始评分计入对应分布，并写入标准化分数
+func (a *AiAgentManager) normalizeScores(ctx context.Context, jobID string, msgs []*Message) {
+	if a.scores == nil {
+		return
+	}
+	for _, msg := range msgs {
+		if msg.Evaluation == nil {
+			continue
+		}
+		key := ScoreKey{AgentKey: msg.AgentKey, JobID: jobID, PromptVersion: msg.PromptVersion}
+		dist, err := a.scores.AddSample(ctx, ScoreSample{
+			ScoreKey:  key,
+			Score:     msg.Evaluation.MatchScore,
+			SessionID: msg.SessionID,
+			CreatedAt: msg.CreatedAt,
+		})
+		if err != nil {
+			a.l.Error("failed to record score sample",
+				logger.Field{Key: "agent_key", Val: key.AgentKey},
+				logger.Field{Key: "error", Val: err},
+			)
+			continue
+		}
+		n := dist.Normalize(msg.Evaluation.MatchScore)
+		msg.Normalized = &n
+	}
+}
+
+// computeConsensus 计算一组评审结果的共识
+func (a *AiAgentManager) computeConsensus(msgs []*Message) Consensus {
+	var c Consensus
+	var percentiles []float64
+	c.Normalized = true
+	for _, msg := range msgs {
+		if msg.Evaluation == nil {
+			continue
+		}
+		c.Reviewers++
+		c.MeanScore += float64(msg.Evaluation.MatchScore)
+		p := float64(clampScore(msg.Evaluation.MatchScore))
+		if a.usesNormalized(msg) {
+			p = msg.Normalized.Percentile
+			c.MeanZScore += msg.Normalized.ZScore
+		} else {
+			c.Normalized = false
+		}
+		percentiles = append(percentiles, p)
+	}
+	if c.Reviewers == 0 {
+		c.Normalized = false
+		return c
+	}
+	n := float64(c.Reviewers)
+	c.MeanScore /= n
+	c.MeanZScore /= n
+	for _, p := range percentiles {
+		c.MeanPercentile += p
+	}
+	c.MeanPercentile /= n
+	for _, p := range percentiles {
+		c.Spread += (p - c.MeanPercentile) * (p - c.MeanPercentile)
+	}
+	c.Spread = math.Sqrt(c.Spread / n)
+	return c
+}
+
+// usesNormalized 评审结果的分布样本是否足以使用标准化分数
+func (a *AiAgentManager) usesNormalized(msg *Message) bool {
+	return msg.Normalized != nil && msg.Normalized.SampleSize >= int64(a.minSamples())
+}
+
+func (a *AiAgentManager) minSamples() int {
+	if a.scoring.MinSamples > 0 {
+		return a.scoring.MinSamples
+	}
+	return defaultMinSamples
+}
+
+// SetScoreRepository 设置评分分布的存储，默认使用内存存储
+func (a *AiAgentManager) SetScoreRepository(repo ScoreRepository) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.scores = repo
+}
+
+// RecalibrateScores 按需根据保存的评分样本重建分布
+// since为零值时使用全部样本，keys为空时重建所有分布
+func (a *AiAgentManager) RecalibrateScores(ctx context.Context, since time.Time, keys ...ScoreKey) error {
+	if a.scores == nil {
+		return nil
+	}
+	if len(keys) == 0 {
+		var err error
+		keys, err = a.scores.ListKeys(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		dist, err := a.scores.Recalibrate(ctx, key, since)
+		if err != nil {
+			return err
+		}
+		a.l.Info("评分分布已重新校准",
+			logger.Field{Key: "agent_key", Val: key.AgentKey},
+			logger.Field{Key: "job_id", Val: key.JobID},
+			logger.Field{Key: "prompt_version", Val: key.PromptVersion},
+			logger.Field{Key: "count", Val: dist.Count},
+		)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"easyHR/internal/agent/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ControlArm 未命中任何实验组的简历所在的对照组
+const ControlArm = "control"
+
+type candidateKeyCtx struct{}
+
+// WithCandidateKey 在ctx中携带候选人标识，实验分组按该标识哈希，同一候选人始终进入同一组
+func WithCandidateKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, candidateKeyCtx{}, key)
+}
+
+// candidateKey 取出候选人标识，未设置时使用邮件标题（包含姓名与手机号）
+func candidateKey(ctx context.Context, title string) string {
+	if key, ok := ctx.Value(candidateKeyCtx{}).(string); ok && key != "" {
+		return key
+	}
+	return title
+}
+
+// experimentArm 一个实验组（或对照组）使用的Agent与提示词
+type experimentArm struct {
+	name      string
+	percent   int
+	primary   map[string]*Agent
+	secondary map[string]*Agent
+
+	sysPrimary   string
+	usrPrimary   string
+	sysSecondary string
+	usrSecondary string
+}
+
+// loadPrompt 读取实验组替换的提示词文件，路径为空时返回默认提示词
+func loadPrompt(path string, def string) (string, error) {
+	if path == "" {
+		return def, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load prompt %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// assignArm 按实验名称与候选人标识的哈希确定性地选择实验组，未命中时返回nil（对照组）
+func assignArm(experiment string, arms []*experimentArm, key string) *experimentArm {
+	if len(arms) == 0 {
+		return nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(experiment + ":" + key))
+	bucket := int(h.Sum32() % 100)
+	for _, arm := range arms {
+		if bucket < arm.percent {
+			return arm
+		}
+		bucket -= arm.percent
+	}
+	return nil
+}
+
+// ArmOutcome 一次分析在实验中的结果
+type ArmOutcome struct {
+	Experiment       string    `json:"experiment" bson:"experiment"`
+	Arm              string    `json:"arm" bson:"arm"`
+	CandidateKey     string    `json:"candidate_key" bson:"candidate_key"`
+	JobID            string    `json:"job_id" bson:"job_id"`
+	PrimaryScore     float64   `json:"primary_score" bson:"primary_score"`
+	Scored           bool      `json:"scored" bson:"scored"` // 是否有主评审给出评分
+	LatencyMs        int64     `json:"latency_ms" bson:"latency_ms"`
+	PromptTokens     int       `json:"prompt_tokens" bson:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens" bson:"completion_tokens"`
+	Cost             float64   `json:"cost" bson:"cost"`
+	CreatedAt        time.Time `json:"created_at" bson:"created_at"`
+}
+
+// HRDecision HR对候选人的最终决定，用于评估各实验组评分与人工判断的一致性
+type HRDecision struct {
+	CandidateKey string    `json:"candidate_key" bson:"candidate_key"`
+	Advance      bool      `json:"advance" bson:"advance"` // 是否进入面试/录用
+	DecidedAt    time.Time `json:"decided_at" bson:"decided_at"`
+}
+
+// ExperimentRepository 实验结果与HR决定的存储
+type ExperimentRepository interface {
+	AddOutcome(ctx context.Context, outcome ArmOutcome) error
+	// AddDecision 记录HR决定，同一候选人以最新决定为准
+	AddDecision(ctx context.Context, decision HRDecision) error
+	Outcomes(ctx context.Context, experiment string) ([]ArmOutcome, error)
+	Decisions(ctx context.Context) (map[string]HRDecision, error)
+}
+
+// MemoryExperimentRepository 内存实验存储，用于测试和单机部署
+type MemoryExperimentRepository struct {
+	mu        sync.RWMutex
+	outcomes  []ArmOutcome
+	decisions map[string]HRDecision
+}
+
+// NewMemoryExperimentRepository 创建内存实验存储
+func NewMemoryExperimentRepository() *MemoryExperimentRepository {
+	return &MemoryExperimentRepository{decisions: make(map[string]HRDecision)}
+}
+
+func (r *MemoryExperimentRepository) AddOutcome(ctx context.Context, outcome ArmOutcome) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, outcome)
+	return nil
+}
+
+func (r *MemoryExperimentRepository) AddDecision(ctx context.Context, decision HRDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions[decision.CandidateKey] = decision
+	return nil
+}
+
+func (r *MemoryExperimentRepository) Outcomes(ctx context.Context, experiment string) ([]ArmOutcome, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []ArmOutcome
+	for _, o := range r.outcomes {
+		if o.Experiment == experiment {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryExperimentRepository) Decisions(ctx context.Context) (map[string]HRDecision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]HRDecision, len(r.decisions))
+	for k, v := range r.decisions {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// MongoExperimentRepository 基于MongoDB的实验存储
+type MongoExperimentRepository struct {
+	client    *mongo.Client
+	outcomes  *mongo.Collection
+	decisions *mongo.Collection
+}
+
+// NewMongoExperimentRepository 创建MongoDB实验存储
+// 配置项与NewMongoDBRepository相同，另支持outcomes_col_name和decisions_col_name
+func NewMongoExperimentRepository(config map[string]interface{}) (*MongoExperimentRepository, error) {
+	client, db, err := connectMongoDB(config)
+	if err != nil {
+		return nil, err
+	}
+	outcomesCol, _ := config["outcomes_col_name"].(string)
+	if outcomesCol == "" {
+		outcomesCol = "experiment_outcomes"
+	}
+	decisionsCol, _ := config["decisions_col_name"].(string)
+	if decisionsCol == "" {
+		decisionsCol = "hr_decisions"
+	}
+	return &MongoExperimentRepository{
+		client:    client,
+		outcomes:  db.Collection(outcomesCol),
+		decisions: db.Collection(decisionsCol),
+	}, nil
+}
+
+func (r *MongoExperimentRepository) AddOutcome(ctx context.Context, outcome ArmOutcome) error {
+	_, err := r.outcomes.InsertOne(ctx, outcome)
+	return err
+}
+
+func (r *MongoExperimentRepository) AddDecision(ctx context.Context, decision HRDecision) error {
+	_, err := r.decisions.ReplaceOne(ctx,
+		bson.M{"candidate_key": decision.CandidateKey},
+		decision,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoExperimentRepository) Outcomes(ctx context.Context, experiment string) ([]ArmOutcome, error) {
+	cursor, err := r.outcomes.Find(ctx, bson.M{"experiment": experiment})
+	if err != nil {
+		return nil, err
+	}
+	var out []ArmOutcome
+	if err := cursor.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *MongoExperimentRepository) Decisions(ctx context.Context) (map[string]HRDecision, error) {
+	cursor, err := r.decisions.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var list []HRDecision
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	out := make(map[string]HRDecision, len(list))
+	for _, d := range list {
+		out[d.CandidateKey] = d
+	}
+	return out, nil
+}
+
+// Close 关闭MongoDB连接
+func (r *MongoExperimentRepository) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
+
+// ArmReport 单个实验组的对比指标
+type ArmReport struct {
+	Arm       string  `json:"arm"`
+	Analyses  int     `json:"analyses"`
+	Scored    int     `json:"scored"`
+	Decided   int     `json:"decided"`   // 已有HR决定的分析数
+	Agreed    int     `json:"agreed"`    // 推荐结果与HR决定一致的分析数
+	Histogram [10]int `json:"histogram"` // 主评审评分分布，每10分一档
+
+	ScoreMean     float64 `json:"score_mean"`
+	ScoreStdDev   float64 `json:"score_std_dev"`
+	Agreement     float64 `json:"agreement"` // Agreed/Decided
+	MeanLatencyMs float64 `json:"mean_latency_ms"`
+	P95LatencyMs  int64   `json:"p95_latency_ms"`
+
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalCost        float64 `json:"total_cost"`
+	CostPerAnalysis  float64 `json:"cost_per_analysis"`
+}
+
+// ExperimentReport 一次实验各组的对比报告
+type ExperimentReport struct {
+	Experiment   string      `json:"experiment"`
+	AdvanceScore int         `json:"advance_score"`
+	Arms         []ArmReport `json:"arms"`
+	GeneratedAt  time.Time   `json:"generated_at"`
+}
+
+// BuildExperimentReport 汇总各实验组的评分分布、与HR决定的一致率、成本与耗时
+// 主评审评分不低于advanceScore视为推荐进入面试
+func BuildExperimentReport(experiment string, outcomes []ArmOutcome, decisions map[string]HRDecision, advanceScore int) ExperimentReport {
+	byArm := make(map[string][]ArmOutcome)
+	for _, o := range outcomes {
+		byArm[o.Arm] = append(byArm[o.Arm], o)
+	}
+	report := ExperimentReport{Experiment: experiment, AdvanceScore: advanceScore, GeneratedAt: time.Now()}
+	for arm, list := range byArm {
+		r := ArmReport{Arm: arm, Analyses: len(list)}
+		var sum, sumSq float64
+		latencies := make([]int64, 0, len(list))
+		for _, o := range list {
+			latencies = append(latencies, o.LatencyMs)
+			r.MeanLatencyMs += float64(o.LatencyMs)
+			r.PromptTokens += o.PromptTokens
+			r.CompletionTokens += o.CompletionTokens
+			r.TotalCost += o.Cost
+			if !o.Scored {
+				continue
+			}
+			r.Scored++
+			sum += o.PrimaryScore
+			sumSq += o.PrimaryScore * o.PrimaryScore
+			r.Histogram[min(clampScore(int(o.PrimaryScore))/10, 9)]++
+			if d, ok := decisions[o.CandidateKey]; ok {
+				r.Decided++
+				if (o.PrimaryScore >= float64(advanceScore)) == d.Advance {
+					r.Agreed++
+				}
+			}
+		}
+		n := float64(r.Analyses)
+		r.MeanLatencyMs /= n
+		r.CostPerAnalysis = r.TotalCost / n
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		r.P95LatencyMs = latencies[int(math.Ceil(0.95*n))-1]
+		if r.Scored > 0 {
+			m := float64(r.Scored)
+			r.ScoreMean = sum / m
+			r.ScoreStdDev = math.Sqrt(math.Max(sumSq/m-r.ScoreMean*r.ScoreMean, 0))
+		}
+		if r.Decided > 0 {
+			r.Agreement = float64(r.Agreed) / float64(r.Decided)
+		}
+		report.Arms = append(report.Arms, r)
+	}
+	sort.Slice(report.Arms, func(i, j int) bool { return report.Arms[i].Arm < report.Arms[j].Arm })
+	return report
+}
+
+// newArmOutcome 由一次分析结果生成实验记录
+func newArmOutcome(experiment string, key string, result *AnalysisResult, latency time.Duration) ArmOutcome {
+	o := ArmOutcome{
+		Experiment:   experiment,
+		Arm:          result.Arm,
+		CandidateKey: key,
+		JobID:        result.JobID,
+		LatencyMs:    latency.Milliseconds(),
+		CreatedAt:    time.Now(),
+	}
+	var n int
+	for _, msg := range result.Primary {
+		if msg.Evaluation != nil {
+			n++
+			o.PrimaryScore += float64(msg.Evaluation.MatchScore)
+		}
+	}
+	if n > 0 {
+		o.Scored = true
+		o.PrimaryScore /= float64(n)
+	}
+	for _, msg := range append(append([]*Message{}, result.Secondary...), result.Primary...) {
+		o.PromptTokens += msg.PromptTokens
+		o.CompletionTokens += msg.CompletionTokens
+		o.Cost += msg.Cost
+	}
+	return o
+}
+
+// experimentName 实验名称，未配置时为"default"
+func experimentName(cfg config.ExperimentConfig) string {
+	if cfg.Name == "" {
+		return "default"
+	}
+	return cfg.Name
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	aiagentmanager "easyHR/event/aiagentmanager"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// recordingProducer 记录发送的事件
+type recordingProducer struct {
+	mu     sync.Mutex
+	events []aiagentmanager.ResponseReceivedEvent
+}
+
+func (p *recordingProducer) AgentProduceResponseReceivedEvent(evt aiagentmanager.ResponseReceivedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, evt)
+	return nil
+}
+
+// usageModel 返回固定评分并附带token用量
+type usageModel struct{ score int }
+
+func (m *usageModel) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
+	return &schema.Message{
+		Role:    schema.Assistant,
+		Content: fmt.Sprintf(`{"match_score":%d}`, m.score),
+		ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens: 1000, CompletionTokens: 200,
+		}},
+	}, nil
+}
+
+func (m *usageModel) GetModelType() string { return "fake" }
+
+func TestAssignArm(t *testing.T) {
+	arms := []*experimentArm{{name: "a", percent: 20}, {name: "b", percent: 30}}
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("candidate-%d", i)
+		arm := assignArm("exp", arms, key)
+		if again := assignArm("exp", arms, key); again != arm {
+			t.Fatal("arm assignment is not deterministic")
+		}
+		name := ControlArm
+		if arm != nil {
+			name = arm.name
+		}
+		counts[name]++
+	}
+	for name, want := range map[string]int{"a": 2000, "b": 3000, ControlArm: 5000} {
+		if got := counts[name]; got < want-300 || got > want+300 {
+			t.Fatalf("arm %s got %d of 10000, want about %d", name, got, want)
+		}
+	}
+}
+
+func TestAnalysis_ExperimentArm(t *testing.T) {
+	producer := &recordingProducer{}
+	a := newTestManager()
+	a.agentMsgProducer = producer
+	a.experiments = NewMemoryExperimentRepository()
+	a.experiment.Name = "exp"
+
+	control := newAgent(&usageModel{score: 80}, "c1", "primary", SysPrimaryReviewPrompt)
+	a.primaryReviewAgents[control.SessionID] = control
+	treated := newAgent(&usageModel{score: 60}, "t1", "primary", "strict")
+	treated.SetPricing(1, 10)
+	a.arms = []*experimentArm{{
+		name:       "strict",
+		percent:    100,
+		primary:    map[string]*Agent{treated.SessionID: treated},
+		secondary:  map[string]*Agent{},
+		usrPrimary: UsrPrimaryReviewPrompt, usrSecondary: UsrSecondaryReviewPrompt,
+	}}
+
+	ctx := WithCandidateKey(context.Background(), "cand-1")
+	res, err := a.Analysis(ctx, "cv.pdf", "2026校园招聘-后端研发-张三-13900000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Arm != "strict" || res.Experiment != "exp" {
+		t.Fatalf("unexpected arm %q/%q", res.Experiment, res.Arm)
+	}
+	if len(res.Primary) != 1 || res.Primary[0].SessionID != "t1" || res.Primary[0].Arm != "strict" {
+		t.Fatalf("expected treatment agent to review, got %+v", res.Primary)
+	}
+	if cost := res.Primary[0].Cost; cost < 0.002999 || cost > 0.003001 {
+		t.Fatalf("unexpected cost %v", cost)
+	}
+	if len(producer.events) != 1 || producer.events[0].Arm != "strict" || producer.events[0].PromptTokens != 1000 {
+		t.Fatalf("unexpected events %+v", producer.events)
+	}
+
+	if err := a.RecordHRDecision(context.Background(), "cand-1", false); err != nil {
+		t.Fatal(err)
+	}
+	report, err := a.ExperimentReport(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Arms) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	arm := report.Arms[0]
+	// 评分60低于默认推荐线70，与HR不推进的决定一致
+	if arm.Analyses != 1 || arm.ScoreMean != 60 || arm.Decided != 1 || arm.Agreement != 1 || arm.Histogram[6] != 1 {
+		t.Fatalf("unexpected arm report %+v", arm)
+	}
+}
+
+func TestBuildExperimentReport(t *testing.T) {
+	now := time.Now()
+	outcomes := []ArmOutcome{
+		{Arm: "a", CandidateKey: "1", PrimaryScore: 90, Scored: true, LatencyMs: 100, Cost: 0.01, CreatedAt: now},
+		{Arm: "a", CandidateKey: "2", PrimaryScore: 50, Scored: true, LatencyMs: 300, Cost: 0.03, CreatedAt: now},
+		{Arm: "b", CandidateKey: "3", LatencyMs: 50, CreatedAt: now},
+	}
+	decisions := map[string]HRDecision{"1": {Advance: true}, "2": {Advance: true}}
+	report := BuildExperimentReport("exp", outcomes, decisions, 70)
+	if len(report.Arms) != 2 {
+		t.Fatalf("unexpected arms %+v", report.Arms)
+	}
+	a := report.Arms[0]
+	if a.ScoreMean != 70 || a.ScoreStdDev != 20 || a.Agreement != 0.5 || a.P95LatencyMs != 300 || a.MeanLatencyMs != 200 {
+		t.Fatalf("unexpected report for arm a: %+v", a)
+	}
+	if b := report.Arms[1]; b.Scored != 0 || b.Analyses != 1 {
+		t.Fatalf("unexpected report for arm b: %+v", b)
+	}
+}
@@ -0,0 +1,381 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"easyHR/internal/agent/prompts/position"
+
+	"github.com/cloudwego/eino/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleInterviewer 生成面试题的Agent角色
+const RoleInterviewer = "interviewer"
+
+var (
+	// ErrNoInterviewer 未配置interviewer角色的Agent
+	ErrNoInterviewer = errors.New("no interviewer agent configured")
+	// ErrNoEvaluation 候选人没有可用的评审结果
+	ErrNoEvaluation = errors.New("no evaluation found for candidate")
+	// ErrKitNotFound 面试题不存在
+	ErrKitNotFound = errors.New("interview kit not found")
+)
+
+// InterviewQuestion 一道面试题
+type InterviewQuestion struct {
+	Question   string   `json:"question" bson:"question"`
+	Purpose    string   `json:"purpose" bson:"purpose"`         // 考察目的
+	GoodAnswer string   `json:"good_answer" bson:"good_answer"` // 优秀回答应包含的要点
+	RedFlags   []string `json:"red_flags,omitempty" bson:"red_flags,omitempty"`
+}
+
+// ProjectQuestions 针对单个项目的追问
+type ProjectQuestions struct {
+	Project   string              `json:"project" bson:"project"`
+	Questions []InterviewQuestion `json:"questions" bson:"questions"`
+}
+
+// GapQuestions 针对未满足或证据不足的岗位要求的问题
+type GapQuestions struct {
+	Requirement string              `json:"requirement" bson:"requirement"`
+	Questions   []InterviewQuestion `json:"questions" bson:"questions"`
+}
+
+// RedFlag 简历中需要在面试中核实的疑点
+type RedFlag struct {
+	Issue    string `json:"issue" bson:"issue"`
+	Evidence string `json:"evidence" bson:"evidence"` // 简历中的依据
+	Probe    string `json:"probe" bson:"probe"`       // 核实方式
+}
+
+// InterviewKit 为单个候选人生成的面试题，按候选人和岗位保存
+type InterviewKit struct {
+	CandidateID      string             `json:"candidate_id" bson:"candidate_id"`
+	JobID            string             `json:"job_id" bson:"job_id"`
+	CandidateName    string             `json:"candidate_name" bson:"candidate_name"`
+	MatchScore       int                `json:"match_score" bson:"match_score"`
+	ProjectQuestions []ProjectQuestions `json:"project_questions" bson:"project_questions"`
+	GapQuestions     []GapQuestions     `json:"gap_questions" bson:"gap_questions"`
+	RedFlags         []RedFlag          `json:"red_flags" bson:"red_flags"`
+	AgentKey         string             `json:"agent_key" bson:"agent_key"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// interviewKitSchema 模型输出的JSON Schema，与InterviewKit中由模型生成的字段一致
+func interviewKitSchema() *schema.ParamsOneOf {
+	str := func(desc string) *schema.ParameterInfo {
+		return &schema.ParameterInfo{Type: schema.String, Desc: desc, Required: true}
+	}
+	question := &schema.ParameterInfo{Type: schema.Object, SubParams: map[string]*schema.ParameterInfo{
+		"question":    str("面试问题"),
+		"purpose":     str("考察目的"),
+		"good_answer": str("优秀回答应包含的要点"),
+		"red_flags": {
+			Type:     schema.Array,
+			ElemInfo: &schema.ParameterInfo{Type: schema.String},
+			Desc:     "回答中需要警惕的信号",
+		},
+	}}
+	questions := &schema.ParameterInfo{Type: schema.Array, ElemInfo: question, Required: true}
+	return schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"project_questions": {
+			Type: schema.Array,
+			Desc: "针对简历中每个主要项目的追问",
+			ElemInfo: &schema.ParameterInfo{Type: schema.Object, SubParams: map[string]*schema.ParameterInfo{
+				"project":   str("项目名称"),
+				"questions": questions,
+			}},
+			Required: true,
+		},
+		"gap_questions": {
+			Type: schema.Array,
+			Desc: "针对未满足或证据不足的岗位要求的问题",
+			ElemInfo: &schema.ParameterInfo{Type: schema.Object, SubParams: map[string]*schema.ParameterInfo{
+				"requirement": str("岗位要求"),
+				"questions":   questions,
+			}},
+			Required: true,
+		},
+		"red_flags": {
+			Type: schema.Array,
+			Desc: "需要在面试中核实的疑点",
+			ElemInfo: &schema.ParameterInfo{Type: schema.Object, SubParams: map[string]*schema.ParameterInfo{
+				"issue":    str("疑点"),
+				"evidence": str("简历中的依据"),
+				"probe":    str("核实方式"),
+			}},
+			Required: true,
+		},
+	})
+}
+
+// InterviewRequest 生成面试题的输入
+type InterviewRequest struct {
+	CandidateID string
+	JobID       string
+	File        string               // 简历文件路径
+	Evaluation  *CandidateEvaluation // 评审结果，为空时从审计记录中取该候选人最近一次主评审结果
+}
+
+// GenerateInterviewKit 调用interviewer角色的Agent，根据评审结果、岗位描述和简历生成面试题并保存
+func (a *AiAgentManager) GenerateInterviewKit(ctx context.Context, req InterviewRequest) (*InterviewKit, error) {
+	a.mu.RLock()
+	interviewers := agentList(a.interviewers)
+	kits := a.kits
+	lang := resumeLanguage(ctx)
+	outputNotice := outputInstruction(a.outputLanguage(lang))
+	a.mu.RUnlock()
+	if len(interviewers) == 0 {
+		return nil, ErrNoInterviewer
+	}
+
+	eval := req.Evaluation
+	if eval == nil {
+		var err error
+		if eval, err = a.latestEvaluation(ctx, req.CandidateID, req.JobID); err != nil {
+			return nil, err
+		}
+	}
+	jobPos, err := position.LoadJobPosition(req.JobID)
+	if err != nil {
+		return nil, err
+	}
+	jobDesc, err := xml.MarshalIndent(jobPos, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	evalJSON, err := json.MarshalIndent(eval, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	prompt := strings.Replace(UsrInterviewerPrompt, "{{JOB_DESCRIPTION}}", string(jobDesc), 1)
+	prompt = strings.Replace(prompt, "{{EVALUATION}}", string(evalJSON), 1)
+	prompt += outputNotice + defensiveNotice(ctx)
+
+	outputSchema, err := interviewKitSchema().ToJSONSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	fileHash, _ := fileSHA256(req.File)
+	kit := &InterviewKit{}
+	msg, err := a.runStructured(ctx, interviewers, stageRequest{
+		file:        req.File,
+		prompt:      prompt,
+		usrTemplate: UsrInterviewerPrompt,
+		audit:       auditInfo{candidateID: req.CandidateID, jobID: req.JobID, fileHash: fileHash},
+	}, outputSchema, func(content string) error {
+		*kit = InterviewKit{}
+		return json.Unmarshal([]byte(content), kit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	kit.CandidateID = req.CandidateID
+	kit.JobID = req.JobID
+	kit.CandidateName = eval.CandidateName
+	kit.MatchScore = eval.MatchScore
+	kit.AgentKey = msg.AgentKey
+	kit.CreatedAt = time.Now()
+	if err := kits.SaveKit(ctx, kit); err != nil {
+		return nil, err
+	}
+	return kit, nil
+}
+
+// latestEvaluation 从审计记录中取候选人在该岗位最近一次主评审的结果
+func (a *AiAgentManager) latestEvaluation(ctx context.Context, candidateID, jobID string) (*CandidateEvaluation, error) {
+	a.mu.RLock()
+	repo := a.messages
+	a.mu.RUnlock()
+	if repo == nil {
+		return nil, ErrNoEvaluation
+	}
+	msgs, err := repo.GetMessagesByCandidateID(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		if m.Role == "primary" && m.JobID == jobID && m.Evaluation != nil {
+			return m.Evaluation, nil
+		}
+	}
+	return nil, ErrNoEvaluation
+}
+
+// InterviewKit 返回已保存的面试题
+func (a *AiAgentManager) InterviewKit(ctx context.Context, candidateID, jobID string) (*InterviewKit, error) {
+	a.mu.RLock()
+	kits := a.kits
+	a.mu.RUnlock()
+	return kits.GetKit(ctx, candidateID, jobID)
+}
+
+// SetInterviewKitRepository 设置面试题存储
+func (a *AiAgentManager) SetInterviewKitRepository(repo InterviewKitRepository) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.kits = repo
+}
+
+// Markdown 将面试题导出为Markdown，便于面试官阅读或打印
+func (k *InterviewKit) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# 面试题：%s\n\n", k.CandidateName)
+	fmt.Fprintf(&sb, "- 岗位：%s\n- 候选人ID：%s\n- 简历匹配度：%d\n- 生成时间：%s\n\n",
+		k.JobID, k.CandidateID, k.MatchScore, k.CreatedAt.Format("2006-01-02 15:04"))
+
+	if len(k.ProjectQuestions) > 0 {
+		sb.WriteString("## 项目追问\n\n")
+		for _, p := range k.ProjectQuestions {
+			fmt.Fprintf(&sb, "### %s\n\n", p.Project)
+			writeQuestions(&sb, p.Questions)
+		}
+	}
+	if len(k.GapQuestions) > 0 {
+		sb.WriteString("## 岗位要求核实\n\n")
+		for _, g := range k.GapQuestions {
+			fmt.Fprintf(&sb, "### %s\n\n", g.Requirement)
+			writeQuestions(&sb, g.Questions)
+		}
+	}
+	if len(k.RedFlags) > 0 {
+		sb.WriteString("## 待核实疑点\n\n")
+		for _, f := range k.RedFlags {
+			fmt.Fprintf(&sb, "- **%s**\n  - 依据：%s\n  - 核实方式：%s\n", f.Issue, f.Evidence, f.Probe)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func writeQuestions(sb *strings.Builder, questions []InterviewQuestion) {
+	for i, q := range questions {
+		fmt.Fprintf(sb, "%d. %s\n", i+1, q.Question)
+		if q.Purpose != "" {
+			fmt.Fprintf(sb, "   - 考察目的：%s\n", q.Purpose)
+		}
+		if q.GoodAnswer != "" {
+			fmt.Fprintf(sb, "   - 优秀回答：%s\n", q.GoodAnswer)
+		}
+		for _, f := range q.RedFlags {
+			fmt.Fprintf(sb, "   - 警惕信号：%s\n", f)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// InterviewKitRepository 面试题存储，同一候选人同一岗位只保留最新一份
+type InterviewKitRepository interface {
+	SaveKit(ctx context.Context, kit *InterviewKit) error
+	GetKit(ctx context.Context, candidateID, jobID string) (*InterviewKit, error)
+	ListKits(ctx context.Context, candidateID string) ([]*InterviewKit, error)
+}
+
+// MemoryInterviewKitRepository 内存面试题存储，用于测试和单机部署
+type MemoryInterviewKitRepository struct {
+	mu   sync.RWMutex
+	kits map[string]*InterviewKit
+}
+
+// NewMemoryInterviewKitRepository 创建内存面试题存储
+func NewMemoryInterviewKitRepository() *MemoryInterviewKitRepository {
+	return &MemoryInterviewKitRepository{kits: make(map[string]*InterviewKit)}
+}
+
+func (r *MemoryInterviewKitRepository) SaveKit(ctx context.Context, kit *InterviewKit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kits[kit.CandidateID+"/"+kit.JobID] = kit
+	return nil
+}
+
+func (r *MemoryInterviewKitRepository) GetKit(ctx context.Context, candidateID, jobID string) (*InterviewKit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kit, ok := r.kits[candidateID+"/"+jobID]
+	if !ok {
+		return nil, ErrKitNotFound
+	}
+	return kit, nil
+}
+
+func (r *MemoryInterviewKitRepository) ListKits(ctx context.Context, candidateID string) ([]*InterviewKit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*InterviewKit
+	for _, kit := range r.kits {
+		if kit.CandidateID == candidateID {
+			out = append(out, kit)
+		}
+	}
+	return out, nil
+}
+
+// MongoInterviewKitRepository 基于MongoDB的面试题存储
+type MongoInterviewKitRepository struct {
+	client *mongo.Client
+	kits   *mongo.Collection
+}
+
+// NewMongoInterviewKitRepository 创建MongoDB面试题存储
+// 配置项与NewMongoDBRepository相同，col_name默认为interview_kits
+func NewMongoInterviewKitRepository(config map[string]interface{}) (*MongoInterviewKitRepository, error) {
+	client, db, err := connectMongoDB(config)
+	if err != nil {
+		return nil, err
+	}
+	colName, _ := config["col_name"].(string)
+	if colName == "" {
+		colName = "interview_kits"
+	}
+	return &MongoInterviewKitRepository{client: client, kits: db.Collection(colName)}, nil
+}
+
+func (r *MongoInterviewKitRepository) SaveKit(ctx context.Context, kit *InterviewKit) error {
+	_, err := r.kits.ReplaceOne(ctx,
+		bson.M{"candidate_id": kit.CandidateID, "job_id": kit.JobID},
+		kit,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoInterviewKitRepository) GetKit(ctx context.Context, candidateID, jobID string) (*InterviewKit, error) {
+	var kit InterviewKit
+	err := r.kits.FindOne(ctx, bson.M{"candidate_id": candidateID, "job_id": jobID}).Decode(&kit)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrKitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kit, nil
+}
+
+func (r *MongoInterviewKitRepository) ListKits(ctx context.Context, candidateID string) ([]*InterviewKit, error) {
+	cursor, err := r.kits.Find(ctx, bson.M{"candidate_id": candidateID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var out []*InterviewKit
+	if err := cursor.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Close 关闭MongoDB连接
+func (r *MongoInterviewKitRepository) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+)
+
+// structuredModel 返回固定的结构化结果，并记录收到的JSON Schema
+type structuredModel struct {
+	content string
+	schema  *jsonschema.Schema
+}
+
+func (m *structuredModel) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (m *structuredModel) GenerateStructured(ctx context.Context, messages []*schema.Message, outputSchema *jsonschema.Schema) (*schema.Message, error) {
+	m.schema = outputSchema
+	return &schema.Message{Role: schema.Assistant, Content: m.content}, nil
+}
+
+func (m *structuredModel) GetModelType() string { return "fake" }
+
+const kitJSON = `{
+		"project_questions":[{"project":"订单系统","questions":[{"question":"如何保证幂等？","purpose":"考察分布式经验","good_answer":"唯一键+状态机","red_flags":["只提到加锁"]}]}],
+		"gap_questions":[{"requirement":"Kubernetes","questions":[{"question":"讲讲你的发布流程","purpose":"核实容器经验","good_answer":"滚动发布与回滚"}]}],
+		"red_flags":[{"issue":"工作经历重叠","evidence":"2021年同时在两家公司","probe":"询问具体时间线"}]
+	}`
+
+func TestGenerateInterviewKit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cv.pdf")
+	if err := os.WriteFile(file, []byte("resume"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewMemoryMessageRepository()
+	_, _ = repo.SaveMessage(context.Background(), Message{
+		Role: "primary", CandidateID: "cand-1", JobID: "SoftWareDeveloper_jobId",
+		Evaluation: &CandidateEvaluation{CandidateName: "张三", MatchScore: 82},
+	})
+	model := &structuredModel{content: kitJSON}
+	a := newTestManager()
+	a.SetMessageRepository(repo)
+	a.kits = NewMemoryInterviewKitRepository()
+	a.interviewers = map[string]*Agent{
+		// 不支持结构化输出的模型失败后由下一个interviewer生成
+		"i1": newAgent(errModel{}, "i1", RoleInterviewer, SysInterviewerPrompt),
+		"i2": newAgent(model, "i2", RoleInterviewer, SysInterviewerPrompt),
+	}
+
+	kit, err := a.GenerateInterviewKit(context.Background(), InterviewRequest{CandidateID: "cand-1", JobID: "SoftWareDeveloper_jobId", File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.schema == nil || model.schema.Properties.Len() != 3 {
+		t.Fatalf("unexpected output schema %+v", model.schema)
+	}
+	if kit.CandidateName != "张三" || kit.MatchScore != 82 || kit.AgentKey == "" {
+		t.Fatalf("kit lacks evaluation details: %+v", kit)
+	}
+	if len(kit.ProjectQuestions) != 1 || len(kit.GapQuestions) != 1 || len(kit.RedFlags) != 1 {
+		t.Fatalf("unexpected kit %+v", kit)
+	}
+
+	saved, err := a.InterviewKit(context.Background(), "cand-1", "SoftWareDeveloper_jobId")
+	if err != nil || saved != kit {
+		t.Fatalf("kit not saved: %v", err)
+	}
+	md := kit.Markdown()
+	for _, want := range []string{"## 项目追问", "### 订单系统", "警惕信号：只提到加锁", "## 待核实疑点"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+
+	// 两次调用都写入审计记录，包括失败的一次
+	msgs, _ := repo.GetMessagesByCandidateID(context.Background(), "cand-1")
+	if len(msgs) != 3 || msgs[1].Error == "" || msgs[2].Role != RoleInterviewer {
+		t.Fatalf("unexpected audit records %+v", msgs)
+	}
+
+	if _, err := a.GenerateInterviewKit(context.Background(), InterviewRequest{CandidateID: "cand-2", JobID: "SoftWareDeveloper_jobId", File: file}); !errors.Is(err, ErrNoEvaluation) {
+		t.Fatalf("expected ErrNoEvaluation, got %v", err)
+	}
+}
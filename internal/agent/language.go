@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"easyHR/internal/agent/skills"
+	"easyHR/internal/cv"
+)
+
+type languageCtx struct{}
+
+// WithLanguage 在ctx中携带简历语言（见cv.DetectLanguage），分析时按语言选择提示词
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageCtx{}, lang)
+}
+
+// resumeLanguage 取出简历语言，未设置或中英混合时按中文处理
+func resumeLanguage(ctx context.Context) string {
+	if lang, _ := ctx.Value(languageCtx{}).(string); lang == cv.LanguageEnglish {
+		return cv.LanguageEnglish
+	}
+	return cv.LanguageChinese
+}
+
+// englishPrompts 默认中文提示词对应的英文版本
+var englishPrompts = map[string]string{
+	SysPrimaryReviewPrompt:   SysPrimaryReviewPromptEn,
+	UsrPrimaryReviewPrompt:   UsrPrimaryReviewPromptEn,
+	SysSecondaryReviewPrompt: SysSecondaryReviewPromptEn,
+	UsrSecondaryReviewPrompt: UsrSecondaryReviewPromptEn,
+}
+
+// localizePrompt 英文简历使用对应的英文提示词；实验组自定义的提示词不做替换
+func localizePrompt(prompt string, lang string) string {
+	if lang == cv.LanguageEnglish {
+		if en, ok := englishPrompts[prompt]; ok {
+			return en
+		}
+	}
+	return prompt
+}
+
+// outputLanguage 返回HR阅读的输出语言，未配置时与简历语言一致
+func (a *AiAgentManager) outputLanguage(resumeLang string) string {
+	switch a.language.Output {
+	case cv.LanguageChinese, cv.LanguageEnglish:
+		return a.language.Output
+	default:
+		return resumeLang
+	}
+}
+
+// outputInstruction 要求模型使用指定语言填写文字说明字段，技能保留通用写法便于跨语言比较
+func outputInstruction(lang string) string {
+	if lang == cv.LanguageEnglish {
+		return "\n<output_language>\nWrite every free-text field (summary, descriptions, comments) in English. Keep skill names in their common form (e.g. Go, Kubernetes, MySQL). Fill candidate_name_latin with the candidate's name in Latin letters (pinyin for Chinese names).\n</output_language>"
+	}
+	return "\n<output_language>\n请使用中文填写summary、description、comment等所有文字说明字段。技能名称使用通用写法（如Go、Kubernetes、MySQL）。candidate_name_latin填写候选人姓名的拉丁字母写法（中文姓名使用拼音）。\n</output_language>"
+}
+
+// normalizeEvaluation 归一化技能名称并计算姓名比较键，使中英文简历的评估结果可以相互比较
+func normalizeEvaluation(eval *CandidateEvaluation) {
+	eval.Skills = skills.NormalizeAll(eval.Skills)
+	for i := range eval.Projects {
+		eval.Projects[i].Skills = skills.NormalizeAll(eval.Projects[i].Skills)
+	}
+	for _, list := range [][]Experience{eval.CampusExperience, eval.WorkExperience} {
+		for i := range list {
+			list[i].Skills = skills.NormalizeAll(list[i].Skills)
+		}
+	}
+	name := eval.CandidateNameLatin
+	if strings.TrimSpace(name) == "" {
+		name = eval.CandidateName
+	}
+	eval.NameKey = cv.NormalizeName(name)
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"easyHR/internal/agent/config"
+	"easyHR/internal/cv"
+)
+
+func TestAnalysis_EnglishResume(t *testing.T) {
+	repo := NewMemoryMessageRepository()
+	a := newTestManager()
+	a.agentMsgProducer = &recordingProducer{}
+	a.language = config.LanguageConfig{Output: cv.LanguageChinese}
+	a.SetMessageRepository(repo)
+	a.primaryReviewAgents["p1"] = newAgent(&fakeModel{content: `{"match_score":80,"candidate_name":"张三","candidate_name_latin":"San Zhang","skills":["golang","K8S","Go语言","微服务"]}`}, "p1", "primary", SysPrimaryReviewPrompt)
+
+	ctx := WithLanguage(context.Background(), cv.LanguageEnglish)
+	res, err := a.Analysis(ctx, "cv.pdf", "2026 Campus Recruitment-后端研发-Zhang San-13900000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval := res.Primary[0].Evaluation
+	if want := []string{"Go", "Kubernetes", "Microservices"}; !reflect.DeepEqual(eval.Skills, want) {
+		t.Fatalf("skills = %v, want %v", eval.Skills, want)
+	}
+	if eval.NameKey != cv.NormalizeName("Zhang San") {
+		t.Fatalf("unexpected name key %q", eval.NameKey)
+	}
+
+	msgs, _ := repo.GetMessagesBySessionID(context.Background(), "p1")
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(msgs))
+	}
+	m := msgs[0]
+	if m.SystemPrompt != SysPrimaryReviewPromptEn || !strings.HasPrefix(m.UserPrompt, UsrPrimaryReviewPromptEn[:40]) {
+		t.Fatal("english resume was not reviewed with the english prompts")
+	}
+	if !strings.Contains(m.UserPrompt, "请使用中文填写") {
+		t.Fatal("user prompt lacks the configured output language")
+	}
+	if m.PromptVersion != PromptVersion(SysPrimaryReviewPromptEn, UsrPrimaryReviewPromptEn) {
+		t.Fatalf("unexpected prompt version %s", m.PromptVersion)
+	}
+}
@@ -20,25 +20,11 @@ type gemini struct {
 
 func (g *gemini) AnalyzeResume(ctx context.Context, role string, sysPrompt string, usrPrompt string, f string, opt ...interface{}) (*llm.ResumeAnalysis, error) {
 
-	file, err := g.client.UploadFileFromPath(ctx, f, nil)
+	file, err := g.uploadFile(ctx, f)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer g.client.DeleteFile(ctx, file.Name)
-	// 检查文件状态，确保处理完毕
-	for {
-		fileInfo, err := g.client.GetFile(ctx, file.Name)
-		if err != nil {
-			return nil, err
-		}
-		if fileInfo.State == genai.FileStateActive {
-			break
-		}
-		if fileInfo.State == genai.FileStateFailed {
-			return nil, errors.New("文件处理失败")
-		}
-		time.Sleep(1 * time.Second)
-	}
 	// 配置模型与 System Instruction
 	model := g.client.GenerativeModel(g.modelName)
 
@@ -46,7 +32,7 @@ func (g *gemini) AnalyzeResume(ctx context.Context, role string, sysPrompt strin
 	model.SystemInstruction = genai.NewUserContent(genai.Text(sysPrompt))
 
 	// 设置生成参数 (可选)
-	model.SetTemperature(0.2) // 分析类任务建议较低的 temperature
+	model.SetTemperature(analysisTemperature) // 分析类任务建议较低的 temperature
 
 	// 3. 配置模型参数
 	model.ResponseMIMEType = "application/json"  // 强制 JSON
@@ -63,12 +49,16 @@ func (g *gemini) AnalyzeResume(ctx context.Context, role string, sysPrompt strin
 
 	// 解析结果
 	var analysis llm.ResumeAnalysis
+	analysis.Usage = &llm.Usage{}
+	analysis.Params = requestParams(g.modelName, false)
+	addUsage(analysis.Usage, resp)
 	for _, cand := range resp.Candidates {
 		if cand.Content != nil {
 			for _, part := range cand.Content.Parts {
 				if txt, ok := part.(genai.Text); ok {
 					fmt.Println("--- 原始 JSON 字符串 ---")
 					fmt.Println(string(txt))
+					analysis.Raw += string(txt)
 
 					// 6. Unmarshal 到 Go 结构体
 					err := json.Unmarshal([]byte(txt), &analysis)
@@ -83,6 +73,51 @@ func (g *gemini) AnalyzeResume(ctx context.Context, role string, sysPrompt strin
 	return &analysis, nil
 }
 
+// analysisTemperature 简历分析使用的temperature
+const analysisTemperature = 0.2
+
+// requestParams 返回请求参数，随分析结果一同记录到审计日志
+func requestParams(modelName string, tools bool) map[string]any {
+	return map[string]any{
+		"model":              modelName,
+		"temperature":        analysisTemperature,
+		"response_mime_type": "application/json",
+		"tools":              tools,
+	}
+}
+
+// addUsage 累加响应中的token用量
+func addUsage(u *llm.Usage, resp *genai.GenerateContentResponse) {
+	if resp == nil || resp.UsageMetadata == nil {
+		return
+	}
+	u.Add(int(resp.UsageMetadata.PromptTokenCount), int(resp.UsageMetadata.CandidatesTokenCount))
+}
+
+// uploadFile 上传文件并等待处理完毕
+func (g *gemini) uploadFile(ctx context.Context, f string) (*genai.File, error) {
+	file, err := g.client.UploadFileFromPath(ctx, f, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 检查文件状态，确保处理完毕
+	for {
+		fileInfo, err := g.client.GetFile(ctx, file.Name)
+		if err != nil {
+			g.client.DeleteFile(ctx, file.Name)
+			return nil, err
+		}
+		if fileInfo.State == genai.FileStateActive {
+			return file, nil
+		}
+		if fileInfo.State == genai.FileStateFailed {
+			g.client.DeleteFile(ctx, file.Name)
+			return nil, errors.New("文件处理失败")
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
 func NewGeminiProvider(key string, modelName string) llm.LLMProvider {
 	ctx := context.Background()
 	// 1. 初始化客户端
@@ -10,6 +10,10 @@ func NewEvaluationSchema() *genai.Schema {
 				Type:        genai.TypeString,
 				Description: "The full name of the candidate found in the resume.",
 			},
+			"candidate_name_latin": {
+				Type:        genai.TypeString,
+				Description: "The candidate's name in Latin letters (pinyin for Chinese names).",
+			},
 			"match_score": {
 				Type:        genai.TypeInteger,
 				Description: "A score from 0 to 100 indicating fit for the role.",
@@ -0,0 +1,47 @@
+package gemini
+
+import (
+	"context"
+	"easyHR/internal/agent/llm"
+	"errors"
+
+	"github.com/eino-contrib/jsonschema"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// GenerateStructured 结合简历文件生成符合outputSchema的JSON
+func (g *gemini) GenerateStructured(ctx context.Context, sysPrompt string, usrPrompt string, f string, outputSchema *jsonschema.Schema) (*llm.StructuredOutput, error) {
+	file, err := g.uploadFile(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defer g.client.DeleteFile(ctx, file.Name)
+
+	model := g.client.GenerativeModel(g.modelName)
+	model.SystemInstruction = genai.NewUserContent(genai.Text(sysPrompt))
+	model.SetTemperature(analysisTemperature)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = toGenaiSchema(outputSchema)
+
+	resp, err := model.GenerateContent(ctx, genai.FileData{URI: file.URI}, genai.Text(usrPrompt))
+	if err != nil {
+		return nil, errors.New("GenerateContentFailed err:" + err.Error())
+	}
+
+	out := &llm.StructuredOutput{Usage: &llm.Usage{}, Params: requestParams(g.modelName, false)}
+	addUsage(out.Usage, resp)
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			if txt, ok := part.(genai.Text); ok {
+				out.Content += string(txt)
+			}
+		}
+	}
+	if out.Content == "" {
+		return nil, errors.New("模型未返回结构化结果")
+	}
+	return out, nil
+}
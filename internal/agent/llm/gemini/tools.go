@@ -0,0 +1,187 @@
+package gemini
+
+import (
+	"context"
+	"easyHR/internal/agent/llm"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// maxToolRounds 单次分析中允许的最大工具调用轮数，防止模型无限循环调用
+const maxToolRounds = 8
+
+// finalAnswerPrompt 工具调用结束后要求模型按Schema输出最终结果
+const finalAnswerPrompt = "工具调用已结束，请结合以上信息，严格按照JSON Schema输出最终的评估结果。"
+
+// AnalyzeResumeWithTools 支持工具调用的简历分析
+// 先以函数调用模式与模型多轮交互，工具执行完毕后再以JSON Schema模式获取最终结果
+func (g *gemini) AnalyzeResumeWithTools(ctx context.Context, sysPrompt string, usrPrompt string, f string, tools []*schema.ToolInfo, exec llm.ToolExecutor) (*llm.ResumeAnalysis, error) {
+	decls, err := toFunctionDeclarations(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := g.uploadFile(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defer g.client.DeleteFile(ctx, file.Name)
+
+	model := g.client.GenerativeModel(g.modelName)
+	model.SystemInstruction = genai.NewUserContent(genai.Text(sysPrompt))
+	model.SetTemperature(analysisTemperature)
+	// Gemini 不支持在函数调用模式下强制JSON输出，工具轮次结束后再切换
+	model.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
+
+	usage := &llm.Usage{}
+	cs := model.StartChat()
+	resp, err := cs.SendMessage(ctx, genai.FileData{URI: file.URI}, genai.Text(usrPrompt))
+	if err != nil {
+		return nil, errors.New("GenerateContentFailed err:" + err.Error())
+	}
+	addUsage(usage, resp)
+
+	for round := 0; round < maxToolRounds; round++ {
+		calls := functionCalls(resp)
+		if len(calls) == 0 {
+			break
+		}
+		parts := make([]genai.Part, 0, len(calls))
+		for i, call := range calls {
+			args, err := json.Marshal(call.Args)
+			if err != nil {
+				return nil, err
+			}
+			output, err := exec(ctx, schema.ToolCall{
+				ID:   call.Name + "-" + strconv.Itoa(round) + "-" + strconv.Itoa(i),
+				Type: "function",
+				Function: schema.FunctionCall{
+					Name:      call.Name,
+					Arguments: string(args),
+				},
+			})
+			parts = append(parts, genai.FunctionResponse{
+				Name:     call.Name,
+				Response: toolResponse(output, err),
+			})
+		}
+		resp, err = cs.SendMessage(ctx, parts...)
+		if err != nil {
+			return nil, errors.New("GenerateContentFailed err:" + err.Error())
+		}
+		addUsage(usage, resp)
+	}
+
+	// 切换为JSON Schema模式获取最终结果
+	model.Tools = nil
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = NewEvaluationSchema()
+	resp, err = cs.SendMessage(ctx, genai.Text(finalAnswerPrompt))
+	if err != nil {
+		return nil, errors.New("GenerateContentFailed err:" + err.Error())
+	}
+	addUsage(usage, resp)
+
+	analysis := llm.ResumeAnalysis{Usage: usage, Params: requestParams(g.modelName, true)}
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			if txt, ok := part.(genai.Text); ok {
+				analysis.Raw += string(txt)
+				if err := json.Unmarshal([]byte(txt), &analysis); err != nil {
+					return nil, fmt.Errorf("JSON 解析失败: %w", err)
+				}
+			}
+		}
+	}
+	return &analysis, nil
+}
+
+// functionCalls 提取响应中的所有函数调用
+func functionCalls(resp *genai.GenerateContentResponse) []genai.FunctionCall {
+	var calls []genai.FunctionCall
+	for _, cand := range resp.Candidates {
+		calls = append(calls, cand.FunctionCalls()...)
+	}
+	return calls
+}
+
+// toolResponse 将工具输出转换为FunctionResponse需要的map结构
+func toolResponse(output string, err error) map[string]any {
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	var v any
+	if json.Unmarshal([]byte(output), &v) != nil {
+		v = output
+	}
+	return map[string]any{"result": v}
+}
+
+// toFunctionDeclarations 将eino的ToolInfo转换为Gemini的函数声明
+func toFunctionDeclarations(tools []*schema.ToolInfo) ([]*genai.FunctionDeclaration, error) {
+	decls := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		decl := &genai.FunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Desc,
+		}
+		if t.ParamsOneOf != nil {
+			js, err := t.ParamsOneOf.ToJSONSchema()
+			if err != nil {
+				return nil, fmt.Errorf("工具%s参数定义无效: %w", t.Name, err)
+			}
+			decl.Parameters = toGenaiSchema(js)
+		}
+		decls = append(decls, decl)
+	}
+	return decls, nil
+}
+
+// toGenaiSchema 将JSON Schema转换为Gemini的Schema
+func toGenaiSchema(js *jsonschema.Schema) *genai.Schema {
+	if js == nil {
+		return nil
+	}
+	s := &genai.Schema{
+		Description: js.Description,
+		Required:    js.Required,
+	}
+	switch schema.DataType(js.Type) {
+	case schema.Object:
+		s.Type = genai.TypeObject
+	case schema.Array:
+		s.Type = genai.TypeArray
+	case schema.Integer:
+		s.Type = genai.TypeInteger
+	case schema.Number:
+		s.Type = genai.TypeNumber
+	case schema.Boolean:
+		s.Type = genai.TypeBoolean
+	default:
+		s.Type = genai.TypeString
+	}
+	for _, e := range js.Enum {
+		if str, ok := e.(string); ok {
+			s.Enum = append(s.Enum, str)
+		}
+	}
+	if js.Items != nil {
+		s.Items = toGenaiSchema(js.Items)
+	}
+	if js.Properties != nil {
+		s.Properties = make(map[string]*genai.Schema, js.Properties.Len())
+		for pair := js.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			s.Properties[pair.Key] = toGenaiSchema(pair.Value)
+		}
+	}
+	return s
+}
@@ -2,6 +2,9 @@ package llm
 
 import (
 	"context"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
 )
 
 // LLMProvider 是所有 AI 模型的通用接口
@@ -9,3 +12,27 @@ type LLMProvider interface {
 	// AnalyzeResume 接收文件流，返回结构化的 ResumeAnalysis
 	AnalyzeResume(ctx context.Context, role string, sysPrompt string, usrPrompt string, file string, opt ...interface{}) (*ResumeAnalysis, error)
 }
+
+// ToolExecutor 执行模型发起的一次工具调用，返回工具输出（JSON字符串）
+type ToolExecutor func(ctx context.Context, call schema.ToolCall) (string, error)
+
+// ToolCallingProvider 支持在分析过程中调用工具的 Provider
+type ToolCallingProvider interface {
+	LLMProvider
+	// AnalyzeResumeWithTools 与 AnalyzeResume 相同，但允许模型在给出结果前调用 tools 中声明的工具
+	// 每次工具调用都通过 exec 在本地执行，结果回传给模型
+	AnalyzeResumeWithTools(ctx context.Context, sysPrompt string, usrPrompt string, file string, tools []*schema.ToolInfo, exec ToolExecutor) (*ResumeAnalysis, error)
+}
+
+// StructuredOutput 按调用方给定的JSON Schema生成的结果
+type StructuredOutput struct {
+	Content string         // 符合Schema的JSON文本
+	Usage   *Usage         // 消耗的token
+	Params  map[string]any // 发送给Provider的请求参数，用于审计
+}
+
+// StructuredOutputProvider 支持按任意JSON Schema生成结构化结果的 Provider，用于简历分析以外的任务（如生成面试题）
+type StructuredOutputProvider interface {
+	// GenerateStructured 结合file与usrPrompt生成符合outputSchema的JSON
+	GenerateStructured(ctx context.Context, sysPrompt string, usrPrompt string, file string, outputSchema *jsonschema.Schema) (*StructuredOutput, error)
+}
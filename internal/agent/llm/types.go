@@ -3,8 +3,28 @@ package llm
 // ResumeAnalysis 是 LLM 分析简历后返回的标准结构
 // 所有的实现（Gemini/OpenAI）都必须解析成这个结构返回
 type ResumeAnalysis struct {
-	CandidateName string   `json:"candidate_name"`
-	MatchScore    int      `json:"match_score"`
-	Skills        []string `json:"skills"`
-	Summary       string   `json:"summary"`
+	CandidateName      string   `json:"candidate_name"`
+	CandidateNameLatin string   `json:"candidate_name_latin,omitempty"`
+	MatchScore         int      `json:"match_score"`
+	Skills             []string `json:"skills"`
+	Summary            string   `json:"summary"`
+
+	// Usage 本次分析消耗的token，不属于模型输出
+	Usage *Usage `json:"-"`
+	// Raw 模型返回的原始文本，用于审计
+	Raw string `json:"-"`
+	// Params 发送给Provider的请求参数（如temperature），用于审计
+	Params map[string]any `json:"-"`
+}
+
+// Usage 一次分析（含多轮工具调用）累计消耗的token数
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Add 累加一次模型调用的token数
+func (u *Usage) Add(prompt, completion int) {
+	u.PromptTokens += prompt
+	u.CompletionTokens += completion
 }
@@ -3,16 +3,21 @@ package agent
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	aiagentmanager "easyHR/event/aiagentmanager"
 	"easyHR/internal/agent/config"
 	"easyHR/internal/agent/prompts/position"
 	"easyHR/pkg/logger"
 	"easyHR/pkg/snowflake"
+
+	"github.com/cloudwego/eino/components/tool"
 )
 
 // AiAgentManager 管理Agent实例的生命周期
@@ -26,6 +31,17 @@ type AiAgentManager struct {
 	agentMsgProducer      aiagentmanager.AgentMsgProducer
 	mu                    sync.RWMutex // 读写锁，保护并发访问
 	promptDir             string
+	review                config.ReviewConfig // 评审阶段的超时与Quorum策略
+	scoring               config.ScoringConfig
+	scores                ScoreRepository // 评分分布存储，用于标准化评分
+	experiment            config.ExperimentConfig
+	arms                  []*experimentArm     // A/B实验组，为空表示未开启实验
+	experiments           ExperimentRepository // 实验结果与HR决定存储
+	messages              MessageRepository    // 审计记录存储，为空时不记录
+	language              config.LanguageConfig
+	interviewers          map[string]*Agent      // 生成面试题的Agent
+	kits                  InterviewKitRepository // 面试题存储
+	extractors            map[string]*Agent      // 抽取候选人档案的Agent
 	wg                    sync.WaitGroup
 	shutdown              bool
 }
@@ -36,6 +52,9 @@ func NewAiAgentManager(cfg config.AgentConfig, producer aiagentmanager.AgentMsgP
 	a := &AiAgentManager{
 		agentMsgProducer: producer,
 		l:                l,
+		scores:           NewMemoryScoreRepository(),
+		experiments:      NewMemoryExperimentRepository(),
+		kits:             NewMemoryInterviewKitRepository(),
 	}
 	a.primaryReviewAgents = make(map[string]*Agent)
 	a.secondaryReviewAgents = make(map[string]*Agent)
@@ -63,47 +82,147 @@ func (a *AiAgentManager) initAiAgentManager(cfg config.AgentConfig) error {
 		return err
 	}
 	a.promptDir = cfg.PromptDir
-	for _, agent := range cfg.Agents {
-		model := NewAiModel(Configuration{
-			ModelType: agent.ModelType,
-			ModelName: agent.ModelName,
-			APIKey:    agent.APIKey,
-			BaseURL:   agent.BaseURL,
-		})
-
-		// Generate snowflake ID
-		id := sfNode.Generate()
-		sid := strconv.FormatInt(id, 10)
-
-		var ag *Agent
-		switch agent.Role {
+	a.review = cfg.Review
+	a.scoring = cfg.Scoring
+	a.experiment = cfg.Experiment
+	a.language = cfg.Language
+	a.primaryReviewAgents, a.secondaryReviewAgents = buildAgents(sfNode, cfg.Agents, SysPrimaryReviewPrompt, SysSecondaryReviewPrompt)
+	a.interviewers = buildRoleAgents(sfNode, cfg.Agents, RoleInterviewer, SysInterviewerPrompt)
+	a.extractors = buildRoleAgents(sfNode, cfg.Agents, RoleExtractor, SysProfileExtractorPrompt)
+
+	// 实验组：未单独配置Agent时沿用对照组的Agent配置，但使用独立的Agent实例与提示词
+	total := 0
+	for _, armCfg := range cfg.Experiment.Arms {
+		if armCfg.Name == "" || armCfg.Name == ControlArm {
+			return fmt.Errorf("experiment arm name %q is reserved or empty", armCfg.Name)
+		}
+		total += armCfg.Percent
+		if armCfg.Percent < 0 || total > 100 {
+			return fmt.Errorf("experiment arm percentages must be between 0 and 100 in total")
+		}
+		arm := &experimentArm{name: armCfg.Name, percent: armCfg.Percent}
+		prompts := []struct {
+			dst  *string
+			path string
+			def  string
+		}{
+			{&arm.sysPrimary, armCfg.Prompts.SysPrimary, SysPrimaryReviewPrompt},
+			{&arm.usrPrimary, armCfg.Prompts.UsrPrimary, UsrPrimaryReviewPrompt},
+			{&arm.sysSecondary, armCfg.Prompts.SysSecondary, SysSecondaryReviewPrompt},
+			{&arm.usrSecondary, armCfg.Prompts.UsrSecondary, UsrSecondaryReviewPrompt},
+		}
+		for _, p := range prompts {
+			if *p.dst, err = loadPrompt(p.path, p.def); err != nil {
+				return err
+			}
+		}
+		providers := armCfg.Providers
+		if len(providers) == 0 {
+			providers = cfg.Agents
+		}
+		arm.primary, arm.secondary = buildAgents(sfNode, providers, arm.sysPrimary, arm.sysSecondary)
+		a.arms = append(a.arms, arm)
+	}
+	return nil
+}
+
+// buildAgents 按配置创建主评审与次级评审Agent
+func buildAgents(sfNode *snowflake.Node, details []config.AgentDetail, sysPrimary, sysSecondary string) (primary, secondary map[string]*Agent) {
+	primary = make(map[string]*Agent)
+	secondary = make(map[string]*Agent)
+	for _, detail := range details {
+		switch detail.Role {
 		case "primary":
-			ag = newAgent(model, sid, agent.Role, SysPrimaryReviewPrompt)
-			a.primaryReviewAgents[sid] = ag
+			ag := buildAgent(sfNode, detail, sysPrimary)
+			primary[ag.SessionID] = ag
 		case "secondary":
-			ag = newAgent(model, sid, agent.Role, SysSecondaryReviewPrompt)
-			a.secondaryReviewAgents[sid] = ag
+			ag := buildAgent(sfNode, detail, sysSecondary)
+			secondary[ag.SessionID] = ag
 		}
 	}
-	return nil
+	return primary, secondary
+}
+
+// buildRoleAgents 按配置创建指定角色的Agent
+func buildRoleAgents(sfNode *snowflake.Node, details []config.AgentDetail, role, sysMsg string) map[string]*Agent {
+	agents := make(map[string]*Agent)
+	for _, detail := range details {
+		if detail.Role == role {
+			ag := buildAgent(sfNode, detail, sysMsg)
+			agents[ag.SessionID] = ag
+		}
+	}
+	return agents
 }
 
+// buildAgent 按单个Agent配置创建Agent实例
+func buildAgent(sfNode *snowflake.Node, detail config.AgentDetail, sysMsg string) *Agent {
+	model := NewAiModel(Configuration{
+		ModelType: detail.ModelType,
+		ModelName: detail.ModelName,
+		APIKey:    detail.APIKey,
+		BaseURL:   detail.BaseURL,
+	})
+
+	// Generate snowflake ID
+	sid := strconv.FormatInt(sfNode.Generate(), 10)
+	ag := newAgent(model, sid, detail.Role, sysMsg)
+	ag.ModelName = detail.ModelName
+	ag.SetPricing(detail.InputPrice, detail.OutputPrice)
+	return ag
+}
+
+// AnalysisResult 一次简历分析的完整结果
+type AnalysisResult struct {
+	JobID     string            // 匹配到的岗位ID
+	Secondary []*Message        // SecondaryReviewer的评审结果
+	Primary   []*Message        // PrimaryReviewer的评审结果
+	Skipped   []SkippedReviewer // 被跳过的评审Agent及原因
+	Consensus Consensus         // SecondaryReviewer评审结果的共识
+
+	Experiment string // 开启A/B实验时的实验名称
+	Arm        string // 所在实验组，对照组为control
+}
+
+// ErrNoPrimaryReview 没有任何PrimaryReviewer在阶段内返回结果
+var ErrNoPrimaryReview = errors.New("no primary reviewer returned a result")
+
 // Analysis 调用Agents解析简历获取分析结果
 // 接收简历文档path和邮件title，返回简历的分析结果和错误信息
-func (a *AiAgentManager) Analysis(ctx context.Context, file string, title string) error {
+// 各阶段按照ReviewConfig中的超时与Quorum策略结束，被跳过的Agent记录在结果中
+func (a *AiAgentManager) Analysis(ctx context.Context, file string, title string) (*AnalysisResult, error) {
 	a.mu.RLock()
 	if a.shutdown {
 		a.mu.RUnlock()
-		return fmt.Errorf("agent manager is shutting down")
+		return nil, fmt.Errorf("agent manager is shutting down")
 	}
 	a.wg.Add(1)
 	defer a.wg.Done()
+	started := time.Now()
+
+	// 按候选人哈希选择实验组，未开启实验或未命中实验组时使用对照组
+	key := candidateKey(ctx, title)
+	arm := assignArm(experimentName(a.experiment), a.arms, key)
+	var secondaryAgents, primaryAgents []*Agent
+	usrSecondary, usrPrimary := UsrSecondaryReviewPrompt, UsrPrimaryReviewPrompt
+	if arm != nil {
+		secondaryAgents = agentList(arm.secondary)
+		primaryAgents = agentList(arm.primary)
+		usrSecondary, usrPrimary = arm.usrSecondary, arm.usrPrimary
+	} else {
+		secondaryAgents = agentList(a.secondaryReviewAgents)
+		primaryAgents = agentList(a.primaryReviewAgents)
+	}
+	// 英文简历使用英文提示词，文字说明按配置的输出语言返回
+	lang := resumeLanguage(ctx)
+	usrSecondary, usrPrimary = localizePrompt(usrSecondary, lang), localizePrompt(usrPrimary, lang)
+	outputNotice := outputInstruction(a.outputLanguage(lang))
 	a.mu.RUnlock()
 
 	// Parse title: "2026校园招聘-后端研发-Name-13333333333"
 	parts := strings.Split(title, "-")
 	if len(parts) < 4 {
-		return fmt.Errorf("invalid title format: %s", title)
+		return nil, fmt.Errorf("invalid title format: %s", title)
 	}
 	positionName := parts[1] // "后端研发"
 
@@ -120,96 +239,86 @@ func (a *AiAgentManager) Analysis(ctx context.Context, file string, title string
 	jobPos, err := position.LoadJobPosition(jobID)
 	if err != nil {
 		a.l.Error("failed to load job position", logger.Field{Key: "job_id", Val: jobID}, logger.Field{Key: "error", Val: err})
-		return err
+		return nil, err
 	}
 
 	// Marshal Job Position to string for prompt injection
 	// Using XML format as it is structured
 	jobDescBytes, err := xml.MarshalIndent(jobPos, "", "  ")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	jobDescStr := string(jobDescBytes)
 
-	var responseMu sync.Mutex
-	var SecReviewerMsgs []*Message
-	var wg sync.WaitGroup
+	result := &AnalysisResult{JobID: jobID}
+	if len(a.arms) > 0 {
+		result.Experiment = experimentName(a.experiment)
+		result.Arm = ControlArm
+		if arm != nil {
+			result.Arm = arm.name
+		}
+	}
+
+	audit := auditInfo{
+		candidateID: candidateIDFromContext(ctx),
+		jobID:       jobID,
+		experiment:  result.Experiment,
+		arm:         result.Arm,
+	}
+	if audit.fileHash, err = fileSHA256(file); err != nil {
+		a.l.Warn("failed to hash cv file", logger.Field{Key: "file", Val: file}, logger.Field{Key: "error", Val: err})
+	}
 
 	a.l.Info("发送简历至SecondaryReviewer评审")
 
 	// Inject Job Description into prompt
-	prompt := strings.Replace(UsrSecondaryReviewPrompt, "{{JOB_DESCRIPTION}}", jobDescStr, 1)
-	// Also replace {{user_query}} if it exists, though the user request focused on job description.
-	// The original prompt has {{user_query}}.
-	// Assuming for now the "user query" is implicit or empty in this flow, or we should leave it?
-	// The user said: "match with LoadJobPosition... replace content... then as UsrSecondaryReviewPrompt whole send".
-	// If UsrSecondaryReviewPrompt has {{user_query}}, we might need to fill it or remove it.
-	// Given the context of "Analysis", there might not be a specific user query yet?
-	// Or maybe the 'title' had the query?
-	// Re-reading: "将需求与...内容进行替换，然后作为一个UsrSecondaryReviewPrompt整体发送".
-	// I will assume specific user query is not the main point here, but the Job Description is.
-	// I'll just replace JOB_DESCRIPTION. If {{user_query}} remains, it might be an issue.
-	// Let's replace {{user_query}} with a generic instruction or empty string to be safe.
+	prompt := strings.Replace(usrSecondary, "{{JOB_DESCRIPTION}}", jobDescStr, 1)
 	prompt = strings.Replace(prompt, "{{user_query}}", "请基于以上岗位描述进行评估。", 1)
+	notice := outputNotice + defensiveNotice(ctx)
+	prompt += notice
 
-	for _, agent := range a.secondaryReviewAgents {
-		agent := agent // Capture loop variable
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			msg, err := agent.AddTask(ctx, file, prompt)
-			if err != nil {
-				a.l.Error("agent add task failed",
-					logger.Field{Key: "session_id", Val: agent.SessionID},
-					logger.Field{Key: "model_type", Val: agent.model.GetModelType()},
-					logger.Field{Key: "model_name", Val: agent.ModelName},
-					logger.Field{Key: "error", Val: err},
-				)
-				return
-			}
-			responseMu.Lock()
-			SecReviewerMsgs = append(SecReviewerMsgs, msg)
-			responseMu.Unlock()
-		}()
-	}
 	a.l.Info("等待SecondaryReviewer返回审评结果")
-	wg.Wait()
+	secondary := a.runStage(ctx, secondaryAgents, a.review.Secondary, stageRequest{file: file, prompt: prompt, usrTemplate: usrSecondary, language: lang, jobID: jobID, audit: audit})
+	result.Secondary = secondary.msgs
+	result.Skipped = append(result.Skipped, secondary.skipped...)
+	result.Consensus = a.computeConsensus(result.Secondary)
 
-	var PrimReviewerMsgs []*Message
 	// Also inject job description into Primary Reviewer Prompt
-	// The user request explicitly mentioned "UsrSecondaryReviewPrompt", but Primary also needs to know the job.
-	// My previous edit to UsrPrimaryReviewPrompt added {{JOB_DESCRIPTION}}.
-	usrPromptTemplate := a.ConstructPrimaryReviewerUsrPrompt(SecReviewerMsgs)
-	usrPrompt := strings.Replace(usrPromptTemplate, "{{JOB_DESCRIPTION}}", jobDescStr, 1)
+	usrPromptTemplate := a.constructPrimaryUsrPrompt(usrPrimary, result.Secondary)
+	usrPrompt := strings.Replace(usrPromptTemplate, "{{JOB_DESCRIPTION}}", jobDescStr, 1) + notice
 
 	a.l.Info("发送简历至PrimaryReviewer评审")
-	for _, agent := range a.primaryReviewAgents {
-		agent := agent // Capture loop variable
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			msg, err := agent.AddTask(ctx, file, usrPrompt)
-			if err != nil {
-				a.l.Error("agent add task failed",
-					logger.Field{Key: "session_id", Val: agent.SessionID},
-					logger.Field{Key: "model_type", Val: agent.model.GetModelType()},
-					logger.Field{Key: "model_name", Val: agent.ModelName},
-					logger.Field{Key: "error", Val: err},
-				)
-				return
-			}
-			responseMu.Lock()
-			PrimReviewerMsgs = append(PrimReviewerMsgs, msg)
-			responseMu.Unlock()
-		}()
-	}
 	a.l.Info("等待PrimaryReviewer返回审评结果")
-	wg.Wait()
+	primary := a.runStage(ctx, primaryAgents, a.review.Primary, stageRequest{file: file, prompt: usrPrompt, usrTemplate: usrPrimary, language: lang, jobID: jobID, audit: audit})
+	result.Primary = primary.msgs
+	result.Skipped = append(result.Skipped, primary.skipped...)
+
 	// Send PrimaryReviewerMsgs and SecondaryReviewerMsgs to agentMsgProducer
-	allMsgs := append(SecReviewerMsgs, PrimReviewerMsgs...)
+	allMsgs := append(append([]*Message{}, result.Secondary...), result.Primary...)
 	a.produceAnalysisEvents(allMsgs)
 
-	return nil
+	if result.Arm != "" {
+		if err := a.experiments.AddOutcome(ctx, newArmOutcome(result.Experiment, key, result, time.Since(started))); err != nil {
+			a.l.Error("failed to record experiment outcome", logger.Field{Key: "arm", Val: result.Arm}, logger.Field{Key: "error", Val: err})
+		}
+	}
+
+	if len(result.Primary) == 0 {
+		return result, ErrNoPrimaryReview
+	}
+	return result, nil
+}
+
+// agentList 将Agent映射转换为按会话ID排序的列表
+func agentList(agents map[string]*Agent) []*Agent {
+	list := make([]*Agent, 0, len(agents))
+	for _, ag := range agents {
+		list = append(list, ag)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].SessionID < list[j].SessionID
+	})
+	return list
 }
 
 func (a *AiAgentManager) produceAnalysisEvents(msgs []*Message) {
@@ -220,22 +329,34 @@ func (a *AiAgentManager) produceAnalysisEvents(msgs []*Message) {
 		var modelType string
 		var modelName string
 		// Try to find agent to get model type
-		if ag, ok := a.secondaryReviewAgents[msg.SessionID]; ok {
-			modelType = ag.model.GetModelType()
-			modelName = ag.ModelName
-		} else if ag, ok := a.primaryReviewAgents[msg.SessionID]; ok {
+		if ag := a.findAgent(msg.SessionID); ag != nil {
 			modelType = ag.model.GetModelType()
 			modelName = ag.ModelName
 		}
 
 		evt := aiagentmanager.ResponseReceivedEvent{
-			SessionID: msg.SessionID,
-			ModelType: modelType,
-			Role:      msg.Role,
-			Input:     msg.Input,
-			Output:    msg.Content,
-			CreatedAt: msg.CreatedAt,
-			ModelName: modelName,
+			SessionID:     msg.SessionID,
+			ModelType:     modelType,
+			Role:          msg.Role,
+			Input:         msg.Input,
+			Output:        msg.Content,
+			CreatedAt:     msg.CreatedAt,
+			ModelName:     modelName,
+			PromptVersion: msg.PromptVersion,
+			Experiment:    msg.Experiment,
+			Arm:           msg.Arm,
+			LatencyMs:     msg.LatencyMs,
+			PromptTokens:  msg.PromptTokens,
+			OutputTokens:  msg.CompletionTokens,
+			Cost:          msg.Cost,
+		}
+		if msg.Evaluation != nil {
+			evt.MatchScore = msg.Evaluation.MatchScore
+		}
+		if msg.Normalized != nil {
+			evt.Percentile = msg.Normalized.Percentile
+			evt.ZScore = msg.Normalized.ZScore
+			evt.SampleSize = msg.Normalized.SampleSize
 		}
 
 		if err := a.agentMsgProducer.AgentProduceResponseReceivedEvent(evt); err != nil {
@@ -251,18 +372,101 @@ func (a *AiAgentManager) produceAnalysisEvents(msgs []*Message) {
 // 接收SecondaryReviewer的分析结果，返回新的User Prompt
 
 func (a *AiAgentManager) ConstructPrimaryReviewerUsrPrompt(msgs []*Message) string {
+	return a.constructPrimaryUsrPrompt(UsrPrimaryReviewPrompt, msgs)
+}
+
+// constructPrimaryUsrPrompt 在指定的主评审提示词后附上次级评审结果
+func (a *AiAgentManager) constructPrimaryUsrPrompt(base string, msgs []*Message) string {
 	var sb strings.Builder
-	sb.WriteString(UsrPrimaryReviewPrompt)
+	sb.WriteString(base)
 	sb.WriteString("\n\n以下是各次级评审员（Secondary Reviewer）的分析结果：\n\n")
 
 	for i, msg := range msgs {
-		sb.WriteString(fmt.Sprintf("评审结果 %d:\n", i+1))
+		if a.usesNormalized(msg) {
+			// 附上该评审员的历史百分位，便于抵消不同模型的评分偏差
+			sb.WriteString(fmt.Sprintf("评审结果 %d（该评审员历史评分百分位：%.1f，样本数：%d）:\n", i+1, msg.Normalized.Percentile, msg.Normalized.SampleSize))
+		} else {
+			sb.WriteString(fmt.Sprintf("评审结果 %d:\n", i+1))
+		}
 		sb.WriteString(msg.Content)
 		sb.WriteString("\n\n")
 	}
 	return sb.String()
 }
 
+// RegisterTools 为所有评审Agent（含各实验组）注册分析过程中可调用的工具
+func (a *AiAgentManager) RegisterTools(tools ...tool.InvokableTool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, ag := range a.allAgents() {
+		ag.SetTools(tools)
+	}
+}
+
+// allAgents 返回对照组与各实验组的所有Agent
+func (a *AiAgentManager) allAgents() []*Agent {
+	var list []*Agent
+	sets := []map[string]*Agent{a.primaryReviewAgents, a.secondaryReviewAgents}
+	for _, arm := range a.arms {
+		sets = append(sets, arm.primary, arm.secondary)
+	}
+	for _, set := range sets {
+		for _, ag := range set {
+			list = append(list, ag)
+		}
+	}
+	return list
+}
+
+// findAgent 按会话ID查找Agent，包括各实验组
+func (a *AiAgentManager) findAgent(sessionID string) *Agent {
+	if ag, ok := a.secondaryReviewAgents[sessionID]; ok {
+		return ag
+	}
+	if ag, ok := a.primaryReviewAgents[sessionID]; ok {
+		return ag
+	}
+	for _, arm := range a.arms {
+		if ag, ok := arm.secondary[sessionID]; ok {
+			return ag
+		}
+		if ag, ok := arm.primary[sessionID]; ok {
+			return ag
+		}
+	}
+	return nil
+}
+
+// SetExperimentRepository 替换实验结果存储，默认使用内存存储
+func (a *AiAgentManager) SetExperimentRepository(repo ExperimentRepository) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.experiments = repo
+}
+
+// RecordHRDecision 记录HR对候选人的决定，candidateKey与分析时的候选人标识一致
+func (a *AiAgentManager) RecordHRDecision(ctx context.Context, candidateKey string, advance bool) error {
+	return a.experiments.AddDecision(ctx, HRDecision{CandidateKey: candidateKey, Advance: advance, DecidedAt: time.Now()})
+}
+
+// ExperimentReport 生成当前实验各组的对比报告
+func (a *AiAgentManager) ExperimentReport(ctx context.Context) (ExperimentReport, error) {
+	name := experimentName(a.experiment)
+	outcomes, err := a.experiments.Outcomes(ctx, name)
+	if err != nil {
+		return ExperimentReport{}, err
+	}
+	decisions, err := a.experiments.Decisions(ctx)
+	if err != nil {
+		return ExperimentReport{}, err
+	}
+	advance := a.experiment.AdvanceScore
+	if advance <= 0 {
+		advance = 70
+	}
+	return BuildExperimentReport(name, outcomes, decisions, advance), nil
+}
+
 // ClearAll 销毁所有Agent实例
 // 清空映射，释放所有资源
 func (a *AiAgentManager) ClearAll() {
@@ -272,4 +476,6 @@ func (a *AiAgentManager) ClearAll() {
 	// 清空映射
 	a.primaryReviewAgents = make(map[string]*Agent)
 	a.secondaryReviewAgents = make(map[string]*Agent)
+	a.interviewers = make(map[string]*Agent)
+	a.extractors = make(map[string]*Agent)
 }
@@ -91,7 +91,7 @@ func TestAiAgentManager_Analysis(t *testing.T) {
 	// Expecting error because "test_key" is invalid and file doesn't exist,
 	// but this verifies the wiring.
 	ctx := context.Background()
-	err = manager.Analysis(ctx, filePath, title)
+	_, err = manager.Analysis(ctx, filePath, title)
 	if err != nil {
 		t.Logf("Analysis finished with error (expected): %v", err)
 	} else {
@@ -5,27 +5,32 @@ import (
 )
 
 type Project struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Skills      []string `json:"skills"`
-	Comment     string   `json:"comment"`
+	Name        string   `json:"name" bson:"name"`
+	Description string   `json:"description" bson:"description"`
+	Skills      []string `json:"skills" bson:"skills"`
+	Comment     string   `json:"comment" bson:"comment"`
 }
 
 type Experience struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Skills      []string `json:"skills"`
-	Comment     string   `json:"comment"`
+	Name        string   `json:"name" bson:"name"`
+	Description string   `json:"description" bson:"description"`
+	Skills      []string `json:"skills" bson:"skills"`
+	Comment     string   `json:"comment" bson:"comment"`
 }
 
+// CandidateEvaluation 评审结果。bson键与json一致，find_past_evaluations等按键名查询历史评估
 type CandidateEvaluation struct {
-	CandidateName    string       `json:"candidate_name"`
-	MatchScore       int          `json:"match_score"`
-	Skills           []string     `json:"skills"`
-	Projects         []Project    `json:"projects"`
-	CampusExperience []Experience `json:"campus_experience"`
-	WorkExperience   []Experience `json:"work_experience"`
-	Summary          string       `json:"summary"`
+	CandidateName      string `json:"candidate_name" bson:"candidate_name"`
+	CandidateNameLatin string `json:"candidate_name_latin,omitempty" bson:"candidate_name_latin,omitempty"`
+	// NameKey 姓名比较键，优先使用拉丁字母写法，使中英文简历中的同一姓名可以比较
+	NameKey string `json:"name_key,omitempty" bson:"name_key,omitempty"`
+
+	MatchScore       int          `json:"match_score" bson:"match_score"`
+	Skills           []string     `json:"skills" bson:"skills"`
+	Projects         []Project    `json:"projects" bson:"projects"`
+	CampusExperience []Experience `json:"campus_experience" bson:"campus_experience"`
+	WorkExperience   []Experience `json:"work_experience" bson:"work_experience"`
+	Summary          string       `json:"summary" bson:"summary"`
 }
 
 // Message 定义消息持久化的结构
@@ -37,11 +42,58 @@ type Message struct {
 
 	// Structured evaluation result
 	Evaluation *CandidateEvaluation `gorm:"serializer:json" json:"evaluation,omitempty" bson:"evaluation,omitempty"`
+	// Normalized match score against the agent's historical distribution
+	Normalized *NormalizedScore `gorm:"serializer:json" json:"normalized_score,omitempty" bson:"normalized_score,omitempty"`
+
+	AgentKey      string `gorm:"type:varchar(128)" json:"agent_key" bson:"agent_key"`
+	PromptVersion string `gorm:"type:varchar(16)" json:"prompt_version" bson:"prompt_version"`
+
+	// A/B experiment arm the analysis was routed to
+	Experiment string `gorm:"type:varchar(64)" json:"experiment,omitempty" bson:"experiment,omitempty"`
+	Arm        string `gorm:"type:varchar(64)" json:"arm,omitempty" bson:"arm,omitempty"`
+
+	// Latency, token usage and cost of the model call
+	LatencyMs        int64   `json:"latency_ms" bson:"latency_ms"`
+	PromptTokens     int     `json:"prompt_tokens" bson:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens" bson:"completion_tokens"`
+	Cost             float64 `json:"cost" bson:"cost"`
+
+	// Tool calls made by the agent while producing this message
+	ToolCalls []ToolCallRecord `gorm:"serializer:json" json:"tool_calls,omitempty" bson:"tool_calls,omitempty"`
+
+	// Audit trail: what was sent to the model, what came back and for whom
+	CandidateID  string         `gorm:"index;type:varchar(64)" json:"candidate_id,omitempty" bson:"candidate_id,omitempty"`
+	JobID        string         `gorm:"index;type:varchar(64)" json:"job_id,omitempty" bson:"job_id,omitempty"`
+	FileHash     string         `gorm:"type:varchar(64)" json:"file_hash,omitempty" bson:"file_hash,omitempty"`
+	SystemPrompt string         `gorm:"type:text" json:"system_prompt,omitempty" bson:"system_prompt,omitempty"`
+	UserPrompt   string         `gorm:"type:text" json:"user_prompt,omitempty" bson:"user_prompt,omitempty"`
+	Request      *RequestParams `gorm:"serializer:json" json:"request,omitempty" bson:"request,omitempty"`
+	RawResponse  string         `gorm:"type:text" json:"raw_response,omitempty" bson:"raw_response,omitempty"`
+	Error        string         `gorm:"type:text" json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt    time.Time      `json:"started_at" bson:"started_at"`
 
 	Input     string    `gorm:"type:text" json:"input" bson:"input"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
 
+// RequestParams 记录一次模型调用的请求参数
+type RequestParams struct {
+	ModelType string         `json:"model_type" bson:"model_type"`
+	ModelName string         `json:"model_name" bson:"model_name"`
+	Tools     []string       `json:"tools,omitempty" bson:"tools,omitempty"`
+	Params    map[string]any `json:"params,omitempty" bson:"params,omitempty"` // Provider相关参数，如temperature
+}
+
+// ToolCallRecord 记录Agent一次工具调用及其结果
+type ToolCallRecord struct {
+	ID        string    `json:"id" bson:"id"`
+	Name      string    `json:"name" bson:"name"`
+	Arguments string    `json:"arguments" bson:"arguments"`
+	Result    string    `json:"result,omitempty" bson:"result,omitempty"`
+	Error     string    `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
 type MessageProcessor struct{}
 
 // NewMessageProcessor 创建一个新的MessageProcessor实例
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"easyHR/internal/agent/llm"
 	"easyHR/internal/agent/llm/gemini"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
 )
 
 // AIModel 定义AIModel的通用接口
@@ -17,6 +19,20 @@ type AIModel interface {
 	GetModelType() string
 }
 
+// ToolCallingModel 支持在生成过程中调用工具的AIModel
+type ToolCallingModel interface {
+	AIModel
+	// GenerateResponseWithTools 生成AI响应，模型可调用tools中声明的工具，由exec在本地执行
+	GenerateResponseWithTools(ctx context.Context, messages []*schema.Message, tools []*schema.ToolInfo, exec llm.ToolExecutor) (*schema.Message, error)
+}
+
+// StructuredModel 支持按任意JSON Schema生成结构化结果的AIModel
+type StructuredModel interface {
+	AIModel
+	// GenerateStructured 生成符合outputSchema的JSON，返回的助手消息Content即为JSON文本
+	GenerateStructured(ctx context.Context, messages []*schema.Message, outputSchema *jsonschema.Schema) (*schema.Message, error)
+}
+
 // Configuration 定义LLM服务的配置
 type Configuration struct {
 	ModelType string `mapstructure:"model_type" yaml:"model_type"` // e.g., "doubao", "gpt4"
@@ -48,40 +64,92 @@ func NewAiModel(config Configuration) AIModel {
 
 // GenerateResponse 生成AI响应，非流式
 func (a *model) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
-	if len(messages) == 0 {
-		return nil, errors.New("messages is empty")
+	sysMsg, filePath, usrPrompt, err := splitMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
-	var sysMsg, filePath, usrPrompt string
-	for _, msg := range messages {
-		switch msg.Name {
-		case "sysMsg":
-			sysMsg = msg.Content
-		case "filePath":
-			filePath = msg.Content
-		case "usrPrompt":
-			usrPrompt = msg.Content
-		}
+	analysis, err := a.provider.AnalyzeResume(ctx, "", sysMsg, usrPrompt, filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	if sysMsg == "" || filePath == "" || usrPrompt == "" {
-		return nil, errors.New("sysMsg, filePath or usrPrompt is empty")
+	return toSchemaMessage(analysis)
+}
+
+// GenerateResponseWithTools 生成AI响应并允许模型调用工具
+// Provider不支持工具调用时退化为GenerateResponse
+func (a *model) GenerateResponseWithTools(ctx context.Context, messages []*schema.Message, tools []*schema.ToolInfo, exec llm.ToolExecutor) (*schema.Message, error) {
+	provider, ok := a.provider.(llm.ToolCallingProvider)
+	if !ok || len(tools) == 0 {
+		return a.GenerateResponse(ctx, messages)
 	}
 
-	analysis, err := a.provider.AnalyzeResume(ctx, "", sysMsg, usrPrompt, filePath)
+	sysMsg, filePath, usrPrompt, err := splitMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := provider.AnalyzeResumeWithTools(ctx, sysMsg, usrPrompt, filePath, tools, exec)
 	if err != nil {
 		return nil, err
 	}
 
+	return toSchemaMessage(analysis)
+}
+
+// 助手消息Extra中携带的审计信息
+const (
+	extraRawResponse  = "raw_response"
+	extraRequestParam = "request_params"
+)
+
+// toSchemaMessage 将分析结果序列化为助手消息，token用量写入ResponseMeta，原始响应与请求参数写入Extra
+func toSchemaMessage(analysis *llm.ResumeAnalysis) (*schema.Message, error) {
 	respBytes, err := json.Marshal(analysis)
 	if err != nil {
 		return nil, err
 	}
 
-	return &schema.Message{
+	msg := &schema.Message{
 		Role:    schema.Assistant,
 		Content: string(respBytes),
-	}, nil
+		Extra: map[string]any{
+			extraRawResponse:  analysis.Raw,
+			extraRequestParam: analysis.Params,
+		},
+	}
+	if analysis.Usage != nil {
+		msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:     analysis.Usage.PromptTokens,
+			CompletionTokens: analysis.Usage.CompletionTokens,
+			TotalTokens:      analysis.Usage.PromptTokens + analysis.Usage.CompletionTokens,
+		}}
+	}
+	return msg, nil
+}
+
+// splitMessages 按Name取出系统提示词、文件路径和用户提示词
+func splitMessages(messages []*schema.Message) (sysMsg, filePath, usrPrompt string, err error) {
+	if len(messages) == 0 {
+		return "", "", "", errors.New("messages is empty")
+	}
+
+	for _, msg := range messages {
+		switch msg.Name {
+		case "sysMsg":
+			sysMsg = msg.Content
+		case "filePath":
+			filePath = msg.Content
+		case "usrPrompt":
+			usrPrompt = msg.Content
+		}
+	}
+
+	if sysMsg == "" || filePath == "" || usrPrompt == "" {
+		return "", "", "", errors.New("sysMsg, filePath or usrPrompt is empty")
+	}
+	return sysMsg, filePath, usrPrompt, nil
 }
 
 func (a *model) GetModelType() string {
@@ -91,3 +159,35 @@ func (a *model) GetModelType() string {
 func (a *model) GetModelName() string {
 	return a.modelName
 }
+
+// GenerateStructured 生成符合outputSchema的JSON，Provider不支持时返回错误
+func (a *model) GenerateStructured(ctx context.Context, messages []*schema.Message, outputSchema *jsonschema.Schema) (*schema.Message, error) {
+	provider, ok := a.provider.(llm.StructuredOutputProvider)
+	if !ok {
+		return nil, fmt.Errorf("model %s does not support structured output", a.modelType)
+	}
+	sysMsg, filePath, usrPrompt, err := splitMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	out, err := provider.GenerateStructured(ctx, sysMsg, usrPrompt, filePath, outputSchema)
+	if err != nil {
+		return nil, err
+	}
+	msg := &schema.Message{
+		Role:    schema.Assistant,
+		Content: out.Content,
+		Extra: map[string]any{
+			extraRawResponse:  out.Content,
+			extraRequestParam: out.Params,
+		},
+	}
+	if out.Usage != nil {
+		msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:     out.Usage.PromptTokens,
+			CompletionTokens: out.Usage.CompletionTokens,
+			TotalTokens:      out.Usage.PromptTokens + out.Usage.CompletionTokens,
+		}}
+	}
+	return msg, nil
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"easyHR/internal/cv"
+	"easyHR/pkg/logger"
+
+	"github.com/eino-contrib/jsonschema"
+)
+
+// RoleExtractor 抽取候选人档案的Agent角色
+const RoleExtractor = "extractor"
+
+// ErrNoExtractor 未配置extractor角色的Agent
+var ErrNoExtractor = errors.New("no extractor agent configured")
+
+// profileSchema 候选人档案的JSON Schema，由cv.CandidateProfile反射生成，不使用$ref以便模型直接使用
+func profileSchema() *jsonschema.Schema {
+	r := &jsonschema.Reflector{DoNotReference: true, ExpandedStruct: true}
+	return r.Reflect(&cv.CandidateProfile{})
+}
+
+// ProfileExtractor 使用extractor角色的Agent抽取候选人档案，实现cv.ProfileExtractor
+// 模型抽取失败时退化为规则抽取，模型遗漏的联系方式、链接等由规则抽取结果补全
+type ProfileExtractor struct {
+	manager  *AiAgentManager
+	fallback cv.ProfileExtractor
+}
+
+// NewProfileExtractor 创建基于模型的档案抽取器
+func NewProfileExtractor(manager *AiAgentManager) *ProfileExtractor {
+	return &ProfileExtractor{manager: manager, fallback: cv.RuleExtractor{}}
+}
+
+// Extract 抽取简历的候选人档案，简历已剔除隐藏文字时使用剔除后的文本
+func (e *ProfileExtractor) Extract(ctx context.Context, doc *cv.CV) (*cv.CandidateProfile, error) {
+	rules, err := e.fallback.Extract(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := e.manager.ExtractProfile(ctx, doc)
+	if err != nil {
+		e.manager.l.Warn("model profile extraction failed, using rules",
+			logger.Field{Key: "file", Val: doc.FilePath},
+			logger.Field{Key: "error", Val: err},
+		)
+		return rules, nil
+	}
+	profile.Merge(rules)
+	return profile, nil
+}
+
+// ExtractProfile 调用extractor角色的Agent抽取候选人档案
+func (a *AiAgentManager) ExtractProfile(ctx context.Context, doc *cv.CV) (*cv.CandidateProfile, error) {
+	a.mu.RLock()
+	extractors := agentList(a.extractors)
+	a.mu.RUnlock()
+	if len(extractors) == 0 {
+		return nil, ErrNoExtractor
+	}
+
+	file := doc.FilePath
+	if doc.SanitizedPath != "" {
+		file = doc.SanitizedPath
+	}
+	if doc.Risk != nil {
+		ctx = WithSuspiciousFindings(ctx, doc.Risk.ModelSummary(doc.SanitizedPath != ""))
+	}
+	info := auditInfo{fileHash: doc.ContentHash}
+	if !doc.CandidateID.IsZero() {
+		info.candidateID = doc.CandidateID.Hex()
+	}
+
+	profile := &cv.CandidateProfile{}
+	_, err := a.runStructured(ctx, extractors, stageRequest{
+		file:        file,
+		prompt:      UsrProfileExtractorPrompt + defensiveNotice(ctx),
+		usrTemplate: UsrProfileExtractorPrompt,
+		audit:       info,
+	}, profileSchema(), func(content string) error {
+		*profile = cv.CandidateProfile{}
+		return json.Unmarshal([]byte(content), profile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile.Source = cv.ProfileSourceModel
+	profile.ExtractedAt = time.Now()
+	return profile, nil
+}
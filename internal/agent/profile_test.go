@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"easyHR/internal/cv"
+)
+
+func TestProfileExtractor(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cv.pdf")
+	if err := os.WriteFile(file, []byte("resume"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &cv.CV{FilePath: file, Content: "张三\n电话：13800138000\nhttps://github.com/zhangsan"}
+
+	model := &structuredModel{content: `{"contact":{"name":"张三"},"education":[{"school":"清华大学","degree":"master"}]}`}
+	a := newTestManager()
+	a.extractors = map[string]*Agent{"e1": newAgent(model, "e1", RoleExtractor, SysProfileExtractorPrompt)}
+
+	profile, err := NewProfileExtractor(a).Extract(context.Background(), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模型的Schema不使用$ref，教育经历的字段直接展开
+	edu, ok := model.schema.Properties.Get("education")
+	if !ok || edu.Items == nil || edu.Items.Properties == nil || edu.Items.Properties.Len() == 0 {
+		t.Fatalf("education schema not expanded: %+v", edu)
+	}
+	if profile.Source != cv.ProfileSourceModel || len(profile.Education) != 1 || profile.Education[0].Degree != "master" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+	// 模型遗漏的联系方式与链接由规则抽取补全
+	if len(profile.Contact.Phones) != 1 || len(profile.Links) != 1 {
+		t.Fatalf("rule details not merged: %+v", profile)
+	}
+
+	// 没有可用的extractor时使用规则抽取结果
+	a.extractors = map[string]*Agent{}
+	profile, err = NewProfileExtractor(a).Extract(context.Background(), doc)
+	if err != nil || profile.Source != cv.ProfileSourceRules || profile.Contact.Name != "张三" {
+		t.Fatalf("expected rule-based profile, got %+v, %v", profile, err)
+	}
+}
@@ -22,3 +22,33 @@ var SysSecondaryReviewPrompt string
 
 //go:embed prompts/usrSecondaryReviewPrompt.xml
 var UsrSecondaryReviewPrompt string
+
+// 英文简历使用的提示词
+//
+//go:embed prompts/en/sysPrimaryReviewPrompt.xml
+var SysPrimaryReviewPromptEn string
+
+//go:embed prompts/en/usrPrimaryReviewPrompt.xml
+var UsrPrimaryReviewPromptEn string
+
+//go:embed prompts/en/sysSecondaryReviewPrompt.xml
+var SysSecondaryReviewPromptEn string
+
+//go:embed prompts/en/usrSecondaryReviewPrompt.xml
+var UsrSecondaryReviewPromptEn string
+
+// InterviewerPrompt variables
+//
+//go:embed prompts/sysInterviewerPrompt.xml
+var SysInterviewerPrompt string
+
+//go:embed prompts/usrInterviewerPrompt.xml
+var UsrInterviewerPrompt string
+
+// ProfileExtractorPrompt variables
+//
+//go:embed prompts/sysProfileExtractorPrompt.xml
+var SysProfileExtractorPrompt string
+
+//go:embed prompts/usrProfileExtractorPrompt.xml
+var UsrProfileExtractorPrompt string
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"encoding/xml"
 	"fmt"
+	"strings"
 )
 
 //go:embed *.xml
@@ -27,3 +28,20 @@ func LoadJobPosition(jobID string) (*JobPosition, error) {
 
 	return &jobPos, nil
 }
+
+// ListJobIDs returns the IDs of all embedded job positions.
+func ListJobIDs() ([]string, error) {
+	entries, err := jobFiles.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job files: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".xml") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".xml"))
+	}
+	return ids, nil
+}
@@ -0,0 +1,337 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxScore 评分上限，match_score取值范围为0-100
+const maxScore = 100
+
+// ScoreKey 评分分布的维度：同一Agent、同一岗位、同一提示词版本的评分才具有可比性
+type ScoreKey struct {
+	AgentKey      string `json:"agent_key" bson:"agent_key"`
+	JobID         string `json:"job_id" bson:"job_id"`
+	PromptVersion string `json:"prompt_version" bson:"prompt_version"`
+}
+
+// ScoreSample 一次原始评分样本
+type ScoreSample struct {
+	ScoreKey  `bson:",inline"`
+	Score     int       `json:"score" bson:"score"`
+	SessionID string    `json:"session_id" bson:"session_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ScoreDistribution 某个ScoreKey下的累计评分分布
+type ScoreDistribution struct {
+	ScoreKey  `bson:",inline"`
+	Count     int64               `json:"count" bson:"count"`
+	Sum       float64             `json:"sum" bson:"sum"`
+	SumSq     float64             `json:"sum_sq" bson:"sum_sq"`
+	Histogram [maxScore + 1]int64 `json:"histogram" bson:"histogram"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// NormalizedScore 原始评分及其在历史分布中的位置
+type NormalizedScore struct {
+	Raw        int     `json:"raw" bson:"raw"`
+	Percentile float64 `json:"percentile" bson:"percentile"` // 0-100，低于该分数的样本占比（相同分数计一半）
+	ZScore     float64 `json:"z_score" bson:"z_score"`
+	SampleSize int64   `json:"sample_size" bson:"sample_size"`
+}
+
+// add 将一个评分计入分布
+func (d *ScoreDistribution) add(score int) {
+	score = clampScore(score)
+	d.Count++
+	d.Sum += float64(score)
+	d.SumSq += float64(score * score)
+	d.Histogram[score]++
+}
+
+// Mean 分布均值
+func (d *ScoreDistribution) Mean() float64 {
+	if d.Count == 0 {
+		return 0
+	}
+	return d.Sum / float64(d.Count)
+}
+
+// StdDev 分布标准差（总体标准差）
+func (d *ScoreDistribution) StdDev() float64 {
+	if d.Count == 0 {
+		return 0
+	}
+	mean := d.Mean()
+	variance := d.SumSq/float64(d.Count) - mean*mean
+	if variance <= 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// Normalize 计算评分在分布中的百分位与Z分数
+func (d *ScoreDistribution) Normalize(score int) NormalizedScore {
+	n := NormalizedScore{Raw: score, SampleSize: d.Count}
+	if d.Count == 0 {
+		return n
+	}
+	score = clampScore(score)
+	var below int64
+	for i := 0; i < score; i++ {
+		below += d.Histogram[i]
+	}
+	n.Percentile = (float64(below) + float64(d.Histogram[score])/2) / float64(d.Count) * 100
+	if std := d.StdDev(); std > 0 {
+		n.ZScore = (float64(score) - d.Mean()) / std
+	}
+	return n
+}
+
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > maxScore {
+		return maxScore
+	}
+	return score
+}
+
+// PromptVersion 根据系统提示词和用户提示词模板计算提示词版本
+func PromptVersion(sysPrompt string, usrPromptTemplate string) string {
+	h := sha256.New()
+	h.Write([]byte(sysPrompt))
+	h.Write([]byte{0})
+	h.Write([]byte(usrPromptTemplate))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// ScoreRepository 评分样本与分布的存储接口
+type ScoreRepository interface {
+	// AddSample 保存一次评分样本并更新对应的分布，返回更新后的分布
+	AddSample(ctx context.Context, sample ScoreSample) (ScoreDistribution, error)
+	// GetDistribution 获取某个ScoreKey的分布，不存在时返回空分布
+	GetDistribution(ctx context.Context, key ScoreKey) (ScoreDistribution, error)
+	// Recalibrate 根据since之后保存的样本重建分布，since为零值时使用全部样本
+	Recalibrate(ctx context.Context, key ScoreKey, since time.Time) (ScoreDistribution, error)
+	// ListKeys 列出所有存在分布的ScoreKey
+	ListKeys(ctx context.Context) ([]ScoreKey, error)
+}
+
+// MemoryScoreRepository 基于内存的评分存储（用于测试或未配置数据库时）
+type MemoryScoreRepository struct {
+	mu      sync.Mutex
+	samples map[ScoreKey][]ScoreSample
+	dists   map[ScoreKey]*ScoreDistribution
+}
+
+// NewMemoryScoreRepository 创建内存评分存储
+func NewMemoryScoreRepository() *MemoryScoreRepository {
+	return &MemoryScoreRepository{
+		samples: make(map[ScoreKey][]ScoreSample),
+		dists:   make(map[ScoreKey]*ScoreDistribution),
+	}
+}
+
+// AddSample 保存样本并更新分布
+func (r *MemoryScoreRepository) AddSample(ctx context.Context, sample ScoreSample) (ScoreDistribution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if sample.CreatedAt.IsZero() {
+		sample.CreatedAt = time.Now()
+	}
+	r.samples[sample.ScoreKey] = append(r.samples[sample.ScoreKey], sample)
+	d, ok := r.dists[sample.ScoreKey]
+	if !ok {
+		d = &ScoreDistribution{ScoreKey: sample.ScoreKey}
+		r.dists[sample.ScoreKey] = d
+	}
+	d.add(sample.Score)
+	d.UpdatedAt = sample.CreatedAt
+	return *d, nil
+}
+
+// GetDistribution 获取分布
+func (r *MemoryScoreRepository) GetDistribution(ctx context.Context, key ScoreKey) (ScoreDistribution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d, ok := r.dists[key]; ok {
+		return *d, nil
+	}
+	return ScoreDistribution{ScoreKey: key}, nil
+}
+
+// Recalibrate 根据样本重建分布
+func (r *MemoryScoreRepository) Recalibrate(ctx context.Context, key ScoreKey, since time.Time) (ScoreDistribution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := &ScoreDistribution{ScoreKey: key, UpdatedAt: time.Now()}
+	for _, s := range r.samples[key] {
+		if s.CreatedAt.Before(since) {
+			continue
+		}
+		d.add(s.Score)
+	}
+	r.dists[key] = d
+	return *d, nil
+}
+
+// ListKeys 列出所有ScoreKey
+func (r *MemoryScoreRepository) ListKeys(ctx context.Context) ([]ScoreKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]ScoreKey, 0, len(r.dists))
+	for k := range r.dists {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys, nil
+}
+
+// MongoScoreRepository 基于MongoDB的评分存储
+// 样本保存在score_samples集合，累计分布保存在score_distributions集合
+type MongoScoreRepository struct {
+	client  *mongo.Client
+	samples *mongo.Collection
+	dists   *mongo.Collection
+}
+
+// NewMongoScoreRepository 创建MongoDB评分存储
+// 配置项与NewMongoDBRepository相同，另支持samples_col_name和distributions_col_name
+func NewMongoScoreRepository(config map[string]interface{}) (*MongoScoreRepository, error) {
+	client, db, err := connectMongoDB(config)
+	if err != nil {
+		return nil, err
+	}
+	samplesCol, ok := config["samples_col_name"].(string)
+	if !ok {
+		samplesCol = "score_samples"
+	}
+	distsCol, ok := config["distributions_col_name"].(string)
+	if !ok {
+		distsCol = "score_distributions"
+	}
+	return &MongoScoreRepository{
+		client:  client,
+		samples: db.Collection(samplesCol),
+		dists:   db.Collection(distsCol),
+	}, nil
+}
+
+func scoreKeyFilter(key ScoreKey) bson.M {
+	return bson.M{"agent_key": key.AgentKey, "job_id": key.JobID, "prompt_version": key.PromptVersion}
+}
+
+// AddSample 保存样本并以原子操作更新分布
+func (r *MongoScoreRepository) AddSample(ctx context.Context, sample ScoreSample) (ScoreDistribution, error) {
+	if sample.CreatedAt.IsZero() {
+		sample.CreatedAt = time.Now()
+	}
+	if _, err := r.samples.InsertOne(ctx, sample); err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to save score sample: %w", err)
+	}
+
+	filter := scoreKeyFilter(sample.ScoreKey)
+	// 首次出现时初始化直方图数组，之后才能按下标累加
+	var empty [maxScore + 1]int64
+	_, err := r.dists.UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": bson.M{"histogram": empty[:], "count": 0, "sum": 0, "sum_sq": 0}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to init score distribution: %w", err)
+	}
+
+	score := clampScore(sample.Score)
+	var d ScoreDistribution
+	err = r.dists.FindOneAndUpdate(ctx, filter,
+		bson.M{
+			"$inc": bson.M{
+				"count":                            1,
+				"sum":                              float64(score),
+				"sum_sq":                           float64(score * score),
+				fmt.Sprintf("histogram.%d", score): 1,
+			},
+			"$set": bson.M{"updated_at": sample.CreatedAt},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&d)
+	if err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to update score distribution: %w", err)
+	}
+	return d, nil
+}
+
+// GetDistribution 获取分布
+func (r *MongoScoreRepository) GetDistribution(ctx context.Context, key ScoreKey) (ScoreDistribution, error) {
+	var d ScoreDistribution
+	err := r.dists.FindOne(ctx, scoreKeyFilter(key)).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return ScoreDistribution{ScoreKey: key}, nil
+	}
+	if err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to get score distribution: %w", err)
+	}
+	return d, nil
+}
+
+// Recalibrate 根据样本重建分布并覆盖保存
+func (r *MongoScoreRepository) Recalibrate(ctx context.Context, key ScoreKey, since time.Time) (ScoreDistribution, error) {
+	filter := scoreKeyFilter(key)
+	if !since.IsZero() {
+		filter["created_at"] = bson.M{"$gte": since}
+	}
+	cursor, err := r.samples.Find(ctx, filter)
+	if err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to load score samples: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	d := ScoreDistribution{ScoreKey: key, UpdatedAt: time.Now()}
+	for cursor.Next(ctx) {
+		var s ScoreSample
+		if err := cursor.Decode(&s); err != nil {
+			return ScoreDistribution{}, fmt.Errorf("failed to decode score sample: %w", err)
+		}
+		d.add(s.Score)
+	}
+	if err := cursor.Err(); err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to load score samples: %w", err)
+	}
+
+	_, err = r.dists.ReplaceOne(ctx, scoreKeyFilter(key), d, options.Replace().SetUpsert(true))
+	if err != nil {
+		return ScoreDistribution{}, fmt.Errorf("failed to save score distribution: %w", err)
+	}
+	return d, nil
+}
+
+// ListKeys 列出所有ScoreKey
+func (r *MongoScoreRepository) ListKeys(ctx context.Context) ([]ScoreKey, error) {
+	cursor, err := r.dists.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"agent_key": 1, "job_id": 1, "prompt_version": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list score keys: %w", err)
+	}
+	var keys []ScoreKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to list score keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Close 关闭MongoDB连接
+func (r *MongoScoreRepository) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
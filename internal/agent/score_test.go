@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScoreDistribution_Normalize(t *testing.T) {
+	var d ScoreDistribution
+	for _, s := range []int{60, 70, 70, 80, 90} {
+		d.add(s)
+	}
+	n := d.Normalize(70)
+	// 低于70的1个，等于70的2个计一半：(1+1)/5
+	if math.Abs(n.Percentile-40) > 1e-9 {
+		t.Fatalf("expected percentile 40, got %v", n.Percentile)
+	}
+	if math.Abs(n.ZScore-(70-74)/d.StdDev()) > 1e-9 {
+		t.Fatalf("unexpected z-score %v", n.ZScore)
+	}
+	if n.SampleSize != 5 {
+		t.Fatalf("expected sample size 5, got %d", n.SampleSize)
+	}
+}
+
+func TestMemoryScoreRepository_Recalibrate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryScoreRepository()
+	key := ScoreKey{AgentKey: "secondary/gemini/flash", JobID: "job", PromptVersion: "v1"}
+	old := time.Now().Add(-48 * time.Hour)
+	for _, s := range []int{10, 20, 30} {
+		if _, err := repo.AddSample(ctx, ScoreSample{ScoreKey: key, Score: s, CreatedAt: old}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.AddSample(ctx, ScoreSample{ScoreKey: key, Score: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := repo.Recalibrate(ctx, key, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Count != 1 || d.Mean() != 90 {
+		t.Fatalf("expected only the recent sample to remain, got count=%d mean=%v", d.Count, d.Mean())
+	}
+}
+
+func TestComputeConsensus_UsesNormalizedScores(t *testing.T) {
+	a := newTestManager()
+	a.scoring.MinSamples = 2
+	a.scores = NewMemoryScoreRepository()
+	ctx := context.Background()
+
+	// 宽松评审员历史评分普遍偏高，严格评审员普遍偏低
+	lenient := ScoreKey{AgentKey: "secondary/fake/lenient", JobID: "job"}
+	strict := ScoreKey{AgentKey: "secondary/fake/strict", JobID: "job"}
+	for _, s := range []int{80, 85, 90} {
+		a.scores.AddSample(ctx, ScoreSample{ScoreKey: lenient, Score: s})
+	}
+	for _, s := range []int{50, 55, 60} {
+		a.scores.AddSample(ctx, ScoreSample{ScoreKey: strict, Score: s})
+	}
+
+	msgs := []*Message{
+		{AgentKey: lenient.AgentKey, Evaluation: &CandidateEvaluation{MatchScore: 85}},
+		{AgentKey: strict.AgentKey, Evaluation: &CandidateEvaluation{MatchScore: 55}},
+	}
+	a.normalizeScores(ctx, "job", msgs)
+	c := a.computeConsensus(msgs)
+	if !c.Normalized {
+		t.Fatal("expected consensus to use normalized scores")
+	}
+	// 两位评审员都给出了各自分布的中位分，标准化后没有分歧
+	if c.Spread > 1e-9 || math.Abs(c.MeanPercentile-50) > 1e-9 {
+		t.Fatalf("unexpected consensus: %+v", c)
+	}
+	if c.MeanScore != 70 {
+		t.Fatalf("expected raw mean 70, got %v", c.MeanScore)
+	}
+}
@@ -0,0 +1,97 @@
+// Package skills 将中英文及各种写法的技能名称归一为统一的英文名称，
+// 使不同语言简历的技能可以相互比较
+package skills
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+//go:embed taxonomy.json
+var taxonomyJSON []byte
+
+// Skill 技能分类体系中的一个技能条目
+type Skill struct {
+	Name     string   `json:"name"`     // 规范名称
+	Category string   `json:"category"` // 所属分类
+	Aliases  []string `json:"aliases"`  // 别名（不区分大小写）
+	Related  []string `json:"related"`  // 相关技能
+}
+
+// taxonomy 内置的技能分类体系，技能归一化与lookup_skill工具共用，保证两者给出相同的统一名称
+var taxonomy []Skill
+
+// aliases 技能的常见写法到统一名称的映射，键为lookupKey处理后的结果
+var aliases = map[string]string{}
+
+// canonical 统一名称及其别名，由技能分类体系生成
+var canonical = map[string][]string{}
+
+func init() {
+	if err := json.Unmarshal(taxonomyJSON, &taxonomy); err != nil {
+		panic("skills: invalid taxonomy.json: " + err.Error())
+	}
+	for _, s := range taxonomy {
+		canonical[s.Name] = s.Aliases
+		aliases[lookupKey(s.Name)] = s.Name
+		for _, alt := range s.Aliases {
+			aliases[lookupKey(alt)] = s.Name
+		}
+	}
+}
+
+// Taxonomy 返回内置技能分类体系的副本
+func Taxonomy() []Skill {
+	out := make([]Skill, len(taxonomy))
+	copy(out, taxonomy)
+	return out
+}
+
+// lookupKey 统一大小写与全角字符，并去掉空格和常见分隔符
+func lookupKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0xFF01 && r <= 0xFF5E { // 全角ASCII
+			r -= 0xFEE0
+		}
+		switch {
+		case unicode.IsSpace(r), r == '-', r == '_', r == '.':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// Normalize 返回技能的统一名称，未知技能去除首尾空白后原样返回
+func Normalize(skill string) string {
+	skill = strings.TrimSpace(skill)
+	if name, ok := aliases[lookupKey(skill)]; ok {
+		return name
+	}
+	return skill
+}
+
+// NormalizeAll 归一化技能列表并按统一名称去重，保持原有顺序
+func NormalizeAll(list []string) []string {
+	if len(list) == 0 {
+		return list
+	}
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		name := Normalize(s)
+		key := Key(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// Key 返回用于比较的技能键，不同写法的同一技能得到相同的键
+func Key(skill string) string {
+	return lookupKey(Normalize(skill))
+}
@@ -0,0 +1,28 @@
+package skills
+
+import "testing"
+
+func TestTaxonomy(t *testing.T) {
+	// 同一写法不能指向两个统一名称
+	owner := make(map[string]string)
+	for _, s := range Taxonomy() {
+		for _, alt := range append([]string{s.Name}, s.Aliases...) {
+			key := lookupKey(alt)
+			if prev, ok := owner[key]; ok && prev != s.Name {
+				t.Errorf("%q is an alias of both %s and %s", alt, prev, s.Name)
+			}
+			owner[key] = s.Name
+		}
+	}
+	cases := map[string]string{
+		"微服务":         "Microservices",
+		"Spring-Boot": "Spring",
+		"ＧＯＬＡＮＧ":      "Go",
+		"Rocket":      "Rocket",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"easyHR/internal/agent/config"
+	"easyHR/pkg/logger"
+
+	"github.com/eino-contrib/jsonschema"
+)
+
+// 评审Agent被跳过的原因
+const (
+	SkipReasonError        = "error"          // Agent调用返回错误
+	SkipReasonAgentTimeout = "agent_timeout"  // 单个Agent调用超时
+	SkipReasonStageTimeout = "stage_timeout"  // 阶段超时时仍未返回
+	SkipReasonQuorum       = "quorum_reached" // 阶段已达到法定响应数，剩余Agent被取消
+	SkipReasonCancelled    = "cancelled"      // 调用方取消了分析
+)
+
+// SkippedReviewer 记录未参与最终结果的评审Agent及原因
+type SkippedReviewer struct {
+	SessionID string `json:"session_id" bson:"session_id"`
+	Role      string `json:"role" bson:"role"`
+	ModelType string `json:"model_type" bson:"model_type"`
+	ModelName string `json:"model_name" bson:"model_name"`
+	Reason    string `json:"reason" bson:"reason"`
+	Detail    string `json:"detail,omitempty" bson:"detail,omitempty"`
+}
+
+// stageResult 单个评审阶段的结果
+type stageResult struct {
+	msgs    []*Message
+	skipped []SkippedReviewer
+}
+
+// stageReply 单个Agent的返回
+type stageReply struct {
+	agent  *Agent
+	msg    *Message
+	err    error
+	reason string
+}
+
+// stageRequest 一个评审阶段的输入
+type stageRequest struct {
+	file        string
+	prompt      string // 渲染后的用户提示词
+	usrTemplate string // 渲染前的用户提示词模板，用于计算结果的提示词版本
+	language    string // 简历语言，用于选择系统提示词
+	jobID       string // 非空时评审结果在写入审计记录前按岗位完成评分标准化
+	audit       auditInfo
+}
+
+// runStage 按照StagePolicy并发执行一个评审阶段
+// 达到Quorum、阶段超时或全部返回后立即结束，并取消仍在运行的Agent
+// 每个Agent的调用无论成功、失败或被取消都会写入审计记录
+func (a *AiAgentManager) runStage(ctx context.Context, agents []*Agent, policy config.StagePolicy, req stageRequest) stageResult {
+	var stageCtx context.Context
+	var cancel context.CancelFunc
+	if policy.Timeout > 0 {
+		stageCtx, cancel = context.WithTimeout(ctx, policy.Timeout)
+	} else {
+		stageCtx, cancel = context.WithCancel(ctx)
+	}
+	// 阶段结束时取消所有未返回的Agent
+	defer cancel()
+
+	quorum := policy.Quorum
+	if quorum <= 0 || quorum > len(agents) {
+		quorum = len(agents)
+	}
+
+	// 缓冲区与Agent数量一致，被取消的Agent返回时不会阻塞
+	replies := make(chan stageReply, len(agents))
+	pending := make(map[*Agent]bool, len(agents))
+	for _, agent := range agents {
+		pending[agent] = true
+		go func(agent *Agent) {
+			callCtx := stageCtx
+			if policy.AgentTimeout > 0 {
+				var callCancel context.CancelFunc
+				callCtx, callCancel = context.WithTimeout(stageCtx, policy.AgentTimeout)
+				defer callCancel()
+			}
+			start := time.Now()
+			sysMsg := localizePrompt(agent.SysMsg, req.language)
+			msg, err := agent.addTask(callCtx, sysMsg, req.file, req.prompt)
+			record := msg
+			if err != nil {
+				record = agent.failedMessage(callCtx, sysMsg, req.file, req.prompt, err)
+			}
+			record.PromptVersion = PromptVersion(sysMsg, req.usrTemplate)
+			record.StartedAt = start
+			record.LatencyMs = time.Since(start).Milliseconds()
+			req.audit.apply(record)
+			if err == nil && req.jobID != "" {
+				// 审计记录中保存标准化分数，与原始评分一并可查
+				a.normalizeScores(ctx, req.jobID, []*Message{msg})
+			}
+			a.saveAudit(ctx, *record)
+			reply := stageReply{agent: agent, msg: msg, err: err}
+			if err != nil {
+				reply.reason = SkipReasonError
+				if stageCtx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+					reply.reason = SkipReasonAgentTimeout
+				}
+			}
+			replies <- reply
+		}(agent)
+	}
+
+	var res stageResult
+wait:
+	for len(pending) > 0 && len(res.msgs) < quorum {
+		select {
+		case reply := <-replies:
+			delete(pending, reply.agent)
+			if reply.err != nil {
+				a.l.Error("agent add task failed",
+					logger.Field{Key: "session_id", Val: reply.agent.SessionID},
+					logger.Field{Key: "model_type", Val: reply.agent.model.GetModelType()},
+					logger.Field{Key: "model_name", Val: reply.agent.ModelName},
+					logger.Field{Key: "error", Val: reply.err},
+				)
+				res.skipped = append(res.skipped, newSkippedReviewer(reply.agent, reply.reason, reply.err))
+				continue
+			}
+			res.msgs = append(res.msgs, reply.msg)
+		case <-stageCtx.Done():
+			break wait
+		}
+	}
+
+	// 剩余未返回的Agent按阶段结束原因记录
+	reason := SkipReasonQuorum
+	switch {
+	case len(res.msgs) >= quorum:
+	case ctx.Err() != nil:
+		reason = SkipReasonCancelled
+	default:
+		reason = SkipReasonStageTimeout
+	}
+	for _, agent := range agents {
+		if !pending[agent] {
+			continue
+		}
+		a.l.Warn("评审Agent未在阶段内返回，已跳过",
+			logger.Field{Key: "session_id", Val: agent.SessionID},
+			logger.Field{Key: "model_name", Val: agent.ModelName},
+			logger.Field{Key: "reason", Val: reason},
+		)
+		res.skipped = append(res.skipped, newSkippedReviewer(agent, reason, nil))
+	}
+	return res
+}
+
+func newSkippedReviewer(agent *Agent, reason string, err error) SkippedReviewer {
+	s := SkippedReviewer{
+		SessionID: agent.SessionID,
+		Role:      agent.role,
+		ModelType: agent.model.GetModelType(),
+		ModelName: agent.ModelName,
+		Reason:    reason,
+	}
+	if err != nil {
+		s.Detail = err.Error()
+	}
+	return s
+}
+
+// runStructured 依次调用agents生成符合outputSchema的结果，第一个生成成功且parse通过的消息即为结果
+// 与评审阶段不同，这里只需要一个结果，因此按顺序调用而非并发；每次调用都写入审计记录
+func (a *AiAgentManager) runStructured(ctx context.Context, agents []*Agent, req stageRequest, outputSchema *jsonschema.Schema, parse func(content string) error) (*Message, error) {
+	var lastErr error
+	for _, ag := range agents {
+		start := time.Now()
+		msg, err := ag.generateStructured(ctx, ag.SysMsg, req.file, req.prompt, outputSchema)
+		if err == nil {
+			if err = parse(msg.Content); err != nil {
+				msg.Error = fmt.Sprintf("failed to parse structured output: %v", err)
+			}
+		} else {
+			msg = ag.failedMessage(ctx, ag.SysMsg, req.file, req.prompt, err)
+		}
+		msg.PromptVersion = PromptVersion(ag.SysMsg, req.usrTemplate)
+		msg.StartedAt = start
+		msg.LatencyMs = time.Since(start).Milliseconds()
+		req.audit.apply(msg)
+		a.saveAudit(ctx, *msg)
+		if err != nil {
+			a.l.Error("agent failed to generate structured output",
+				logger.Field{Key: "session_id", Val: ag.SessionID},
+				logger.Field{Key: "role", Val: ag.role},
+				logger.Field{Key: "error", Val: err},
+			)
+			lastErr = err
+			continue
+		}
+		return msg, nil
+	}
+	return nil, lastErr
+}
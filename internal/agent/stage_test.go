@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"easyHR/internal/agent/config"
+	"easyHR/pkg/logger"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeModel 按照固定延迟返回结果的AIModel，延迟期间响应ctx取消
+type fakeModel struct {
+	delay   time.Duration
+	content string
+}
+
+func (f *fakeModel) GenerateResponse(ctx context.Context, messages []*schema.Message) (*schema.Message, error) {
+	select {
+	case <-time.After(f.delay):
+		return &schema.Message{Role: schema.Assistant, Content: f.content}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *fakeModel) GetModelType() string {
+	return "fake"
+}
+
+func newTestManager() *AiAgentManager {
+	return &AiAgentManager{
+		primaryReviewAgents:   make(map[string]*Agent),
+		secondaryReviewAgents: make(map[string]*Agent),
+		l:                     logger.NewNopLogger(),
+	}
+}
+
+func TestRunStage_Quorum(t *testing.T) {
+	a := newTestManager()
+	agents := []*Agent{
+		newAgent(&fakeModel{delay: 10 * time.Millisecond, content: `{"match_score":80}`}, "1", "secondary", "sys"),
+		newAgent(&fakeModel{delay: 20 * time.Millisecond, content: `{"match_score":70}`}, "2", "secondary", "sys"),
+		newAgent(&fakeModel{delay: time.Minute, content: `{"match_score":60}`}, "3", "secondary", "sys"),
+	}
+
+	start := time.Now()
+	res := a.runStage(context.Background(), agents, config.StagePolicy{Quorum: 2}, stageRequest{file: "cv.pdf", prompt: "usr", usrTemplate: "usr"})
+	if time.Since(start) > time.Second {
+		t.Fatalf("stage should finish once quorum is reached, took %v", time.Since(start))
+	}
+	if len(res.msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(res.msgs))
+	}
+	if len(res.skipped) != 1 || res.skipped[0].SessionID != "3" || res.skipped[0].Reason != SkipReasonQuorum {
+		t.Fatalf("unexpected skipped reviewers: %+v", res.skipped)
+	}
+}
+
+func TestRunStage_Timeouts(t *testing.T) {
+	a := newTestManager()
+	agents := []*Agent{
+		newAgent(&fakeModel{delay: 10 * time.Millisecond, content: "{}"}, "1", "secondary", "sys"),
+		newAgent(&fakeModel{delay: 200 * time.Millisecond, content: "{}"}, "2", "secondary", "sys"),
+		newAgent(&fakeModel{delay: time.Minute, content: "{}"}, "3", "secondary", "sys"),
+	}
+
+	policy := config.StagePolicy{
+		Timeout:      500 * time.Millisecond,
+		AgentTimeout: 100 * time.Millisecond,
+	}
+	res := a.runStage(context.Background(), agents, policy, stageRequest{file: "cv.pdf", prompt: "usr", usrTemplate: "usr"})
+	if len(res.msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(res.msgs))
+	}
+	reasons := make(map[string]string)
+	for _, s := range res.skipped {
+		reasons[s.SessionID] = s.Reason
+	}
+	if reasons["2"] != SkipReasonAgentTimeout || reasons["3"] != SkipReasonAgentTimeout {
+		t.Fatalf("unexpected skip reasons: %+v", reasons)
+	}
+
+	// 阶段超时先于单Agent超时触发
+	policy = config.StagePolicy{Timeout: 50 * time.Millisecond}
+	res = a.runStage(context.Background(), agents, policy, stageRequest{file: "cv.pdf", prompt: "usr", usrTemplate: "usr"})
+	if len(res.msgs) != 1 || len(res.skipped) != 2 {
+		t.Fatalf("unexpected stage result: %d messages, %+v", len(res.msgs), res.skipped)
+	}
+	for _, s := range res.skipped {
+		if s.Reason != SkipReasonStageTimeout {
+			t.Fatalf("expected stage timeout, got %+v", s)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"easyHR/pkg/snowflake"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -24,6 +29,12 @@ type MessageRepository interface {
 	// 接收上下文和会话ID，返回消息列表和错误
 	GetMessagesBySessionID(ctx context.Context, sessionID string) ([]Message, error)
 
+	// GetMessagesByCandidateID 根据候选人ID获取消息列表，按创建时间升序
+	GetMessagesByCandidateID(ctx context.Context, candidateID string) ([]Message, error)
+
+	// GetMessagesByJobID 根据岗位ID获取消息列表，按创建时间升序
+	GetMessagesByJobID(ctx context.Context, jobID string) ([]Message, error)
+
 	// DeleteMessage 删除消息
 	// 接收上下文和消息ID，返回删除结果和错误
 	DeleteMessage(ctx context.Context, id uint) (bool, error)
@@ -32,6 +43,10 @@ type MessageRepository interface {
 	// 接收上下文和会话ID，返回删除结果和错误
 	DeleteMessagesBySessionID(ctx context.Context, sessionID string) (bool, error)
 
+	// DeleteMessagesBefore 删除创建时间早于before的消息，用于执行保留策略
+	// 返回删除的消息数量和错误
+	DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error)
+
 	// GetMessageCount 获取消息总数
 	// 接收上下文，返回消息总数和错误
 	GetMessageCount(ctx context.Context) (int64, error)
@@ -69,8 +84,7 @@ func NewMessageRepository(storageType StorageType, config map[string]interface{}
 		// 目前未实现MySQL存储，可扩展
 		return nil, ErrStorageTypeNotSupported
 	case StorageTypeMemory:
-		// 目前未实现内存存储，可扩展
-		return nil, ErrStorageTypeNotSupported
+		return NewMemoryMessageRepository(), nil
 	default:
 		return nil, ErrStorageTypeNotSupported
 	}
@@ -103,12 +117,45 @@ type MongoDBRepository struct {
 	client     *mongo.Client     // MongoDB客户端
 	db         *mongo.Database   // 数据库实例
 	collection *mongo.Collection // 集合实例
+	ids        *snowflake.Node   // 生成消息ID
 }
 
 // NewMongoDBRepository 创建一个新的MongoDBRepository实例
 // 接收配置信息，返回MongoDBRepository实例和错误
 // 配置信息包含MongoDB连接URL、数据库名称和集合名称
 func NewMongoDBRepository(config map[string]interface{}) (*MongoDBRepository, error) {
+	client, db, err := connectMongoDB(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 从配置中获取集合名称
+	colName, ok := config["col_name"].(string)
+	if !ok || colName == "" {
+		// 使用默认集合名称
+		colName = "messages"
+	}
+
+	// 获取集合实例
+	collection := db.Collection(colName)
+
+	ids, err := snowflake.NewNode(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回MongoDBRepository实例
+	return &MongoDBRepository{
+		client:     client,
+		db:         db,
+		collection: collection,
+		ids:        ids,
+	}, nil
+}
+
+// connectMongoDB 根据配置建立MongoDB连接并返回数据库实例
+// 配置信息包含MongoDB连接URL、数据库名称以及可选的用户名和密码
+func connectMongoDB(config map[string]interface{}) (*mongo.Client, *mongo.Database, error) {
 	// 从配置中获取MongoDB连接URL
 	connURL, ok := config["conn_url"].(string)
 	if !ok {
@@ -123,39 +170,25 @@ func NewMongoDBRepository(config map[string]interface{}) (*MongoDBRepository, er
 		dbName = "ai_helper"
 	}
 
-	// 从配置中获取集合名称
-	colName, ok := config["col_name"].(string)
-	if !ok {
-		// 使用默认集合名称
-		colName = "messages"
-	}
-
 	// 设置MongoDB客户端选项
 	opts := options.Client().ApplyURI(connURL)
+	if username, ok := config["username"].(string); ok && username != "" {
+		password, _ := config["password"].(string)
+		opts.SetAuth(options.Credential{Username: username, Password: password})
+	}
 
 	// 创建MongoDB客户端
 	client, err := mongo.Connect(context.Background(), opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
 
 	// 检查连接是否成功
 	if err := client.Ping(context.Background(), readpref.Primary()); err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		return nil, nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	// 获取数据库实例
-	db := client.Database(dbName)
-
-	// 获取集合实例
-	collection := db.Collection(colName)
-
-	// 返回MongoDBRepository实例
-	return &MongoDBRepository{
-		client:     client,
-		db:         db,
-		collection: collection,
-	}, nil
+	return client, client.Database(dbName), nil
 }
 
 // SaveMessage 保存消息到MongoDB
@@ -166,25 +199,37 @@ func (r *MongoDBRepository) SaveMessage(ctx context.Context, msg Message) (Messa
 		msg.CreatedAt = time.Now()
 	}
 
+	// 消息ID为uint，由snowflake生成，不使用MongoDB默认的ObjectID
+	if msg.ID == 0 {
+		msg.ID = uint(r.ids.Generate())
+	}
+
 	// 保存消息到MongoDB
-	result, err := r.collection.InsertOne(ctx, msg)
-	if err != nil {
+	if _, err := r.collection.InsertOne(ctx, msg); err != nil {
 		return Message{}, fmt.Errorf("%w: %v", ErrFailedToSaveMessage, err)
 	}
 
-	// 将ObjectID转换为uint类型（仅用于演示，实际使用时可能需要调整）
-	// 注意：MongoDB使用ObjectID作为默认ID，这里简化处理
-	msg.ID = uint(result.InsertedID.(uint))
-
 	return msg, nil
 }
 
 // GetMessagesBySessionID 根据会话ID获取消息列表
 // 接收上下文和会话ID，返回消息列表和错误
 func (r *MongoDBRepository) GetMessagesBySessionID(ctx context.Context, sessionID string) ([]Message, error) {
-	// 构建查询条件
-	filter := bson.M{"session_id": sessionID}
+	return r.findMessages(ctx, bson.M{"session_id": sessionID})
+}
 
+// GetMessagesByCandidateID 根据候选人ID获取消息列表
+func (r *MongoDBRepository) GetMessagesByCandidateID(ctx context.Context, candidateID string) ([]Message, error) {
+	return r.findMessages(ctx, bson.M{"candidate_id": candidateID})
+}
+
+// GetMessagesByJobID 根据岗位ID获取消息列表
+func (r *MongoDBRepository) GetMessagesByJobID(ctx context.Context, jobID string) ([]Message, error) {
+	return r.findMessages(ctx, bson.M{"job_id": jobID})
+}
+
+// findMessages 按条件查询消息，按创建时间升序排列
+func (r *MongoDBRepository) findMessages(ctx context.Context, filter bson.M) ([]Message, error) {
 	// 设置排序选项
 	sort := bson.D{{Key: "created_at", Value: 1}} // 按创建时间升序排列
 
@@ -253,6 +298,51 @@ func (r *MongoDBRepository) DeleteMessagesBySessionID(ctx context.Context, sessi
 	return true, nil
 }
 
+// DeleteMessagesBefore 删除创建时间早于before的消息
+func (r *MongoDBRepository) DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrFailedToDeleteMessage, err)
+	}
+	return result.DeletedCount, nil
+}
+
+// SetRetention 按保留时长在created_at上创建TTL索引，由MongoDB自动清理过期消息
+// retention为0时删除TTL索引，消息永久保留；同时为常用查询字段创建索引
+func (r *MongoDBRepository) SetRetention(ctx context.Context, retention time.Duration) error {
+	indexes := r.collection.Indexes()
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "session_id", Value: 1}}},
+		{Keys: bson.D{{Key: "candidate_id", Value: 1}}},
+		{Keys: bson.D{{Key: "job_id", Value: 1}}},
+	}
+	if _, err := indexes.CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create message indexes: %w", err)
+	}
+
+	// 保留时长变化时TTL索引需重建
+	if _, err := indexes.DropOne(ctx, messageTTLIndex); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Name != "IndexNotFound" {
+			return fmt.Errorf("failed to drop message ttl index: %w", err)
+		}
+	}
+	if retention <= 0 {
+		return nil
+	}
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName(messageTTLIndex).SetExpireAfterSeconds(int32(retention / time.Second)),
+	}
+	if _, err := indexes.CreateOne(ctx, ttl); err != nil {
+		return fmt.Errorf("failed to create message ttl index: %w", err)
+	}
+	return nil
+}
+
+// messageTTLIndex 消息保留策略使用的TTL索引名称
+const messageTTLIndex = "created_at_ttl"
+
 // GetMessageCount 获取消息总数
 // 接收上下文，返回消息总数和错误
 func (r *MongoDBRepository) GetMessageCount(ctx context.Context) (int64, error) {
@@ -275,3 +365,108 @@ func (r *MongoDBRepository) Close(ctx context.Context) error {
 
 	return nil
 }
+
+// MemoryMessageRepository 内存消息存储，用于测试和未配置数据库的场景
+type MemoryMessageRepository struct {
+	mu       sync.RWMutex
+	messages []Message
+	nextID   uint
+}
+
+// NewMemoryMessageRepository 创建一个新的内存消息存储
+func NewMemoryMessageRepository() *MemoryMessageRepository {
+	return &MemoryMessageRepository{}
+}
+
+// SaveMessage 保存消息，未设置ID时自动分配
+func (r *MemoryMessageRepository) SaveMessage(ctx context.Context, msg Message) (Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	if msg.ID == 0 {
+		r.nextID++
+		msg.ID = r.nextID
+	}
+	r.messages = append(r.messages, msg)
+	return msg, nil
+}
+
+// GetMessagesBySessionID 根据会话ID获取消息列表
+func (r *MemoryMessageRepository) GetMessagesBySessionID(ctx context.Context, sessionID string) ([]Message, error) {
+	return r.filter(func(m *Message) bool { return m.SessionID == sessionID }), nil
+}
+
+// GetMessagesByCandidateID 根据候选人ID获取消息列表
+func (r *MemoryMessageRepository) GetMessagesByCandidateID(ctx context.Context, candidateID string) ([]Message, error) {
+	return r.filter(func(m *Message) bool { return m.CandidateID == candidateID }), nil
+}
+
+// GetMessagesByJobID 根据岗位ID获取消息列表
+func (r *MemoryMessageRepository) GetMessagesByJobID(ctx context.Context, jobID string) ([]Message, error) {
+	return r.filter(func(m *Message) bool { return m.JobID == jobID }), nil
+}
+
+// filter 返回满足条件的消息，按创建时间升序
+func (r *MemoryMessageRepository) filter(match func(*Message) bool) []Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Message
+	for i := range r.messages {
+		if match(&r.messages[i]) {
+			out = append(out, r.messages[i])
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// DeleteMessage 删除消息
+func (r *MemoryMessageRepository) DeleteMessage(ctx context.Context, id uint) (bool, error) {
+	if r.deleteWhere(func(m *Message) bool { return m.ID == id }) == 0 {
+		return false, ErrMessageNotFound
+	}
+	return true, nil
+}
+
+// DeleteMessagesBySessionID 根据会话ID删除所有消息
+func (r *MemoryMessageRepository) DeleteMessagesBySessionID(ctx context.Context, sessionID string) (bool, error) {
+	if r.deleteWhere(func(m *Message) bool { return m.SessionID == sessionID }) == 0 {
+		return false, ErrMessageNotFound
+	}
+	return true, nil
+}
+
+// DeleteMessagesBefore 删除创建时间早于before的消息
+func (r *MemoryMessageRepository) DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.deleteWhere(func(m *Message) bool { return m.CreatedAt.Before(before) }), nil
+}
+
+func (r *MemoryMessageRepository) deleteWhere(match func(*Message) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.messages[:0]
+	var deleted int64
+	for _, m := range r.messages {
+		if match(&m) {
+			deleted++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	r.messages = kept
+	return deleted
+}
+
+// GetMessageCount 获取消息总数
+func (r *MemoryMessageRepository) GetMessageCount(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.messages)), nil
+}
+
+// Close 内存存储无需关闭
+func (r *MemoryMessageRepository) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"easyHR/internal/agent/tools"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// bsonFinder 按MongoDB驱动的方式把消息编码为BSON后再查询与解码，
+// 只支持工具用到的等值与$in条件
+type bsonFinder struct {
+	messages []Message
+}
+
+func (f *bsonFinder) FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error {
+	var docs []bson.Raw
+	for _, msg := range f.messages {
+		data, err := bson.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if doc := bson.Raw(data); matches(doc, filter.(bson.M)) {
+			docs = append(docs, doc)
+		}
+	}
+	data, err := bson.Marshal(bson.M{"docs": docs})
+	if err != nil {
+		return err
+	}
+	return bson.Raw(data).Lookup("docs").Unmarshal(results)
+}
+
+func matches(doc bson.Raw, filter bson.M) bool {
+	for key, cond := range filter {
+		val, err := doc.LookupErr(strings.Split(key, ".")...)
+		if err != nil {
+			return false
+		}
+		op, ok := cond.(bson.M)
+		if !ok {
+			if val.StringValue() != cond {
+				return false
+			}
+			continue
+		}
+		var have []string
+		if err := val.Unmarshal(&have); err != nil {
+			return false
+		}
+		found := false
+		for _, h := range have {
+			for _, w := range op["$in"].([]string) {
+				found = found || h == w
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPastEvaluationsTool_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	eval := &CandidateEvaluation{CandidateName: "张三", MatchScore: 82, Skills: []string{"Go", "Redis"}, Summary: "后端基础扎实"}
+	finder := &bsonFinder{messages: []Message{
+		{SessionID: "s1", Role: "primary", Evaluation: eval},
+		{SessionID: "s1", Role: "secondary", Evaluation: &CandidateEvaluation{CandidateName: "张三", Skills: []string{"Go"}}},
+	}}
+
+	out, err := tools.NewPastEvaluationsTool(finder, "messages").InvokableRun(ctx, `{"skills":["golang"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evals []tools.PastEvaluation
+	if err := json.Unmarshal([]byte(out), &evals); err != nil {
+		t.Fatal(err)
+	}
+	if len(evals) != 1 || evals[0].CandidateName != "张三" || evals[0].MatchScore != 82 || len(evals[0].Skills) != 2 || evals[0].Summary != eval.Summary {
+		t.Fatalf("unexpected evaluations %+v", evals)
+	}
+}
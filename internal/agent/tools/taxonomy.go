@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"strings"
+	"sync"
+
+	"easyHR/internal/agent/skills"
+)
+
+// Skill 技能分类体系中的一个技能条目
+type Skill = skills.Skill
+
+// SkillTaxonomy 技能分类体系，支持按名称或别名查找
+type SkillTaxonomy struct {
+	skills []Skill
+	index  map[string]int // 小写名称/别名到skills下标的映射
+}
+
+var (
+	defaultTaxonomy     *SkillTaxonomy
+	defaultTaxonomyOnce sync.Once
+)
+
+// DefaultSkillTaxonomy 返回内置的技能分类体系（与技能归一化共用skills包中的taxonomy.json）
+func DefaultSkillTaxonomy() (*SkillTaxonomy, error) {
+	defaultTaxonomyOnce.Do(func() {
+		defaultTaxonomy = NewSkillTaxonomy(skills.Taxonomy())
+	})
+	return defaultTaxonomy, nil
+}
+
+// NewSkillTaxonomy 根据技能列表构建分类体系
+func NewSkillTaxonomy(skills []Skill) *SkillTaxonomy {
+	t := &SkillTaxonomy{
+		skills: skills,
+		index:  make(map[string]int, len(skills)*2),
+	}
+	for i, s := range skills {
+		t.index[normalizeSkillKey(s.Name)] = i
+		for _, alias := range s.Aliases {
+			t.index[normalizeSkillKey(alias)] = i
+		}
+	}
+	return t
+}
+
+// Lookup 按名称或别名查找技能，先按skills.Normalize归一化，与评估结果中的技能名称一致
+func (t *SkillTaxonomy) Lookup(name string) (Skill, bool) {
+	i, ok := t.index[normalizeSkillKey(skills.Normalize(name))]
+	if !ok {
+		i, ok = t.index[normalizeSkillKey(name)]
+	}
+	if !ok {
+		return Skill{}, false
+	}
+	return t.skills[i], true
+}
+
+func normalizeSkillKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"easyHR/internal/agent/prompts/position"
+	"easyHR/internal/agent/skills"
+	"easyHR/internal/cv"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 工具名称，模型通过名称发起调用
+const (
+	ToolGetJobPosition       = "get_job_position"
+	ToolFindPrevApplications = "find_previous_applications"
+	ToolFindPastEvaluations  = "find_past_evaluations"
+	ToolLookupSkill          = "lookup_skill"
+)
+
+// 单次查询返回的最大记录数
+const defaultQueryLimit = 10
+
+// Finder 工具查询本地存储所需的最小接口，由pkg/storage.Storage实现
+type Finder interface {
+	FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error
+}
+
+// Config 工具查询的集合配置
+type Config struct {
+	CVCollection      string // 简历集合，默认cvs
+	MessageCollection string // Agent消息集合，默认messages
+	// PastEvaluations 是否注册find_past_evaluations；消息集合只由审计记录写入，未启用审计时应关闭，否则工具始终查不到结果
+	PastEvaluations bool
+}
+
+// DefaultTools 创建评审Agent可用的全部工具，find_past_evaluations仅在cfg.PastEvaluations为true时注册
+func DefaultTools(finder Finder, cfg Config) ([]tool.InvokableTool, error) {
+	if cfg.CVCollection == "" {
+		cfg.CVCollection = "cvs"
+	}
+	if cfg.MessageCollection == "" {
+		cfg.MessageCollection = "messages"
+	}
+	taxonomy, err := DefaultSkillTaxonomy()
+	if err != nil {
+		return nil, err
+	}
+	list := []tool.InvokableTool{
+		NewJobPositionTool(),
+		NewPreviousApplicationsTool(finder, cfg.CVCollection),
+		NewSkillLookupTool(taxonomy),
+	}
+	if cfg.PastEvaluations {
+		list = append(list, NewPastEvaluationsTool(finder, cfg.MessageCollection))
+	}
+	return list, nil
+}
+
+// JobPositionInput get_job_position的参数
+type JobPositionInput struct {
+	JobID string `json:"job_id"`
+}
+
+// JobPositionOutput get_job_position的返回
+type JobPositionOutput struct {
+	Position        *position.JobPosition `json:"position,omitempty"`
+	AvailableJobIDs []string              `json:"available_job_ids,omitempty"`
+}
+
+// NewJobPositionTool 查询完整岗位信息的工具，job_id为空时返回可用岗位列表
+func NewJobPositionTool() tool.InvokableTool {
+	info := &schema.ToolInfo{
+		Name: ToolGetJobPosition,
+		Desc: "查询岗位的完整描述（职责、要求、技能、福利）。job_id为空时返回所有可用的岗位ID。",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"job_id": {Type: schema.String, Desc: "岗位ID，例如SoftWareDeveloper_jobId"},
+		}),
+	}
+	return utils.NewTool(info, func(ctx context.Context, in JobPositionInput) (JobPositionOutput, error) {
+		if in.JobID == "" {
+			ids, err := position.ListJobIDs()
+			return JobPositionOutput{AvailableJobIDs: ids}, err
+		}
+		pos, err := position.LoadJobPosition(in.JobID)
+		if err != nil {
+			return JobPositionOutput{}, err
+		}
+		return JobPositionOutput{Position: pos}, nil
+	})
+}
+
+// PreviousApplicationsInput find_previous_applications的参数
+type PreviousApplicationsInput struct {
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+}
+
+// Application 历史投递记录
+type Application struct {
+	FilePath string    `json:"file_path" bson:"file_path"`
+	ParsedAt time.Time `json:"parsed_at" bson:"parsed_at"`
+}
+
+// NewPreviousApplicationsTool 按手机号/邮箱查询同一候选人历史投递的工具
+func NewPreviousApplicationsTool(finder Finder, collection string) tool.InvokableTool {
+	info := &schema.ToolInfo{
+		Name: ToolFindPrevApplications,
+		Desc: "按手机号或邮箱查询该候选人以往投递过的简历记录。",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"phone": {Type: schema.String, Desc: "候选人手机号"},
+			"email": {Type: schema.String, Desc: "候选人邮箱"},
+		}),
+	}
+	return utils.NewTool(info, func(ctx context.Context, in PreviousApplicationsInput) ([]Application, error) {
+		var or []bson.M
+		for _, v := range []string{strings.TrimSpace(in.Phone), strings.TrimSpace(in.Email)} {
+			if v != "" {
+				or = append(or, bson.M{"content": bson.M{"$regex": regexp.QuoteMeta(v), "$options": "i"}})
+			}
+		}
+		// 已抽取候选人档案的简历按规范化后的联系方式精确匹配
+		if phone := cv.NormalizePhone(in.Phone); phone != "" {
+			or = append(or, bson.M{"profile.contact.phones": phone})
+		}
+		if email := cv.NormalizeEmail(in.Email); email != "" {
+			or = append(or, bson.M{"profile.contact.emails": email})
+		}
+		if len(or) == 0 {
+			return nil, errors.New("phone和email至少需要提供一个")
+		}
+		var apps []Application
+		if err := finder.FindMany(ctx, collection, bson.M{"$or": or}, defaultQueryLimit, &apps); err != nil {
+			return nil, err
+		}
+		return apps, nil
+	})
+}
+
+// PastEvaluationsInput find_past_evaluations的参数
+type PastEvaluationsInput struct {
+	Skills []string `json:"skills"`
+}
+
+// PastEvaluation 相似候选人的历史评估摘要
+type PastEvaluation struct {
+	CandidateName string   `json:"candidate_name" bson:"candidate_name"`
+	MatchScore    int      `json:"match_score" bson:"match_score"`
+	Skills        []string `json:"skills" bson:"skills"`
+	Summary       string   `json:"summary" bson:"summary"`
+}
+
+// NewPastEvaluationsTool 查询拥有相似技能的候选人历史评估的工具
+// 历史评估来自审计记录（agent.MongoDBRepository）写入的消息集合，collection须与审计集合一致
+func NewPastEvaluationsTool(finder Finder, collection string) tool.InvokableTool {
+	info := &schema.ToolInfo{
+		Name: ToolFindPastEvaluations,
+		Desc: "查询拥有相似技能的候选人过往的PrimaryReviewer评估结果，用于校准评分。",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"skills": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     "候选人的技能列表",
+				Required: true,
+			},
+		}),
+	}
+	return utils.NewTool(info, func(ctx context.Context, in PastEvaluationsInput) ([]PastEvaluation, error) {
+		if len(in.Skills) == 0 {
+			return nil, errors.New("skills不能为空")
+		}
+		// 同时匹配原始写法与归一化后的技能名称，兼容归一化之前保存的评估
+		filter := bson.M{
+			"role":              "primary",
+			"evaluation.skills": bson.M{"$in": append(append([]string{}, in.Skills...), skills.NormalizeAll(in.Skills)...)},
+		}
+		var docs []struct {
+			Evaluation PastEvaluation `bson:"evaluation"`
+		}
+		if err := finder.FindMany(ctx, collection, filter, defaultQueryLimit, &docs); err != nil {
+			return nil, err
+		}
+		evals := make([]PastEvaluation, 0, len(docs))
+		for _, d := range docs {
+			evals = append(evals, d.Evaluation)
+		}
+		return evals, nil
+	})
+}
+
+// SkillLookupInput lookup_skill的参数
+type SkillLookupInput struct {
+	Skill string `json:"skill"`
+}
+
+// SkillLookupOutput lookup_skill的返回
+type SkillLookupOutput struct {
+	Found bool   `json:"found"`
+	Skill *Skill `json:"skill,omitempty"`
+}
+
+// NewSkillLookupTool 在技能分类体系中查询技能规范名称、分类与相关技能的工具
+func NewSkillLookupTool(taxonomy *SkillTaxonomy) tool.InvokableTool {
+	info := &schema.ToolInfo{
+		Name: ToolLookupSkill,
+		Desc: "在技能分类体系中查询技能的规范名称、分类、别名和相关技能。",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"skill": {Type: schema.String, Desc: "技能名称或别名", Required: true},
+		}),
+	}
+	return utils.NewTool(info, func(ctx context.Context, in SkillLookupInput) (SkillLookupOutput, error) {
+		s, ok := taxonomy.Lookup(in.Skill)
+		if !ok {
+			return SkillLookupOutput{Found: false}, nil
+		}
+		return SkillLookupOutput{Found: true, Skill: &s}, nil
+	})
+}
@@ -0,0 +1,350 @@
+package cv
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+// Kinds of attachment recognised by the Converter.
+const (
+	KindPDF     = "pdf"
+	KindWord    = "word"
+	KindImage   = "image"
+	KindArchive = "archive"
+)
+
+// attachmentKinds maps file extensions to the kind of attachment they hold.
+var attachmentKinds = map[string]string{
+	".pdf":  KindPDF,
+	".doc":  KindWord,
+	".docx": KindWord,
+	".jpg":  KindImage,
+	".jpeg": KindImage,
+	".png":  KindImage,
+	".zip":  KindArchive,
+	".rar":  KindArchive,
+	".7z":   KindArchive,
+}
+
+// AttachmentKind returns the kind of the file by its extension, or "" when the
+// Converter cannot handle it.
+func AttachmentKind(name string) string {
+	return attachmentKinds[strings.ToLower(filepath.Ext(name))]
+}
+
+// ConvertConfig configures the Converter.
+type ConvertConfig struct {
+	// ArchiveTool is a 7-Zip compatible command used for RAR and 7z archives,
+	// e.g. "7z". Left empty, such archives are reported as unsupported.
+	ArchiveTool string `yaml:"archive_tool"`
+	// MaxArchiveFiles caps the files taken from one archive, 20 by default.
+	MaxArchiveFiles int `yaml:"max_archive_files"`
+	// MaxExtractedSize caps the bytes extracted from one archive, 100MB by default.
+	MaxExtractedSize int64 `yaml:"max_extracted_size"`
+	// MaxArchiveEntries caps the entries one archive may hold, 1000 by default.
+	MaxArchiveEntries int `yaml:"max_archive_entries"`
+}
+
+// Unsupported is a file the Converter could not turn into a resume.
+type Unsupported struct {
+	Name   string
+	Reason string
+}
+
+// Conversion is the result of converting one attachment.
+type Conversion struct {
+	// Files are the canonical resumes: PDF files or UTF-8 text files.
+	Files []string
+	// Unsupported lists files that could not be converted.
+	Unsupported []Unsupported
+	// Skipped lists archive entries that are not resumes, such as portfolio assets.
+	Skipped []string
+}
+
+// Converter turns resume attachments into the canonical forms the pipeline parses:
+// PDFs are kept, images are wrapped into a one-page PDF, Word documents become
+// UTF-8 text and archives are extracted and their resumes converted.
+type Converter struct {
+	cfg ConvertConfig
+}
+
+// NewConverter creates a Converter.
+func NewConverter(cfg ConvertConfig) *Converter {
+	if cfg.MaxArchiveFiles <= 0 {
+		cfg.MaxArchiveFiles = 20
+	}
+	if cfg.MaxExtractedSize <= 0 {
+		cfg.MaxExtractedSize = 100 << 20
+	}
+	if cfg.MaxArchiveEntries <= 0 {
+		cfg.MaxArchiveEntries = 1000
+	}
+	return &Converter{cfg: cfg}
+}
+
+// archiveToolTimeout bounds one run of the external archive tool.
+const archiveToolTimeout = time.Minute
+
+// Convert converts the attachment at path. Converted files are written next to it.
+func (c *Converter) Convert(path string) Conversion {
+	var res Conversion
+	c.convert(path, filepath.Base(path), true, &res)
+	return res
+}
+
+func (c *Converter) convert(path, name string, topLevel bool, res *Conversion) {
+	var (
+		out string
+		err error
+	)
+	switch AttachmentKind(path) {
+	case KindPDF:
+		out = path
+	case KindWord:
+		out, err = convertWord(path)
+	case KindImage:
+		out, err = convertImage(path)
+	case KindArchive:
+		if !topLevel {
+			res.Unsupported = append(res.Unsupported, Unsupported{Name: name, Reason: "nested archives are not extracted"})
+			return
+		}
+		c.convertArchive(path, name, res)
+		return
+	default:
+		res.Unsupported = append(res.Unsupported, Unsupported{Name: name, Reason: "unsupported file type"})
+		return
+	}
+	if err != nil {
+		res.Unsupported = append(res.Unsupported, Unsupported{Name: name, Reason: err.Error()})
+		return
+	}
+	res.Files = append(res.Files, out)
+}
+
+// convertArchive extracts the archive and converts the resumes inside. Documents
+// are taken in preference to images, which in a portfolio are usually artwork.
+func (c *Converter) convertArchive(path, name string, res *Conversion) {
+	dir := path + ".d"
+	files, err := c.extract(path, dir)
+	if err != nil {
+		res.Unsupported = append(res.Unsupported, Unsupported{Name: name, Reason: err.Error()})
+		return
+	}
+
+	var docs, images []string
+	for _, f := range files {
+		switch AttachmentKind(f) {
+		case KindPDF, KindWord:
+			docs = append(docs, f)
+		case KindImage:
+			images = append(images, f)
+		default:
+			rel, _ := filepath.Rel(dir, f)
+			res.Skipped = append(res.Skipped, name+"/"+rel)
+		}
+	}
+	resumes := docs
+	if len(resumes) == 0 {
+		resumes = images
+	} else {
+		for _, f := range images {
+			rel, _ := filepath.Rel(dir, f)
+			res.Skipped = append(res.Skipped, name+"/"+rel)
+		}
+	}
+	if len(resumes) == 0 {
+		res.Unsupported = append(res.Unsupported, Unsupported{Name: name, Reason: "archive contains no resume"})
+		return
+	}
+	if len(resumes) > c.cfg.MaxArchiveFiles {
+		for _, f := range resumes[c.cfg.MaxArchiveFiles:] {
+			rel, _ := filepath.Rel(dir, f)
+			res.Skipped = append(res.Skipped, name+"/"+rel)
+		}
+		resumes = resumes[:c.cfg.MaxArchiveFiles]
+	}
+	for _, f := range resumes {
+		rel, _ := filepath.Rel(dir, f)
+		c.convert(f, name+"/"+rel, false, res)
+	}
+}
+
+// extract unpacks the archive into dir and returns the extracted regular files.
+func (c *Converter) extract(path, dir string) ([]string, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".zip" {
+		return extractZip(path, dir, c.cfg.MaxExtractedSize, c.cfg.MaxArchiveEntries)
+	}
+	if c.cfg.ArchiveTool == "" {
+		return nil, fmt.Errorf("%s archives need archive_tool to be configured", strings.TrimPrefix(filepath.Ext(path), "."))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), archiveToolTimeout)
+	defer cancel()
+	// Check the listed sizes first so an archive bomb is never written to disk;
+	// listExtracted still checks what was actually extracted.
+	out, err := exec.CommandContext(ctx, c.cfg.ArchiveTool, "l", "-slt", path).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	entries, size := parseArchiveListing(out)
+	if entries > c.cfg.MaxArchiveEntries {
+		return nil, errArchiveTooManyEntries
+	}
+	if size > c.cfg.MaxExtractedSize {
+		return nil, errArchiveTooLarge
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	out, err = exec.CommandContext(ctx, c.cfg.ArchiveTool, "x", "-y", "-o"+dir, path).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return listExtracted(dir, c.cfg.MaxExtractedSize)
+}
+
+// parseArchiveListing counts the entries of a "7z l -slt" listing and sums
+// their uncompressed sizes. Entry blocks follow the "----------" separator.
+func parseArchiveListing(out []byte) (entries int, size int64) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	inEntries := false
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !inEntries {
+			inEntries = line == "----------"
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		switch {
+		case !ok:
+		case key == "Path":
+			entries++
+		case key == "Size":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				size += n
+			}
+		}
+	}
+	return entries, size
+}
+
+// extractZip unpacks a ZIP archive, refusing entries that escape dir and
+// stopping once maxSize bytes have been written or maxEntries entries seen.
+func extractZip(path, dir string, maxSize int64, maxEntries int) ([]string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer r.Close()
+
+	var (
+		files   []string
+		written int64
+	)
+	for i, f := range r.File {
+		if i >= maxEntries {
+			return nil, errArchiveTooManyEntries
+		}
+		if f.FileInfo().IsDir() || strings.HasPrefix(filepath.Base(f.Name), ".") || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(decodeZipName(f)))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("zip entry %q escapes the archive", f.Name)
+		}
+		n, err := extractZipFile(f, target, maxSize-written)
+		if err != nil {
+			return nil, err
+		}
+		written += n
+		files = append(files, target)
+	}
+	return files, nil
+}
+
+var (
+	errArchiveTooLarge       = errors.New("archive exceeds the extraction size limit")
+	errArchiveTooManyEntries = errors.New("archive exceeds the entry count limit")
+)
+
+func extractZipFile(f *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	out, err := os.Create(target)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	// Copy one byte past the limit to detect oversized entries without trusting the header.
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return n, err
+	}
+	if n > limit {
+		return n, errArchiveTooLarge
+	}
+	return n, nil
+}
+
+// decodeZipName returns the entry name, decoding GBK names written by Chinese
+// Windows archivers that do not set the UTF-8 flag.
+func decodeZipName(f *zip.File) string {
+	if f.NonUTF8 {
+		if name, ok := decodeGBK(f.Name); ok {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// listExtracted walks dir and returns its regular files.
+func listExtracted(dir string, maxSize int64) ([]string, error) {
+	var (
+		files []string
+		total int64
+	)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if total += info.Size(); total > maxSize {
+			return errArchiveTooLarge
+		}
+		files = append(files, p)
+		return nil
+	})
+	return files, err
+}
+
+// decodeGBK decodes GBK (GB18030) text.
+func decodeGBK(s string) (string, bool) {
+	out, _, err := transform.String(simplifiedchinese.GB18030.NewDecoder(), s)
+	return out, err == nil
+}
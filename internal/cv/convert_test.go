@@ -0,0 +1,184 @@
+package cv
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gen2brain/go-fitz"
+)
+
+// writeZip writes an archive holding the given entries.
+func writeZip(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, data := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// docx returns a minimal DOCX whose body holds the given paragraphs.
+func docx(t *testing.T, paragraphs ...string) []byte {
+	t.Helper()
+	var body strings.Builder
+	for _, p := range paragraphs {
+		body.WriteString("<w:p><w:r><w:t>" + p + "</w:t></w:r></w:p>")
+	}
+	path := filepath.Join(t.TempDir(), "cv.docx")
+	writeZip(t, path, map[string][]byte{
+		"word/document.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			body.String() + `</w:body></w:document>`),
+	})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 60, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 60; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 3), B: 128, A: 255})
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestConverter(t *testing.T) {
+	c := NewConverter(ConvertConfig{})
+	dir := t.TempDir()
+
+	// Word documents become UTF-8 text.
+	word := filepath.Join(dir, "张三-简历.docx")
+	if err := os.WriteFile(word, docx(t, "张三 Go developer", "Skills: gRPC"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := c.Convert(word)
+	if len(res.Files) != 1 || len(res.Unsupported) != 0 {
+		t.Fatalf("unexpected conversion: %+v", res)
+	}
+	text, err := os.ReadFile(res.Files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), "张三 Go developer\nSkills: gRPC") {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	// Images are wrapped into a PDF MuPDF can open.
+	img := filepath.Join(dir, "scan.png")
+	if err := os.WriteFile(img, pngImage(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = c.Convert(img)
+	if len(res.Files) != 1 || filepath.Ext(res.Files[0]) != ".pdf" {
+		t.Fatalf("unexpected conversion: %+v", res)
+	}
+	doc, err := fitz.New(res.Files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.NumPage() != 1 {
+		t.Fatalf("expected one page, got %d", doc.NumPage())
+	}
+	_ = doc.Close()
+
+	// Archives yield their documents; portfolio images and other files are skipped.
+	archive := filepath.Join(dir, "resume.zip")
+	writeZip(t, archive, map[string][]byte{
+		"resume/cv.docx":       docx(t, "Li Si"),
+		"resume/work/logo.png": pngImage(t),
+		"resume/readme.md":     []byte("hello"),
+		"../escape.txt":        []byte("x"),
+	})
+	res = c.Convert(archive)
+	if len(res.Unsupported) != 1 || !strings.Contains(res.Unsupported[0].Reason, "escapes") {
+		t.Fatalf("expected zip-slip entry to be rejected: %+v", res)
+	}
+
+	writeZip(t, archive, map[string][]byte{
+		"resume/cv.docx":       docx(t, "Li Si"),
+		"resume/work/logo.png": pngImage(t),
+		"resume/readme.md":     []byte("hello"),
+	})
+	res = c.Convert(archive)
+	if len(res.Files) != 1 || len(res.Skipped) != 2 || len(res.Unsupported) != 0 {
+		t.Fatalf("unexpected conversion: %+v", res)
+	}
+
+	// RAR needs an archive tool; without one the attachment is reported, not dropped.
+	rar := filepath.Join(dir, "cv.rar")
+	if err := os.WriteFile(rar, []byte("Rar!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = c.Convert(rar)
+	if len(res.Files) != 0 || len(res.Unsupported) != 1 || res.Unsupported[0].Name != "cv.rar" {
+		t.Fatalf("unexpected conversion: %+v", res)
+	}
+}
+
+func TestConverter_ArchiveLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	// ZIP extraction stops at the entry limit.
+	archive := filepath.Join(dir, "resume.zip")
+	writeZip(t, archive, map[string][]byte{
+		"cv.docx":   docx(t, "Li Si"),
+		"a.png":     pngImage(t),
+		"readme.md": []byte("hello"),
+	})
+	res := NewConverter(ConvertConfig{MaxArchiveEntries: 2}).Convert(archive)
+	if len(res.Files) != 0 || len(res.Unsupported) != 1 || !strings.Contains(res.Unsupported[0].Reason, "entry count") {
+		t.Fatalf("expected entry limit to reject the archive: %+v", res)
+	}
+
+	// RAR/7z sizes are checked from the listing before anything is extracted.
+	extracted := filepath.Join(dir, "extracted")
+	tool := filepath.Join(dir, "7z")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = l ]; then\n" +
+		"printf 'Path = cv.rar\\nType = Rar\\n\\n----------\\nPath = cv.pdf\\nSize = 600\\n\\nPath = big.bin\\nSize = 600\\n'\n" +
+		"exit 0\nfi\n" +
+		"touch " + extracted + "\n"
+	if err := os.WriteFile(tool, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rar := filepath.Join(dir, "cv.rar")
+	if err := os.WriteFile(rar, []byte("Rar!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = NewConverter(ConvertConfig{ArchiveTool: tool, MaxExtractedSize: 1000}).Convert(rar)
+	if len(res.Unsupported) != 1 || !strings.Contains(res.Unsupported[0].Reason, "size limit") {
+		t.Fatalf("expected size limit to reject the archive: %+v", res)
+	}
+	if _, err := os.Stat(extracted); !os.IsNotExist(err) {
+		t.Fatal("archive was extracted despite exceeding the size limit")
+	}
+}
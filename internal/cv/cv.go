@@ -14,11 +14,56 @@ type CV struct {
 	Content   string             `bson:"content" json:"content"`
 	ParsedAt  time.Time          `bson:"parsed_at" json:"parsed_at"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	// Submission metadata.
+	Subject     string       `bson:"subject,omitempty" json:"subject,omitempty"`
+	Sender      string       `bson:"sender,omitempty" json:"sender,omitempty"`
+	Application *Application `bson:"application,omitempty" json:"application,omitempty"`
+	Bulk        bool         `bson:"bulk,omitempty" json:"bulk,omitempty"`
+
+	// Identity, filled by IdentityResolver.
+	CandidateID primitive.ObjectID `bson:"candidate_id,omitempty" json:"candidate_id,omitempty"`
+	Version     int                `bson:"version,omitempty" json:"version,omitempty"`
+	Name        string             `bson:"name,omitempty" json:"name,omitempty"`
+	Phones      []string           `bson:"phones,omitempty" json:"phones,omitempty"`
+	Emails      []string           `bson:"emails,omitempty" json:"emails,omitempty"`
+	ContentHash string             `bson:"content_hash,omitempty" json:"content_hash,omitempty"`
+	SimHash     int64              `bson:"simhash,omitempty" json:"simhash,omitempty"`
+	DuplicateOf primitive.ObjectID `bson:"duplicate_of,omitempty" json:"duplicate_of,omitempty"`
+
+	// Language of the extracted text, see DetectLanguage.
+	Language string `bson:"language,omitempty" json:"language,omitempty"`
+
+	// Prompt-injection scan, filled by InjectionScanner.
+	Risk          *RiskReport `bson:"risk,omitempty" json:"risk,omitempty"`
+	ManualReview  bool        `bson:"manual_review,omitempty" json:"manual_review,omitempty"`
+	SanitizedPath string      `bson:"sanitized_path,omitempty" json:"sanitized_path,omitempty"` // visible text only, sent to the model instead of the PDF
+
+	// Structured extraction, filled by a ProfileExtractor.
+	Profile *CandidateProfile `bson:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// Submission is a resume file handed to the Service, with the mail it arrived in.
+type Submission struct {
+	FilePath    string
+	Subject     string
+	Sender      string
+	Application *Application // details parsed from the mail body, nil when there are none
+	Bulk        bool         // imported from a directory by an administrator rather than received by mail
+}
+
+// Application holds the details a candidate typed into the mail body.
+type Application struct {
+	Name     string `bson:"name,omitempty" json:"name,omitempty"`
+	Phone    string `bson:"phone,omitempty" json:"phone,omitempty"`
+	School   string `bson:"school,omitempty" json:"school,omitempty"`
+	Position string `bson:"position,omitempty" json:"position,omitempty"`
 }
 
 // Storage defines the interface for persisting data.
 type Storage interface {
 	Insert(ctx context.Context, collection string, doc interface{}) error
+	Update(ctx context.Context, collection string, filter interface{}, update interface{}) error
 }
 
 // Parser defines the interface for parsing CV documents.
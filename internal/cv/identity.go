@@ -0,0 +1,481 @@
+package cv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MatchReason describes why a resume was linked to an existing candidate.
+type MatchReason string
+
+const (
+	MatchNone    MatchReason = "new"          // no existing candidate matched
+	MatchContent MatchReason = "content_hash" // byte-identical resume already on file
+	MatchPhone   MatchReason = "phone"        // same normalized phone number
+	MatchEmail   MatchReason = "email"        // same normalized email address
+	MatchProfile MatchReason = "name_text"    // similar name and similar resume text
+)
+
+// ResumeVersion is one resume received from a candidate.
+type ResumeVersion struct {
+	CVID        primitive.ObjectID `bson:"cv_id" json:"cv_id"`
+	FilePath    string             `bson:"file_path" json:"file_path"`
+	Subject     string             `bson:"subject" json:"subject"`
+	ContentHash string             `bson:"content_hash" json:"content_hash"`
+	SimHash     int64              `bson:"simhash" json:"simhash"`
+	ReceivedAt  time.Time          `bson:"received_at" json:"received_at"`
+}
+
+// Candidate is a single applicant; every resume they send is linked to it as a version.
+type Candidate struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name         string             `bson:"name" json:"name"`
+	NameKey      string             `bson:"name_key" json:"name_key"`
+	Phones       []string           `bson:"phones" json:"phones"`
+	Emails       []string           `bson:"emails" json:"emails"`
+	SimHashBands []string           `bson:"simhash_bands" json:"-"`
+	Versions     []ResumeVersion    `bson:"versions" json:"versions"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Latest returns the most recently received resume version.
+func (c *Candidate) Latest() *ResumeVersion {
+	if len(c.Versions) == 0 {
+		return nil
+	}
+	return &c.Versions[len(c.Versions)-1]
+}
+
+// IdentityKeys are the normalized identifiers used to look up existing candidates.
+type IdentityKeys struct {
+	Name         string
+	NameKey      string
+	Phones       []string
+	Emails       []string
+	ContentHash  string
+	SimHash      uint64
+	SimHashBands []string
+}
+
+// CandidateRepository persists candidates.
+type CandidateRepository interface {
+	// FindCandidates returns candidates sharing any phone, email, name key, content hash or SimHash band with keys.
+	FindCandidates(ctx context.Context, keys IdentityKeys) ([]*Candidate, error)
+	// SaveCandidate inserts the candidate, or replaces it when ID is already set.
+	SaveCandidate(ctx context.Context, c *Candidate) error
+	// DeleteCandidate removes a candidate that was merged into another.
+	DeleteCandidate(ctx context.Context, id primitive.ObjectID) error
+}
+
+// IdentityConfig tunes duplicate detection.
+type IdentityConfig struct {
+	// NameThreshold is the minimum NameSimilarity for a name/text match. Defaults to 0.85.
+	NameThreshold float64 `yaml:"name_threshold"`
+	// MaxSimHashDistance is the maximum Hamming distance for resume texts to count as similar. Defaults to 10.
+	MaxSimHashDistance int `yaml:"max_simhash_distance"`
+}
+
+// Resolution is the outcome of resolving a resume to a candidate.
+type Resolution struct {
+	Candidate *Candidate
+	Reason    MatchReason
+	// Identical is set when the same file content was already received; re-analysis can be skipped.
+	Identical bool
+	// DuplicateOf is the CV that first carried identical content.
+	DuplicateOf primitive.ObjectID
+	// Merged lists candidates folded into Candidate because this resume matched several of them.
+	Merged []primitive.ObjectID
+}
+
+// IdentityResolver links incoming resumes to candidates.
+type IdentityResolver struct {
+	repo CandidateRepository
+	cfg  IdentityConfig
+	mu   sync.Mutex
+}
+
+// NewIdentityResolver creates a resolver backed by repo.
+func NewIdentityResolver(repo CandidateRepository, cfg IdentityConfig) *IdentityResolver {
+	if cfg.NameThreshold <= 0 {
+		cfg.NameThreshold = 0.85
+	}
+	if cfg.MaxSimHashDistance <= 0 {
+		cfg.MaxSimHashDistance = 10
+	}
+	return &IdentityResolver{repo: repo, cfg: cfg}
+}
+
+// ContentHash returns the hex sha256 of raw file content.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseSubject extracts the applicant name and contact details from a mail subject
+// such as "2026校园招聘-后端研发-Name-13333333333".
+func ParseSubject(subject string) (name string, phones, emails []string) {
+	parts := strings.Split(subject, "-")
+	if len(parts) >= 4 {
+		name = strings.TrimSpace(parts[2])
+	}
+	return name, ExtractPhones(subject), ExtractEmails(subject)
+}
+
+// Keys derives the identity keys of cv from the subject and resume text. The
+// sender address is not a key: shared and forwarding mailboxes (campus career
+// centres, recruiters, colleagues passing on a referral) send resumes of many
+// different applicants. It still counts when the same address also appears in
+// the subject or resume, since it is then taken from there.
+func Keys(cv *CV) IdentityKeys {
+	name, phones, emails := ParseSubject(cv.Subject)
+	if app := cv.Application; app != nil {
+		if name == "" {
+			name = strings.TrimSpace(app.Name)
+		}
+		if phone := NormalizePhone(app.Phone); phone != "" {
+			phones = appendUnique(phones, phone)
+		}
+	}
+	if cv.Name != "" {
+		name = cv.Name
+	}
+	for _, p := range ExtractPhones(cv.Content) {
+		phones = appendUnique(phones, p)
+	}
+	for _, e := range ExtractEmails(cv.Content) {
+		emails = appendUnique(emails, e)
+	}
+	// Contact details are matched on their own; leave them out of the text hash
+	// so a changed phone or address doesn't make an updated resume look unrelated.
+	body := emailPattern.ReplaceAllString(phonePattern.ReplaceAllString(cv.Content, ""), "")
+	sh := SimHash(body)
+	keys := IdentityKeys{
+		Name:        name,
+		NameKey:     NormalizeName(name),
+		Phones:      phones,
+		Emails:      emails,
+		ContentHash: cv.ContentHash,
+		SimHash:     sh,
+	}
+	if strings.TrimSpace(cv.Content) != "" {
+		keys.SimHashBands = simHashBands(sh)
+	}
+	return keys
+}
+
+// Resolve links cv to an existing candidate or creates a new one, records the resume
+// as a new version and fills the identity fields on cv. cv.ID must be set.
+func (r *IdentityResolver) Resolve(ctx context.Context, cv *CV) (*Resolution, error) {
+	if cv.ID.IsZero() {
+		return nil, errors.New("cv id is required to resolve identity")
+	}
+	keys := Keys(cv)
+	cv.Name, cv.Phones, cv.Emails = keys.Name, keys.Phones, keys.Emails
+	cv.SimHash = int64(keys.SimHash)
+
+	// Serialize resolution so two copies of the same resume arriving together
+	// don't both create a candidate.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found, err := r.repo.FindCandidates(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	res := &Resolution{Reason: MatchNone}
+	var matched []*Candidate
+	for _, c := range found {
+		reason, dup := r.match(c, keys)
+		if reason == MatchNone {
+			continue
+		}
+		matched = append(matched, c)
+		if rank(reason) > rank(res.Reason) {
+			res.Reason = reason
+		}
+		if !dup.IsZero() && res.DuplicateOf.IsZero() {
+			res.Identical = true
+			res.DuplicateOf = dup
+		}
+	}
+
+	now := time.Now()
+	var cand *Candidate
+	if len(matched) == 0 {
+		cand = &Candidate{CreatedAt: now}
+	} else {
+		sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.Before(matched[j].CreatedAt) })
+		cand = matched[0]
+		for _, other := range matched[1:] {
+			mergeCandidate(cand, other)
+			res.Merged = append(res.Merged, other.ID)
+		}
+	}
+
+	if cand.Name == "" {
+		cand.Name = keys.Name
+		cand.NameKey = keys.NameKey
+	}
+	for _, p := range keys.Phones {
+		cand.Phones = appendUnique(cand.Phones, p)
+	}
+	for _, e := range keys.Emails {
+		cand.Emails = appendUnique(cand.Emails, e)
+	}
+	for _, b := range keys.SimHashBands {
+		cand.SimHashBands = appendUnique(cand.SimHashBands, b)
+	}
+	cand.Versions = append(cand.Versions, ResumeVersion{
+		CVID:        cv.ID,
+		FilePath:    cv.FilePath,
+		Subject:     cv.Subject,
+		ContentHash: cv.ContentHash,
+		SimHash:     cv.SimHash,
+		ReceivedAt:  now,
+	})
+	cand.UpdatedAt = now
+
+	if err := r.repo.SaveCandidate(ctx, cand); err != nil {
+		return nil, err
+	}
+	for _, id := range res.Merged {
+		if err := r.repo.DeleteCandidate(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	cv.CandidateID = cand.ID
+	cv.Version = len(cand.Versions)
+	cv.DuplicateOf = res.DuplicateOf
+	res.Candidate = cand
+	return res, nil
+}
+
+// match reports whether keys identify candidate c, and the CV carrying identical content if any.
+func (r *IdentityResolver) match(c *Candidate, keys IdentityKeys) (MatchReason, primitive.ObjectID) {
+	var dup primitive.ObjectID
+	for _, v := range c.Versions {
+		if keys.ContentHash != "" && v.ContentHash == keys.ContentHash {
+			dup = v.CVID
+			return MatchContent, dup
+		}
+	}
+	for _, p := range keys.Phones {
+		if contains(c.Phones, p) {
+			return MatchPhone, dup
+		}
+	}
+	for _, e := range keys.Emails {
+		if contains(c.Emails, e) {
+			return MatchEmail, dup
+		}
+	}
+	if len(keys.SimHashBands) == 0 || NameSimilarity(c.Name, keys.Name) < r.cfg.NameThreshold {
+		return MatchNone, dup
+	}
+	for _, v := range c.Versions {
+		if HammingDistance(uint64(v.SimHash), keys.SimHash) <= r.cfg.MaxSimHashDistance {
+			return MatchProfile, dup
+		}
+	}
+	return MatchNone, dup
+}
+
+// rank orders match reasons by strength.
+func rank(r MatchReason) int {
+	switch r {
+	case MatchContent:
+		return 4
+	case MatchPhone:
+		return 3
+	case MatchEmail:
+		return 2
+	case MatchProfile:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// mergeCandidate folds other into dst, keeping resume versions in receive order.
+func mergeCandidate(dst, other *Candidate) {
+	if dst.Name == "" {
+		dst.Name, dst.NameKey = other.Name, other.NameKey
+	}
+	for _, p := range other.Phones {
+		dst.Phones = appendUnique(dst.Phones, p)
+	}
+	for _, e := range other.Emails {
+		dst.Emails = appendUnique(dst.Emails, e)
+	}
+	for _, b := range other.SimHashBands {
+		dst.SimHashBands = appendUnique(dst.SimHashBands, b)
+	}
+	dst.Versions = append(dst.Versions, other.Versions...)
+	sort.SliceStable(dst.Versions, func(i, j int) bool {
+		return dst.Versions[i].ReceivedAt.Before(dst.Versions[j].ReceivedAt)
+	})
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CandidateStorage is the subset of storage used by MongoCandidateRepository.
+type CandidateStorage interface {
+	Storage
+	FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error
+	Delete(ctx context.Context, collection string, filter interface{}) error
+}
+
+// maxCandidateMatches bounds how many candidates a single lookup may return.
+const maxCandidateMatches = 50
+
+// MongoCandidateRepository stores candidates in a MongoDB collection.
+type MongoCandidateRepository struct {
+	storage    CandidateStorage
+	collection string
+}
+
+// NewMongoCandidateRepository creates a repository on collection, defaulting to "candidates".
+func NewMongoCandidateRepository(storage CandidateStorage, collection string) *MongoCandidateRepository {
+	if collection == "" {
+		collection = "candidates"
+	}
+	return &MongoCandidateRepository{storage: storage, collection: collection}
+}
+
+func (m *MongoCandidateRepository) FindCandidates(ctx context.Context, keys IdentityKeys) ([]*Candidate, error) {
+	var or bson.A
+	if keys.ContentHash != "" {
+		or = append(or, bson.M{"versions.content_hash": keys.ContentHash})
+	}
+	if len(keys.Phones) > 0 {
+		or = append(or, bson.M{"phones": bson.M{"$in": keys.Phones}})
+	}
+	if len(keys.Emails) > 0 {
+		or = append(or, bson.M{"emails": bson.M{"$in": keys.Emails}})
+	}
+	if keys.NameKey != "" {
+		or = append(or, bson.M{"name_key": keys.NameKey})
+	}
+	if len(keys.SimHashBands) > 0 {
+		or = append(or, bson.M{"simhash_bands": bson.M{"$in": keys.SimHashBands}})
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+	var out []*Candidate
+	if err := m.storage.FindMany(ctx, m.collection, bson.M{"$or": or}, maxCandidateMatches, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (m *MongoCandidateRepository) SaveCandidate(ctx context.Context, c *Candidate) error {
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+		return m.storage.Insert(ctx, m.collection, c)
+	}
+	return m.storage.Update(ctx, m.collection, bson.M{"_id": c.ID}, bson.M{"$set": c})
+}
+
+func (m *MongoCandidateRepository) DeleteCandidate(ctx context.Context, id primitive.ObjectID) error {
+	return m.storage.Delete(ctx, m.collection, bson.M{"_id": id})
+}
+
+// MemoryCandidateRepository keeps candidates in memory, for tests and single-process use.
+type MemoryCandidateRepository struct {
+	mu         sync.Mutex
+	candidates map[primitive.ObjectID]*Candidate
+}
+
+// NewMemoryCandidateRepository creates an empty in-memory repository.
+func NewMemoryCandidateRepository() *MemoryCandidateRepository {
+	return &MemoryCandidateRepository{candidates: make(map[primitive.ObjectID]*Candidate)}
+}
+
+func (m *MemoryCandidateRepository) FindCandidates(ctx context.Context, keys IdentityKeys) ([]*Candidate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Candidate
+	for _, c := range m.candidates {
+		if candidateHasKey(c, keys) {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func candidateHasKey(c *Candidate, keys IdentityKeys) bool {
+	if keys.NameKey != "" && c.NameKey == keys.NameKey {
+		return true
+	}
+	for _, v := range c.Versions {
+		if keys.ContentHash != "" && v.ContentHash == keys.ContentHash {
+			return true
+		}
+	}
+	for _, p := range keys.Phones {
+		if contains(c.Phones, p) {
+			return true
+		}
+	}
+	for _, e := range keys.Emails {
+		if contains(c.Emails, e) {
+			return true
+		}
+	}
+	for _, b := range keys.SimHashBands {
+		if contains(c.SimHashBands, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryCandidateRepository) SaveCandidate(ctx context.Context, c *Candidate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+	}
+	cp := *c
+	m.candidates[c.ID] = &cp
+	return nil
+}
+
+func (m *MemoryCandidateRepository) DeleteCandidate(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.candidates, id)
+	return nil
+}
+
+// Get returns a copy of the candidate with id, or nil.
+func (m *MemoryCandidateRepository) Get(id primitive.ObjectID) *Candidate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.candidates[id]
+	if !ok {
+		return nil
+	}
+	cp := *c
+	return &cp
+}
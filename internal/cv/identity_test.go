@@ -0,0 +1,137 @@
+package cv
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const resumeText = `雷宇林 后端开发工程师
+电话: +86 133-3333-3333 邮箱: Lei.Yulin@Example.com
+教育背景: 2019-2023 某大学 计算机科学与技术 本科
+专业技能: 熟悉Go、MySQL、Redis，了解Docker与Kubernetes，熟悉常用数据结构与算法。
+项目经历: 负责订单系统的微服务拆分，基于gRPC设计服务间接口，引入Redis缓存热点数据，
+接口平均延迟从120ms降低到35ms；参与消息队列削峰改造，保证大促期间下单链路稳定。
+实习经历: 某互联网公司 后端开发实习生，负责支付对账服务的开发与维护，编写单元测试，
+推动接入链路追踪与告警，线上故障平均定位时间缩短一半。`
+
+func newCV(subject, sender, content string) *CV {
+	return &CV{
+		ID:          primitive.NewObjectID(),
+		Subject:     subject,
+		Sender:      sender,
+		Content:     content,
+		ContentHash: ContentHash([]byte(content)),
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := NormalizePhone("+86 133-3333-3333"); got != "13333333333" {
+		t.Fatalf("NormalizePhone = %q", got)
+	}
+	if got := NormalizeEmail(" Lei.Yulin@Example.COM "); got != "lei.yulin@example.com" {
+		t.Fatalf("NormalizeEmail = %q", got)
+	}
+	if NameSimilarity("Yulin Lei", "lei  yulin") != 1 {
+		t.Fatal("expected reordered latin names to match")
+	}
+	if HammingDistance(SimHash(resumeText), SimHash(resumeText+" 熟悉Kafka。")) > 10 {
+		t.Fatal("expected similar texts to have close SimHashes")
+	}
+}
+
+func TestIdentityResolver(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryCandidateRepository()
+	r := NewIdentityResolver(repo, IdentityConfig{})
+
+	first := newCV("2026校园招聘-后端研发-雷宇林-13333333333", "a@qq.com", resumeText)
+	res, err := r.Resolve(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != MatchNone || first.Version != 1 {
+		t.Fatalf("expected new candidate, got %s version %d", res.Reason, first.Version)
+	}
+
+	// 同一份简历从另一个邮箱再次投递
+	dup := newCV("2026校园招聘-后端研发-雷宇林-13333333333", "b@163.com", resumeText)
+	res, err = r.Resolve(ctx, dup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Identical || res.DuplicateOf != first.ID || dup.CandidateID != first.CandidateID {
+		t.Fatalf("expected identical resume linked to first, got %+v", res)
+	}
+
+	// 更换了手机号和邮箱的更新版简历，只能通过姓名和正文相似度识别
+	updated := newCV("社招-后端研发-雷 宇林-无", "", strings.NewReplacer("+86 133-3333-3333", "135 0000 0000", "Lei.Yulin@Example.com", "yl@new.cn").Replace(resumeText)+"\n熟悉Kafka。")
+	res, err = r.Resolve(ctx, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Identical || res.Reason != MatchProfile || updated.CandidateID != first.CandidateID {
+		t.Fatalf("expected updated resume matched by profile, got %+v", res)
+	}
+	if c := repo.Get(first.CandidateID); c == nil || len(c.Versions) != 3 || len(c.Phones) != 2 {
+		t.Fatalf("unexpected candidate %+v", c)
+	}
+
+	// 不同的人
+	other := newCV("2026校园招聘-后端研发-张三-13900000000", "", "张三 前端开发 React Vue")
+	if _, err := r.Resolve(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if other.CandidateID == first.CandidateID {
+		t.Fatal("different applicant linked to the same candidate")
+	}
+
+	// 邮件正文填写的手机号同样用于识别
+	applied := newCV("简历投递", "", "前端开发 React Vue TypeScript 组件库")
+	applied.Application = &Application{Name: "张三", Phone: "139-0000-0000"}
+	res, err = r.Resolve(ctx, applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != MatchPhone || applied.CandidateID != other.CandidateID {
+		t.Fatalf("expected resume matched by the phone in the mail body, got %+v", res)
+	}
+
+	// 从同一个转发邮箱投递的不同候选人不因发件人合并
+	forwarded := newCV("内推-前端研发-王五-13700000000", "lei.yulin@example.com", "王五 测试开发 Selenium")
+	if _, err := r.Resolve(ctx, forwarded); err != nil {
+		t.Fatal(err)
+	}
+	if forwarded.CandidateID == first.CandidateID {
+		t.Fatal("applicant linked to another candidate by the sender address")
+	}
+
+	// 同时命中两位候选人时合并为一人
+	bridge := newCV("2026校园招聘-后端研发-张三-13900000000", "", "邮箱: lei.yulin@example.com")
+	res, err = r.Resolve(ctx, bridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Merged) != 1 || repo.Get(res.Merged[0]) != nil {
+		t.Fatalf("expected candidates merged, got %+v", res)
+	}
+	if len(res.Candidate.Versions) != 6 {
+		t.Fatalf("expected all versions kept after merge, got %d", len(res.Candidate.Versions))
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"张三，五年Go后端开发经验，熟悉MySQL、Redis与Kubernetes，负责订单系统的重构":                                    LanguageChinese,
+		"Zhang San. Backend engineer with five years of Go, MySQL and Kubernetes experience.": LanguageEnglish,
+		"张三 Zhang San\n后端工程师 Backend engineer with five years of Go experience":               LanguageMixed,
+		"13900000000": "",
+	}
+	for text, want := range cases {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cv
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"os"
+	"strconv"
+)
+
+// a4Width and a4Height are the A4 page size in PDF points.
+const (
+	a4Width  = 595.0
+	a4Height = 842.0
+)
+
+// convertImage wraps a photographed or scanned resume into a one-page PDF next
+// to it, so it follows the same path as PDF resumes. The page carries no text
+// layer; its content is read by the model.
+func convertImage(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Re-encode as baseline RGB JPEG, which every PDF reader can draw.
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return "", err
+	}
+	out := path + ".pdf"
+	if err := os.WriteFile(out, imagePDF(buf.Bytes(), img.Bounds().Dx(), img.Bounds().Dy()), 0o644); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// imagePDF builds a PDF with one A4-wide page showing the JPEG image.
+func imagePDF(jpg []byte, width, height int) []byte {
+	// Fit the image to the A4 width, growing the page for long screenshots.
+	pageW := a4Width
+	drawH := pageW * float64(height) / float64(width)
+	pageH := max(drawH, a4Height)
+	content := fmt.Sprintf("q %s 0 0 %s 0 %s cm /Im0 Do Q", fmtFloat(pageW), fmtFloat(drawH), fmtFloat(pageH-drawH))
+
+	var b bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			b.WriteString("stream\n")
+			b.Write(stream)
+			b.WriteString("\nendstream\n")
+		}
+		b.WriteString("endobj\n")
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>",
+		fmtFloat(pageW), fmtFloat(pageH)), nil)
+	obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>",
+		width, height, len(jpg)), jpg)
+	obj(fmt.Sprintf("<< /Length %d >>", len(content)), []byte(content))
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,341 @@
+package cv
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gen2brain/go-fitz"
+)
+
+// Kinds of findings reported by the InjectionScanner.
+const (
+	FindingInvisible   = "invisible"   // text drawn in white or not drawn at all
+	FindingTiny        = "tiny"        // text below the minimum readable font size
+	FindingOffPage     = "off_page"    // text placed outside the page
+	FindingInstruction = "instruction" // text that reads like an instruction to the model
+)
+
+// Finding is one suspicious piece of text in a resume.
+type Finding struct {
+	Kind string `bson:"kind" json:"kind"`
+	Page int    `bson:"page" json:"page"` // 1-based
+	Text string `bson:"text" json:"text"`
+}
+
+// RiskReport is the result of scanning a resume for prompt injection.
+type RiskReport struct {
+	Score    int       `bson:"score" json:"score"` // 0-100
+	Flagged  bool      `bson:"flagged" json:"flagged"`
+	Findings []Finding `bson:"findings,omitempty" json:"findings,omitempty"`
+
+	// VisibleText is the text a human reader sees, with hidden text removed.
+	VisibleText string `bson:"-" json:"-"`
+}
+
+// Hidden reports whether any text is hidden from a human reader.
+func (r *RiskReport) Hidden() bool {
+	for _, f := range r.Findings {
+		if f.Kind != FindingInstruction {
+			return true
+		}
+	}
+	return false
+}
+
+// KindCounts returns the number of findings of each kind.
+func (r *RiskReport) KindCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Kind]++
+	}
+	return counts
+}
+
+// ModelSummary describes the findings for warning the model about them, as
+// "kind: count" lines sorted by kind. The planted text itself is never
+// included, so it cannot reach a prompt through the warning. Nothing is
+// returned when the hidden text was stripped, since the model never sees it.
+func (r *RiskReport) ModelSummary(stripped bool) []string {
+	if r == nil || stripped || len(r.Findings) == 0 {
+		return nil
+	}
+	counts := r.KindCounts()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	out := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		out = append(out, kind+": "+strconv.Itoa(counts[kind]))
+	}
+	return out
+}
+
+// ScanConfig configures the InjectionScanner.
+type ScanConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Strip       bool     `yaml:"strip"`         // send only the visible text to the model when hidden text is found
+	FlagScore   int      `yaml:"flag_score"`    // risk score routing a resume to manual review, default 50
+	MinFontSize float64  `yaml:"min_font_size"` // smaller text counts as hidden, default 4pt
+	Phrases     []string `yaml:"phrases"`       // extra instruction patterns (regular expressions)
+}
+
+// Risk added per finding; the total is capped at 100.
+var findingWeights = map[string]int{
+	FindingInvisible:   25,
+	FindingOffPage:     20,
+	FindingTiny:        15,
+	FindingInstruction: 40,
+}
+
+// defaultPhrases match instructions aimed at the reviewing model.
+var defaultPhrases = []string{
+	`ignore\s+(all\s+|any\s+)?(the\s+)?(previous|prior|above|earlier)\s+(instructions|prompts|rules)`,
+	`disregard\s+(all\s+|any\s+)?(the\s+)?(previous|prior|above|earlier)`,
+	`(forget|override)\s+(all\s+|your\s+)?(previous\s+|prior\s+)?instructions`,
+	`(system|developer)\s+prompt`,
+	`you\s+are\s+(now\s+)?(an?\s+)?(ai|assistant|language\s+model|chatgpt|gpt|gemini)\b`,
+	`\b(give|assign|rate|score)\b[^.\n]{0,40}\b(100|full\s+marks|highest\s+score)`,
+	`match_score`,
+	`忽略(之前|以上|上述|前面|先前)的?(所有)?(指令|指示|提示|要求|规则)`,
+	`(无视|忘记)(之前|以上|上述|所有)`,
+	`(打|给|评)[^。\n]{0,10}满分`,
+	`(评分|分数|打分)[^。\n]{0,6}(100|一百)`,
+	`系统提示词`,
+	`你(现在)?是一?个?(AI|人工智能|助手|模型)`,
+}
+
+// InjectionScanner looks for text in a PDF that a human reader would not see and
+// for instruction-like phrases, which candidates use to steer the reviewing model.
+type InjectionScanner struct {
+	cfg     ScanConfig
+	phrases []*regexp.Regexp
+}
+
+// NewInjectionScanner compiles the instruction patterns of cfg.
+func NewInjectionScanner(cfg ScanConfig) (*InjectionScanner, error) {
+	if cfg.FlagScore <= 0 {
+		cfg.FlagScore = 50
+	}
+	if cfg.MinFontSize <= 0 {
+		cfg.MinFontSize = 4
+	}
+	s := &InjectionScanner{cfg: cfg}
+	for _, p := range append(append([]string{}, defaultPhrases...), cfg.Phrases...) {
+		re, err := regexp.Compile(`(?i)` + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid injection phrase %q: %w", p, err)
+		}
+		s.phrases = append(s.phrases, re)
+	}
+	return s, nil
+}
+
+// Strip reports whether hidden text should be kept away from the model.
+func (s *InjectionScanner) Strip() bool {
+	return s.cfg.Strip
+}
+
+// Scan inspects every page of the PDF at path.
+func (s *InjectionScanner) Scan(path string) (*RiskReport, error) {
+	doc, err := fitz.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pdf: %w", err)
+	}
+	defer doc.Close()
+
+	report := &RiskReport{}
+	var visible strings.Builder
+	for n := 0; n < doc.NumPage(); n++ {
+		bound, err := doc.Bound(n)
+		if err != nil {
+			return nil, err
+		}
+		// The HTML output carries position, size and colour of each line; the SVG
+		// output contains only the glyphs that are actually painted.
+		page, err := doc.HTML(n, false)
+		if err != nil {
+			return nil, err
+		}
+		svg, err := doc.SVG(n)
+		if err != nil {
+			return nil, err
+		}
+		glyphs := parseGlyphs(svg)
+
+		var all strings.Builder
+		for _, line := range parseLines(page) {
+			onPage := line.left < float64(bound.Dx()) && line.top < float64(bound.Dy()) &&
+				line.left >= 0 && line.top+line.height >= 0
+			drawn := line.drawn(glyphs)
+			for _, span := range line.spans {
+				all.WriteString(span.text)
+				kind := ""
+				switch {
+				case !onPage:
+					kind = FindingOffPage
+				case !drawn || isLight(span.color):
+					kind = FindingInvisible
+				case span.size < s.cfg.MinFontSize:
+					kind = FindingTiny
+				}
+				if kind == "" {
+					visible.WriteString(span.text)
+					continue
+				}
+				report.addHidden(kind, n+1, span.text)
+			}
+			all.WriteString("\n")
+			visible.WriteString("\n")
+		}
+
+		s.matchPhrases(report, n+1, all.String())
+	}
+
+	report.VisibleText = visible.String()
+	s.score(report)
+	return report, nil
+}
+
+// ScanText looks for injected instructions in a plain-text resume, which
+// cannot hide text from human readers.
+func (s *InjectionScanner) ScanText(text string) *RiskReport {
+	report := &RiskReport{VisibleText: text}
+	s.matchPhrases(report, 1, text)
+	s.score(report)
+	return report
+}
+
+func (s *InjectionScanner) matchPhrases(report *RiskReport, page int, text string) {
+	for _, re := range s.phrases {
+		for _, m := range re.FindAllString(text, -1) {
+			report.Findings = append(report.Findings, Finding{Kind: FindingInstruction, Page: page, Text: m})
+		}
+	}
+}
+
+func (s *InjectionScanner) score(report *RiskReport) {
+	for _, f := range report.Findings {
+		report.Score += findingWeights[f.Kind]
+	}
+	if report.Score > 100 {
+		report.Score = 100
+	}
+	report.Flagged = report.Score >= s.cfg.FlagScore
+}
+
+// addHidden records hidden text, merging it into the previous finding when it
+// continues the same run of hidden text.
+func (r *RiskReport) addHidden(kind string, page int, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if n := len(r.Findings); n > 0 {
+		last := &r.Findings[n-1]
+		if last.Kind == kind && last.Page == page {
+			last.Text = truncateRunes(last.Text+" "+text, 200)
+			return
+		}
+	}
+	r.Findings = append(r.Findings, Finding{Kind: kind, Page: page, Text: truncateRunes(text, 200)})
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// isLight reports whether a "#rrggbb" colour is close to white, i.e. invisible
+// on the white background resumes are printed on.
+func isLight(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	v, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return false
+	}
+	r, g, b := float64(v>>16&0xff), float64(v>>8&0xff), float64(v&0xff)
+	return (0.299*r+0.587*g+0.114*b)/255 >= 0.9
+}
+
+// textLine is one line of the page as laid out by MuPDF.
+type textLine struct {
+	top, left, height float64
+	spans             []textSpan
+}
+
+type textSpan struct {
+	size  float64
+	color string
+	text  string
+}
+
+type glyph struct{ x, y float64 }
+
+// drawn reports whether any painted glyph starts the line.
+func (l textLine) drawn(glyphs []glyph) bool {
+	for _, g := range glyphs {
+		if math.Abs(g.x-l.left) <= 2 && g.y >= l.top-2 && g.y <= l.top+l.height+2 {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	lineRe  = regexp.MustCompile(`<p style="top:(-?[\d.]+)pt;left:(-?[\d.]+)pt;line-height:(-?[\d.]+)pt">(.*?)</p>`)
+	spanRe  = regexp.MustCompile(`<span style="([^"]*)">(.*?)</span>`)
+	sizeRe  = regexp.MustCompile(`font-size:([\d.]+)pt`)
+	colorRe = regexp.MustCompile(`color:(#[0-9a-fA-F]{6})`)
+	tagRe   = regexp.MustCompile(`<[^>]+>`)
+	glyphRe = regexp.MustCompile(`<use data-text="[^"]*" xlink:href="[^"]*" transform="matrix\(([^)]*)\)"`)
+)
+
+// parseLines extracts positioned text lines from MuPDF's HTML output.
+func parseLines(page string) []textLine {
+	var lines []textLine
+	for _, m := range lineRe.FindAllStringSubmatch(page, -1) {
+		line := textLine{}
+		line.top, _ = strconv.ParseFloat(m[1], 64)
+		line.left, _ = strconv.ParseFloat(m[2], 64)
+		line.height, _ = strconv.ParseFloat(m[3], 64)
+		for _, sm := range spanRe.FindAllStringSubmatch(m[4], -1) {
+			span := textSpan{text: html.UnescapeString(tagRe.ReplaceAllString(sm[2], ""))}
+			if v := sizeRe.FindStringSubmatch(sm[1]); v != nil {
+				span.size, _ = strconv.ParseFloat(v[1], 64)
+			}
+			if v := colorRe.FindStringSubmatch(sm[1]); v != nil {
+				span.color = strings.ToLower(v[1])
+			}
+			line.spans = append(line.spans, span)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseGlyphs extracts the origin of every painted glyph from MuPDF's SVG output.
+func parseGlyphs(svg string) []glyph {
+	var glyphs []glyph
+	for _, m := range glyphRe.FindAllStringSubmatch(svg, -1) {
+		parts := strings.Split(m[1], ",")
+		if len(parts) != 6 {
+			continue
+		}
+		x, err1 := strconv.ParseFloat(parts[4], 64)
+		y, err2 := strconv.ParseFloat(parts[5], 64)
+		if err1 == nil && err2 == nil {
+			glyphs = append(glyphs, glyph{x: x, y: y})
+		}
+	}
+	return glyphs
+}
@@ -0,0 +1,38 @@
+package cv
+
+import "unicode"
+
+// Resume languages returned by DetectLanguage.
+const (
+	LanguageChinese = "zh"
+	LanguageEnglish = "en"
+	LanguageMixed   = "mixed" // bilingual, or Chinese with a lot of English
+)
+
+// DetectLanguage classifies resume text by the share of Chinese characters among
+// words. Each Han character counts as a word and every five Latin letters as one,
+// so the English technology names common in Chinese resumes do not tip the balance.
+// It returns "" for text without letters.
+func DetectLanguage(text string) string {
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxLatin1 && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	words := float64(han) + float64(latin)/5
+	if words == 0 {
+		return ""
+	}
+	switch share := float64(han) / words; {
+	case share >= 0.6:
+		return LanguageChinese
+	case share <= 0.2:
+		return LanguageEnglish
+	default:
+		return LanguageMixed
+	}
+}
@@ -0,0 +1,557 @@
+package cv
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Sources of a CandidateProfile.
+const (
+	ProfileSourceRules = "rules" // extracted by RuleExtractor
+	ProfileSourceModel = "model" // extracted by a language model
+)
+
+// Kinds of an Experience entry.
+const (
+	ExperienceInternship = "internship"
+	ExperienceJob        = "job"
+)
+
+// CandidateProfile is a neutral, structured extraction of a resume. Unlike an
+// evaluation it carries no judgement, so every downstream component can reuse it.
+// Dates are "YYYY-MM" (or "YYYY" when the month is unknown); an ongoing entry
+// ends with "present".
+type CandidateProfile struct {
+	Contact    Contact         `bson:"contact" json:"contact"`
+	Education  []Education     `bson:"education,omitempty" json:"education,omitempty"`
+	Experience []Experience    `bson:"experience,omitempty" json:"experience,omitempty" jsonschema_description:"Internships and jobs, most recent first"`
+	Projects   []Project       `bson:"projects,omitempty" json:"projects,omitempty"`
+	Awards     []Award         `bson:"awards,omitempty" json:"awards,omitempty"`
+	Links      []Link          `bson:"links,omitempty" json:"links,omitempty"`
+	Languages  []LanguageSkill `bson:"languages,omitempty" json:"languages,omitempty" jsonschema_description:"Spoken languages and certificates such as CET-6 or IELTS"`
+
+	// Filled from the mail subject, not extracted from the resume.
+	AppliedPosition string    `bson:"applied_position,omitempty" json:"applied_position,omitempty" jsonschema:"-"`
+	Source          string    `bson:"source" json:"source" jsonschema:"-"`
+	ExtractedAt     time.Time `bson:"extracted_at" json:"extracted_at" jsonschema:"-"`
+}
+
+// Contact holds the candidate's contact details.
+type Contact struct {
+	Name     string   `bson:"name,omitempty" json:"name,omitempty"`
+	Phones   []string `bson:"phones,omitempty" json:"phones,omitempty"`
+	Emails   []string `bson:"emails,omitempty" json:"emails,omitempty"`
+	Location string   `bson:"location,omitempty" json:"location,omitempty" jsonschema_description:"City the candidate lives in or wants to work in"`
+}
+
+// Education is one school attended.
+type Education struct {
+	School string `bson:"school" json:"school"`
+	Degree string `bson:"degree,omitempty" json:"degree,omitempty" jsonschema:"enum=associate,enum=bachelor,enum=master,enum=phd"`
+	Major  string `bson:"major,omitempty" json:"major,omitempty"`
+	GPA    string `bson:"gpa,omitempty" json:"gpa,omitempty" jsonschema_description:"As written, with its scale, e.g. 3.8/4.0"`
+	Start  string `bson:"start,omitempty" json:"start,omitempty"`
+	End    string `bson:"end,omitempty" json:"end,omitempty"`
+}
+
+// Experience is one internship or job.
+type Experience struct {
+	Kind        string `bson:"kind" json:"kind" jsonschema:"enum=internship,enum=job"`
+	Company     string `bson:"company" json:"company"`
+	Title       string `bson:"title,omitempty" json:"title,omitempty"`
+	Start       string `bson:"start,omitempty" json:"start,omitempty"`
+	End         string `bson:"end,omitempty" json:"end,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// Project is one project the candidate worked on.
+type Project struct {
+	Name        string `bson:"name" json:"name"`
+	Role        string `bson:"role,omitempty" json:"role,omitempty"`
+	Start       string `bson:"start,omitempty" json:"start,omitempty"`
+	End         string `bson:"end,omitempty" json:"end,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// Award is one award, honour or competition result.
+type Award struct {
+	Name string `bson:"name" json:"name"`
+	Date string `bson:"date,omitempty" json:"date,omitempty"`
+}
+
+// Link is a personal page such as GitHub or a blog.
+type Link struct {
+	Kind string `bson:"kind" json:"kind" jsonschema:"enum=github,enum=linkedin,enum=blog,enum=other"`
+	URL  string `bson:"url" json:"url"`
+}
+
+// LanguageSkill is a spoken language and the level or certificate held.
+type LanguageSkill struct {
+	Language string `bson:"language" json:"language"`
+	Level    string `bson:"level,omitempty" json:"level,omitempty"`
+}
+
+// ProfileExtractor extracts a CandidateProfile from a parsed CV.
+type ProfileExtractor interface {
+	Extract(ctx context.Context, cv *CV) (*CandidateProfile, error)
+}
+
+// ProfileConfig configures profile extraction in the Service.
+type ProfileConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Model   bool `yaml:"model"` // extract with the "extractor" agents, falling back to the rules on failure
+}
+
+// FillFromSubject fills details missing from the resume with those in the mail
+// subject ("2026校园招聘-后端研发-Name-13333333333") and the sender address.
+func (p *CandidateProfile) FillFromSubject(subject, sender string) {
+	name, phones, emails := ParseSubject(subject)
+	if p.Contact.Name == "" {
+		p.Contact.Name = name
+	}
+	if len(p.Contact.Phones) == 0 {
+		p.Contact.Phones = phones
+	}
+	emails = append(emails, ExtractEmails(sender)...)
+	if len(p.Contact.Emails) == 0 {
+		for _, e := range emails {
+			p.Contact.Emails = appendUnique(p.Contact.Emails, e)
+		}
+	}
+	if parts := strings.Split(subject, "-"); p.AppliedPosition == "" && len(parts) >= 2 {
+		p.AppliedPosition = strings.TrimSpace(parts[1])
+	}
+}
+
+// FillFromApplication fills details missing from the resume with those the
+// candidate typed into the mail body.
+func (p *CandidateProfile) FillFromApplication(app *Application) {
+	if app == nil {
+		return
+	}
+	if p.Contact.Name == "" {
+		p.Contact.Name = strings.TrimSpace(app.Name)
+	}
+	if phone := NormalizePhone(app.Phone); phone != "" {
+		p.Contact.Phones = appendUnique(p.Contact.Phones, phone)
+	}
+	if school := strings.TrimSpace(app.School); len(p.Education) == 0 && school != "" {
+		p.Education = []Education{{School: school}}
+	}
+	if p.AppliedPosition == "" {
+		p.AppliedPosition = strings.TrimSpace(app.Position)
+	}
+}
+
+// Merge fills fields of p that are empty with those of other, e.g. the rule-based
+// profile filling gaps left by the model.
+func (p *CandidateProfile) Merge(other *CandidateProfile) {
+	if other == nil {
+		return
+	}
+	if p.Contact.Name == "" {
+		p.Contact.Name = other.Contact.Name
+	}
+	if p.Contact.Location == "" {
+		p.Contact.Location = other.Contact.Location
+	}
+	for _, v := range other.Contact.Phones {
+		p.Contact.Phones = appendUnique(p.Contact.Phones, NormalizePhone(v))
+	}
+	for _, v := range other.Contact.Emails {
+		p.Contact.Emails = appendUnique(p.Contact.Emails, NormalizeEmail(v))
+	}
+	if len(p.Education) == 0 {
+		p.Education = other.Education
+	}
+	if len(p.Experience) == 0 {
+		p.Experience = other.Experience
+	}
+	if len(p.Projects) == 0 {
+		p.Projects = other.Projects
+	}
+	if len(p.Awards) == 0 {
+		p.Awards = other.Awards
+	}
+	for _, l := range other.Links {
+		if !hasLink(p.Links, l.URL) {
+			p.Links = append(p.Links, l)
+		}
+	}
+	if len(p.Languages) == 0 {
+		p.Languages = other.Languages
+	}
+}
+
+func hasLink(links []Link, url string) bool {
+	for _, l := range links {
+		if strings.EqualFold(l.URL, url) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleExtractor extracts a profile from the resume text with section headings
+// and patterns. It needs no model and serves as the fallback of the model extractor.
+type RuleExtractor struct{}
+
+// Resume sections recognised by their heading.
+const (
+	sectionNone       = ""
+	sectionEducation  = "education"
+	sectionInternship = "internship"
+	sectionJob        = "job"
+	sectionProjects   = "projects"
+	sectionAwards     = "awards"
+	sectionLanguages  = "languages"
+	sectionOther      = "other"
+)
+
+var sectionHeadings = []struct {
+	section string
+	re      *regexp.Regexp
+}{
+	{sectionEducation, regexp.MustCompile(`(?i)^(教育(经历|背景)|学历|education)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionInternship, regexp.MustCompile(`(?i)^(实习(经历|经验)|internships?)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionJob, regexp.MustCompile(`(?i)^(工作(经历|经验)|(work\s+|professional\s+)?experience|employment)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionProjects, regexp.MustCompile(`(?i)^(项目(经历|经验)|projects?)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionAwards, regexp.MustCompile(`(?i)^(获奖(经历|情况)?|荣誉|奖项|awards?|honou?rs)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionLanguages, regexp.MustCompile(`(?i)^(语言(能力)?|languages?)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+	{sectionOther, regexp.MustCompile(`(?i)^(专业技能|技能|个人(评价|总结|简介)|自我评价|skills|summary|about)(\s*[/|]?\s*[A-Za-z ]*)$`)},
+}
+
+var (
+	dateRangeRe = regexp.MustCompile(`(?i)(\d{4})\s*[.\-/年]\s*(\d{1,2})\s*月?\s*(?:-|–|—|~|～|至|to)\s*(?:(\d{4})\s*[.\-/年]\s*(\d{1,2})\s*月?|(至今|现在|今|present|now|current))`)
+	yearRe      = regexp.MustCompile(`(19|20)\d{2}`)
+	gpaRe       = regexp.MustCompile(`(?i)(?:GPA|绩点)\s*[:：]?\s*(\d(?:\.\d+)?)\s*(?:/\s*(\d(?:\.\d+)?))?`)
+	schoolRe    = regexp.MustCompile(`[\p{Han}]{2,}(?:大学|学院)|(?:[A-Z][\w&.'-]*\s+)*(?:University|College|Institute)(?:\s+of(?:\s+[A-Z][\w&.'-]*)+)?`)
+	majorRe     = regexp.MustCompile(`(?:专业\s*[:：]\s*([\p{Han}\w]+))|([\p{Han}]{2,}专业)|(?i:(?:in|major:?)\s+([A-Z][A-Za-z ]+?))(?:\s*[,|，]|\s{2,}|$)`)
+	companyRe   = regexp.MustCompile(`[\p{Han}\w（）()]{2,}(?:公司|集团|科技|银行|研究院|工作室)|(?:[A-Z][\w&.'-]*\s+)+(?:Inc|Ltd|LLC|Corp|Co|Technologies|Group)\.?`)
+	titleRe     = regexp.MustCompile(`(?i)[\p{Han}\w]*(?:工程师|实习生|开发|研发|经理|分析师|设计师|架构师)|(?:[A-Z][a-z]+\s+)*(?:Engineer|Intern|Developer|Manager|Analyst|Designer|Architect)`)
+	roleRe      = regexp.MustCompile(`(?:角色|担任|职责)\s*[:：]\s*([\p{Han}\w]+)|(?i:role\s*:\s*([A-Za-z ]+))`)
+	urlRe       = regexp.MustCompile(`(?i)(?:https?://|www\.|(?:github|gitee|linkedin)\.com/)[^\s，。；;,)）]+`)
+	locationRe  = regexp.MustCompile(`(?:现居|所在地|居住地|期望城市|意向城市|城市)\s*[:：]\s*([\p{Han}]{2,})`)
+)
+
+// Degrees in order of precedence, with the words that denote them.
+var degreeWords = []struct {
+	degree string
+	re     *regexp.Regexp
+}{
+	{"phd", regexp.MustCompile(`(?i)博士|ph\.?d|doctor`)},
+	{"master", regexp.MustCompile(`(?i)硕士|研究生|master|m\.?s\.?c?\b|mba|m\.?eng`)},
+	{"bachelor", regexp.MustCompile(`(?i)本科|学士|bachelor|b\.?s\.?c?\b|b\.?eng|b\.?a\.?\b`)},
+	{"associate", regexp.MustCompile(`(?i)专科|大专|associate`)},
+}
+
+// Language certificates, mapped to the language they certify.
+var languageCerts = []struct {
+	language string
+	re       *regexp.Regexp
+}{
+	{"English", regexp.MustCompile(`(?i)CET-?\s?[46](?:\s*[:：(（]?\s*\d{3})?|英语[四六]级|TEM-?\s?[48]|专[四八]|IELTS\s*\d(?:\.\d)?|雅思\s*\d(?:\.\d)?|TOEFL\s*\d{2,3}|托福\s*\d{2,3}`)},
+	{"Japanese", regexp.MustCompile(`(?i)JLPT\s*N[1-5]|日语\s*N[1-5]`)},
+	{"Korean", regexp.MustCompile(`(?i)TOPIK\s*[1-6]级?`)},
+}
+
+var spokenLanguages = map[string]string{
+	"英语": "English", "日语": "Japanese", "韩语": "Korean", "法语": "French", "德语": "German",
+	"西班牙语": "Spanish", "俄语": "Russian", "粤语": "Cantonese", "普通话": "Mandarin",
+	"english": "English", "japanese": "Japanese", "korean": "Korean", "french": "French",
+	"german": "German", "spanish": "Spanish", "russian": "Russian", "mandarin": "Mandarin",
+	"cantonese": "Cantonese", "chinese": "Chinese",
+}
+
+// Extract implements ProfileExtractor.
+func (RuleExtractor) Extract(ctx context.Context, cv *CV) (*CandidateProfile, error) {
+	return ExtractProfile(cv.Content), nil
+}
+
+// ExtractProfile extracts a profile from resume text with RuleExtractor's rules.
+func ExtractProfile(text string) *CandidateProfile {
+	p := &CandidateProfile{Source: ProfileSourceRules, ExtractedAt: time.Now()}
+	p.Contact.Phones = ExtractPhones(text)
+	p.Contact.Emails = ExtractEmails(text)
+	if m := locationRe.FindStringSubmatch(text); m != nil {
+		p.Contact.Location = m[1]
+	}
+	for _, u := range urlRe.FindAllString(text, -1) {
+		u = strings.TrimRight(u, ".")
+		if !hasLink(p.Links, u) {
+			p.Links = append(p.Links, Link{Kind: linkKind(u), URL: u})
+		}
+	}
+	p.Languages = extractLanguages(text)
+
+	section := sectionNone
+	var block []string
+	flush := func() {
+		switch section {
+		case sectionEducation:
+			p.Education = append(p.Education, parseEducation(block)...)
+		case sectionInternship, sectionJob:
+			p.Experience = append(p.Experience, parseExperience(block, section)...)
+		case sectionProjects:
+			p.Projects = append(p.Projects, parseProjects(block)...)
+		case sectionAwards:
+			p.Awards = append(p.Awards, parseAwards(block)...)
+		case sectionLanguages:
+			for _, l := range extractSpokenLanguages(strings.Join(block, "\n")) {
+				if !hasLanguage(p.Languages, l.Language) {
+					p.Languages = append(p.Languages, l)
+				}
+			}
+		}
+		block = nil
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if s, ok := headingSection(line); ok {
+			flush()
+			section = s
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	if p.Contact.Name == "" && len(p.Contact.Phones)+len(p.Contact.Emails) > 0 {
+		p.Contact.Name = guessName(text)
+	}
+	return p
+}
+
+// headingSection reports whether line is a section heading and which one.
+func headingSection(line string) (string, bool) {
+	heading := strings.Trim(line, " :：|#*-—【】[]")
+	if len([]rune(heading)) > 24 {
+		return "", false
+	}
+	for _, h := range sectionHeadings {
+		if h.re.MatchString(heading) {
+			return h.section, true
+		}
+	}
+	return "", false
+}
+
+// splitEntries splits the lines of a section into entries. A line carrying a
+// date range, or matched by head, begins a new entry unless the current entry
+// has no such line yet (dates are often on the line after the school or company).
+func splitEntries(lines []string, head func(string) bool) [][]string {
+	if head == nil {
+		head = func(string) bool { return false }
+	}
+	var entries [][]string
+	var dated, headed bool
+	for _, line := range lines {
+		d, h := dateRangeRe.MatchString(line), head(line)
+		if len(entries) == 0 || d && dated || h && headed {
+			entries = append(entries, nil)
+			dated, headed = false, false
+		}
+		entries[len(entries)-1] = append(entries[len(entries)-1], line)
+		dated, headed = dated || d, headed || h
+	}
+	return entries
+}
+
+func parseEducation(lines []string) []Education {
+	var out []Education
+	for _, entry := range splitEntries(lines, schoolRe.MatchString) {
+		text := strings.Join(entry, " ")
+		school := schoolRe.FindString(text)
+		if school == "" {
+			continue
+		}
+		e := Education{School: strings.TrimSpace(school), Degree: degree(text)}
+		e.Start, e.End = dateRange(text)
+		if m := gpaRe.FindStringSubmatch(text); m != nil {
+			e.GPA = m[1]
+			if m[2] != "" {
+				e.GPA += "/" + m[2]
+			}
+		}
+		if m := majorRe.FindStringSubmatch(strings.Replace(text, school, "", 1)); m != nil {
+			e.Major = strings.TrimSpace(strings.TrimSuffix(firstNonEmpty(m[1:]...), "专业"))
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func parseExperience(lines []string, section string) []Experience {
+	var out []Experience
+	for _, entry := range splitEntries(lines, nil) {
+		head := entry[0]
+		e := Experience{Kind: ExperienceJob}
+		if section == sectionInternship || strings.Contains(head, "实习") || strings.Contains(strings.ToLower(head), "intern") {
+			e.Kind = ExperienceInternship
+		}
+		e.Start, e.End = dateRange(head)
+		rest := strings.TrimSpace(dateRangeRe.ReplaceAllString(head, ""))
+		e.Company = strings.TrimSpace(companyRe.FindString(rest))
+		if e.Company == "" {
+			e.Company = firstField(rest)
+		}
+		e.Title = strings.TrimSpace(titleRe.FindString(strings.Replace(rest, e.Company, "", 1)))
+		e.Description = strings.Join(entry[1:], "\n")
+		if e.Company != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func parseProjects(lines []string) []Project {
+	var out []Project
+	for _, entry := range splitEntries(lines, nil) {
+		head := entry[0]
+		pr := Project{}
+		pr.Start, pr.End = dateRange(head)
+		pr.Name = firstField(strings.TrimSpace(dateRangeRe.ReplaceAllString(head, "")))
+		if m := roleRe.FindStringSubmatch(strings.Join(entry, "\n")); m != nil {
+			pr.Role = strings.TrimSpace(firstNonEmpty(m[1:]...))
+		}
+		pr.Description = strings.Join(entry[1:], "\n")
+		if pr.Name != "" {
+			out = append(out, pr)
+		}
+	}
+	return out
+}
+
+func parseAwards(lines []string) []Award {
+	out := make([]Award, 0, len(lines))
+	for _, line := range lines {
+		a := Award{Name: strings.TrimLeft(line, "•·-*● ")}
+		if y := yearRe.FindString(line); y != "" {
+			a.Date = y
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func extractLanguages(text string) []LanguageSkill {
+	var out []LanguageSkill
+	for _, c := range languageCerts {
+		for _, m := range c.re.FindAllString(text, -1) {
+			out = append(out, LanguageSkill{Language: c.language, Level: strings.TrimSpace(m)})
+		}
+	}
+	return out
+}
+
+// extractSpokenLanguages finds language names in the languages section, with
+// the rest of their line as the level.
+func extractSpokenLanguages(text string) []LanguageSkill {
+	var out []LanguageSkill
+	for _, line := range strings.Split(text, "\n") {
+		lower := strings.ToLower(line)
+		for word, lang := range spokenLanguages {
+			if i := strings.Index(lower, word); i >= 0 && !hasLanguage(out, lang) {
+				level := strings.Trim(line[i+len(word):], " :：,，()（）")
+				out = append(out, LanguageSkill{Language: lang, Level: level})
+			}
+		}
+	}
+	return out
+}
+
+func hasLanguage(list []LanguageSkill, lang string) bool {
+	for _, l := range list {
+		if l.Language == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// degree returns the highest degree mentioned in text.
+func degree(text string) string {
+	for _, d := range degreeWords {
+		if d.re.MatchString(text) {
+			return d.degree
+		}
+	}
+	return ""
+}
+
+// dateRange returns the first date range in text as "YYYY-MM" strings.
+func dateRange(text string) (start, end string) {
+	m := dateRangeRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", ""
+	}
+	start = yearMonth(m[1], m[2])
+	if m[5] != "" {
+		return start, "present"
+	}
+	return start, yearMonth(m[3], m[4])
+}
+
+func yearMonth(year, month string) string {
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	return year + "-" + month
+}
+
+func linkKind(url string) string {
+	lower := strings.ToLower(url)
+	switch {
+	case strings.Contains(lower, "github.com"), strings.Contains(lower, "gitee.com"):
+		return "github"
+	case strings.Contains(lower, "linkedin.com"):
+		return "linkedin"
+	case strings.Contains(lower, "blog"), strings.Contains(lower, "csdn.net"),
+		strings.Contains(lower, "juejin.cn"), strings.Contains(lower, "zhihu.com"):
+		return "blog"
+	}
+	return "other"
+}
+
+// guessName takes the first short line of the resume, where the name usually is.
+func guessName(text string) string {
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "姓名："), "姓名:"))
+		if n := []rune(line); len(n) >= 2 && len(n) <= 20 && !strings.ContainsAny(line, "@0123456789:：") {
+			if _, ok := headingSection(line); !ok {
+				return line
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// firstField returns the first part of a line split on common separators.
+func firstField(line string) string {
+	f := strings.FieldsFunc(line, func(r rune) bool {
+		return r == '|' || r == '｜' || r == '，' || r == ',' || r == '/' || r == '\t'
+	})
+	if len(f) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(f[0])
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
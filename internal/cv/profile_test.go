@@ -0,0 +1,112 @@
+package cv
+
+import (
+	"reflect"
+	"testing"
+)
+
+const structuredResume = `张三
+电话：13800138000 | 邮箱：zhangsan@example.com | 现居：上海
+GitHub: https://github.com/zhangsan
+
+教育经历
+清华大学 计算机科学与技术专业 硕士
+2020.09 - 2023.06 GPA: 3.8/4.0
+北京邮电大学 软件工程专业 本科
+2016.09 - 2020.06
+
+实习经历
+2022.06 - 2022.09 字节跳动科技有限公司 后端开发实习生
+负责推荐服务的性能优化
+
+工作经历
+2023.07 - 至今 某某网络科技公司 | 高级后端工程师
+负责订单系统
+
+项目经历
+2021.03 - 2021.12 分布式缓存系统
+角色：核心开发
+基于一致性哈希实现数据分片
+
+获奖情况
+2019 ACM-ICPC 亚洲区域赛银奖
+全国大学生数学建模竞赛一等奖
+
+语言能力
+英语 CET-6 580
+日语 N2`
+
+func TestExtractProfile(t *testing.T) {
+	p := ExtractProfile(structuredResume)
+
+	if p.Contact.Name != "张三" || p.Contact.Location != "上海" ||
+		!reflect.DeepEqual(p.Contact.Phones, []string{"13800138000"}) ||
+		!reflect.DeepEqual(p.Contact.Emails, []string{"zhangsan@example.com"}) {
+		t.Fatalf("unexpected contact %+v", p.Contact)
+	}
+	if len(p.Links) != 1 || p.Links[0].Kind != "github" {
+		t.Fatalf("unexpected links %+v", p.Links)
+	}
+
+	wantEdu := []Education{
+		{School: "清华大学", Degree: "master", Major: "计算机科学与技术", GPA: "3.8/4.0", Start: "2020-09", End: "2023-06"},
+		{School: "北京邮电大学", Degree: "bachelor", Major: "软件工程", Start: "2016-09", End: "2020-06"},
+	}
+	if !reflect.DeepEqual(p.Education, wantEdu) {
+		t.Fatalf("unexpected education:\n got %+v\nwant %+v", p.Education, wantEdu)
+	}
+
+	if len(p.Experience) != 2 {
+		t.Fatalf("unexpected experience %+v", p.Experience)
+	}
+	intern, job := p.Experience[0], p.Experience[1]
+	if intern.Kind != ExperienceInternship || intern.Company != "字节跳动科技有限公司" || intern.Title != "后端开发实习生" ||
+		intern.Start != "2022-06" || intern.End != "2022-09" || intern.Description != "负责推荐服务的性能优化" {
+		t.Fatalf("unexpected internship %+v", intern)
+	}
+	if job.Kind != ExperienceJob || job.Company != "某某网络科技公司" || job.Title != "高级后端工程师" || job.End != "present" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	if len(p.Projects) != 1 || p.Projects[0].Name != "分布式缓存系统" || p.Projects[0].Role != "核心开发" || p.Projects[0].Start != "2021-03" {
+		t.Fatalf("unexpected projects %+v", p.Projects)
+	}
+	if len(p.Awards) != 2 || p.Awards[0].Date != "2019" {
+		t.Fatalf("unexpected awards %+v", p.Awards)
+	}
+
+	langs := map[string]bool{}
+	for _, l := range p.Languages {
+		langs[l.Language] = true
+	}
+	if !langs["English"] || !langs["Japanese"] {
+		t.Fatalf("unexpected languages %+v", p.Languages)
+	}
+}
+
+func TestCandidateProfile_FillFromSubject(t *testing.T) {
+	p := ExtractProfile("Skills\nGo, Kubernetes")
+	p.FillFromSubject("2026校园招聘-后端研发-李四-13900000000", "Li Si <lisi@example.com>")
+	if p.Contact.Name != "李四" || p.AppliedPosition != "后端研发" ||
+		!reflect.DeepEqual(p.Contact.Phones, []string{"13900000000"}) ||
+		!reflect.DeepEqual(p.Contact.Emails, []string{"lisi@example.com"}) {
+		t.Fatalf("subject details not filled: %+v", p)
+	}
+
+	// The mail body fills gaps before the subject.
+	p = ExtractProfile("Skills\nGo, Kubernetes")
+	p.FillFromApplication(&Application{Name: "王五", Phone: "137 0000 0000", School: "浙江大学", Position: "测试开发"})
+	p.FillFromSubject("2026校园招聘-后端研发-李四-13900000000", "")
+	if p.Contact.Name != "王五" || p.AppliedPosition != "测试开发" ||
+		!reflect.DeepEqual(p.Contact.Phones, []string{"13700000000"}) ||
+		len(p.Education) != 1 || p.Education[0].School != "浙江大学" {
+		t.Fatalf("application details not filled: %+v", p)
+	}
+
+	// Details in the resume win over the subject.
+	p = ExtractProfile(structuredResume)
+	p.FillFromSubject("2026校园招聘-后端研发-李四-13900000000", "")
+	if p.Contact.Name != "张三" || !reflect.DeepEqual(p.Contact.Phones, []string{"13800138000"}) {
+		t.Fatalf("resume details overwritten: %+v", p.Contact)
+	}
+}
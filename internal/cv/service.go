@@ -5,18 +5,42 @@ import (
 	"context"
 	"easyHR/pkg/logger"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ledongthuc/pdf"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Service handles the processing of CV files.
 type Service struct {
-	storage    Storage
-	collection string
-	log        logger.LoggerV1
+	storage       Storage
+	collection    string
+	log           logger.LoggerV1
+	resolver      *IdentityResolver
+	scanner       *InjectionScanner
+	extractor     ProfileExtractor
+	profileRunner ProfileRunner
+	converter     *Converter
+	onProcessed   ProcessedCallback
+	onUnsupported UnsupportedCallback
 }
 
+// ProcessedCallback is called after a CV has been parsed and stored.
+// res is nil when no IdentityResolver is configured.
+type ProcessedCallback func(cv *CV, res *Resolution)
+
+// ProfileRunner runs a profile extraction outside the processing loop, for
+// example on the analysis worker pool. run stores the extracted profile on cv.
+type ProfileRunner func(cv *CV, run func(ctx context.Context) error) error
+
+// UnsupportedCallback is called for each file of a submission that could not
+// be converted into a resume.
+type UnsupportedCallback func(sub Submission, file Unsupported)
+
 // NewCVService creates a new CV processing service.
 func NewCVService(storage Storage, collection string, log logger.LoggerV1) *Service {
 	return &Service{
@@ -26,22 +50,55 @@ func NewCVService(storage Storage, collection string, log logger.LoggerV1) *Serv
 	}
 }
 
+// SetIdentityResolver enables linking each CV to a candidate.
+func (s *Service) SetIdentityResolver(r *IdentityResolver) {
+	s.resolver = r
+}
+
+// SetInjectionScanner enables scanning each CV for hidden text and prompt injection.
+func (s *Service) SetInjectionScanner(sc *InjectionScanner) {
+	s.scanner = sc
+}
+
+// SetProfileExtractor enables extracting a CandidateProfile from each CV.
+func (s *Service) SetProfileExtractor(e ProfileExtractor) {
+	s.extractor = e
+}
+
+// SetProfileRunner stores each CV with the rule-based profile right away and
+// hands the extractor's profile to r, which runs it and updates the stored CV.
+// Without a runner the extractor runs before the CV is stored.
+func (s *Service) SetProfileRunner(r ProfileRunner) {
+	s.profileRunner = r
+}
+
+// SetConverter enables converting Word, image and archive attachments before parsing.
+// Without a converter every submission is parsed as a PDF.
+func (s *Service) SetConverter(c *Converter) {
+	s.converter = c
+}
+
+// OnUnsupported registers the callback invoked for each unconvertible file.
+func (s *Service) OnUnsupported(cb UnsupportedCallback) {
+	s.onUnsupported = cb
+}
+
+// OnProcessed registers the callback invoked for each stored CV.
+func (s *Service) OnProcessed(cb ProcessedCallback) {
+	s.onProcessed = cb
+}
+
 // Run starts the service to process files from the channel.
 // onIdle is called when the channel is empty.
-func (s *Service) Run(ch <-chan string, onIdle func()) {
+func (s *Service) Run(ch <-chan Submission, onIdle func()) {
 	go func() {
 		for {
 			select {
-			case filePath, ok := <-ch:
+			case sub, ok := <-ch:
 				if !ok {
 					return
 				}
-				s.log.Info("CV Service received file: " + filePath)
-				if err := s.processFile(filePath); err != nil {
-					s.log.Error("Failed to process file " + filePath + ": " + err.Error())
-				} else {
-					s.log.Info("Successfully processed file: " + filePath)
-				}
+				s.handle(sub)
 			default:
 				// Channel is empty
 				if onIdle != nil {
@@ -52,16 +109,11 @@ func (s *Service) Run(ch <-chan string, onIdle func()) {
 
 				// Check channel again (blocking wait to resume normal processing)
 				select {
-				case filePath, ok := <-ch:
+				case sub, ok := <-ch:
 					if !ok {
 						return
 					}
-					s.log.Info("CV Service received file: " + filePath)
-					if err := s.processFile(filePath); err != nil {
-						s.log.Error("Failed to process file " + filePath + ": " + err.Error())
-					} else {
-						s.log.Info("Successfully processed file: " + filePath)
-					}
+					s.handle(sub)
 				case <-time.After(time.Second):
 					// Continue loop to trigger onIdle again if needed
 				}
@@ -70,29 +122,197 @@ func (s *Service) Run(ch <-chan string, onIdle func()) {
 	}()
 }
 
-func (s *Service) processFile(filePath string) error {
-	// Parse PDF
-	content, err := s.Parse(filePath)
+func (s *Service) handle(sub Submission) {
+	s.log.Info("CV Service received file: " + sub.FilePath)
+	if s.converter == nil {
+		s.handleFile(sub)
+		return
+	}
+
+	conv := s.converter.Convert(sub.FilePath)
+	for _, name := range conv.Skipped {
+		s.log.Info("Skipped non-resume file " + name)
+	}
+	for _, u := range conv.Unsupported {
+		s.log.Warn(fmt.Sprintf("Unsupported attachment %s: %s", u.Name, u.Reason))
+		if s.onUnsupported != nil {
+			s.onUnsupported(sub, u)
+		}
+	}
+	for _, file := range conv.Files {
+		converted := sub
+		converted.FilePath = file
+		s.handleFile(converted)
+	}
+}
+
+func (s *Service) handleFile(sub Submission) {
+	if err := s.processFile(sub); err != nil {
+		s.log.Error("Failed to process file " + sub.FilePath + ": " + err.Error())
+	} else {
+		s.log.Info("Successfully processed file: " + sub.FilePath)
+	}
+}
+
+func (s *Service) processFile(sub Submission) error {
+	data, err := os.ReadFile(sub.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Parse PDF or converted text
+	content, err := s.Parse(sub.FilePath)
 	if err != nil {
 		return err
 	}
 
 	// Create CV model
 	cv := &CV{
-		FilePath:  filePath,
-		Content:   content,
-		ParsedAt:  time.Now(),
-		CreatedAt: time.Now(),
+		ID:          primitive.NewObjectID(),
+		FilePath:    sub.FilePath,
+		Content:     content,
+		Subject:     sub.Subject,
+		Sender:      sub.Sender,
+		Application: sub.Application,
+		Bulk:        sub.Bulk,
+		ContentHash: ContentHash(data),
+		ParsedAt:    time.Now(),
+		CreatedAt:   time.Now(),
+	}
+
+	// Look for text hidden from human readers and instructions aimed at the model
+	if s.scanner != nil {
+		var risk *RiskReport
+		if isText(sub.FilePath) {
+			risk = s.scanner.ScanText(content)
+		} else if risk, err = s.scanner.Scan(sub.FilePath); err != nil {
+			return fmt.Errorf("failed to scan for prompt injection: %w", err)
+		}
+		cv.Risk = risk
+		cv.ManualReview = risk.Flagged
+		if s.scanner.Strip() && risk.Hidden() {
+			cv.Content = risk.VisibleText
+			cv.SanitizedPath = sub.FilePath + ".sanitized.txt"
+			if err := os.WriteFile(cv.SanitizedPath, []byte(risk.VisibleText), 0o644); err != nil {
+				return fmt.Errorf("failed to write sanitized text: %w", err)
+			}
+		}
+		if len(risk.Findings) > 0 {
+			s.log.Warn(fmt.Sprintf("Suspicious content in %s: risk score %d, %d findings", sub.FilePath, risk.Score, len(risk.Findings)))
+		}
+	}
+	cv.Language = DetectLanguage(cv.Content)
+
+	// Link to a candidate before saving so the stored CV carries its identity
+	var res *Resolution
+	if s.resolver != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		res, err = s.resolver.Resolve(ctx, cv)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to resolve candidate: %w", err)
+		}
+		if res.Identical {
+			s.log.Info("Identical resume already received: " + sub.FilePath)
+		}
+	}
+
+	// Extract the profile once the candidate is known, so model calls are audited against it.
+	// An identical resume is not analysed again, so the rules are enough. With a runner
+	// the rules go in first and the extractor's profile replaces them once it is done.
+	identical := res != nil && res.Identical
+	async := s.extractor != nil && s.profileRunner != nil && !identical
+	if s.extractor != nil {
+		s.extractProfile(cv, identical || async)
 	}
 
-	// Save to Store
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return s.storage.Insert(ctx, s.collection, cv)
+	// Save to Store
+	if err := s.storage.Insert(ctx, s.collection, cv); err != nil {
+		return err
+	}
+
+	// CVs of merged candidates now belong to the surviving one
+	if res != nil && len(res.Merged) > 0 {
+		for _, v := range res.Candidate.Versions {
+			if err := s.storage.Update(ctx, s.collection,
+				bson.M{"_id": v.CVID},
+				bson.M{"$set": bson.M{"candidate_id": res.Candidate.ID}}); err != nil {
+				return fmt.Errorf("failed to relink merged cv: %w", err)
+			}
+		}
+	}
+
+	if async {
+		s.scheduleProfile(cv)
+	}
+	if s.onProcessed != nil {
+		s.onProcessed(cv, res)
+	}
+	return nil
 }
 
+// profileTimeout bounds profile extraction, which may call a language model.
+const profileTimeout = 2 * time.Minute
+
+// extractProfile fills cv.Profile. A failed extraction falls back to the rules
+// so every stored CV carries a profile; gaps are filled from the mail subject.
+func (s *Service) extractProfile(cv *CV, rulesOnly bool) {
+	if rulesOnly {
+		cv.Profile = ExtractProfile(cv.Content)
+		fillFromMail(cv.Profile, cv)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), profileTimeout)
+	defer cancel()
+	profile, err := s.extractor.Extract(ctx, cv)
+	if err != nil {
+		s.log.Warn(fmt.Sprintf("Failed to extract profile of %s, using rules: %v", cv.FilePath, err))
+		profile = ExtractProfile(cv.Content)
+	}
+	fillFromMail(profile, cv)
+	cv.Profile = profile
+}
+
+// fillFromMail fills gaps in a profile with the details of the mail the CV
+// arrived in. The mail body is more specific than the subject, so it goes first.
+func fillFromMail(p *CandidateProfile, cv *CV) {
+	p.FillFromApplication(cv.Application)
+	p.FillFromSubject(cv.Subject, cv.Sender)
+}
+
+// scheduleProfile hands the extractor's profile of a stored CV to the runner.
+// The run works on a copy, since cv is still passed on to the callback.
+func (s *Service) scheduleProfile(cv *CV) {
+	doc := *cv
+	run := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, profileTimeout)
+		defer cancel()
+		profile, err := s.extractor.Extract(ctx, &doc)
+		if err != nil {
+			return fmt.Errorf("failed to extract profile: %w", err)
+		}
+		fillFromMail(profile, &doc)
+		return s.storage.Update(ctx, s.collection,
+			bson.M{"_id": doc.ID},
+			bson.M{"$set": bson.M{"profile": profile}})
+	}
+	if err := s.profileRunner(&doc, run); err != nil {
+		s.log.Warn(fmt.Sprintf("Failed to schedule profile extraction of %s, keeping rules: %v", doc.FilePath, err))
+	}
+}
+
+// Parse returns the text of a PDF, or the content of a text file produced by the Converter.
 func (s *Service) Parse(filePath string) (string, error) {
+	if isText(filePath) {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read text: %w", err)
+		}
+		return string(data), nil
+	}
 	f, r, err := pdf.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open pdf: %w", err)
@@ -112,3 +332,7 @@ func (s *Service) Parse(filePath string) (string, error) {
 
 	return buf.String(), nil
 }
+
+func isText(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".txt")
+}
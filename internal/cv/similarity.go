@@ -0,0 +1,187 @@
+package cv
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	phonePattern = regexp.MustCompile(`(?:\+?86[-\s]?)?1[3-9]\d[-\s]?\d{4}[-\s]?\d{4}`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// NormalizePhone normalizes a mainland phone number to its 11 digits, or returns "" if it is not one.
+func NormalizePhone(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := strings.TrimPrefix(b.String(), "86")
+	if len(digits) != 11 || digits[0] != '1' {
+		return ""
+	}
+	return digits
+}
+
+// NormalizeEmail lowercases and trims an email address.
+func NormalizeEmail(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !emailPattern.MatchString(s) {
+		return ""
+	}
+	return s
+}
+
+// ExtractPhones returns the normalized, de-duplicated phone numbers found in text.
+func ExtractPhones(text string) []string {
+	var phones []string
+	for _, m := range phonePattern.FindAllString(text, -1) {
+		if p := NormalizePhone(m); p != "" {
+			phones = appendUnique(phones, p)
+		}
+	}
+	return phones
+}
+
+// ExtractEmails returns the normalized, de-duplicated email addresses found in text.
+func ExtractEmails(text string) []string {
+	var emails []string
+	for _, m := range emailPattern.FindAllString(text, -1) {
+		if e := NormalizeEmail(m); e != "" {
+			emails = appendUnique(emails, e)
+		}
+	}
+	return emails
+}
+
+// NormalizeName makes names comparable: Han names lose whitespace,
+// Latin names are lowercased and their tokens sorted so "Yulin Lei" equals "lei yulin".
+func NormalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	hasHan := false
+	for _, r := range name {
+		if unicode.Is(unicode.Han, r) {
+			hasHan = true
+			break
+		}
+	}
+	if hasHan {
+		return strings.Join(fields, "")
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
+
+// NameSimilarity returns a 0-1 similarity between two names based on edit distance of their normalized forms.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(NormalizeName(a)), []rune(NormalizeName(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// shingleSize is the number of runes per shingle used by SimHash.
+const shingleSize = 3
+
+// SimHash computes a 64-bit SimHash of text over rune shingles, ignoring whitespace and case.
+// Near-identical texts produce hashes with a small Hamming distance.
+func SimHash(text string) uint64 {
+	var runes []rune
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsSpace(r) {
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) == 0 {
+		return 0
+	}
+	var weights [64]int
+	n := len(runes) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(runes) {
+			end = len(runes)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i:end])))
+		v := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if v&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var hash uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// HammingDistance returns the number of differing bits between two SimHashes.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// simHashBands splits a SimHash into four 16-bit bands used as lookup keys.
+// Two hashes within Hamming distance 3 always share at least one band.
+func simHashBands(h uint64) []string {
+	bands := make([]string, 4)
+	for i := 0; i < 4; i++ {
+		bands[i] = fmt.Sprintf("%d:%04x", i, uint16(h>>(16*uint(i))))
+	}
+	return bands
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
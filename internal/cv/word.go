@@ -0,0 +1,422 @@
+package cv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// convertWord extracts the text of a DOC or DOCX file into a .txt file next to it.
+func convertWord(path string) (string, error) {
+	var (
+		text string
+		err  error
+	)
+	if strings.ToLower(filepath.Ext(path)) == ".docx" {
+		text, err = docxText(path)
+	} else {
+		text, err = docText(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("word document contains no text")
+	}
+	out := path + ".txt"
+	if err := os.WriteFile(out, []byte(text), 0o644); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// docxText reads the body text of a DOCX file, one line per paragraph and
+// table cells separated by tabs.
+func docxText(path string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx: %w", err)
+	}
+	defer r.Close()
+
+	var doc *zip.File
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			doc = f
+			break
+		}
+	}
+	if doc == nil {
+		return "", errors.New("docx has no word/document.xml")
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var b strings.Builder
+	dec := xml.NewDecoder(rc)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			case "tc":
+				b.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// docText reads the text of a Word 97-2003 document through its piece table.
+func docText(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	cf, err := openCompoundFile(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to open doc: %w", err)
+	}
+	word, err := cf.stream("WordDocument")
+	if err != nil {
+		return "", err
+	}
+	if len(word) < 0x1AA || binary.LittleEndian.Uint16(word) != 0xA5EC {
+		return "", errors.New("not a Word 97-2003 document")
+	}
+	// fWhichTblStm selects the table stream holding the piece table.
+	table := "0Table"
+	if binary.LittleEndian.Uint16(word[0x0A:])&0x0200 != 0 {
+		table = "1Table"
+	}
+	tbl, err := cf.stream(table)
+	if err != nil {
+		return "", err
+	}
+	fcClx := binary.LittleEndian.Uint32(word[0x1A2:])
+	lcbClx := binary.LittleEndian.Uint32(word[0x1A6:])
+	if uint64(fcClx)+uint64(lcbClx) > uint64(len(tbl)) {
+		return "", errors.New("doc piece table out of range")
+	}
+	return pieceText(word, tbl[fcClx:fcClx+lcbClx])
+}
+
+// pieceText walks the Clx and concatenates the text of each piece.
+func pieceText(word, clx []byte) (string, error) {
+	// Skip the Prc entries preceding the piece table.
+	for len(clx) > 0 && clx[0] == 0x01 {
+		if len(clx) < 3 {
+			return "", errors.New("truncated doc Clx")
+		}
+		clx = clx[3+int(binary.LittleEndian.Uint16(clx[1:])):]
+	}
+	if len(clx) < 5 || clx[0] != 0x02 {
+		return "", errors.New("doc piece table not found")
+	}
+	plc := clx[5:]
+	if n := binary.LittleEndian.Uint32(clx[1:]); int(n) <= len(plc) {
+		plc = plc[:n]
+	}
+	// PlcPcd: n+1 character positions followed by n 8-byte piece descriptors.
+	n := (len(plc) - 4) / 12
+	if n <= 0 {
+		return "", errors.New("empty doc piece table")
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		cpStart := binary.LittleEndian.Uint32(plc[i*4:])
+		cpEnd := binary.LittleEndian.Uint32(plc[(i+1)*4:])
+		if cpEnd <= cpStart {
+			continue
+		}
+		count := int(cpEnd - cpStart)
+		fc := binary.LittleEndian.Uint32(plc[(n+1)*4+i*8+2:])
+		if fc&0x40000000 != 0 {
+			// Compressed piece: one byte per character in CP1252.
+			off := int(fc&^0x40000000) / 2
+			if off+count > len(word) {
+				return "", errors.New("doc piece out of range")
+			}
+			for _, c := range word[off : off+count] {
+				b.WriteRune(cp1252Rune(c))
+			}
+			continue
+		}
+		off := int(fc)
+		if off+2*count > len(word) {
+			return "", errors.New("doc piece out of range")
+		}
+		units := make([]uint16, count)
+		for j := range units {
+			units[j] = binary.LittleEndian.Uint16(word[off+2*j:])
+		}
+		b.WriteString(string(utf16.Decode(units)))
+	}
+	return cleanWordText(b.String()), nil
+}
+
+// cleanWordText maps Word control characters to plain text and drops field codes.
+func cleanWordText(s string) string {
+	var b strings.Builder
+	fieldDepth := 0    // nesting of field begin marks
+	inInstruction := 0 // depth of the field whose instruction is being skipped
+	for _, r := range s {
+		switch r {
+		case 0x13: // field begin: the instruction follows
+			fieldDepth++
+			if inInstruction == 0 {
+				inInstruction = fieldDepth
+			}
+			continue
+		case 0x14: // field separator: the displayed result follows
+			if inInstruction == fieldDepth {
+				inInstruction = 0
+			}
+			continue
+		case 0x15: // field end
+			if inInstruction == fieldDepth {
+				inInstruction = 0
+			}
+			if fieldDepth > 0 {
+				fieldDepth--
+			}
+			continue
+		}
+		if inInstruction != 0 {
+			continue
+		}
+		switch r {
+		case '\r', 0x0B, 0x0C:
+			b.WriteByte('\n')
+		case 0x07: // table cell or row end
+			b.WriteByte('\t')
+		case '\t', '\n':
+			b.WriteRune(r)
+		default:
+			if r >= 0x20 {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// cp1252High holds the CP1252 characters in 0x80-0x9F that differ from Latin-1.
+var cp1252High = [32]rune{
+	0x20AC, 0x81, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x8D, 0x017D, 0x8F,
+	0x90, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x9D, 0x017E, 0x0178,
+}
+
+func cp1252Rune(c byte) rune {
+	if c >= 0x80 && c < 0xA0 {
+		return cp1252High[c-0x80]
+	}
+	return rune(c)
+}
+
+// compoundFile is a minimal reader for the OLE compound file format used by
+// Word 97-2003 documents.
+type compoundFile struct {
+	data       []byte
+	sectorSize int
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	entries    []cfEntry
+}
+
+type cfEntry struct {
+	name  string
+	typ   byte
+	start uint32
+	size  uint64
+}
+
+// cfMaxSectorID is the largest regular sector id; larger values mark free
+// sectors and the end of a chain.
+const cfMaxSectorID = 0xFFFFFFFA
+
+// cfMiniSectorSize is the size of the sectors in the mini stream.
+const cfMiniSectorSize = 64
+
+var cfSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+func openCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < 512 || !bytes.Equal(data[:8], cfSignature) {
+		return nil, errors.New("not a compound file")
+	}
+	shift := binary.LittleEndian.Uint16(data[0x1E:])
+	if shift != 9 && shift != 12 {
+		return nil, errors.New("unsupported compound file sector size")
+	}
+	cf := &compoundFile{data: data, sectorSize: 1 << shift}
+
+	// The DIFAT lists the FAT sectors: 109 entries in the header, the rest chained.
+	var difat []uint32
+	for i := 0; i < 109; i++ {
+		difat = append(difat, binary.LittleEndian.Uint32(data[0x4C+4*i:]))
+	}
+	next := binary.LittleEndian.Uint32(data[0x44:])
+	for seen := 0; next <= cfMaxSectorID && seen < 1<<16; seen++ {
+		sec, err := cf.sector(next)
+		if err != nil {
+			return nil, err
+		}
+		per := cf.sectorSize/4 - 1
+		for i := 0; i < per; i++ {
+			difat = append(difat, binary.LittleEndian.Uint32(sec[4*i:]))
+		}
+		next = binary.LittleEndian.Uint32(sec[4*per:])
+	}
+	for _, id := range difat {
+		if id > cfMaxSectorID {
+			continue
+		}
+		sec, err := cf.sector(id)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < cf.sectorSize/4; i++ {
+			cf.fat = append(cf.fat, binary.LittleEndian.Uint32(sec[4*i:]))
+		}
+	}
+
+	dir, err := cf.chain(binary.LittleEndian.Uint32(data[0x30:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+128 <= len(dir); off += 128 {
+		e := dir[off : off+128]
+		nameLen := int(binary.LittleEndian.Uint16(e[0x40:]))
+		if nameLen < 2 || nameLen > 64 {
+			cf.entries = append(cf.entries, cfEntry{})
+			continue
+		}
+		units := make([]uint16, nameLen/2-1)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(e[2*i:])
+		}
+		size := binary.LittleEndian.Uint64(e[0x78:])
+		if shift == 9 {
+			size &= 0xFFFFFFFF // the high half is undefined in version 3 files
+		}
+		cf.entries = append(cf.entries, cfEntry{
+			name:  string(utf16.Decode(units)),
+			typ:   e[0x42],
+			start: binary.LittleEndian.Uint32(e[0x74:]),
+			size:  size,
+		})
+	}
+	if len(cf.entries) == 0 || cf.entries[0].typ != 5 {
+		return nil, errors.New("compound file has no root entry")
+	}
+
+	// Small streams live in the mini stream, addressed through the mini FAT.
+	root := cf.entries[0]
+	if cf.miniStream, err = cf.chain(root.start, int64(root.size)); err != nil {
+		return nil, err
+	}
+	miniFAT, err := cf.chain(binary.LittleEndian.Uint32(data[0x3C:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+4 <= len(miniFAT); i += 4 {
+		cf.miniFAT = append(cf.miniFAT, binary.LittleEndian.Uint32(miniFAT[i:]))
+	}
+	return cf, nil
+}
+
+// sector returns the sector with the given id; sector 0 follows the header.
+func (cf *compoundFile) sector(id uint32) ([]byte, error) {
+	off := (int64(id) + 1) * int64(cf.sectorSize)
+	if off+int64(cf.sectorSize) > int64(len(cf.data)) {
+		return nil, errors.New("compound file sector out of range")
+	}
+	return cf.data[off : off+int64(cf.sectorSize)], nil
+}
+
+// chain reads a FAT chain, truncated to size when size >= 0.
+func (cf *compoundFile) chain(start uint32, size int64) ([]byte, error) {
+	var out []byte
+	for id, n := start, 0; id <= cfMaxSectorID; n++ {
+		if n > len(cf.fat) || int(id) >= len(cf.fat) {
+			return nil, errors.New("corrupt compound file chain")
+		}
+		sec, err := cf.sector(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sec...)
+		id = cf.fat[id]
+	}
+	if size >= 0 && size < int64(len(out)) {
+		out = out[:size]
+	}
+	return out, nil
+}
+
+// stream returns the content of the named stream.
+func (cf *compoundFile) stream(name string) ([]byte, error) {
+	for _, e := range cf.entries {
+		if e.typ != 2 || e.name != name {
+			continue
+		}
+		cutoff := uint64(binary.LittleEndian.Uint32(cf.data[0x38:]))
+		if e.size >= cutoff {
+			return cf.chain(e.start, int64(e.size))
+		}
+		var out []byte
+		for id, n := e.start, 0; id <= cfMaxSectorID && uint64(len(out)) < e.size; n++ {
+			if n > len(cf.miniFAT) || int(id) >= len(cf.miniFAT) {
+				return nil, errors.New("corrupt compound file mini chain")
+			}
+			off := int(id) * cfMiniSectorSize
+			if off+cfMiniSectorSize > len(cf.miniStream) {
+				return nil, errors.New("compound file mini sector out of range")
+			}
+			out = append(out, cf.miniStream[off:off+cfMiniSectorSize]...)
+			id = cf.miniFAT[id]
+		}
+		if uint64(len(out)) > e.size {
+			out = out[:e.size]
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("doc has no %s stream", name)
+}
@@ -19,9 +19,18 @@ type EmailAttacher struct {
 // AttachmentCallback 附件下载成功回调函数类型
 type AttachmentCallback = domain.AttachmentCallback
 
+// UnsupportedCallback 不支持的附件回调函数类型
+type UnsupportedCallback = domain.UnsupportedCallback
+
 // ErrorCallback 错误回调函数类型
 type ErrorCallback = domain.ErrorCallback
 
+// ConnectionStatus 邮箱连接状态
+type ConnectionStatus = domain.ConnectionStatus
+
+// LinkFetcher 正文简历链接下载器
+type LinkFetcher = domain.LinkFetcher
+
 // NewEmailAttacher 初始化下载器（主项目入口方法）
 func NewEmailAttacher(cfg *config.AppConfig, logger logger.LoggerV1) (*EmailAttacher, error) {
 	// 1. 校验外部配置合法性
@@ -64,11 +73,26 @@ func (e *EmailAttacher) Stop() {
 	e.cancel()
 }
 
+// ConnectionStatus 返回各服务商的连接状态（供健康检查使用），断线期间State为disconnected
+func (e *EmailAttacher) ConnectionStatus() []ConnectionStatus {
+	return e.poller.ConnectionStatus()
+}
+
 // OnAttachmentDownloaded 注册附件下载成功回调（主项目可选）
 func (e *EmailAttacher) OnAttachmentDownloaded(callback AttachmentCallback) {
 	e.poller.SetAttachmentCallback(callback)
 }
 
+// OnUnsupportedAttachment 注册不支持的附件回调（主项目可选），未注册时只记录日志
+func (e *EmailAttacher) OnUnsupportedAttachment(callback UnsupportedCallback) {
+	e.poller.SetUnsupportedCallback(callback)
+}
+
+// SetLinkFetcher 替换正文简历链接下载器（默认通过HTTP下载body.link_hosts白名单内的链接）
+func (e *EmailAttacher) SetLinkFetcher(fetcher LinkFetcher) {
+	e.poller.SetLinkFetcher(fetcher)
+}
+
 // OnError 注册错误回调（主项目可选）
 func (e *EmailAttacher) OnError(callback ErrorCallback) {
 	e.poller.SetErrorCallback(callback)
@@ -1,6 +1,10 @@
 package config
 
-import "time"
+import (
+	"time"
+
+	"easyHR/internal/email/mailauth"
+)
 
 // AppConfig 子包核心配置（主项目需构造此结构体传入）
 type AppConfig struct {
@@ -9,12 +13,94 @@ type AppConfig struct {
 	ProcessedEmailsPath string           `yaml:"processed_emails_path"` // 已处理邮件记录路径（主项目指定）
 	Providers           []ProviderConfig `yaml:"providers"`             // 服务商列表
 	RetryConfig         *RetryConfig     `yaml:"retry_config"`          // 重试配置（可选）
+	AttachmentTypes     []string         `yaml:"attachment_types"`      // 下载的附件扩展名（可选），默认pdf/doc/docx/jpg/jpeg/png/zip/rar/7z，其他附件触发不支持回调
+	// ProcessedStore 已处理邮件存储（可选），默认使用processed_emails_path文件；使用mongo时该文件仅用于迁移旧记录
+	ProcessedStore *ProcessedStoreConfig `yaml:"processed_store"`
+	// LegacyAccount 旧版processed_emails.json所属的账号（可选）。旧记录只有UID，只迁移到该账号的收件箱；
+	// 为空时迁移到qq服务商的账号（旧版只支持QQ邮箱）
+	LegacyAccount string `yaml:"legacy_account"`
+	// Download 附件下载配置（可选）
+	Download *DownloadConfig `yaml:"download"`
+	// Body 正文解析配置（可选），默认关闭
+	Body *BodyConfig `yaml:"body"`
+	// Safety 附件安全检查配置（可选）
+	Safety *SafetyConfig `yaml:"safety"`
+}
+
+// SafetyConfig 附件安全检查：保存路径中的主题与文件名始终清理；超过大小上限、内容与扩展名不符、
+// PDF含JavaScript或启动动作的文件移入隔离目录，并记录拒绝原因
+type SafetyConfig struct {
+	MaxAttachmentSize int64  `yaml:"max_attachment_size"` // 单个附件大小上限（默认20MB）
+	MaxEmailSize      int64  `yaml:"max_email_size"`      // 单封邮件的附件与链接文件总大小上限（默认50MB）
+	QuarantinePath    string `yaml:"quarantine_path"`     // 隔离目录（默认attachment_save_path下的_quarantine）
+}
+
+// BodyConfig 正文解析配置：提取姓名、电话、学校、岗位与简历链接，白名单内主机的链接会被下载并按附件回调处理
+type BodyConfig struct {
+	Enabled     bool          `yaml:"enabled"`       // 是否解析正文
+	LinkHosts   []string      `yaml:"link_hosts"`    // 允许下载的链接主机（含子域名），为空时链接只上报为不支持
+	MaxLinkSize int64         `yaml:"max_link_size"` // 单个链接文件大小上限（默认20MB）
+	LinkTimeout time.Duration `yaml:"link_timeout"`  // 单个链接下载超时（默认30s）
+}
+
+// DownloadConfig 附件下载配置：列表只获取邮件元数据，附件内容在下载时流式解码写入磁盘
+type DownloadConfig struct {
+	FetchBatchSize   int   `yaml:"fetch_batch_size"`   // 每条FETCH命令获取的邮件数（默认50）
+	MaxInflightBytes int64 `yaml:"max_inflight_bytes"` // 所有服务商同时下载的附件总字节上限（默认64MB），超过上限的单个附件独占下载
+}
+
+// ProcessedStoreConfig 已处理邮件存储配置
+type ProcessedStoreConfig struct {
+	Type       string `yaml:"type"`       // file（默认）/mongo
+	MongoURI   string `yaml:"mongo_uri"`  // type为mongo时必填
+	Database   string `yaml:"database"`   // type为mongo时必填
+	Collection string `yaml:"collection"` // 默认processed_emails
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
 }
 
 // ProviderConfig 单个服务商配置
 type ProviderConfig struct {
-	Type   string                 `yaml:"type"`   // 服务商类型（qq/netease/gmail/outlook）
-	Config map[string]interface{} `yaml:"config"` // 服务商专属配置（imap地址、账号等）
+	Type        string                 `yaml:"type"`         // 服务商类型（qq/163/netease/126/yeah/gmail/outlook/custom/pop3）
+	Config      map[string]interface{} `yaml:"config"`       // 服务商专属配置：username必填，未配置oauth2时password必填；imap_addr、port、tls_mode（tls/starttls/none）、send_id、insecure_skip_verify可覆盖预设，custom必须填写imap_addr与port；pop3必须填写pop3_addr，可选port（默认995）、tls_mode、insecure_skip_verify、delete_after_download（处理后从服务器删除）、state_file（UIDL状态文件）、since（只收取该日期及之后的邮件）、max_messages_per_poll（每轮最多取回的邮件数，默认50）
+	Mode        string                 `yaml:"mode"`         // 收信模式：poll（默认，按poll_interval轮询）/idle（IMAP IDLE推送，服务器不支持时回退轮询）
+	IdleTimeout time.Duration          `yaml:"idle_timeout"` // IDLE重新发起间隔（可选，默认25m），需小于服务器的IDLE超时
+	Keepalive   time.Duration          `yaml:"keepalive"`    // 连接空闲时发送NOOP保活的间隔（可选，默认5m，负数关闭），断线后自动重连
+	Search      *SearchConfig          `yaml:"search"`       // 邮件搜索条件（可选），为空时搜索正文包含"校园招聘"或"Campus Recruitment"的未读邮件
+	Mailboxes   []string               `yaml:"mailboxes"`    // 收取的文件夹（可选，默认["INBOX"]），IDLE只监听第一个
+	PostProcess *PostProcessConfig     `yaml:"post_process"` // 处理完成后的操作（可选），未配置的结果默认标记已读
+	// OAuth2 使用OAuth2（XOAUTH2/OAUTHBEARER）代替密码登录（可选），gmail/outlook可省略provider
+	OAuth2 *mailauth.OAuth2Config `yaml:"oauth2"`
+}
+
+// PostProcessConfig 按处理结果配置处理后操作，每种结果可配置多个操作，按顺序执行（move总在最后）
+type PostProcessConfig struct {
+	Processed  []PostAction `yaml:"processed"`  // 附件或链接全部下载成功
+	Unroutable []PostAction `yaml:"unroutable"` // 没有可下载的简历（无附件或附件类型不支持）
+	Failed     []PostAction `yaml:"failed"`     // 有附件或链接下载失败
+}
+
+// PostAction 处理后操作
+type PostAction struct {
+	Action  string `yaml:"action"`  // seen（标记已读）/keyword（添加关键字）/move（移动）/copy（复制）/none（不修改，需单独使用）
+	Keyword string `yaml:"keyword"` // action为keyword时必填，如$EasyHR-Processed
+	Folder  string `yaml:"folder"`  // action为move/copy时必填，不存在时自动创建
+}
+
+// SearchConfig 邮件搜索条件：同一层级的条件之间为AND关系，or中任一条件满足即可，not中的条件均不满足
+// 未设置not_flags: ["seen"]时已读邮件也会被搜索到（已处理的邮件仍会按记录跳过）
+type SearchConfig struct {
+	Subject    []string       `yaml:"subject"`     // 主题包含（每个关键词都需包含，下同）
+	Body       []string       `yaml:"body"`        // 正文包含
+	From       []string       `yaml:"from"`        // 发件人包含
+	To         []string       `yaml:"to"`          // 收件人包含
+	Since      string         `yaml:"since"`       // 发送日期不早于（2006-01-02）
+	Before     string         `yaml:"before"`      // 发送日期早于（2006-01-02）
+	WithinDays int            `yaml:"within_days"` // 最近N天内发送
+	Flags      []string       `yaml:"flags"`       // 必须带有的标记：seen/answered/flagged/deleted/draft，或以$开头的自定义关键字
+	NotFlags   []string       `yaml:"not_flags"`   // 必须不带的标记，如seen表示未读，$EasyHR-Processed表示未被处理过
+	Or         []SearchConfig `yaml:"or"`          // 任一条件满足（至少两项）
+	Not        []SearchConfig `yaml:"not"`         // 均不满足
 }
 
 // RetryConfig 重试配置（可选）
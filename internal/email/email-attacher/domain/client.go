@@ -1,6 +1,11 @@
 package domain
 
-import "github.com/emersion/go-imap/v2"
+import (
+	"context"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+)
 
 // EmailClient 统一邮件客户端接口（所有服务商实现需遵循）
 type EmailClient interface {
@@ -15,3 +20,60 @@ type EmailClient interface {
 	// GetProvider 获取服务商名称（如 "qq"、"netease"）
 	GetProvider() string
 }
+
+// IdleWatcher 支持IMAP IDLE推送的客户端（可选实现，轮询器据此选择监听模式）
+type IdleWatcher interface {
+	// SupportsIdle 服务器是否支持IDLE扩展
+	SupportsIdle() bool
+	// WaitForNewMail 进入IDLE等待新邮件，收到通知时返回true；超过timeout或ctx取消时返回false，调用方应重新发起
+	WaitForNewMail(ctx context.Context, timeout time.Duration) (bool, error)
+}
+
+// BodyReader 支持读取邮件正文的客户端（可选实现，用于提取正文中的投递信息与简历链接）
+type BodyReader interface {
+	// ReadBody 获取并解码邮件的纯文本与HTML正文，不修改已读标记
+	ReadBody(email Email) (text, html string, err error)
+}
+
+// LinkFetcher 简历链接下载器（可替换实现，如对接网盘开放接口）
+type LinkFetcher interface {
+	// Fetch 下载link指向的简历文件并保存到savePath目录，返回保存的附件（Name为文件名，URL为link）
+	Fetch(ctx context.Context, link, savePath string) (Attachment, error)
+}
+
+// PostProcessor 支持处理完成后执行自定义操作的客户端（可选实现，未实现时只能标记已读）
+type PostProcessor interface {
+	// ApplyActions 按顺序对邮件执行操作，移动操作必须在最后
+	ApplyActions(email Email, actions []PostAction) error
+}
+
+// ProcessedRecorder 在本地记录已读状态的客户端（可选实现）。轮询器记录邮件已处理后通知客户端，
+// 处理后操作不含标记已读时（如none）也不会在下一轮重新下载
+type ProcessedRecorder interface {
+	// RecordProcessed 记录邮件已处理，不修改服务器上的邮件
+	RecordProcessed(emailID imap.UID) error
+}
+
+// SearchPreviewer 支持预览搜索结果的客户端（可选实现，用于验证搜索条件）
+type SearchPreviewer interface {
+	// PreviewSearch 列出匹配搜索条件的邮件，不下载附件、不修改标记
+	PreviewSearch(limit int) ([]Email, error)
+}
+
+// HealthReporter 可报告连接状态的客户端（可选实现，用于健康检查）
+type HealthReporter interface {
+	// ConnectionStatus 返回当前连接状态
+	ConnectionStatus() ConnectionStatus
+}
+
+// ConnectionStatus 邮箱连接状态
+type ConnectionStatus struct {
+	Provider    string    // 服务商名称
+	Account     string    // 邮箱账号
+	State       string    // disconnected/connecting/connected/closed
+	Mailbox     string    // 当前选中的邮箱
+	ConnectedAt time.Time // 最近一次连接成功的时间
+	LastError   string    // 最近一次连接错误
+	LastErrorAt time.Time // 最近一次连接错误的时间
+	Reconnects  int       // 断线后重连成功的次数
+}
@@ -8,26 +8,78 @@ import (
 
 // Attachment 邮件附件模型（主项目可能需要引用）
 type Attachment struct {
-	ID      string // 附件唯一标识
-	Name    string // 附件文件名
-	Size    int64  // 附件大小（字节）
-	URL     string // 附件下载地址（部分服务商提供）
-	Content []byte // 附件二进制内容（直接获取时使用）
+	ID          string   // 附件唯一标识
+	Name        string   // 附件文件名
+	ContentType string   // MIME类型（如application/pdf）
+	Size        int64    // 附件大小（字节，服务器报告的编码后大小）
+	URL         string   // 附件下载地址（部分服务商提供）
+	EmailID     imap.UID // 所属邮件UID
+	Mailbox     string   // 所属邮件所在的邮箱
+	Part        []int    // MIME分段路径，下载时按此获取内容
+	Encoding    string   // 传输编码（base64/quoted-printable/7bit等）
 }
 
 // Email 邮件模型（主项目可能需要引用）
 type Email struct {
-	ID          imap.UID     // 邮件唯一标识
+	ID          imap.UID     // 邮件UID（仅在同一邮箱、同一UIDVALIDITY下唯一）
+	Mailbox     string       // 所在邮箱（如INBOX）
+	UIDValidity uint32       // 邮箱的UIDVALIDITY，变化后旧UID全部失效
 	From        string       // 发件人（xxx@xxx.com）
 	To          []string     // 收件人列表
 	Subject     string       // 邮件主题
 	SentAt      time.Time    // 发送时间
 	Attachments []Attachment // 附件列表
+	TextParts   []TextPart   // 正文分段（text/plain、text/html）
+	Application *Application // 从正文中提取的投递信息（开启正文解析后填充）
 	IsRead      bool         // 是否已读
 }
 
+// TextPart 邮件正文分段
+type TextPart struct {
+	Part      []int  // MIME分段路径
+	MediaType string // text/plain或text/html
+	Charset   string // 字符集（如utf-8、gbk）
+	Encoding  string // 传输编码
+	Size      int64  // 分段大小（字节，编码后）
+}
+
+// Application 候选人在正文中填写的投递信息
+type Application struct {
+	Name     string   // 姓名
+	Phone    string   // 手机号
+	School   string   // 学校
+	Position string   // 应聘岗位
+	Links    []string // 正文中的简历链接（网盘分享或文件直链）
+}
+
 // AttachmentCallback 附件下载成功回调函数类型
 type AttachmentCallback func(email Email, att Attachment, savePath string)
 
+// UnsupportedCallback 附件类型不在下载范围内时的回调函数类型
+type UnsupportedCallback func(email Email, att Attachment, reason string)
+
 // ErrorCallback 错误回调函数类型
 type ErrorCallback func(err error, provider string)
+
+// 邮件处理结果，决定处理完成后执行哪些操作
+const (
+	OutcomeProcessed  = "processed"  // 附件或链接全部下载成功
+	OutcomeUnroutable = "unroutable" // 没有可下载的简历
+	OutcomeFailed     = "failed"     // 有附件或链接下载失败
+)
+
+// 处理完成后的操作类型
+const (
+	ActionSeen    = "seen"    // 标记已读
+	ActionKeyword = "keyword" // 添加自定义关键字（如$EasyHR-Processed）
+	ActionMove    = "move"    // 移动到文件夹
+	ActionCopy    = "copy"    // 复制到文件夹
+	ActionNone    = "none"    // 不做任何修改
+)
+
+// PostAction 邮件处理完成后的操作
+type PostAction struct {
+	Type    string // seen/keyword/move/copy/none
+	Keyword string // Type为keyword时的关键字
+	Folder  string // Type为move/copy时的目标文件夹
+}
@@ -0,0 +1,763 @@
+// Package imapmail 通用IMAP邮件客户端，QQ、网易、Gmail、Outlook及自建服务器共用
+package imapmail
+
+import (
+	"bytes"
+	"context"
+	"easyHR/internal/email/email-attacher/domain"
+	"easyHR/internal/email/email-attacher/internal/config"
+	imapconn "easyHR/internal/email/email-attacher/internal/imap"
+	"easyHR/internal/email/email-attacher/internal/safety"
+	"easyHR/internal/email/mailauth"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// inbox 默认收取的文件夹
+const inbox = "INBOX"
+
+// clientID 发送给服务器的IMAP ID，网易邮箱据此识别客户端
+var clientID = &imap.IDData{
+	Name:    "easyHR",
+	Version: "1.0.0",
+	Vendor:  "easyHR",
+}
+
+// Client 通用IMAP客户端，服务商之间的差异由config中的预设处理
+type Client struct {
+	provider  string
+	imapCfg   config.IMAPConfig
+	search    config.SearchConfig   // 服务商配置的搜索条件
+	download  config.DownloadConfig // 附件下载配置
+	mailboxes []string              // 收取的文件夹，第一个用于IDLE
+	selected  string                // 当前选中的文件夹
+	conn      *imapconn.Manager     // 连接管理：断线重连、重新选中邮箱与NOOP保活
+	newMail   chan struct{}         // 服务器推送EXISTS时通知，容量为1，多次推送合并为一次
+}
+
+// NewClient 创建指定服务商的IMAP客户端实例（工厂调用）
+func NewClient(provider string) *Client {
+	return &Client{
+		provider: provider,
+		newMail:  make(chan struct{}, 1),
+	}
+}
+
+// Init 初始化客户端
+func (c *Client) Init(cfg interface{}) error {
+	// 类型断言（内部配置已确保类型正确）
+	internalCfg, ok := cfg.(config.ProviderConfig)
+	if !ok {
+		return fmt.Errorf("%s客户端配置类型错误", c.provider)
+	}
+	c.imapCfg = internalCfg.IMAPConfig
+	c.search = internalCfg.Search
+	c.download = internalCfg.Download
+	c.mailboxes = internalCfg.Mailboxes
+	if len(c.mailboxes) == 0 {
+		c.mailboxes = []string{inbox}
+	}
+
+	opts, err := imapOptions(c.imapCfg, &imapclient.UnilateralDataHandler{
+		Mailbox: c.onMailbox,
+	})
+	if err != nil {
+		return err
+	}
+	c.conn = imapconn.NewManager(opts, imapconn.ManagerConfig{Keepalive: c.imapCfg.Keepalive})
+
+	// 建立IMAP连接（带重试）
+	if err := c.conn.Connect(context.Background()); err != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// ListUnreadEmails 依次在配置的文件夹中获取匹配搜索条件的邮件列表
+func (c *Client) ListUnreadEmails() ([]domain.Email, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("IMAP客户端未初始化")
+	}
+
+	emails := []domain.Email{}
+	for _, mailbox := range c.mailboxes {
+		list, err := c.listMailbox(mailbox)
+		if err != nil {
+			return nil, fmt.Errorf("文件夹%s：%w", mailbox, err)
+		}
+		emails = append(emails, list...)
+	}
+	return emails, nil
+}
+
+// listMailbox 获取单个文件夹中匹配搜索条件的邮件
+func (c *Client) listMailbox(mailbox string) ([]domain.Email, error) {
+	uids, uidValidity, err := c.searchMailbox(mailbox)
+	if err != nil || len(uids) == 0 {
+		return nil, err
+	}
+	// 获取邮件详情：只取信封与结构，附件内容在下载时再获取
+	fetchOptions := &imap.FetchOptions{
+		Envelope:      true,
+		Flags:         true,
+		InternalDate:  true,
+		RFC822Size:    true,
+		BodyStructure: &imap.FetchItemBodyStructure{Extended: true},
+	}
+
+	emails := make([]domain.Email, 0, len(uids))
+	// 分批获取，避免单条FETCH命令过长、响应过大
+	batchSize := c.download.FetchBatchSize
+	if batchSize <= 0 {
+		batchSize = len(uids)
+	}
+	for start := 0; start < len(uids); start += batchSize {
+		end := min(start+batchSize, len(uids))
+		messages, err := c.fetch(uids[start:end], fetchOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			emails = append(emails, parseEmail(msg, mailbox, uidValidity))
+		}
+	}
+
+	return emails, nil
+}
+
+// parseEmail 解析邮件信封与附件元数据
+func parseEmail(msg *imapclient.FetchMessageBuffer, mailbox string, uidValidity uint32) domain.Email {
+	isRead := false
+	for _, flag := range msg.Flags {
+		if flag == imap.FlagSeen {
+			isRead = true
+			break
+		}
+	}
+
+	email := domain.Email{
+		ID:          msg.UID,
+		Mailbox:     mailbox,
+		UIDValidity: uidValidity,
+		IsRead:      isRead,
+	}
+
+	// 解析信封信息
+	if msg.Envelope != nil {
+		if len(msg.Envelope.From) > 0 {
+			email.From = msg.Envelope.From[0].Addr()
+		}
+		email.To = make([]string, len(msg.Envelope.To))
+		for i, to := range msg.Envelope.To {
+			email.To[i] = to.Addr()
+		}
+		email.Subject = msg.Envelope.Subject
+		email.SentAt = msg.Envelope.Date
+	}
+
+	// 解析附件与正文分段元数据
+	email.Attachments, email.TextParts = parseParts(msg)
+	for i := range email.Attachments {
+		email.Attachments[i].Mailbox = mailbox
+	}
+	return email
+}
+
+// PreviewSearch 列出匹配搜索条件的邮件（只获取信封，不下载附件、不修改标记），最多返回limit封，limit<=0时不限
+func (c *Client) PreviewSearch(limit int) ([]domain.Email, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("IMAP客户端未初始化")
+	}
+	emails := []domain.Email{}
+	for _, mailbox := range c.mailboxes {
+		list, err := c.previewMailbox(mailbox, limit)
+		if err != nil {
+			return nil, fmt.Errorf("文件夹%s：%w", mailbox, err)
+		}
+		emails = append(emails, list...)
+	}
+	return emails, nil
+}
+
+// previewMailbox 列出单个文件夹中匹配搜索条件的最新limit封邮件
+func (c *Client) previewMailbox(mailbox string, limit int) ([]domain.Email, error) {
+	uids, uidValidity, err := c.searchMailbox(mailbox)
+	if err != nil || len(uids) == 0 {
+		return nil, err
+	}
+	// 优先展示最新的邮件
+	if limit > 0 && len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+
+	messages, err := c.fetch(uids, &imap.FetchOptions{
+		Envelope: true,
+		Flags:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	emails := make([]domain.Email, 0, len(messages))
+	for _, msg := range messages {
+		email := domain.Email{ID: msg.UID, Mailbox: mailbox, UIDValidity: uidValidity}
+		for _, flag := range msg.Flags {
+			if flag == imap.FlagSeen {
+				email.IsRead = true
+			}
+		}
+		if msg.Envelope != nil {
+			if len(msg.Envelope.From) > 0 {
+				email.From = msg.Envelope.From[0].Addr()
+			}
+			email.Subject = msg.Envelope.Subject
+			email.SentAt = msg.Envelope.Date
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+// searchMailbox 选中文件夹并按配置的搜索条件查找邮件UID，同时返回文件夹的UIDVALIDITY
+func (c *Client) searchMailbox(mailbox string) ([]imap.UID, uint32, error) {
+	ctx := context.Background()
+	selected, err := c.selectMailbox(ctx, mailbox)
+	if err != nil {
+		return nil, 0, err
+	}
+	var data *imap.SearchData
+	err = c.conn.Do(ctx, func(client *imapclient.Client) error {
+		data, err = client.UIDSearch(c.search.Criteria(time.Now()), nil).Wait()
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return data.AllUIDs(), selected.UIDValidity, nil
+}
+
+// selectMailbox 选中文件夹并记录，UID只在所属文件夹内有效
+func (c *Client) selectMailbox(ctx context.Context, mailbox string) (*imap.SelectData, error) {
+	data, err := c.conn.Select(ctx, mailbox)
+	if err != nil {
+		return nil, err
+	}
+	c.selected = mailbox
+	return data, nil
+}
+
+// ensureSelected 邮件所在文件夹不是当前选中的文件夹时重新选中（旧记录未保存文件夹时视为收件箱）
+func (c *Client) ensureSelected(mailbox string) error {
+	if mailbox == "" {
+		mailbox = inbox
+	}
+	if mailbox == c.selected {
+		return nil
+	}
+	_, err := c.selectMailbox(context.Background(), mailbox)
+	return err
+}
+
+// fetch 获取指定UID的邮件数据
+func (c *Client) fetch(uids []imap.UID, options *imap.FetchOptions) ([]*imapclient.FetchMessageBuffer, error) {
+	var messages []*imapclient.FetchMessageBuffer
+	err := c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		var err error
+		messages, err = client.Fetch(imap.UIDSetNum(uids...), options).Collect()
+		return err
+	})
+	return messages, err
+}
+
+// Close 登出并关闭连接
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// DownloadAttachment 获取附件所在的MIME分段，边接收边解码写入磁盘，内存占用与附件大小无关
+// 使用BODY.PEEK获取，下载失败不会把邮件标记为已读；写入临时文件后重命名，不会留下不完整的附件
+func (c *Client) DownloadAttachment(att domain.Attachment, savePath string) error {
+	if c.conn == nil {
+		return fmt.Errorf("IMAP客户端未初始化")
+	}
+	if len(att.Part) == 0 {
+		return fmt.Errorf("附件%s缺少MIME分段路径，无法下载", att.Name)
+	}
+	// 文件名来自发件人，调用方需先清理，避免写到savePath之外
+	if safety.SanitizeName(att.Name) != att.Name {
+		return fmt.Errorf("附件名%q未清理，拒绝保存", att.Name)
+	}
+	if err := c.ensureSelected(att.Mailbox); err != nil {
+		return err
+	}
+
+	// 创建保存路径（如果不存在）
+	if err := os.MkdirAll(savePath, 0755); err != nil {
+		return fmt.Errorf("创建保存路径失败: %w", err)
+	}
+
+	section := &imap.FetchItemBodySection{Part: att.Part, Peek: true}
+	return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		fetchCmd := client.Fetch(imap.UIDSetNum(att.EmailID), &imap.FetchOptions{
+			BodySection: []*imap.FetchItemBodySection{section},
+		})
+		err := streamAttachment(fetchCmd, att, filepath.Join(savePath, att.Name))
+		// Close会读完剩余响应，连接才能继续执行其他命令
+		if closeErr := fetchCmd.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+// streamAttachment 从FETCH响应中读取附件分段并写入filePath
+func streamAttachment(fetchCmd *imapclient.FetchCommand, att domain.Attachment, filePath string) error {
+	msg := fetchCmd.Next()
+	if msg == nil {
+		return fmt.Errorf("邮件%d不存在，无法下载附件%s", att.EmailID, att.Name)
+	}
+	for item := msg.Next(); item != nil; item = msg.Next() {
+		data, ok := item.(imapclient.FetchItemDataBodySection)
+		if !ok {
+			continue
+		}
+		if data.Literal == nil {
+			return fmt.Errorf("附件%s内容为空，无法保存", att.Name)
+		}
+		return writeFileStream(filePath, decodeTransfer(data.Literal, att.Encoding))
+	}
+	return fmt.Errorf("服务器未返回附件%s的内容", att.Name)
+}
+
+// maxTextPartSize 单个正文分段的大小上限，超过时跳过（通常是嵌入图片的HTML）
+const maxTextPartSize = 1 << 20
+
+// ReadBody 获取邮件的纯文本与HTML正文（BODY.PEEK，不修改已读标记），按传输编码与字符集解码
+func (c *Client) ReadBody(email domain.Email) (string, string, error) {
+	if c.conn == nil {
+		return "", "", fmt.Errorf("IMAP客户端未初始化")
+	}
+	var (
+		parts    []domain.TextPart
+		sections []*imap.FetchItemBodySection
+	)
+	for _, part := range email.TextParts {
+		if part.Size > maxTextPartSize {
+			continue
+		}
+		parts = append(parts, part)
+		sections = append(sections, &imap.FetchItemBodySection{Part: part.Part, Peek: true})
+	}
+	if len(sections) == 0 {
+		return "", "", nil
+	}
+	if err := c.ensureSelected(email.Mailbox); err != nil {
+		return "", "", err
+	}
+
+	messages, err := c.fetch([]imap.UID{email.ID}, &imap.FetchOptions{BodySection: sections})
+	if err != nil {
+		return "", "", err
+	}
+	if len(messages) == 0 {
+		return "", "", fmt.Errorf("邮件%d不存在", email.ID)
+	}
+
+	var text, html strings.Builder
+	for i, part := range parts {
+		raw := messages[0].FindBodySection(sections[i])
+		if raw == nil {
+			continue
+		}
+		content, err := decodeText(raw, part)
+		if err != nil {
+			return "", "", fmt.Errorf("解码正文失败: %w", err)
+		}
+		if part.MediaType == "text/html" {
+			html.WriteString(content)
+		} else {
+			text.WriteString(content)
+		}
+	}
+	return text.String(), html.String(), nil
+}
+
+// decodeText 解码正文分段：先按传输编码，再按字符集转为UTF-8
+func decodeText(raw []byte, part domain.TextPart) (string, error) {
+	data, err := io.ReadAll(decodeTransfer(bytes.NewReader(raw), part.Encoding))
+	if err != nil {
+		return "", err
+	}
+	charset := strings.ToLower(part.Charset)
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return string(data), nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		// 未知字符集按原样返回
+		return string(data), nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// decodeTransfer 按传输编码包装解码器，其他编码（如7BIT、8BIT等）直接使用原始内容
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(encoding) {
+	case "base64":
+		// 解码器会忽略换行
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// writeFileStream 写入同目录下的临时文件，完成后重命名为目标文件
+func writeFileStream(filePath string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".part-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("写入附件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入附件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// MarkAsRead 标记邮件为已读
+func (c *Client) MarkAsRead(emailID imap.UID) error {
+	if c.conn == nil {
+		return fmt.Errorf("IMAP客户端未初始化")
+	}
+
+	// 标记为已读（添加Seen标记）
+	return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		_, err := client.Store(imap.UIDSetNum(emailID), &imap.StoreFlags{
+			Op:    imap.StoreFlagsAdd,
+			Flags: []imap.Flag{imap.FlagSeen},
+		}, nil).Collect()
+		return err
+	})
+}
+
+// ApplyActions 按顺序对邮件执行处理后操作，move会使邮件离开原文件夹，调用方需保证它排在最后
+func (c *Client) ApplyActions(email domain.Email, actions []domain.PostAction) error {
+	if c.conn == nil {
+		return fmt.Errorf("IMAP客户端未初始化")
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+	if err := c.ensureSelected(email.Mailbox); err != nil {
+		return err
+	}
+	uids := imap.UIDSetNum(email.ID)
+	for _, action := range actions {
+		var err error
+		switch action.Type {
+		case domain.ActionSeen:
+			err = c.addFlag(uids, imap.FlagSeen)
+		case domain.ActionKeyword:
+			err = c.addFlag(uids, imap.Flag(action.Keyword))
+		case domain.ActionCopy:
+			err = c.copyTo(uids, action.Folder)
+		case domain.ActionMove:
+			err = c.moveTo(uids, action.Folder)
+		case domain.ActionNone:
+		default:
+			err = fmt.Errorf("不支持的处理后操作：%s", action.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("%s操作失败：%w", action.Type, err)
+		}
+	}
+	return nil
+}
+
+// addFlag 为邮件添加标记或关键字
+func (c *Client) addFlag(uids imap.UIDSet, flag imap.Flag) error {
+	return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		return client.Store(uids, &imap.StoreFlags{
+			Op:     imap.StoreFlagsAdd,
+			Silent: true,
+			Flags:  []imap.Flag{flag},
+		}, nil).Close()
+	})
+}
+
+// copyTo 复制邮件到folder，目标文件夹不存在时（TRYCREATE）创建后重试
+func (c *Client) copyTo(uids imap.UIDSet, folder string) error {
+	return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		_, err := client.Copy(uids, folder).Wait()
+		if !isTryCreate(err) {
+			return err
+		}
+		if err := client.Create(folder, nil).Wait(); err != nil {
+			return fmt.Errorf("创建文件夹%s失败：%w", folder, err)
+		}
+		_, err = client.Copy(uids, folder).Wait()
+		return err
+	})
+}
+
+// moveTo 移动邮件到folder。服务器支持MOVE时直接移动；否则复制成功后再标记删除，
+// 支持UIDPLUS时只清除这封邮件，不支持时保留\Deleted标记，避免误删文件夹中其他待删除的邮件
+func (c *Client) moveTo(uids imap.UIDSet, folder string) error {
+	var caps imap.CapSet
+	if err := c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		caps = client.Caps()
+		return nil
+	}); err != nil {
+		return err
+	}
+	if caps.Has(imap.CapMove) || caps.Has(imap.CapIMAP4rev2) {
+		return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+			_, err := client.Move(uids, folder).Wait()
+			if !isTryCreate(err) {
+				return err
+			}
+			if err := client.Create(folder, nil).Wait(); err != nil {
+				return fmt.Errorf("创建文件夹%s失败：%w", folder, err)
+			}
+			_, err = client.Move(uids, folder).Wait()
+			return err
+		})
+	}
+
+	// 复制失败时不能标记删除（imapclient自带的回退会无条件删除）
+	if err := c.copyTo(uids, folder); err != nil {
+		return err
+	}
+	if err := c.addFlag(uids, imap.FlagDeleted); err != nil {
+		return err
+	}
+	if !caps.Has(imap.CapUIDPlus) {
+		return nil
+	}
+	return c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		return client.UIDExpunge(uids).Close()
+	})
+}
+
+// isTryCreate 判断是否为目标文件夹不存在的错误
+func isTryCreate(err error) bool {
+	var imapErr *imap.Error
+	return errors.As(err, &imapErr) && imapErr.Code == imap.ResponseCodeTryCreate
+}
+
+// SupportsIdle 服务器是否支持IDLE（IMAP4rev2内置IDLE）
+func (c *Client) SupportsIdle() bool {
+	if c.conn == nil {
+		return false
+	}
+	var caps imap.CapSet
+	if err := c.conn.Do(context.Background(), func(client *imapclient.Client) error {
+		caps = client.Caps()
+		return nil
+	}); err != nil {
+		return false
+	}
+	return caps.Has(imap.CapIdle) || caps.Has(imap.CapIMAP4rev2)
+}
+
+// WaitForNewMail 在第一个收取的文件夹上发起IDLE，直到收到新邮件通知、超过timeout或ctx取消
+// IDLE期间连接被占用，保活协程不会发送NOOP；连接断开时立即返回，重连后重新发起
+func (c *Client) WaitForNewMail(ctx context.Context, timeout time.Duration) (bool, error) {
+	if c.conn == nil {
+		return false, fmt.Errorf("IMAP客户端未初始化")
+	}
+
+	// IDLE只推送已选中文件夹的变化，监听第一个文件夹
+	if _, err := c.selectMailbox(ctx, c.mailboxes[0]); err != nil {
+		return false, err
+	}
+
+	newMail := false
+	err := c.conn.Do(ctx, func(client *imapclient.Client) error {
+		idleCmd, err := client.Idle()
+		if err != nil {
+			return err
+		}
+
+		// IDLE期间连接断开时Wait立即返回，不必等到timeout才发现
+		// Wait只能调用一次，结果通过idleDone传回
+		idleDone := make(chan error, 1)
+		go func() {
+			idleDone <- idleCmd.Wait()
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case err := <-idleDone:
+			return err
+		case <-c.newMail:
+			newMail = true
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		// 结束IDLE（发送DONE）后连接才能继续执行其他命令
+		if err := idleCmd.Close(); err != nil {
+			return err
+		}
+		return <-idleDone
+	})
+	return newMail, err
+}
+
+// ConnectionStatus 返回连接状态，供健康检查使用
+func (c *Client) ConnectionStatus() domain.ConnectionStatus {
+	status := domain.ConnectionStatus{
+		Provider: c.provider,
+		Account:  c.imapCfg.Username,
+		State:    imapconn.StateDisconnected,
+	}
+	if c.conn == nil {
+		return status
+	}
+	s := c.conn.Status()
+	status.State = s.State
+	status.Mailbox = s.Mailbox
+	status.ConnectedAt = s.ConnectedAt
+	status.LastError = s.LastError
+	status.LastErrorAt = s.LastErrorAt
+	status.Reconnects = s.Reconnects
+	return status
+}
+
+// onMailbox 处理服务器推送的邮箱状态，收到EXISTS即视为有新邮件
+func (c *Client) onMailbox(data *imapclient.UnilateralDataMailbox) {
+	if data.NumMessages == nil {
+		return
+	}
+	select {
+	case c.newMail <- struct{}{}:
+	default:
+	}
+}
+
+// GetProvider 获取服务商名称
+func (c *Client) GetProvider() string {
+	return c.provider
+}
+
+// parseParts 从邮件结构中解析附件与正文分段的元数据（不获取内容）
+func parseParts(msg *imapclient.FetchMessageBuffer) ([]domain.Attachment, []domain.TextPart) {
+	var (
+		attachments []domain.Attachment
+		textParts   []domain.TextPart
+	)
+	if msg.BodyStructure == nil {
+		return attachments, textParts
+	}
+
+	// 定义遍历函数，符合 BodyStructureWalkFunc 签名
+	walkFunc := func(path []int, part imap.BodyStructure) (walkChildren bool) {
+		// 总是继续遍历子部分
+		walkChildren = true
+
+		// 类型断言：检查是否是单个部分
+		singlePart, ok := part.(*imap.BodyStructureSinglePart)
+		if !ok {
+			// 不是单个部分，继续遍历子部分
+			return
+		}
+
+		// 获取文件名
+		filename := singlePart.Filename()
+
+		// 如果 Filename() 返回空，尝试从 disposition params 获取
+		disposition := part.Disposition()
+		if filename == "" && disposition != nil && disposition.Value == "attachment" && disposition.Params != nil {
+			filename = disposition.Params["filename"]
+		}
+
+		// 有文件名即视为附件（含内联附件），没有文件名的text/plain、text/html为正文
+		if filename == "" {
+			if mediaType := singlePart.MediaType(); mediaType == "text/plain" || mediaType == "text/html" {
+				textParts = append(textParts, domain.TextPart{
+					Part:      append([]int(nil), path...),
+					MediaType: mediaType,
+					Charset:   singlePart.Params["charset"],
+					Encoding:  singlePart.Encoding,
+					Size:      int64(singlePart.Size),
+				})
+			}
+			return
+		}
+
+		attachments = append(attachments, domain.Attachment{
+			// 生成唯一 ID（使用邮件 UID 和文件名生成）
+			ID:          fmt.Sprintf("%d-%s", msg.UID, filename),
+			Name:        filename,
+			ContentType: singlePart.MediaType(),
+			Size:        int64(singlePart.Size),
+			EmailID:     msg.UID,
+			Part:        append([]int(nil), path...), // Walk会复用path
+			Encoding:    singlePart.Encoding,
+		})
+		return
+	}
+
+	// 使用 Walk 方法遍历邮件结构树
+	msg.BodyStructure.Walk(walkFunc)
+
+	return attachments, textParts
+}
+
+// imapOptions 按服务商配置生成IMAP连接选项，配置了OAuth2时创建令牌源
+func imapOptions(cfg config.IMAPConfig, handler *imapclient.UnilateralDataHandler) (imapconn.Options, error) {
+	opts := imapconn.Options{
+		Addr:                  cfg.Addr,
+		Username:              cfg.Username,
+		Password:              cfg.Password,
+		TLSMode:               cfg.TLSMode,
+		InsecureSkipVerify:    cfg.InsecureSkipVerify,
+		UnilateralDataHandler: handler,
+	}
+	if cfg.SendID {
+		opts.ID = clientID
+	}
+	if cfg.OAuth2 != nil {
+		source, err := mailauth.NewTokenSource(*cfg.OAuth2)
+		if err != nil {
+			return opts, fmt.Errorf("%s的OAuth2配置无效：%w", cfg.Username, err)
+		}
+		opts.TokenSource = source
+		opts.AuthMechanism = cfg.OAuth2.Mechanism
+	}
+	return opts, nil
+}
@@ -0,0 +1,668 @@
+package imapmail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	extconfig "easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/domain"
+	"easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/internal/email/mailauth"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+	"github.com/emersion/go-imap/v2/imapserver/imapmemserver"
+	"github.com/emersion/go-sasl"
+)
+
+// startServer 启动一个不加密的内存IMAP服务器，返回监听地址与测试账号
+func startServer(t *testing.T) (string, *imapmemserver.User) {
+	t.Helper()
+	addr, user, _ := serveIMAP(t)
+	return addr, user
+}
+
+// serveIMAP 同startServer，额外返回服务器以便测试中途断开连接
+func serveIMAP(t *testing.T) (string, *imapmemserver.User, *imapserver.Server) {
+	t.Helper()
+	user := imapmemserver.NewUser("hr@example.com", "secret")
+	if err := user.Create("INBOX", nil); err != nil {
+		t.Fatal(err)
+	}
+	mem := imapmemserver.New()
+	mem.AddUser(user)
+
+	srv := imapserver.New(&imapserver.Options{
+		NewSession: func(*imapserver.Conn) (imapserver.Session, *imapserver.GreetingData, error) {
+			return mem.NewSession(), nil, nil
+		},
+		Caps:         imap.CapSet{imap.CapIMAP4rev1: {}},
+		InsecureAuth: true,
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String(), user, srv
+}
+
+// appendResume 向收件箱投递一封带PDF附件的简历邮件
+func appendResume(t *testing.T, user *imapmemserver.User, subject string, pdf []byte) {
+	t.Helper()
+	appendResumeTo(t, user, "INBOX", subject, pdf)
+}
+
+// appendResumeTo 向指定文件夹投递一封带PDF附件的简历邮件
+func appendResumeTo(t *testing.T, user *imapmemserver.User, mailbox, subject string, pdf []byte) {
+	t.Helper()
+	msg := strings.Join([]string{
+		"From: candidate@example.com",
+		"To: hr@example.com",
+		"Subject: " + subject,
+		"Date: Mon, 02 Jan 2026 15:04:05 +0800",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"校园招聘 简历投递",
+		"--b1",
+		`Content-Type: application/pdf; name="resume.pdf"`,
+		`Content-Disposition: attachment; filename="resume.pdf"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		foldBase64(pdf),
+		"--b1--",
+		"",
+	}, "\r\n")
+	if _, err := user.Append(mailbox, bytes.NewReader([]byte(msg)), &imap.AppendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// foldBase64 按MIME要求每76个字符折行
+func foldBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	return strings.Join(append(lines, encoded), "\r\n")
+}
+
+func TestClient_CustomServer(t *testing.T) {
+	addr, user := startServer(t)
+	pdf := []byte("%PDF-1.4 resume")
+	appendResume(t, user, "2026校园招聘-后端研发-张三-13900000000", pdf)
+	// 较大的附件，流式解码写入
+	large := bytes.Repeat([]byte("%PDF-1.4 portfolio "), 20000)
+	appendResume(t, user, "2026校园招聘-前端研发-李四-13900000001", large)
+
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	internalCfg, err := config.InitInternalConfig(&extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{{
+			Type: "custom",
+			Config: map[string]interface{}{
+				"imap_addr": host,
+				"port":      portNum,
+				"username":  "hr@example.com",
+				"password":  "secret",
+				"tls_mode":  "none",
+				"send_id":   true, // 服务器不支持ID扩展时不发送
+			},
+		}},
+		Download: &extconfig.DownloadConfig{FetchBatchSize: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient("custom")
+	if err := c.Init(internalCfg.ProvidersConfig[0]); err != nil {
+		t.Fatal(err)
+	}
+	emails, err := c.ListUnreadEmails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分批获取后仍返回全部邮件
+	if len(emails) != 2 || emails[0].From != "candidate@example.com" || len(emails[0].Attachments) != 1 {
+		t.Fatalf("unexpected emails %+v", emails)
+	}
+	if text, _, err := c.ReadBody(emails[0]); err != nil || !strings.Contains(text, "简历投递") {
+		t.Fatalf("unexpected body %q, %v", text, err)
+	}
+	dir := t.TempDir()
+	for i, want := range [][]byte{pdf, large} {
+		att := emails[i].Attachments[0]
+		if att.Name != "resume.pdf" || att.EmailID != emails[i].ID {
+			t.Fatalf("unexpected attachment %+v", att)
+		}
+		if err := c.DownloadAttachment(att, dir); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "resume.pdf")); err != nil || !bytes.Equal(data, want) {
+			t.Fatalf("unexpected attachment content (%d bytes), %v", len(data), err)
+		}
+	}
+
+	// 下载不会把邮件标记为已读
+	if emails, err = c.ListUnreadEmails(); err != nil || len(emails) != 2 {
+		t.Fatalf("expected 2 unread emails, got %d, %v", len(emails), err)
+	}
+	for _, email := range emails {
+		if err := c.MarkAsRead(email.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if emails, err = c.ListUnreadEmails(); err != nil || len(emails) != 0 {
+		t.Fatalf("expected no unread emails, got %d, %v", len(emails), err)
+	}
+}
+
+func TestClient_WaitForNewMail(t *testing.T) {
+	addr, user := startServer(t)
+	c := NewClient("custom")
+	if err := c.Init(config.ProviderConfig{
+		Type:       "custom",
+		IMAPConfig: config.IMAPConfig{Addr: addr, Username: "hr@example.com", Password: "secret", TLSMode: "none"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.SupportsIdle() {
+		t.Fatal("expected server to support IDLE")
+	}
+
+	// 没有新邮件时到期返回，可重新发起
+	newMail, err := c.WaitForNewMail(context.Background(), 50*time.Millisecond)
+	if err != nil || newMail {
+		t.Fatalf("expected idle timeout, got %v, %v", newMail, err)
+	}
+
+	type result struct {
+		newMail bool
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		newMail, err := c.WaitForNewMail(context.Background(), 5*time.Second)
+		done <- result{newMail, err}
+	}()
+	time.Sleep(200 * time.Millisecond)
+	appendResume(t, user, "2026校园招聘-后端研发-李四-13900000001", []byte("%PDF-1.4"))
+
+	select {
+	case res := <-done:
+		if res.err != nil || !res.newMail {
+			t.Fatalf("expected new mail notification, got %v, %v", res.newMail, res.err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("IDLE did not report the new message")
+	}
+
+	emails, err := c.ListUnreadEmails()
+	if err != nil || len(emails) != 1 {
+		t.Fatalf("expected 1 unread email after notification, got %d, %v", len(emails), err)
+	}
+}
+
+func TestClient_WaitForNewMail_ConnectionLost(t *testing.T) {
+	addr, _, srv := serveIMAP(t)
+	c := NewClient("custom")
+	if err := c.Init(config.ProviderConfig{
+		Type:       "custom",
+		IMAPConfig: config.IMAPConfig{Addr: addr, Username: "hr@example.com", Password: "secret", TLSMode: "none"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.WaitForNewMail(context.Background(), time.Minute)
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	srv.Close()
+
+	// 连接断开后立即返回错误，而不是等到IDLE超时
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected error after connection loss")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("IDLE did not notice the dropped connection")
+	}
+}
+
+func TestClient_Search(t *testing.T) {
+	addr, user := startServer(t)
+	for _, subject := range []string{"2026校园招聘-后端研发-张三", "社会招聘-运维-李四", "社会招聘-实习-王五"} {
+		appendResume(t, user, subject, []byte("%PDF-1.4"))
+	}
+
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	appCfg := &extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{{
+			Type: "custom",
+			Config: map[string]interface{}{
+				"imap_addr": host,
+				"port":      portNum,
+				"username":  "hr@example.com",
+				"password":  "secret",
+				"tls_mode":  "none",
+			},
+			Search: &extconfig.SearchConfig{
+				NotFlags: []string{"seen"},
+				Or:       []extconfig.SearchConfig{{Subject: []string{"校园招聘"}}, {Subject: []string{"社会招聘"}}},
+				Not:      []extconfig.SearchConfig{{Subject: []string{"实习"}}},
+			},
+		}},
+	}
+	if err := config.ValidateConfig(appCfg); err != nil {
+		t.Fatal(err)
+	}
+	internalCfg, err := config.InitInternalConfig(appCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient("custom")
+	if err := c.Init(internalCfg.ProvidersConfig[0]); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	emails, err := c.PreviewSearch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for _, e := range emails {
+		subjects = append(subjects, e.Subject)
+	}
+	if strings.Join(subjects, ",") != "2026校园招聘-后端研发-张三,社会招聘-运维-李四" {
+		t.Fatalf("unexpected matches %v", subjects)
+	}
+	// 预览不修改标记
+	if emails, _ = c.PreviewSearch(1); len(emails) != 1 || emails[0].IsRead {
+		t.Fatalf("unexpected preview %+v", emails)
+	}
+
+	appCfg.Providers[0].Search = &extconfig.SearchConfig{Or: []extconfig.SearchConfig{{NotFlags: []string{"unread"}}}}
+	if err := config.ValidateConfig(appCfg); err == nil || !strings.Contains(err.Error(), "search.or") {
+		t.Fatalf("expected invalid search error, got %v", err)
+	}
+}
+
+func TestClient_PostProcess(t *testing.T) {
+	addr, user := startServer(t)
+	if err := user.Create("简历", nil); err != nil {
+		t.Fatal(err)
+	}
+	appendResume(t, user, "2026校园招聘-后端研发-张三", []byte("%PDF-1.4"))
+	appendResumeTo(t, user, "简历", "2026校园招聘-前端研发-李四", []byte("%PDF-1.4"))
+
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	appCfg := &extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{{
+			Type: "custom",
+			Config: map[string]interface{}{
+				"imap_addr": host,
+				"port":      portNum,
+				"username":  "hr@example.com",
+				"password":  "secret",
+				"tls_mode":  "none",
+			},
+			Mailboxes: []string{"inbox", "简历", "INBOX"},
+			Search:    &extconfig.SearchConfig{NotFlags: []string{"$EasyHR-Processed"}},
+			PostProcess: &extconfig.PostProcessConfig{
+				Processed: []extconfig.PostAction{
+					{Action: "move", Folder: "已处理"},
+					{Action: "keyword", Keyword: "$EasyHR-Processed"},
+				},
+				Unroutable: []extconfig.PostAction{{Action: "none"}},
+			},
+		}},
+	}
+	if err := config.ValidateConfig(appCfg); err != nil {
+		t.Fatal(err)
+	}
+	internalCfg, err := config.InitInternalConfig(appCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provCfg := internalCfg.ProvidersConfig[0]
+	if strings.Join(provCfg.Mailboxes, ",") != "INBOX,简历" {
+		t.Fatalf("unexpected mailboxes %v", provCfg.Mailboxes)
+	}
+	// move排在最后执行；none不执行任何操作，未配置的结果默认标记已读
+	processed := provCfg.PostProcess.Actions(domain.OutcomeProcessed)
+	if len(processed) != 2 || processed[0].Type != domain.ActionKeyword || processed[1].Type != domain.ActionMove {
+		t.Fatalf("unexpected processed actions %+v", processed)
+	}
+	if actions := provCfg.PostProcess.Actions(domain.OutcomeUnroutable); len(actions) != 0 {
+		t.Fatalf("unexpected unroutable actions %+v", actions)
+	}
+	if actions := provCfg.PostProcess.Actions(domain.OutcomeFailed); len(actions) != 1 || actions[0].Type != domain.ActionSeen {
+		t.Fatalf("unexpected failed actions %+v", actions)
+	}
+
+	c := NewClient("custom")
+	if err := c.Init(provCfg); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	emails, err := c.ListUnreadEmails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 2 || emails[0].Mailbox != "INBOX" || emails[1].Mailbox != "简历" || emails[1].Attachments[0].Mailbox != "简历" {
+		t.Fatalf("unexpected emails %+v", emails)
+	}
+	// 附件从所在文件夹下载
+	if err := c.DownloadAttachment(emails[0].Attachments[0], t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	// 目标文件夹不存在时自动创建；服务器不支持MOVE时复制后标记删除
+	if err := c.ApplyActions(emails[0], processed); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ApplyActions(emails[1], []domain.PostAction{{Type: domain.ActionKeyword, Keyword: "$EasyHR-Processed"}}); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := user.Status("已处理", &imap.StatusOptions{NumMessages: true}); err != nil || *status.NumMessages != 1 {
+		t.Fatalf("expected 1 moved email, got %+v, %v", status, err)
+	}
+	// 加了关键字的邮件不再被搜索到，且仍为未读
+	if emails, err = c.ListUnreadEmails(); err != nil || len(emails) != 0 {
+		t.Fatalf("expected no emails, got %+v, %v", emails, err)
+	}
+	if status, err := user.Status("简历", &imap.StatusOptions{NumUnseen: true}); err != nil || *status.NumUnseen != 1 {
+		t.Fatalf("expected unseen email, got %+v, %v", status, err)
+	}
+
+	appCfg.Providers[0].PostProcess = &extconfig.PostProcessConfig{Failed: []extconfig.PostAction{{Action: "none"}, {Action: "seen"}}}
+	if err := config.ValidateConfig(appCfg); err == nil || !strings.Contains(err.Error(), "post_process.failed") {
+		t.Fatalf("expected invalid post_process error, got %v", err)
+	}
+
+	// 默认搜索条件（未读邮件）下，不标记已读的结果需添加关键字，搜索时排除该关键字
+	appCfg.Providers[0].Search = nil
+	appCfg.Providers[0].PostProcess = &extconfig.PostProcessConfig{
+		Processed:  []extconfig.PostAction{{Action: "keyword", Keyword: "$EasyHR-Processed"}},
+		Unroutable: []extconfig.PostAction{{Action: "none"}},
+	}
+	if err := config.ValidateConfig(appCfg); err == nil || !strings.Contains(err.Error(), "post_process.unroutable") {
+		t.Fatalf("expected unroutable mail to be re-listed error, got %v", err)
+	}
+	appCfg.Providers[0].PostProcess.Unroutable = []extconfig.PostAction{{Action: "keyword", Keyword: "EasyHR-Unroutable"}}
+	if err := config.ValidateConfig(appCfg); err != nil {
+		t.Fatal(err)
+	}
+	if internalCfg, err = config.InitInternalConfig(appCfg); err != nil {
+		t.Fatal(err)
+	}
+	notFlags := internalCfg.ProvidersConfig[0].Search.NotFlags
+	if !slices.Equal(notFlags, []imap.Flag{imap.FlagSeen, "$EasyHR-Processed", "EasyHR-Unroutable"}) {
+		t.Fatalf("unexpected default search not_flags %v", notFlags)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	cfg, err := config.InitInternalConfig(&extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{
+			{Type: "163", Config: map[string]interface{}{"username": "a@163.com", "password": "x"}},
+			{Type: "Gmail", Config: map[string]interface{}{"username": "a@gmail.com", "password": "x", "port": 1993}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	netease, gmail := cfg.ProvidersConfig[0].IMAPConfig, cfg.ProvidersConfig[1].IMAPConfig
+	if netease.Addr != "imap.163.com:993" || !netease.SendID || netease.TLSMode != "tls" {
+		t.Fatalf("unexpected 163 preset %+v", netease)
+	}
+	if gmail.Addr != "imap.gmail.com:1993" || gmail.SendID {
+		t.Fatalf("unexpected gmail preset %+v", gmail)
+	}
+
+	// custom没有预设，必须填写服务器地址
+	err = config.ValidateConfig(&extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{
+			{Type: "custom", Config: map[string]interface{}{"username": "a", "password": "x"}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "imap_addr") {
+		t.Fatalf("expected missing imap_addr error, got %v", err)
+	}
+}
+
+// dropProxy 转发到IMAP服务器的TCP代理，drop断开当前所有连接以模拟服务器掉线
+type dropProxy struct {
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startProxy(t *testing.T, target string) (string, *dropProxy) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	p := &dropProxy{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			p.mu.Lock()
+			p.conns = append(p.conns, conn, upstream)
+			p.mu.Unlock()
+			go io.Copy(upstream, conn)
+			go io.Copy(conn, upstream)
+		}
+	}()
+	return ln.Addr().String(), p
+}
+
+func (p *dropProxy) drop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	addr, user := startServer(t)
+	proxyAddr, proxy := startProxy(t, addr)
+	appendResume(t, user, "2026校园招聘-后端研发-张三-13900000000", []byte("%PDF-1.4"))
+
+	c := NewClient("custom")
+	if err := c.Init(config.ProviderConfig{
+		Type:       "custom",
+		IMAPConfig: config.IMAPConfig{Addr: proxyAddr, Username: "hr@example.com", Password: "secret", TLSMode: "none"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if emails, err := c.ListUnreadEmails(); err != nil || len(emails) != 1 {
+		t.Fatalf("expected 1 unread email, got %d, %v", len(emails), err)
+	}
+
+	// 服务器断开后，下一次操作自动重连并重新选中收件箱
+	proxy.drop()
+	time.Sleep(100 * time.Millisecond)
+	emails, err := c.ListUnreadEmails()
+	if err != nil || len(emails) != 1 {
+		t.Fatalf("expected 1 unread email after reconnect, got %d, %v", len(emails), err)
+	}
+	if err := c.MarkAsRead(emails[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	status := c.ConnectionStatus()
+	if status.State != "connected" || status.Reconnects != 1 || status.Mailbox != "INBOX" || status.LastError == "" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+// oauthSession 只接受OAUTHBEARER认证的会话，令牌正确时以用户密码登录内存服务器
+type oauthSession struct {
+	imapserver.Session
+	token string
+}
+
+func (s *oauthSession) AuthenticateMechanisms() []string {
+	return []string{"OAUTHBEARER"}
+}
+
+func (s *oauthSession) Authenticate(mech string) (sasl.Server, error) {
+	if mech != "OAUTHBEARER" {
+		return nil, &imap.Error{Type: imap.StatusResponseTypeNo, Text: "SASL mechanism not supported"}
+	}
+	return sasl.NewOAuthBearerServer(func(opts sasl.OAuthBearerOptions) *sasl.OAuthBearerError {
+		if opts.Token != s.token || s.Login(opts.Username, "secret") != nil {
+			return &sasl.OAuthBearerError{Status: "invalid_token"}
+		}
+		return nil
+	}), nil
+}
+
+func TestClient_OAuth2(t *testing.T) {
+	var refreshes int
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("refresh_token") != "rt" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"at","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenSrv.Close()
+
+	user := imapmemserver.NewUser("hr@example.com", "secret")
+	if err := user.Create("INBOX", nil); err != nil {
+		t.Fatal(err)
+	}
+	appendResume(t, user, "2026校园招聘-后端研发-张三", []byte("%PDF-1.4"))
+	mem := imapmemserver.New()
+	mem.AddUser(user)
+	srv := imapserver.New(&imapserver.Options{
+		NewSession: func(*imapserver.Conn) (imapserver.Session, *imapserver.GreetingData, error) {
+			return &oauthSession{Session: mem.NewSession(), token: "at"}, nil, nil
+		},
+		Caps:         imap.CapSet{imap.CapIMAP4rev1: {}, imap.CapSASLIR: {}},
+		InsecureAuth: true,
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	appCfg := &extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: t.TempDir() + "/processed.json",
+		Providers: []extconfig.ProviderConfig{{
+			Type: "gmail",
+			Config: map[string]interface{}{
+				"imap_addr": host,
+				"port":      portNum,
+				"username":  "hr@example.com",
+				"tls_mode":  "none",
+			},
+			OAuth2: &mailauth.OAuth2Config{
+				ClientID:     "easyhr",
+				TokenURL:     tokenSrv.URL,
+				RefreshToken: "rt",
+				TokenFile:    filepath.Join(t.TempDir(), "gmail.json"),
+				Mechanism:    "OAUTHBEARER",
+			},
+		}},
+	}
+	if err := config.ValidateConfig(appCfg); err != nil {
+		t.Fatal(err)
+	}
+	internalCfg, err := config.InitInternalConfig(appCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// gmail默认使用google预设的权限
+	if oauth2 := internalCfg.ProvidersConfig[0].IMAPConfig.OAuth2; oauth2.Provider != mailauth.ProviderGoogle || oauth2.Scopes[0] != "https://mail.google.com/" {
+		t.Fatalf("unexpected oauth2 config %+v", oauth2)
+	}
+
+	c := NewClient("gmail")
+	if err := c.Init(internalCfg.ProvidersConfig[0]); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if emails, err := c.ListUnreadEmails(); err != nil || len(emails) != 1 {
+		t.Fatalf("expected 1 unread email, got %d, %v", len(emails), err)
+	}
+	if refreshes != 1 {
+		t.Fatalf("expected 1 token refresh, got %d", refreshes)
+	}
+
+	// 刷新令牌被撤销时登录失败
+	appCfg.Providers[0].OAuth2.RefreshToken = "revoked"
+	appCfg.Providers[0].OAuth2.TokenFile = ""
+	internalCfg, _ = config.InitInternalConfig(appCfg)
+	if err := NewClient("gmail").Init(internalCfg.ProvidersConfig[0]); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected refresh error, got %v", err)
+	}
+
+	appCfg.Providers[0].OAuth2 = nil
+	if err := config.ValidateConfig(appCfg); err == nil || !strings.Contains(err.Error(), "password") {
+		t.Fatalf("expected missing password error, got %v", err)
+	}
+}
@@ -0,0 +1,471 @@
+// Package pop3mail POP3邮件客户端，用于只提供POP3的合作方邮箱
+// POP3没有UID、标记与搜索：按UIDL在本地分配UID并记录已读状态，每轮收取所有未读取过的邮件
+package pop3mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"easyHR/internal/email/email-attacher/domain"
+	"easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/internal/email/email-attacher/internal/pop3"
+	"easyHR/internal/email/email-attacher/internal/retry"
+	"easyHR/internal/email/email-attacher/internal/safety"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset" // 支持GBK等非UTF-8字符集的主题与正文
+	"github.com/emersion/go-message/mail"
+)
+
+// 建立会话时的重试策略
+const (
+	connectAttempts = 3
+	connectInterval = 2 * time.Second
+)
+
+// maxTextPartSize 单个正文分段的大小上限，超过时跳过（通常是嵌入图片的HTML）
+const maxTextPartSize = 1 << 20
+
+// errPartFound 找到目标分段后停止遍历
+var errPartFound = errors.New("part found")
+
+// Client POP3客户端。每次操作建立一个短会话，避免长时间锁定服务器上的邮箱；
+// 列表时取回未读邮件的原文暂存到本地，附件与正文从暂存的原文中解析
+type Client struct {
+	provider string
+	cfg      config.POP3Config
+	state    *uidlState // UIDL状态：本地UID与已读记录
+	spool    string     // 原文暂存目录，文件按UID命名，每轮列表前清空
+}
+
+// NewClient 创建POP3客户端实例（工厂调用）
+func NewClient(provider string) *Client {
+	return &Client{provider: provider}
+}
+
+// Init 初始化客户端：加载UIDL状态并检查服务器是否可登录、是否支持UIDL
+func (c *Client) Init(cfg interface{}) error {
+	internalCfg, ok := cfg.(config.ProviderConfig)
+	if !ok {
+		return fmt.Errorf("%s客户端配置类型错误", c.provider)
+	}
+	c.cfg = internalCfg.POP3Config
+
+	state, err := loadState(c.cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	if err := c.session(func(conn *pop3.Conn) error {
+		_, err := conn.UIDL()
+		return err
+	}); err != nil {
+		return err
+	}
+	spool, err := os.MkdirTemp("", "easyhr-pop3-*")
+	if err != nil {
+		return fmt.Errorf("创建邮件暂存目录失败：%w", err)
+	}
+	c.state = state
+	c.spool = spool
+	return nil
+}
+
+// ListUnreadEmails 取回未读取过的邮件（按UIDL判断，每轮最多max_messages_per_poll封）并解析附件与正文分段
+func (c *Client) ListUnreadEmails() ([]domain.Email, error) {
+	if c.state == nil {
+		return nil, fmt.Errorf("POP3客户端未初始化")
+	}
+	// 上一轮的邮件已处理完毕
+	if err := os.RemoveAll(c.spool); err != nil {
+		return nil, fmt.Errorf("清理邮件暂存目录失败：%w", err)
+	}
+	if err := os.MkdirAll(c.spool, 0700); err != nil {
+		return nil, fmt.Errorf("创建邮件暂存目录失败：%w", err)
+	}
+
+	emails := []domain.Email{}
+	err := c.session(func(conn *pop3.Conn) error {
+		count, _, err := conn.Stat()
+		if err != nil {
+			return err
+		}
+		var ids []pop3.MessageID
+		if count > 0 {
+			if ids, err = conn.UIDL(); err != nil {
+				return err
+			}
+		}
+		c.state.sync(ids)
+
+		for _, id := range ids {
+			entry := c.state.Messages[id.UID]
+			if entry.Seen {
+				continue
+			}
+			// 每轮取回的邮件数有上限，首次连接有大量历史邮件时分多轮下载
+			if len(emails) >= c.cfg.MaxPerPoll {
+				break
+			}
+			if !c.cfg.Since.IsZero() && !entry.Checked {
+				old, err := sentBefore(conn, id.Number, c.cfg.Since)
+				if err != nil {
+					return fmt.Errorf("获取邮件%s的邮件头失败：%w", id.UID, err)
+				}
+				if old {
+					// 早于since的历史邮件不下载，记为已读
+					entry.Seen = true
+					continue
+				}
+				entry.Checked = true
+			}
+			path := c.spoolPath(entry.UID)
+			if err := retrieve(conn, id.Number, path); err != nil {
+				return fmt.Errorf("获取邮件%s失败：%w", id.UID, err)
+			}
+			email, err := parseMessage(path, entry.UID, c.state.UIDValidity)
+			if err != nil {
+				return err
+			}
+			emails = append(emails, email)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 新分配的UID在交给调用方前保存，重启后保持不变
+	if err := c.state.save(); err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// sentBefore 通过TOP只读取邮件头，判断邮件是否早于since发送；没有可解析的Date时按新邮件处理
+func sentBefore(conn *pop3.Conn, num int, since time.Time) (bool, error) {
+	var buf bytes.Buffer
+	if _, err := conn.Top(num, 0, &buf); err != nil {
+		return false, err
+	}
+	entity, _ := message.Read(&buf)
+	if entity == nil {
+		return false, nil
+	}
+	header := mail.Header{Header: entity.Header}
+	date, err := header.Date()
+	if err != nil || date.IsZero() {
+		return false, nil
+	}
+	return date.Before(since), nil
+}
+
+// retrieve 获取邮件原文写入path
+func retrieve(conn *pop3.Conn, num int, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	_, err = conn.Retr(num, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// parseMessage 解析暂存的邮件原文。头部或MIME结构损坏时返回已解析的部分，
+// 没有附件的邮件按无可处理的简历处理
+func parseMessage(path string, uid imap.UID, uidValidity uint32) (domain.Email, error) {
+	email := domain.Email{
+		ID:          uid,
+		Mailbox:     config.DefaultMailbox,
+		UIDValidity: uidValidity,
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return email, err
+	}
+	defer f.Close()
+
+	// 未知的字符集或传输编码不影响解析其余内容
+	entity, _ := message.Read(bufio.NewReader(f))
+	if entity == nil {
+		return email, nil
+	}
+	header := mail.Header{Header: entity.Header}
+	email.Subject, _ = header.Subject()
+	email.SentAt, _ = header.Date()
+	if from, _ := header.AddressList("From"); len(from) > 0 {
+		email.From = from[0].Address
+	}
+	to, _ := header.AddressList("To")
+	for _, addr := range to {
+		email.To = append(email.To, addr.Address)
+	}
+
+	// 解析时已按传输编码与字符集解码，Size为解码后的大小，Encoding为空
+	_ = entity.Walk(func(path []int, part *message.Entity, _ error) error {
+		mediaType, params, _ := part.Header.ContentType()
+		if strings.HasPrefix(mediaType, "multipart/") {
+			return nil
+		}
+		attachment := mail.AttachmentHeader{Header: part.Header}
+		filename, _ := attachment.Filename()
+		size, _ := io.Copy(io.Discard, part.Body)
+
+		// 有文件名即视为附件（含内联附件），没有文件名的text/plain、text/html为正文
+		if filename == "" {
+			if mediaType == "text/plain" || mediaType == "text/html" {
+				email.TextParts = append(email.TextParts, domain.TextPart{
+					Part:      partPath(path),
+					MediaType: mediaType,
+					Charset:   params["charset"],
+					Size:      size,
+				})
+			}
+			return nil
+		}
+		email.Attachments = append(email.Attachments, domain.Attachment{
+			ID:          fmt.Sprintf("%d-%s", uid, filename),
+			Name:        filename,
+			ContentType: mediaType,
+			Size:        size,
+			EmailID:     uid,
+			Mailbox:     email.Mailbox,
+			Part:        partPath(path),
+		})
+		return nil
+	})
+	return email, nil
+}
+
+// partPath 将go-message的分段路径（从0开始，单分段邮件为nil）转换为与IMAP一致的路径（从1开始）
+func partPath(path []int) []int {
+	if len(path) == 0 {
+		return []int{1}
+	}
+	out := make([]int, len(path))
+	for i, p := range path {
+		out[i] = p + 1
+	}
+	return out
+}
+
+// openPart 在暂存的原文中找到分段并交给fn读取
+func (c *Client) openPart(uid imap.UID, part []int, fn func(body io.Reader) error) error {
+	f, err := os.Open(c.spoolPath(uid))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("邮件%d的原文已清理，需重新收取", uid)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entity, _ := message.Read(bufio.NewReader(f))
+	if entity == nil {
+		return fmt.Errorf("邮件%d无法解析", uid)
+	}
+	err = entity.Walk(func(path []int, e *message.Entity, _ error) error {
+		if mediaType, _, _ := e.Header.ContentType(); strings.HasPrefix(mediaType, "multipart/") || !equalPath(partPath(path), part) {
+			return nil
+		}
+		if err := fn(e.Body); err != nil {
+			return err
+		}
+		return errPartFound
+	})
+	if errors.Is(err, errPartFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("邮件%d中找不到分段%v", uid, part)
+}
+
+func equalPath(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DownloadAttachment 从暂存的原文中解码附件，写入临时文件后重命名，不会留下不完整的附件
+func (c *Client) DownloadAttachment(att domain.Attachment, savePath string) error {
+	if c.state == nil {
+		return fmt.Errorf("POP3客户端未初始化")
+	}
+	// 文件名来自发件人，调用方需先清理，避免写到savePath之外
+	if safety.SanitizeName(att.Name) != att.Name {
+		return fmt.Errorf("附件名%q未清理，拒绝保存", att.Name)
+	}
+	if err := os.MkdirAll(savePath, 0755); err != nil {
+		return fmt.Errorf("创建保存路径失败: %w", err)
+	}
+	return c.openPart(att.EmailID, att.Part, func(body io.Reader) error {
+		return writeFileStream(filepath.Join(savePath, att.Name), body)
+	})
+}
+
+// ReadBody 从暂存的原文中读取纯文本与HTML正文（已转为UTF-8）
+func (c *Client) ReadBody(email domain.Email) (string, string, error) {
+	if c.state == nil {
+		return "", "", fmt.Errorf("POP3客户端未初始化")
+	}
+	var text, html strings.Builder
+	for _, part := range email.TextParts {
+		if part.Size > maxTextPartSize {
+			continue
+		}
+		err := c.openPart(email.ID, part.Part, func(body io.Reader) error {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return fmt.Errorf("解码正文失败: %w", err)
+			}
+			if part.MediaType == "text/html" {
+				html.Write(data)
+			} else {
+				text.Write(data)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return text.String(), html.String(), nil
+}
+
+// writeFileStream 写入同目录下的临时文件，完成后重命名为目标文件
+func writeFileStream(filePath string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".part-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("写入附件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入附件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// MarkAsRead 在UIDL状态中记录为已读；开启delete_after_download时先从服务器删除（QUIT后生效）
+func (c *Client) MarkAsRead(emailID imap.UID) error {
+	if c.state == nil {
+		return fmt.Errorf("POP3客户端未初始化")
+	}
+	uidl, ok := c.state.uidl(emailID)
+	if !ok {
+		return fmt.Errorf("邮件%d不在POP3状态中", emailID)
+	}
+	if c.cfg.DeleteAfterDownload {
+		err := c.session(func(conn *pop3.Conn) error {
+			ids, err := conn.UIDL()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if id.UID == uidl {
+					return conn.Dele(id.Number)
+				}
+			}
+			// 已被其他客户端删除
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("删除邮件%d失败：%w", emailID, err)
+		}
+	}
+	return c.markSeen(emailID, uidl)
+}
+
+// RecordProcessed 轮询器记录邮件已处理后在UIDL状态中记为已读，处理后操作为none时也不再重新下载；不删除服务器上的邮件
+func (c *Client) RecordProcessed(emailID imap.UID) error {
+	if c.state == nil {
+		return fmt.Errorf("POP3客户端未初始化")
+	}
+	uidl, ok := c.state.uidl(emailID)
+	if !ok || c.state.Messages[uidl].Seen {
+		return nil
+	}
+	return c.markSeen(emailID, uidl)
+}
+
+// markSeen 记为已读并清理暂存的原文
+func (c *Client) markSeen(emailID imap.UID, uidl string) error {
+	c.state.Messages[uidl].Seen = true
+	_ = os.Remove(c.spoolPath(emailID))
+	return c.state.save()
+}
+
+// session 建立一次POP3会话执行fn（连接带重试），成功后QUIT使DELE生效，失败时直接断开，本次会话的DELE不生效
+func (c *Client) session(fn func(conn *pop3.Conn) error) error {
+	var conn *pop3.Conn
+	err := retry.Retry(context.Background(), connectAttempts, connectInterval, func() error {
+		var err error
+		conn, err = pop3.Dial(pop3.Options{
+			Addr:               c.cfg.Addr,
+			Username:           c.cfg.Username,
+			Password:           c.cfg.Password,
+			TLSMode:            c.cfg.TLSMode,
+			InsecureSkipVerify: c.cfg.InsecureSkipVerify,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("连接POP3服务器失败：%w", err)
+	}
+	if err := fn(conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return conn.Quit()
+}
+
+// spoolPath 邮件原文的暂存路径
+func (c *Client) spoolPath(uid imap.UID) string {
+	return filepath.Join(c.spool, strconv.FormatUint(uint64(uid), 10)+".eml")
+}
+
+// Close 删除暂存的邮件原文
+func (c *Client) Close() error {
+	if c.state == nil {
+		return nil
+	}
+	c.state = nil
+	return os.RemoveAll(c.spool)
+}
+
+// GetProvider 获取服务商名称
+func (c *Client) GetProvider() string {
+	return c.provider
+}
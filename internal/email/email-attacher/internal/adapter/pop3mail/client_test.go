@@ -0,0 +1,360 @@
+package pop3mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	extconfig "easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/internal/config"
+)
+
+// fakeMessage 服务器上的一封邮件
+type fakeMessage struct {
+	uidl string
+	raw  string
+}
+
+// fakeServer 最小的POP3服务器：USER/PASS、STAT、UIDL、TOP、RETR、DELE、NOOP、QUIT，DELE在QUIT后生效
+type fakeServer struct {
+	mu       sync.Mutex
+	messages []fakeMessage
+	retrs    int // RETR次数
+}
+
+// startServer 启动一个不加密的POP3服务器，返回监听地址
+func startServer(t *testing.T) (*fakeServer, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv := &fakeServer{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv, ln.Addr().String()
+}
+
+func (s *fakeServer) add(uidl, raw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, fakeMessage{uidl: uidl, raw: raw})
+}
+
+func (s *fakeServer) uidls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, m := range s.messages {
+		out = append(out, m.uidl)
+	}
+	return out
+}
+
+func (s *fakeServer) retrCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retrs
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+	s.mu.Lock()
+	messages := append([]fakeMessage(nil), s.messages...)
+	s.mu.Unlock()
+	deleted := make(map[string]bool)
+
+	tp.PrintfLine("+OK POP3 ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		first, _, _ := strings.Cut(arg, " ")
+		num, _ := strconv.Atoi(first)
+		if (cmd == "RETR" || cmd == "DELE" || cmd == "TOP") && (num < 1 || num > len(messages)) {
+			tp.PrintfLine("-ERR no such message")
+			continue
+		}
+		switch cmd {
+		case "USER", "NOOP":
+			tp.PrintfLine("+OK")
+		case "PASS":
+			if arg != "secret" {
+				tp.PrintfLine("-ERR invalid password")
+				continue
+			}
+			tp.PrintfLine("+OK logged in")
+		case "STAT":
+			size := 0
+			for _, m := range messages {
+				size += len(m.raw)
+			}
+			tp.PrintfLine("+OK %d %d", len(messages), size)
+		case "UIDL":
+			tp.PrintfLine("+OK")
+			w := tp.DotWriter()
+			for i, m := range messages {
+				fmt.Fprintf(w, "%d %s\n", i+1, m.uidl)
+			}
+			w.Close()
+		case "TOP":
+			// 只支持TOP n 0：返回邮件头
+			header, _, _ := strings.Cut(messages[num-1].raw, "\n\n")
+			tp.PrintfLine("+OK")
+			w := tp.DotWriter()
+			io.WriteString(w, header+"\n\n")
+			w.Close()
+		case "RETR":
+			s.mu.Lock()
+			s.retrs++
+			s.mu.Unlock()
+			tp.PrintfLine("+OK")
+			w := tp.DotWriter()
+			io.WriteString(w, messages[num-1].raw)
+			w.Close()
+		case "DELE":
+			deleted[messages[num-1].uidl] = true
+			tp.PrintfLine("+OK")
+		case "QUIT":
+			s.mu.Lock()
+			kept := s.messages[:0]
+			for _, m := range s.messages {
+				if !deleted[m.uidl] {
+					kept = append(kept, m)
+				}
+			}
+			s.messages = kept
+			s.mu.Unlock()
+			tp.PrintfLine("+OK bye")
+			return
+		default:
+			tp.PrintfLine("-ERR unknown command")
+		}
+	}
+}
+
+// resumeMessage 带PDF附件的简历邮件，正文中有以"."开头的行（传输时需转义）
+func resumeMessage(subject string, pdf []byte) string {
+	return strings.Join([]string{
+		"From: candidate@example.com",
+		"To: hr@example.com",
+		"Subject: " + mime.BEncoding.Encode("utf-8", subject),
+		"Date: Mon, 02 Jan 2026 15:04:05 +0800",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"校园招聘 简历投递",
+		".NET开发经验",
+		"--b1",
+		`Content-Type: application/pdf; name="resume.pdf"`,
+		`Content-Disposition: attachment; filename="resume.pdf"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		base64.StdEncoding.EncodeToString(pdf),
+		"--b1--",
+		"",
+	}, "\n")
+}
+
+// plainMessage 没有附件的纯文本邮件
+const plainMessage = "From: notice@example.com\nSubject: hello\n\nno attachment\n"
+
+// oldMessage 早于since的历史邮件
+const oldMessage = "From: old@example.com\nSubject: 2020 resume\nDate: Mon, 06 Jan 2020 10:00:00 +0800\n\nold\n"
+
+// newConfig 构建POP3服务商配置，extra中的配置项覆盖默认值
+func newConfig(t *testing.T, addr, stateFile string, deleteAfterDownload bool, extra ...map[string]interface{}) config.ProviderConfig {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	provCfg := map[string]interface{}{
+		"pop3_addr":             host,
+		"port":                  portNum,
+		"username":              "hr@example.com",
+		"password":              "secret",
+		"tls_mode":              "none",
+		"delete_after_download": deleteAfterDownload,
+		"state_file":            stateFile,
+	}
+	for _, m := range extra {
+		for k, v := range m {
+			provCfg[k] = v
+		}
+	}
+	cfg := &extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: filepath.Join(t.TempDir(), "processed.json"),
+		Providers: []extconfig.ProviderConfig{{
+			Type:   "pop3",
+			Config: provCfg,
+		}},
+	}
+	if err := config.ValidateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	internalCfg, err := config.InitInternalConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return internalCfg.ProvidersConfig[0]
+}
+
+func TestClient(t *testing.T) {
+	srv, addr := startServer(t)
+	pdf := []byte("%PDF-1.4 resume")
+	srv.add("uidl-a", resumeMessage("2026校园招聘-后端研发-张三", pdf))
+	srv.add("uidl-b", plainMessage)
+	stateFile := filepath.Join(t.TempDir(), "pop3-state.json")
+
+	c := NewClient("pop3")
+	if err := c.Init(newConfig(t, addr, stateFile, true)); err != nil {
+		t.Fatal(err)
+	}
+	emails, err := c.ListUnreadEmails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 2 || emails[0].Subject != "2026校园招聘-后端研发-张三" || emails[0].From != "candidate@example.com" ||
+		len(emails[0].Attachments) != 1 || len(emails[1].Attachments) != 0 || len(emails[1].TextParts) != 1 {
+		t.Fatalf("unexpected emails %+v", emails)
+	}
+	att := emails[0].Attachments[0]
+	if att.Name != "resume.pdf" || att.Size != int64(len(pdf)) || att.EmailID != emails[0].ID {
+		t.Fatalf("unexpected attachment %+v", att)
+	}
+	if text, _, err := c.ReadBody(emails[0]); err != nil || !strings.Contains(text, "\n.NET开发经验") {
+		t.Fatalf("unexpected body %q, %v", text, err)
+	}
+	dir := t.TempDir()
+	if err := c.DownloadAttachment(att, dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "resume.pdf")); err != nil || !bytes.Equal(data, pdf) {
+		t.Fatalf("unexpected attachment content %q, %v", data, err)
+	}
+
+	// 标记已读后从服务器删除
+	if err := c.MarkAsRead(emails[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if uidls := srv.uidls(); len(uidls) != 1 || uidls[0] != "uidl-b" {
+		t.Fatalf("expected uidl-a deleted, got %v", uidls)
+	}
+	remaining, err := c.ListUnreadEmails()
+	if err != nil || len(remaining) != 1 || remaining[0].ID != emails[1].ID {
+		t.Fatalf("unexpected emails %+v, %v", remaining, err)
+	}
+	c.Close()
+
+	// 重启后UID与UIDVALIDITY保持不变；不删除时按UIDL记录已读
+	c = NewClient("pop3")
+	if err := c.Init(newConfig(t, addr, stateFile, false)); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	srv.add("uidl-c", plainMessage)
+	emails, err = c.ListUnreadEmails()
+	if err != nil || len(emails) != 2 || emails[0].ID != remaining[0].ID || emails[0].UIDValidity != remaining[0].UIDValidity || emails[1].ID <= emails[0].ID {
+		t.Fatalf("unexpected emails %+v, %v", emails, err)
+	}
+	for _, email := range emails {
+		if err := c.MarkAsRead(email.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(srv.uidls()) != 2 {
+		t.Fatalf("expected messages kept on server, got %v", srv.uidls())
+	}
+	if emails, err = c.ListUnreadEmails(); err != nil || len(emails) != 0 {
+		t.Fatalf("expected no unread emails, got %d, %v", len(emails), err)
+	}
+}
+
+func TestClient_SinceAndRecordProcessed(t *testing.T) {
+	srv, addr := startServer(t)
+	srv.add("uidl-old", oldMessage)
+	srv.add("uidl-a", resumeMessage("2026校园招聘-后端研发-张三", []byte("%PDF-1.4 a")))
+	srv.add("uidl-b", resumeMessage("2026校园招聘-后端研发-李四", []byte("%PDF-1.4 b")))
+	srv.add("uidl-c", resumeMessage("2026校园招聘-后端研发-王五", []byte("%PDF-1.4 c")))
+
+	c := NewClient("pop3")
+	// YAML中不加引号的日期解析为time.Time，加引号时为字符串，两者都支持
+	if err := c.Init(newConfig(t, addr, filepath.Join(t.TempDir(), "state.json"), false, map[string]interface{}{
+		"since":                 "2026-01-01",
+		"max_messages_per_poll": 2,
+	})); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// 早于since的邮件只读取邮件头，每轮最多取回2封
+	emails, err := c.ListUnreadEmails()
+	if err != nil || len(emails) != 2 || srv.retrCount() != 2 {
+		t.Fatalf("unexpected emails %+v, %v, retr %d", emails, err, srv.retrCount())
+	}
+	// 处理后操作为none时不调用MarkAsRead，轮询器记录已处理后不再重新下载
+	for _, email := range emails {
+		if err := c.RecordProcessed(email.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emails, err = c.ListUnreadEmails()
+	if err != nil || len(emails) != 1 || emails[0].Subject != "2026校园招聘-后端研发-王五" || srv.retrCount() != 3 {
+		t.Fatalf("unexpected emails %+v, %v, retr %d", emails, err, srv.retrCount())
+	}
+	if err := c.RecordProcessed(emails[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if emails, err = c.ListUnreadEmails(); err != nil || len(emails) != 0 || srv.retrCount() != 3 {
+		t.Fatalf("expected nothing downloaded again, got %d, %v, retr %d", len(emails), err, srv.retrCount())
+	}
+	if len(srv.uidls()) != 4 {
+		t.Fatalf("expected messages kept on server, got %v", srv.uidls())
+	}
+}
+
+func TestValidatePOP3(t *testing.T) {
+	for name, prov := range map[string]extconfig.ProviderConfig{
+		"missing addr": {Type: "pop3", Config: map[string]interface{}{"username": "hr", "password": "secret"}},
+		"search":       {Type: "pop3", Config: map[string]interface{}{"pop3_addr": "pop.example.com", "username": "hr", "password": "secret"}, Search: &extconfig.SearchConfig{Subject: []string{"简历"}}},
+		"move": {Type: "pop3", Config: map[string]interface{}{"pop3_addr": "pop.example.com", "username": "hr", "password": "secret"},
+			PostProcess: &extconfig.PostProcessConfig{Processed: []extconfig.PostAction{{Action: "move", Folder: "done"}}}},
+		"since": {Type: "pop3", Config: map[string]interface{}{"pop3_addr": "pop.example.com", "username": "hr", "password": "secret", "since": "2026/01/01"}},
+		"max":   {Type: "pop3", Config: map[string]interface{}{"pop3_addr": "pop.example.com", "username": "hr", "password": "secret", "max_messages_per_poll": 0}},
+	} {
+		err := config.ValidateConfig(&extconfig.AppConfig{
+			PollInterval:        60,
+			AttachmentSavePath:  t.TempDir(),
+			ProcessedEmailsPath: filepath.Join(t.TempDir(), "processed.json"),
+			Providers:           []extconfig.ProviderConfig{prov},
+		})
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package pop3mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"easyHR/internal/email/email-attacher/internal/pop3"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// uidlEntry 单封邮件的本地状态
+type uidlEntry struct {
+	UID     imap.UID `json:"uid"`               // 本地分配的UID
+	Seen    bool     `json:"seen"`              // 已处理或早于since被跳过（代替IMAP的\Seen标记）
+	Checked bool     `json:"checked,omitempty"` // 已按邮件头Date确认不早于since，不再重复读取邮件头
+}
+
+// uidlState POP3没有UID与标记，按UIDL为每封邮件分配递增的UID并记录是否已读，保存在状态文件中
+// 状态文件丢失后UID会重新分配，此时生成新的UIDVALIDITY，旧的已处理记录随之失效
+type uidlState struct {
+	path        string
+	UIDValidity uint32                `json:"uid_validity"`
+	NextUID     imap.UID              `json:"next_uid"`
+	Messages    map[string]*uidlEntry `json:"messages"` // 按UIDL索引
+}
+
+// loadState 读取状态文件，不存在时创建新的状态（不立即写入）
+func loadState(path string) (*uidlState, error) {
+	s := &uidlState{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("读取POP3状态文件失败：%w", err)
+	default:
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("解析POP3状态文件%s失败：%w", path, err)
+		}
+	}
+	if s.UIDValidity == 0 {
+		s.UIDValidity = uint32(time.Now().Unix())
+	}
+	if s.NextUID == 0 {
+		s.NextUID = 1
+	}
+	if s.Messages == nil {
+		s.Messages = make(map[string]*uidlEntry)
+	}
+	return s, nil
+}
+
+// sync 按服务器当前的UIDL列表更新状态：新邮件分配UID，已不在服务器上的邮件删除记录
+func (s *uidlState) sync(ids []pop3.MessageID) {
+	current := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		current[id.UID] = true
+		if _, ok := s.Messages[id.UID]; !ok {
+			s.Messages[id.UID] = &uidlEntry{UID: s.NextUID}
+			s.NextUID++
+		}
+	}
+	for uidl := range s.Messages {
+		if !current[uidl] {
+			delete(s.Messages, uidl)
+		}
+	}
+}
+
+// uidl 返回本地UID对应的UIDL
+func (s *uidlState) uidl(uid imap.UID) (string, bool) {
+	for uidl, entry := range s.Messages {
+		if entry.UID == uid {
+			return uidl, true
+		}
+	}
+	return "", false
+}
+
+// save 写入临时文件后重命名
+func (s *uidlState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建POP3状态目录失败：%w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("保存POP3状态失败：%w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("保存POP3状态失败：%w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
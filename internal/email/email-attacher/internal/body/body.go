@@ -0,0 +1,155 @@
+// Package body 解析邮件正文：提取候选人填写的投递信息与简历链接
+package body
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"easyHR/internal/email/email-attacher/domain"
+)
+
+// 投递信息字段：标签后跟冒号，值截止到换行或分隔符
+var (
+	nameRe     = regexp.MustCompile(`(?i)(?:姓\s*名|名\s*字|\bname)\s*[:：]\s*(\p{Han}[\p{Han}·]{0,9}|[a-z][a-z.'-]*(?: [a-z][a-z.'-]*){0,3})`)
+	phoneRe    = regexp.MustCompile(`(?:\+?86[-\s]?)?(1[3-9]\d{9})\b`)
+	schoolRe   = regexp.MustCompile(`(?i)(?:毕业院校|毕业学校|就读院校|学\s*校|院\s*校|\bschool|\buniversity)\s*[:：]\s*([^\n,，;；|]+)`)
+	positionRe = regexp.MustCompile(`(?i)(?:应聘岗位|应聘职位|投递岗位|意向岗位|求职意向|岗\s*位|职\s*位|\bposition)\s*[:：]\s*([^\n,，;；|]+)`)
+	// 未标注学校时取"就读于XX大学"或第一个"XX大学/XX学院"
+	schoolAfterRe = regexp.MustCompile(`(?:就读于|毕业于|来自|在读于)(\p{Han}{2,12}?(?:大学|学院))`)
+	schoolNameRe  = regexp.MustCompile(`\p{Han}{2,12}?(?:大学|学院)`)
+
+	urlRe  = regexp.MustCompile(`https?://[^\s<>"'（）()，。、；！]+`)
+	hrefRe = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+)
+
+// HTML转文本
+var (
+	scriptRe = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	breakRe  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h[1-6])>`)
+	cellRe   = regexp.MustCompile(`(?i)</t[dh]>`)
+	tagRe    = regexp.MustCompile(`<[^>]*>`)
+	spaceRe  = regexp.MustCompile(`[ \t\x{00a0}\x{3000}]+`)
+)
+
+// cloudDriveHosts 常见网盘分享域名（含子域名），链接指向分享页而非文件本身
+var cloudDriveHosts = []string{
+	"pan.baidu.com",
+	"aliyundrive.com",
+	"alipan.com",
+	"share.weiyun.com",
+	"pan.quark.cn",
+	"123pan.com",
+	"lanzoui.com",
+	"lanzoux.com",
+	"drive.google.com",
+	"docs.google.com",
+	"onedrive.live.com",
+	"1drv.ms",
+	"dropbox.com",
+}
+
+// resumeExts 可直接下载的简历文件扩展名
+var resumeExts = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true,
+	".jpg": true, ".jpeg": true, ".png": true,
+	".zip": true, ".rar": true, ".7z": true,
+}
+
+// Parse 从正文中提取投递信息与简历链接；text为空时使用HTML转换的文本。未提取到任何信息时返回nil
+func Parse(text, htmlBody string) *domain.Application {
+	if strings.TrimSpace(text) == "" {
+		text = HTMLToText(htmlBody)
+	}
+	app := &domain.Application{
+		Name:     firstGroup(nameRe, text),
+		Phone:    firstGroup(phoneRe, text),
+		School:   firstGroup(schoolRe, text),
+		Position: firstGroup(positionRe, text),
+		Links:    ResumeLinks(text, htmlBody),
+	}
+	if app.School == "" {
+		app.School = firstGroup(schoolAfterRe, text)
+	}
+	if app.School == "" {
+		app.School = schoolNameRe.FindString(text)
+	}
+	if app.Name == "" && app.Phone == "" && app.School == "" && app.Position == "" && len(app.Links) == 0 {
+		return nil
+	}
+	return app
+}
+
+// ResumeLinks 返回正文中指向网盘分享页或简历文件的链接（去重，保持出现顺序）
+func ResumeLinks(text, htmlBody string) []string {
+	var candidates []string
+	candidates = append(candidates, urlRe.FindAllString(text, -1)...)
+	for _, m := range hrefRe.FindAllStringSubmatch(htmlBody, -1) {
+		candidates = append(candidates, html.UnescapeString(m[1]))
+	}
+	candidates = append(candidates, urlRe.FindAllString(HTMLToText(htmlBody), -1)...)
+
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range candidates {
+		link = strings.TrimRight(link, ".,;:!?")
+		if seen[link] || !IsResumeLink(link) {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+// IsResumeLink 判断链接是否指向网盘分享页或简历文件
+func IsResumeLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	if MatchHost(u.Hostname(), cloudDriveHosts) {
+		return true
+	}
+	return resumeExts[strings.ToLower(path.Ext(u.Path))]
+}
+
+// MatchHost 判断host是否为hosts中的域名或其子域名
+func MatchHost(host string, hosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTMLToText 将HTML正文转换为纯文本，保留换行
+func HTMLToText(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = scriptRe.ReplaceAllString(s, "")
+	s = breakRe.ReplaceAllString(s, "\n")
+	s = cellRe.ReplaceAllString(s, " ")
+	s = html.UnescapeString(tagRe.ReplaceAllString(s, ""))
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(spaceRe.ReplaceAllString(line, " ")); line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func firstGroup(re *regexp.Regexp, s string) string {
+	if m := re.FindStringSubmatch(s); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
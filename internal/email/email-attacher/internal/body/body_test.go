@@ -0,0 +1,43 @@
+package body
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := strings.Join([]string{
+		"您好，我想应聘贵公司的岗位，简历见网盘：",
+		"姓名：张三",
+		"电话：+86 139-0000-0000 / 13900000000",
+		"毕业院校：北京邮电大学，计算机科学与技术",
+		"应聘岗位：后端研发工程师",
+		"链接: https://pan.baidu.com/s/1abcDEF?pwd=x1y2 提取码: x1y2",
+		"个人主页 https://example.com/about",
+	}, "\n")
+	app := Parse(text, "")
+	if app == nil {
+		t.Fatal("expected application")
+	}
+	if app.Name != "张三" || app.Phone != "13900000000" || app.School != "北京邮电大学" || app.Position != "后端研发工程师" {
+		t.Fatalf("unexpected application %+v", app)
+	}
+	if len(app.Links) != 1 || app.Links[0] != "https://pan.baidu.com/s/1abcDEF?pwd=x1y2" {
+		t.Fatalf("unexpected links %v", app.Links)
+	}
+
+	// 只有HTML正文时从标签中提取
+	html := `<html><body><style>p{color:red}</style><table><tr><td>Name:</td><td>Li Si</td></tr></table>` +
+		`<p>就读于浙江大学，手机13800000000</p><p><a href="https://cdn.example.com/files/li%20si.pdf?sig=a&amp;t=1">我的简历</a></p></body></html>`
+	app = Parse("", html)
+	if app == nil || app.Name != "Li Si" || app.Phone != "13800000000" || app.School != "浙江大学" {
+		t.Fatalf("unexpected application %+v", app)
+	}
+	if len(app.Links) != 1 || app.Links[0] != "https://cdn.example.com/files/li%20si.pdf?sig=a&t=1" {
+		t.Fatalf("unexpected links %v", app.Links)
+	}
+
+	if app := Parse("谢谢", ""); app != nil {
+		t.Fatalf("expected nil, got %+v", app)
+	}
+}
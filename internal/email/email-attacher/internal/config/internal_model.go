@@ -1,8 +1,12 @@
 package config
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 
+	"easyHR/internal/email/mailauth"
+
 	"go.uber.org/zap"
 )
 
@@ -12,6 +16,9 @@ type InternalConfig struct {
 	StorageConfig   StorageConfig    // 存储配置
 	Logger          *zap.Logger      // 初始化后的日志器
 	RetryConfig     RetryConfig      // 重试配置
+	DownloadConfig  DownloadConfig   // 附件下载配置
+	BodyConfig      BodyConfig       // 正文解析配置
+	SafetyConfig    SafetyConfig     // 附件安全检查配置
 	ProvidersConfig []ProviderConfig // 服务商配置（强类型）
 }
 
@@ -22,8 +29,26 @@ type PollerConfig struct {
 
 // StorageConfig 存储模块专属配置
 type StorageConfig struct {
-	AttachmentSavePath  string // 附件保存路径（绝对路径）
-	ProcessedEmailsPath string // 已处理邮件记录路径（绝对路径）
+	AttachmentSavePath  string           // 附件保存路径（绝对路径）
+	ProcessedEmailsPath string           // 已处理邮件记录路径（绝对路径）
+	ProcessedStoreType  string           // file/mongo
+	Mongo               MongoStoreConfig // ProcessedStoreType为mongo时使用
+	LegacyAccount       string           // 旧版记录所属的账号，为空时为qq服务商的账号
+}
+
+// 已处理邮件存储类型
+const (
+	ProcessedStoreFile  = "file"
+	ProcessedStoreMongo = "mongo"
+)
+
+// MongoStoreConfig MongoDB存储配置
+type MongoStoreConfig struct {
+	URI        string
+	Database   string
+	Collection string
+	Username   string
+	Password   string
 }
 
 // RetryConfig 重试模块专属配置
@@ -34,13 +59,118 @@ type RetryConfig struct {
 
 // ProviderConfig 内部服务商配置
 type ProviderConfig struct {
-	Type       string     // 服务商类型（qq/netease等）
-	IMAPConfig IMAPConfig // IMAP协议配置（强类型）
+	Type        string            // 服务商类型（qq/163/gmail/outlook/custom/pop3等）
+	Protocol    string            // 收信协议（imap/pop3）
+	IMAPConfig  IMAPConfig        // IMAP协议配置（强类型）
+	POP3Config  POP3Config        // POP3协议配置（Protocol为pop3时使用）
+	WatchConfig WatchConfig       // 收信模式配置
+	Search      SearchConfig      // 邮件搜索条件
+	Attachments AttachmentConfig  // 附件下载配置
+	Download    DownloadConfig    // 附件下载配置（与InternalConfig.DownloadConfig相同）
+	Mailboxes   []string          // 收取的文件夹
+	PostProcess PostProcessConfig // 处理完成后的操作
+}
+
+// Account 邮箱账号
+func (c ProviderConfig) Account() string {
+	if c.Protocol == ProtocolPOP3 {
+		return c.POP3Config.Username
+	}
+	return c.IMAPConfig.Username
+}
+
+// DefaultAttachmentTypes 默认下载的附件扩展名：PDF、Word、图片与压缩包
+var DefaultAttachmentTypes = []string{".pdf", ".doc", ".docx", ".jpg", ".jpeg", ".png", ".zip", ".rar", ".7z"}
+
+// AttachmentConfig 附件下载配置
+type AttachmentConfig struct {
+	Types map[string]bool // 允许下载的扩展名（小写，含"."）
+}
+
+// Allowed 按扩展名判断附件是否需要下载
+func (c AttachmentConfig) Allowed(name string) bool {
+	return c.Types[strings.ToLower(filepath.Ext(name))]
+}
+
+// 附件下载默认值
+const (
+	DefaultFetchBatchSize         = 50
+	DefaultMaxInflightBytes int64 = 64 << 20
+)
+
+// DownloadConfig 附件下载配置
+type DownloadConfig struct {
+	FetchBatchSize   int   // 每条FETCH命令获取的邮件数
+	MaxInflightBytes int64 // 同时下载的附件总字节上限
+}
+
+// 正文链接下载默认值
+const (
+	DefaultMaxLinkSize int64 = 20 << 20
+	DefaultLinkTimeout       = 30 * time.Second
+)
+
+// BodyConfig 正文解析配置
+type BodyConfig struct {
+	Enabled     bool          // 是否解析正文
+	LinkHosts   []string      // 允许下载的链接主机（小写，不含协议）
+	MaxLinkSize int64         // 单个链接文件大小上限
+	LinkTimeout time.Duration // 单个链接下载超时
+}
+
+// 附件安全检查默认值
+const (
+	DefaultMaxAttachmentSize int64 = 20 << 20
+	DefaultMaxEmailSize      int64 = 50 << 20
+	DefaultQuarantineDir           = "_quarantine"
+)
+
+// SafetyConfig 附件安全检查配置
+type SafetyConfig struct {
+	MaxAttachmentSize int64  // 单个附件大小上限
+	MaxEmailSize      int64  // 单封邮件的附件总大小上限
+	QuarantinePath    string // 隔离目录（绝对路径）
+}
+
+// 收信模式
+const (
+	WatchModePoll = "poll" // 按固定间隔轮询
+	WatchModeIdle = "idle" // IMAP IDLE推送，服务器不支持时回退轮询
+)
+
+// DefaultIdleTimeout IDLE默认重新发起间隔，RFC 2177建议客户端在29分钟内重新发起
+const DefaultIdleTimeout = 25 * time.Minute
+
+// WatchConfig 收信模式配置
+type WatchConfig struct {
+	Mode        string        // poll/idle
+	IdleTimeout time.Duration // IDLE重新发起间隔
 }
 
 // IMAPConfig IMAP协议专属配置
 type IMAPConfig struct {
-	Addr     string // 完整地址（host:port）
-	Username string // 账号
-	Password string // 授权码/密码
+	Addr               string                 // 完整地址（host:port）
+	Username           string                 // 账号
+	Password           string                 // 授权码/密码
+	TLSMode            string                 // tls/starttls/none
+	InsecureSkipVerify bool                   // 跳过证书校验（自签名证书的自建服务器）
+	SendID             bool                   // 登录后发送IMAP ID命令
+	Keepalive          time.Duration          // NOOP保活间隔，0使用默认值，负数关闭
+	OAuth2             *mailauth.OAuth2Config // 不为空时使用OAuth2登录（已补全默认值）
+}
+
+// POP3Config POP3协议专属配置
+type POP3Config struct {
+	Addr                string    // 完整地址（host:port）
+	Username            string    // 账号
+	Password            string    // 授权码/密码
+	TLSMode             string    // tls/starttls/none
+	InsecureSkipVerify  bool      // 跳过证书校验（自签名证书的自建服务器）
+	DeleteAfterDownload bool      // 处理完成（标记已读）后从服务器删除
+	StateFile           string    // UIDL状态文件（绝对路径）：记录已读邮件与本地分配的UID，代替IMAP标记
+	Since               time.Time // 只收取该日期及之后发送的邮件（按邮件头Date判断），为零值表示不限
+	MaxPerPoll          int       // 每轮最多取回的邮件数，其余留到下一轮
 }
+
+// DefaultPOP3MaxPerPoll 未配置max_messages_per_poll时每轮最多取回的邮件数，避免首次连接时在一次会话中下载整个邮箱
+const DefaultPOP3MaxPerPoll = 50
@@ -1,12 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/internal/safety"
+	"easyHR/internal/email/mailauth"
 )
 
 // InitInternalConfig 转换外部配置为内部配置
@@ -25,10 +30,20 @@ func InitInternalConfig(externalCfg *config.AppConfig) (*InternalConfig, error)
 	retryCfg := buildRetryConfig(externalCfg.RetryConfig)
 
 	// 3. 构建服务商配置
-	providersCfg, err := buildProvidersConfig(externalCfg.Providers)
+	providersCfg, err := buildProvidersConfig(externalCfg.Providers, filepath.Dir(storageCfg.ProcessedEmailsPath))
 	if err != nil {
 		return nil, fmt.Errorf("服务商配置：%w", err)
 	}
+	safetyCfg, err := buildSafetyConfig(externalCfg.Safety, storageCfg.AttachmentSavePath)
+	if err != nil {
+		return nil, fmt.Errorf("附件安全配置：%w", err)
+	}
+	attachmentCfg := buildAttachmentConfig(externalCfg.AttachmentTypes)
+	downloadCfg := buildDownloadConfig(externalCfg.Download)
+	for i := range providersCfg {
+		providersCfg[i].Attachments = attachmentCfg
+		providersCfg[i].Download = downloadCfg
+	}
 
 	// 组装内部配置
 	return &InternalConfig{
@@ -36,6 +51,9 @@ func InitInternalConfig(externalCfg *config.AppConfig) (*InternalConfig, error)
 		StorageConfig:   *storageCfg,
 		ProvidersConfig: providersCfg,
 		RetryConfig:     retryCfg,
+		DownloadConfig:  downloadCfg,
+		BodyConfig:      buildBodyConfig(externalCfg.Body),
+		SafetyConfig:    safetyCfg,
 	}, nil
 }
 
@@ -80,38 +98,286 @@ func buildStorageConfig(externalCfg *config.AppConfig) (*StorageConfig, error) {
 		return nil, err
 	}
 
-	return &StorageConfig{
+	storageCfg := &StorageConfig{
 		AttachmentSavePath:  attachPath,
 		ProcessedEmailsPath: processedPath,
-	}, nil
+		ProcessedStoreType:  ProcessedStoreFile,
+		LegacyAccount:       strings.TrimSpace(externalCfg.LegacyAccount),
+	}
+	if store := externalCfg.ProcessedStore; store != nil && strings.ToLower(store.Type) == ProcessedStoreMongo {
+		storageCfg.ProcessedStoreType = ProcessedStoreMongo
+		storageCfg.Mongo = MongoStoreConfig{
+			URI:        store.MongoURI,
+			Database:   store.Database,
+			Collection: store.Collection,
+			Username:   store.Username,
+			Password:   store.Password,
+		}
+		if storageCfg.Mongo.Collection == "" {
+			storageCfg.Mongo.Collection = "processed_emails"
+		}
+	}
+	return storageCfg, nil
 }
 
-// 构建服务商配置（map转强类型）
-func buildProvidersConfig(externalProviders []config.ProviderConfig) ([]ProviderConfig, error) {
+// 构建服务商配置（map转强类型，未填写的地址、端口与TLS模式使用服务商预设）
+// stateDir为POP3 UIDL状态文件的默认目录（已处理邮件记录所在目录）
+func buildProvidersConfig(externalProviders []config.ProviderConfig, stateDir string) ([]ProviderConfig, error) {
 	var internalProviders []ProviderConfig
 
 	for _, externalProv := range externalProviders {
 		provType := strings.ToLower(externalProv.Type)
 		extCfg := externalProv.Config
+		preset, _ := LookupPreset(provType)
 
 		// 提取IMAP配置（已校验过类型，可安全断言）
-		imapAddr := extCfg["imap_addr"].(string)
-		port := extCfg["port"].(int)
+		imapAddr := preset.Host
+		if v, ok := extCfg["imap_addr"].(string); ok && v != "" {
+			imapAddr = v
+		}
+		port := preset.Port
+		if v, ok := extCfg["port"].(int); ok {
+			port = v
+		}
+		tlsMode := preset.TLSMode
+		if v, ok := extCfg["tls_mode"].(string); ok && v != "" {
+			tlsMode = strings.ToLower(v)
+		}
+		sendID := preset.SendID
+		if v, ok := extCfg["send_id"].(bool); ok {
+			sendID = v
+		}
+		insecure, _ := extCfg["insecure_skip_verify"].(bool)
 		username := extCfg["username"].(string)
-		password := extCfg["password"].(string)
+		password, _ := extCfg["password"].(string)
+		oauth2, err := BuildOAuth2Config(externalProv.OAuth2, preset)
+		if err != nil {
+			return nil, err
+		}
+		mailboxes, err := BuildMailboxes(externalProv.Mailboxes)
+		if err != nil {
+			return nil, err
+		}
+		postProcess, err := BuildPostProcessConfig(externalProv.PostProcess)
+		if err != nil {
+			return nil, err
+		}
 
-		// 组装完整IMAP地址
-		fullAddr := fmt.Sprintf("%s:%d", imapAddr, port)
+		if preset.Protocol == ProtocolPOP3 {
+			pop3Cfg, err := buildPOP3Config(extCfg, preset, provType, stateDir)
+			if err != nil {
+				return nil, err
+			}
+			internalProviders = append(internalProviders, ProviderConfig{
+				Type:        provType,
+				Protocol:    ProtocolPOP3,
+				POP3Config:  pop3Cfg,
+				WatchConfig: buildWatchConfig(externalProv),
+				Mailboxes:   mailboxes,
+				PostProcess: postProcess,
+			})
+			continue
+		}
+
+		search, err := BuildProviderSearch(externalProv.Search, postProcess)
+		if err != nil {
+			return nil, err
+		}
 
 		internalProviders = append(internalProviders, ProviderConfig{
-			Type: provType,
+			Type:     provType,
+			Protocol: ProtocolIMAP,
 			IMAPConfig: IMAPConfig{
-				Addr:     fullAddr,
-				Username: username,
-				Password: password,
+				Addr:               net.JoinHostPort(imapAddr, strconv.Itoa(port)), // 组装完整IMAP地址
+				Username:           username,
+				Password:           password,
+				TLSMode:            tlsMode,
+				InsecureSkipVerify: insecure,
+				SendID:             sendID,
+				Keepalive:          externalProv.Keepalive,
+				OAuth2:             oauth2,
 			},
+			WatchConfig: buildWatchConfig(externalProv),
+			Search:      search,
+			Mailboxes:   mailboxes,
+			PostProcess: postProcess,
 		})
 	}
 
 	return internalProviders, nil
 }
+
+// 构建POP3配置：未填写state_file时保存在stateDir下，按服务商与账号区分
+func buildPOP3Config(extCfg map[string]interface{}, preset ProviderPreset, provType, stateDir string) (POP3Config, error) {
+	addr := preset.Host
+	if v, ok := extCfg["pop3_addr"].(string); ok && v != "" {
+		addr = v
+	}
+	port := preset.Port
+	if v, ok := extCfg["port"].(int); ok {
+		port = v
+	}
+	tlsMode := preset.TLSMode
+	if v, ok := extCfg["tls_mode"].(string); ok && v != "" {
+		tlsMode = strings.ToLower(v)
+	}
+	username := extCfg["username"].(string)
+	cfg := POP3Config{
+		Addr:     net.JoinHostPort(addr, strconv.Itoa(port)),
+		Username: username,
+		TLSMode:  tlsMode,
+	}
+	cfg.Password, _ = extCfg["password"].(string)
+	cfg.InsecureSkipVerify, _ = extCfg["insecure_skip_verify"].(bool)
+	cfg.DeleteAfterDownload, _ = extCfg["delete_after_download"].(bool)
+	since, err := parsePOP3Since(extCfg["since"])
+	if err != nil {
+		return cfg, fmt.Errorf("since：%w", err)
+	}
+	cfg.Since = since
+	cfg.MaxPerPoll = DefaultPOP3MaxPerPoll
+	if v, ok := extCfg["max_messages_per_poll"].(int); ok && v > 0 {
+		cfg.MaxPerPoll = v
+	}
+
+	stateFile, _ := extCfg["state_file"].(string)
+	if stateFile == "" {
+		stateFile = filepath.Join(stateDir, "pop3", safety.SanitizeName(provType+"-"+username)+".json")
+	}
+	path, err := filepath.Abs(stateFile)
+	if err != nil {
+		return cfg, fmt.Errorf("state_file转换失败：%w", err)
+	}
+	cfg.StateFile = path
+	return cfg, nil
+}
+
+// parsePOP3Since 解析POP3的since：YAML中不加引号的日期解析为time.Time，加引号时为字符串
+func parsePOP3Since(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case string:
+		return parseSearchDate(v)
+	default:
+		return time.Time{}, errors.New("日期格式必须是" + searchDateLayout)
+	}
+}
+
+// BuildOAuth2Config 补全OAuth2配置，未填写provider时使用服务商预设；未配置时返回nil
+func BuildOAuth2Config(ext *mailauth.OAuth2Config, preset ProviderPreset) (*mailauth.OAuth2Config, error) {
+	if ext == nil {
+		return nil, nil
+	}
+	cfg := *ext
+	if cfg.Provider == "" {
+		cfg.Provider = preset.OAuth2Provider
+	}
+	cfg, err := cfg.Complete()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// 构建收信模式配置（补全默认值）
+func buildWatchConfig(externalProv config.ProviderConfig) WatchConfig {
+	cfg := WatchConfig{
+		Mode:        strings.ToLower(externalProv.Mode),
+		IdleTimeout: externalProv.IdleTimeout,
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = WatchModePoll
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+	return cfg
+}
+
+// 构建附件下载配置，扩展名统一为小写并带"."
+func buildAttachmentConfig(types []string) AttachmentConfig {
+	if len(types) == 0 {
+		types = DefaultAttachmentTypes
+	}
+	cfg := AttachmentConfig{Types: make(map[string]bool, len(types))}
+	for _, t := range types {
+		cfg.Types["."+strings.TrimPrefix(strings.ToLower(strings.TrimSpace(t)), ".")] = true
+	}
+	return cfg
+}
+
+// 构建附件下载配置（补全默认值）
+func buildDownloadConfig(externalDownload *config.DownloadConfig) DownloadConfig {
+	cfg := DownloadConfig{
+		FetchBatchSize:   DefaultFetchBatchSize,
+		MaxInflightBytes: DefaultMaxInflightBytes,
+	}
+	if externalDownload == nil {
+		return cfg
+	}
+	if externalDownload.FetchBatchSize > 0 {
+		cfg.FetchBatchSize = externalDownload.FetchBatchSize
+	}
+	if externalDownload.MaxInflightBytes > 0 {
+		cfg.MaxInflightBytes = externalDownload.MaxInflightBytes
+	}
+	return cfg
+}
+
+// 构建附件安全检查配置（补全默认值，隔离目录转为绝对路径）
+func buildSafetyConfig(externalSafety *config.SafetyConfig, attachmentSavePath string) (SafetyConfig, error) {
+	cfg := SafetyConfig{
+		MaxAttachmentSize: DefaultMaxAttachmentSize,
+		MaxEmailSize:      DefaultMaxEmailSize,
+		QuarantinePath:    filepath.Join(attachmentSavePath, DefaultQuarantineDir),
+	}
+	if externalSafety == nil {
+		return cfg, nil
+	}
+	if externalSafety.MaxAttachmentSize > 0 {
+		cfg.MaxAttachmentSize = externalSafety.MaxAttachmentSize
+	}
+	if externalSafety.MaxEmailSize > 0 {
+		cfg.MaxEmailSize = externalSafety.MaxEmailSize
+	}
+	if externalSafety.QuarantinePath != "" {
+		path, err := filepath.Abs(externalSafety.QuarantinePath)
+		if err != nil {
+			return cfg, fmt.Errorf("隔离目录转换失败：%w", err)
+		}
+		cfg.QuarantinePath = path
+	}
+	return cfg, nil
+}
+
+// 构建正文解析配置（补全默认值，主机名统一为小写并去掉协议）
+func buildBodyConfig(externalBody *config.BodyConfig) BodyConfig {
+	cfg := BodyConfig{
+		MaxLinkSize: DefaultMaxLinkSize,
+		LinkTimeout: DefaultLinkTimeout,
+	}
+	if externalBody == nil {
+		return cfg
+	}
+	cfg.Enabled = externalBody.Enabled
+	for _, h := range externalBody.LinkHosts {
+		cfg.LinkHosts = append(cfg.LinkHosts, normalizeHost(h))
+	}
+	if externalBody.MaxLinkSize > 0 {
+		cfg.MaxLinkSize = externalBody.MaxLinkSize
+	}
+	if externalBody.LinkTimeout > 0 {
+		cfg.LinkTimeout = externalBody.LinkTimeout
+	}
+	return cfg
+}
+
+// normalizeHost 去掉协议与结尾的"/"并转为小写
+func normalizeHost(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	h = strings.TrimPrefix(strings.TrimPrefix(h, "https://"), "http://")
+	return strings.TrimSuffix(h, "/")
+}
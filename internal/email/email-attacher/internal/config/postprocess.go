@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/domain"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// DefaultMailbox 未配置mailboxes时收取的文件夹
+const DefaultMailbox = "INBOX"
+
+// 支持的处理后操作
+var supportedActions = map[string]bool{
+	domain.ActionSeen:    true,
+	domain.ActionKeyword: true,
+	domain.ActionMove:    true,
+	domain.ActionCopy:    true,
+	domain.ActionNone:    true,
+}
+
+// defaultActions 未配置post_process时的操作：与旧版一致，处理后标记已读
+var defaultActions = []domain.PostAction{{Type: domain.ActionSeen}}
+
+// PostProcessConfig 按处理结果区分的处理后操作
+type PostProcessConfig struct {
+	Processed  []domain.PostAction
+	Unroutable []domain.PostAction
+	Failed     []domain.PostAction
+}
+
+// Actions 返回处理结果对应的操作
+func (c PostProcessConfig) Actions(outcome string) []domain.PostAction {
+	switch outcome {
+	case domain.OutcomeUnroutable:
+		return c.Unroutable
+	case domain.OutcomeFailed:
+		return c.Failed
+	default:
+		return c.Processed
+	}
+}
+
+// searchExclusions 返回默认搜索条件需排除的关键字：处理后既不标记已读也不移动的结果，以添加的关键字排除；
+// 连关键字也不添加时（如none、copy），默认条件会重复收取这些邮件，视为配置错误
+func (c PostProcessConfig) searchExclusions() ([]imap.Flag, error) {
+	var keywords []imap.Flag
+	for _, outcome := range []string{domain.OutcomeProcessed, domain.OutcomeUnroutable, domain.OutcomeFailed} {
+		var (
+			hidden  bool
+			keyword imap.Flag
+		)
+		for _, action := range c.Actions(outcome) {
+			switch action.Type {
+			case domain.ActionSeen, domain.ActionMove:
+				hidden = true
+			case domain.ActionKeyword:
+				keyword = imap.Flag(action.Keyword)
+			}
+		}
+		if hidden {
+			continue
+		}
+		if keyword == "" {
+			return nil, fmt.Errorf("post_process.%s不标记已读、不移动也不添加关键字，默认搜索条件（未读邮件）会重复收取这些邮件，请添加keyword操作或配置search", outcome)
+		}
+		if !slices.Contains(keywords, keyword) {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords, nil
+}
+
+// BuildPostProcessConfig 校验并转换处理后操作；未配置的结果使用默认操作（标记已读）
+func BuildPostProcessConfig(ext *config.PostProcessConfig) (PostProcessConfig, error) {
+	if ext == nil {
+		ext = &config.PostProcessConfig{}
+	}
+	var (
+		cfg PostProcessConfig
+		err error
+	)
+	if cfg.Processed, err = buildActions(ext.Processed); err != nil {
+		return cfg, fmt.Errorf("post_process.processed：%w", err)
+	}
+	if cfg.Unroutable, err = buildActions(ext.Unroutable); err != nil {
+		return cfg, fmt.Errorf("post_process.unroutable：%w", err)
+	}
+	if cfg.Failed, err = buildActions(ext.Failed); err != nil {
+		return cfg, fmt.Errorf("post_process.failed：%w", err)
+	}
+	return cfg, nil
+}
+
+// buildActions 转换一组操作：none只能单独使用，最多一个move且排在最后执行
+func buildActions(ext []config.PostAction) ([]domain.PostAction, error) {
+	if len(ext) == 0 {
+		return defaultActions, nil
+	}
+	var (
+		actions []domain.PostAction
+		move    *domain.PostAction
+	)
+	for i, a := range ext {
+		action := domain.PostAction{
+			Type:    strings.ToLower(strings.TrimSpace(a.Action)),
+			Keyword: strings.TrimSpace(a.Keyword),
+			Folder:  strings.TrimSpace(a.Folder),
+		}
+		if !supportedActions[action.Type] {
+			return nil, fmt.Errorf("第%d个操作不支持：%q（支持：%s）", i+1, a.Action, strings.Join(getMapKeys(supportedActions), "/"))
+		}
+		switch action.Type {
+		case domain.ActionNone:
+			if len(ext) > 1 {
+				return nil, errors.New("none不能与其他操作同时使用")
+			}
+			return nil, nil
+		case domain.ActionKeyword:
+			if err := validateKeyword(action.Keyword); err != nil {
+				return nil, fmt.Errorf("第%d个操作：%w", i+1, err)
+			}
+		case domain.ActionMove, domain.ActionCopy:
+			if action.Folder == "" {
+				return nil, fmt.Errorf("第%d个操作（%s）缺少folder", i+1, action.Type)
+			}
+		}
+		if action.Type == domain.ActionMove {
+			if move != nil {
+				return nil, errors.New("最多只能有一个move操作")
+			}
+			move = &action
+			continue
+		}
+		actions = append(actions, action)
+	}
+	// 移动后邮件离开原文件夹，其他操作需在此之前执行
+	if move != nil {
+		actions = append(actions, *move)
+	}
+	return actions, nil
+}
+
+// validateKeyword 校验IMAP关键字：非空的atom，不能以"\"开头（系统标记）
+func validateKeyword(keyword string) error {
+	if keyword == "" {
+		return errors.New("keyword不能为空")
+	}
+	if strings.HasPrefix(keyword, `\`) {
+		return fmt.Errorf("关键字%s不能以\\开头（系统标记请使用seen等操作）", keyword)
+	}
+	for _, r := range keyword {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`(){%*"\]`, r) {
+			return fmt.Errorf("关键字%s包含非法字符%q", keyword, r)
+		}
+	}
+	return nil
+}
+
+// BuildMailboxes 校验并去重收取的文件夹，未配置时为INBOX
+func BuildMailboxes(mailboxes []string) ([]string, error) {
+	if len(mailboxes) == 0 {
+		return []string{DefaultMailbox}, nil
+	}
+	seen := make(map[string]bool, len(mailboxes))
+	var out []string
+	for _, m := range mailboxes {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			return nil, errors.New("mailboxes不能包含空文件夹名")
+		}
+		// INBOX不区分大小写（RFC 3501）
+		if strings.EqualFold(m, DefaultMailbox) {
+			m = DefaultMailbox
+		}
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"easyHR/internal/email/email-attacher/internal/imap"
+	"easyHR/internal/email/email-attacher/internal/pop3"
+	"easyHR/internal/email/mailauth"
+)
+
+// ProviderQQ QQ邮箱。旧版只支持QQ邮箱，只有UID的旧版已处理记录来自该服务商
+const ProviderQQ = "qq"
+
+// ProviderCustom 自定义服务商类型，服务器地址与端口全部由配置指定
+const ProviderCustom = "custom"
+
+// ProviderPOP3 POP3服务商类型（只提供POP3的邮箱），服务器地址由配置指定
+const ProviderPOP3 = "pop3"
+
+// 收信协议
+const (
+	ProtocolIMAP = "imap"
+	ProtocolPOP3 = "pop3"
+)
+
+// ProviderPreset 服务商预设（服务器地址、端口、TLS模式及兼容性处理）
+// 配置中显式填写的imap_addr（POP3为pop3_addr）、port、tls_mode优先于预设
+type ProviderPreset struct {
+	Host    string
+	Port    int
+	TLSMode string
+	SendID  bool // 登录后发送IMAP ID命令（网易邮箱要求）
+	// OAuth2Provider 配置oauth2时默认的令牌服务商
+	OAuth2Provider string
+	// Protocol 收信协议，为空时为imap
+	Protocol string
+}
+
+// providerPresets 支持的服务商及其预设，custom没有预设值
+var providerPresets = map[string]ProviderPreset{
+	ProviderQQ:     {Host: "imap.qq.com", Port: 993, TLSMode: imap.TLSModeTLS},
+	"163":          {Host: "imap.163.com", Port: 993, TLSMode: imap.TLSModeTLS, SendID: true},
+	"netease":      {Host: "imap.163.com", Port: 993, TLSMode: imap.TLSModeTLS, SendID: true},
+	"126":          {Host: "imap.126.com", Port: 993, TLSMode: imap.TLSModeTLS, SendID: true},
+	"yeah":         {Host: "imap.yeah.net", Port: 993, TLSMode: imap.TLSModeTLS, SendID: true},
+	"gmail":        {Host: "imap.gmail.com", Port: 993, TLSMode: imap.TLSModeTLS, OAuth2Provider: mailauth.ProviderGoogle},
+	"outlook":      {Host: "outlook.office365.com", Port: 993, TLSMode: imap.TLSModeTLS, OAuth2Provider: mailauth.ProviderMicrosoft},
+	ProviderCustom: {TLSMode: imap.TLSModeTLS},
+	ProviderPOP3:   {Protocol: ProtocolPOP3, Port: 995, TLSMode: pop3.TLSModeTLS},
+}
+
+// 支持的TLS模式
+var supportedTLSModes = map[string]bool{
+	imap.TLSModeTLS:      true,
+	imap.TLSModeStartTLS: true,
+	imap.TLSModeNone:     true,
+}
+
+// LookupPreset 返回服务商类型对应的预设
+func LookupPreset(provType string) (ProviderPreset, bool) {
+	p, ok := providerPresets[provType]
+	return p, ok
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"easyHR/internal/email/email-attacher/config"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// searchDateLayout 搜索条件中的日期格式
+const searchDateLayout = "2006-01-02"
+
+// 支持的邮件标记
+var supportedFlags = map[string]imap.Flag{
+	"seen":     imap.FlagSeen,
+	"answered": imap.FlagAnswered,
+	"flagged":  imap.FlagFlagged,
+	"deleted":  imap.FlagDeleted,
+	"draft":    imap.FlagDraft,
+}
+
+// DefaultSearch 未配置search时的默认条件：正文包含"校园招聘"或"Campus Recruitment"的未读邮件
+var DefaultSearch = config.SearchConfig{
+	NotFlags: []string{"seen"},
+	Or: []config.SearchConfig{
+		{Body: []string{"校园招聘"}},
+		{Body: []string{"Campus Recruitment"}},
+	},
+}
+
+// SearchConfig 内部搜索条件（强类型，已校验）
+type SearchConfig struct {
+	Subject    []string
+	Body       []string
+	From       []string
+	To         []string
+	Since      time.Time // 为零值表示不限
+	Before     time.Time // 为零值表示不限
+	WithinDays int       // 相对日期在每次搜索时按当前时间计算
+	Flags      []imap.Flag
+	NotFlags   []imap.Flag
+	Or         []SearchConfig
+	Not        []SearchConfig
+}
+
+// Criteria 转换为IMAP搜索条件，now用于计算within_days
+func (s SearchConfig) Criteria(now time.Time) *imap.SearchCriteria {
+	criteria := &imap.SearchCriteria{
+		Body:       s.Body,
+		SentSince:  s.Since,
+		SentBefore: s.Before,
+		Flag:       s.Flags,
+		NotFlag:    s.NotFlags,
+	}
+	criteria.Header = appendHeaders(criteria.Header, "Subject", s.Subject)
+	criteria.Header = appendHeaders(criteria.Header, "From", s.From)
+	criteria.Header = appendHeaders(criteria.Header, "To", s.To)
+	if s.WithinDays > 0 {
+		// 与since取交集
+		criteria.And(&imap.SearchCriteria{SentSince: now.AddDate(0, 0, -s.WithinDays)})
+	}
+	for _, not := range s.Not {
+		criteria.Not = append(criteria.Not, *not.Criteria(now))
+	}
+	if len(s.Or) > 0 {
+		items := make([]imap.SearchCriteria, len(s.Or))
+		for i, or := range s.Or {
+			items[i] = *or.Criteria(now)
+		}
+		criteria.And(orCriteria(items))
+	}
+	return criteria
+}
+
+// appendHeaders 追加“头部字段包含”条件
+func appendHeaders(headers []imap.SearchCriteriaHeaderField, key string, values []string) []imap.SearchCriteriaHeaderField {
+	for _, v := range values {
+		headers = append(headers, imap.SearchCriteriaHeaderField{Key: key, Value: v})
+	}
+	return headers
+}
+
+// orCriteria IMAP的OR只接受两个条件，多个条件折叠为嵌套的OR
+func orCriteria(items []imap.SearchCriteria) *imap.SearchCriteria {
+	if len(items) == 1 {
+		return &items[0]
+	}
+	return &imap.SearchCriteria{
+		Or: [][2]imap.SearchCriteria{{items[0], *orCriteria(items[1:])}},
+	}
+}
+
+// BuildSearchConfig 校验并转换外部搜索条件，ext为空时使用DefaultSearch
+func BuildSearchConfig(ext *config.SearchConfig) (SearchConfig, error) {
+	if ext == nil {
+		ext = &DefaultSearch
+	}
+	return buildSearchNode(*ext, "search")
+}
+
+// BuildProviderSearch 转换IMAP服务商的搜索条件。未配置search时使用DefaultSearch（未读邮件），
+// 处理后不标记已读也不移走的邮件会被一直搜索到，因此补充排除处理后添加的关键字
+func BuildProviderSearch(ext *config.SearchConfig, postProcess PostProcessConfig) (SearchConfig, error) {
+	search, err := BuildSearchConfig(ext)
+	if err != nil || ext != nil {
+		return search, err
+	}
+	keywords, err := postProcess.searchExclusions()
+	if err != nil {
+		return SearchConfig{}, err
+	}
+	search.NotFlags = append(search.NotFlags, keywords...)
+	return search, nil
+}
+
+// buildSearchNode 递归转换单个条件节点，path用于错误信息定位
+func buildSearchNode(ext config.SearchConfig, path string) (SearchConfig, error) {
+	if searchNodeEmpty(ext) {
+		return SearchConfig{}, fmt.Errorf("%s不能为空", path)
+	}
+	for _, values := range [][]string{ext.Subject, ext.Body, ext.From, ext.To} {
+		for _, v := range values {
+			if strings.TrimSpace(v) == "" {
+				return SearchConfig{}, fmt.Errorf("%s的关键词不能为空字符串", path)
+			}
+		}
+	}
+
+	s := SearchConfig{
+		Subject:    ext.Subject,
+		Body:       ext.Body,
+		From:       ext.From,
+		To:         ext.To,
+		WithinDays: ext.WithinDays,
+	}
+	var err error
+	if s.Since, err = parseSearchDate(ext.Since); err != nil {
+		return SearchConfig{}, fmt.Errorf("%s.since：%w", path, err)
+	}
+	if s.Before, err = parseSearchDate(ext.Before); err != nil {
+		return SearchConfig{}, fmt.Errorf("%s.before：%w", path, err)
+	}
+	if !s.Since.IsZero() && !s.Before.IsZero() && !s.Since.Before(s.Before) {
+		return SearchConfig{}, fmt.Errorf("%s的since必须早于before", path)
+	}
+	if s.WithinDays < 0 {
+		return SearchConfig{}, fmt.Errorf("%s.within_days不能为负数", path)
+	}
+	if s.Flags, err = parseSearchFlags(ext.Flags); err != nil {
+		return SearchConfig{}, fmt.Errorf("%s.flags：%w", path, err)
+	}
+	if s.NotFlags, err = parseSearchFlags(ext.NotFlags); err != nil {
+		return SearchConfig{}, fmt.Errorf("%s.not_flags：%w", path, err)
+	}
+
+	if len(ext.Or) == 1 {
+		return SearchConfig{}, fmt.Errorf("%s.or至少需要两个条件", path)
+	}
+	for i, or := range ext.Or {
+		node, err := buildSearchNode(or, fmt.Sprintf("%s.or[%d]", path, i))
+		if err != nil {
+			return SearchConfig{}, err
+		}
+		s.Or = append(s.Or, node)
+	}
+	for i, not := range ext.Not {
+		node, err := buildSearchNode(not, fmt.Sprintf("%s.not[%d]", path, i))
+		if err != nil {
+			return SearchConfig{}, err
+		}
+		s.Not = append(s.Not, node)
+	}
+	return s, nil
+}
+
+// searchNodeEmpty 节点未设置任何条件（会匹配整个邮箱，视为配置错误）
+func searchNodeEmpty(ext config.SearchConfig) bool {
+	return len(ext.Subject) == 0 && len(ext.Body) == 0 && len(ext.From) == 0 && len(ext.To) == 0 &&
+		ext.Since == "" && ext.Before == "" && ext.WithinDays == 0 &&
+		len(ext.Flags) == 0 && len(ext.NotFlags) == 0 && len(ext.Or) == 0 && len(ext.Not) == 0
+}
+
+// parseSearchDate 解析日期，空字符串表示不限
+func parseSearchDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(searchDateLayout, v)
+	if err != nil {
+		return time.Time{}, errors.New("日期格式必须是" + searchDateLayout)
+	}
+	return t, nil
+}
+
+// parseSearchFlags 解析邮件标记名称，以"$"开头的为自定义关键字（如处理后添加的$EasyHR-Processed）
+func parseSearchFlags(names []string) ([]imap.Flag, error) {
+	var flags []imap.Flag
+	for _, name := range names {
+		if strings.HasPrefix(name, "$") {
+			if err := validateKeyword(name); err != nil {
+				return nil, err
+			}
+			flags = append(flags, imap.Flag(name))
+			continue
+		}
+		flag, ok := supportedFlags[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("不支持的标记%s（支持：%s）", name, strings.Join(getMapKeys(supportedFlags), "/"))
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
@@ -2,18 +2,15 @@ package config
 
 import (
 	"easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/domain"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
-// 支持的服务商类型
-var supportedProviders = map[string]bool{
-	"qq": true,
-}
-
 // 支持的日志级别
 var supportedLogLevels = map[string]bool{
 	"debug": true,
@@ -22,6 +19,12 @@ var supportedLogLevels = map[string]bool{
 	"error": true,
 }
 
+// 支持的收信模式
+var supportedWatchModes = map[string]bool{
+	WatchModePoll: true,
+	WatchModeIdle: true,
+}
+
 // ValidateConfig 校验外部配置合法性
 func ValidateConfig(cfg *config.AppConfig) error {
 	// 1. 附件保存路径校验
@@ -41,6 +44,42 @@ func ValidateConfig(cfg *config.AppConfig) error {
 		return fmt.Errorf("已处理邮件路径无效：%w", err)
 	}
 
+	if err := validateProcessedStore(cfg.ProcessedStore); err != nil {
+		return err
+	}
+
+	for _, t := range cfg.AttachmentTypes {
+		if strings.Trim(strings.TrimSpace(t), ".") == "" {
+			return errors.New("附件类型（AttachmentTypes）不能包含空扩展名")
+		}
+	}
+
+	if d := cfg.Download; d != nil && (d.FetchBatchSize < 0 || d.MaxInflightBytes < 0) {
+		return errors.New("附件下载配置（Download）的fetch_batch_size与max_inflight_bytes不能为负数")
+	}
+
+	if b := cfg.Body; b != nil {
+		for _, h := range b.LinkHosts {
+			if h = normalizeHost(h); h == "" || strings.ContainsAny(h, "/?#") {
+				return fmt.Errorf("正文链接白名单（body.link_hosts）只能填写主机名：%q", h)
+			}
+		}
+		if b.MaxLinkSize < 0 || b.LinkTimeout < 0 {
+			return errors.New("正文解析配置（body）的max_link_size与link_timeout不能为负数")
+		}
+	}
+
+	if s := cfg.Safety; s != nil {
+		if s.MaxAttachmentSize < 0 || s.MaxEmailSize < 0 {
+			return errors.New("附件安全配置（safety）的max_attachment_size与max_email_size不能为负数")
+		}
+		if s.QuarantinePath != "" {
+			if err := checkPathWritable(s.QuarantinePath); err != nil {
+				return fmt.Errorf("隔离目录（safety.quarantine_path）无效：%w", err)
+			}
+		}
+	}
+
 	// 3. 轮询间隔校验
 	if cfg.PollInterval <= 0 {
 		return errors.New("轮询间隔（PollInterval）必须大于0秒")
@@ -55,29 +94,86 @@ func ValidateConfig(cfg *config.AppConfig) error {
 		if provType == "" {
 			return fmt.Errorf("第%d个服务商Type不能为空", idx+1)
 		}
-		if !supportedProviders[provType] {
-			supportedStr := strings.Join(getMapKeys(supportedProviders), ", ")
+		preset, ok := LookupPreset(provType)
+		if !ok {
+			supportedStr := strings.Join(getMapKeys(providerPresets), ", ")
 			return fmt.Errorf("第%d个服务商类型不支持（支持：%s）", idx+1, supportedStr)
 		}
 		if prov.Config == nil || len(prov.Config) == 0 {
 			return fmt.Errorf("第%d个服务商（%s）Config不能为空", idx+1, provType)
 		}
-		// 校验IMAP必填项
-		if err := validateIMAPConfig(prov.Config, provType, idx+1); err != nil {
+		if preset.Protocol == ProtocolPOP3 {
+			// 校验POP3必填项及POP3不支持的配置
+			if err := validatePOP3Provider(prov, provType, preset, idx+1); err != nil {
+				return err
+			}
+		} else if err := validateIMAPConfig(prov.Config, provType, preset, prov.OAuth2 != nil, idx+1); err != nil {
+			// 校验IMAP必填项
 			return err
 		}
+		if _, err := BuildOAuth2Config(prov.OAuth2, preset); err != nil {
+			return fmt.Errorf("第%d个服务商（%s）：%w", idx+1, provType, err)
+		}
+		// 校验收信模式
+		if prov.Mode != "" && !supportedWatchModes[strings.ToLower(prov.Mode)] {
+			return fmt.Errorf("第%d个服务商（%s）的mode必须是%s之一", idx+1, provType, strings.Join(getMapKeys(supportedWatchModes), "/"))
+		}
+		if prov.IdleTimeout < 0 {
+			return fmt.Errorf("第%d个服务商（%s）的idle_timeout不能为负数", idx+1, provType)
+		}
+		if _, err := BuildMailboxes(prov.Mailboxes); err != nil {
+			return fmt.Errorf("第%d个服务商（%s）：%w", idx+1, provType, err)
+		}
+		postProcess, err := BuildPostProcessConfig(prov.PostProcess)
+		if err != nil {
+			return fmt.Errorf("第%d个服务商（%s）的处理后操作无效：%w", idx+1, provType, err)
+		}
+		// 校验搜索条件（POP3不支持search，已在上面校验）
+		if preset.Protocol != ProtocolPOP3 {
+			if _, err := BuildProviderSearch(prov.Search, postProcess); err != nil {
+				return fmt.Errorf("第%d个服务商（%s）的搜索条件无效：%w", idx+1, provType, err)
+			}
+		}
 	}
-	return nil
+	return validateLegacyAccount(cfg)
 }
 
-// 校验IMAP协议必填配置
-func validateIMAPConfig(provConfig map[string]interface{}, provType string, idx int) error {
-	requiredKeys := []string{"imap_addr", "port", "username", "password"}
+// 旧版记录指定的账号必须是已配置的IMAP账号，否则旧记录无处迁移，已处理的邮件会被重新收取
+func validateLegacyAccount(cfg *config.AppConfig) error {
+	account := strings.TrimSpace(cfg.LegacyAccount)
+	if account == "" {
+		return nil
+	}
+	for _, prov := range cfg.Providers {
+		if preset, _ := LookupPreset(strings.ToLower(prov.Type)); preset.Protocol == ProtocolPOP3 {
+			continue
+		}
+		if username, _ := prov.Config["username"].(string); strings.EqualFold(strings.TrimSpace(username), account) {
+			return nil
+		}
+	}
+	return fmt.Errorf("旧版记录账号（legacy_account）%s不是已配置的IMAP账号", account)
+}
+
+// 校验IMAP协议配置，有预设的服务商可省略imap_addr与port，使用OAuth2时可省略password
+func validateIMAPConfig(provConfig map[string]interface{}, provType string, preset ProviderPreset, oauth2 bool, idx int) error {
+	requiredKeys := []string{"username"}
+	if !oauth2 {
+		requiredKeys = append(requiredKeys, "password")
+	}
+	if preset.Host == "" {
+		requiredKeys = append(requiredKeys, "imap_addr")
+	}
+	if preset.Port == 0 {
+		requiredKeys = append(requiredKeys, "port")
+	}
 	for _, key := range requiredKeys {
-		val, exists := provConfig[key]
-		if !exists {
+		if _, exists := provConfig[key]; !exists {
 			return fmt.Errorf("第%d个服务商（%s）缺少必填项：%s", idx, provType, key)
 		}
+	}
+
+	for key, val := range provConfig {
 		// 类型校验
 		switch key {
 		case "imap_addr", "username", "password":
@@ -93,11 +189,115 @@ func validateIMAPConfig(provConfig map[string]interface{}, provType string, idx
 			if portVal < 1 || portVal > 65535 {
 				return fmt.Errorf("第%d个服务商（%s）的port必须在1-65535之间", idx, provType)
 			}
+		case "tls_mode":
+			strVal, ok := val.(string)
+			if !ok || !supportedTLSModes[strings.ToLower(strVal)] {
+				return fmt.Errorf("第%d个服务商（%s）的tls_mode必须是%s之一", idx, provType, strings.Join(getMapKeys(supportedTLSModes), "/"))
+			}
+		case "send_id", "insecure_skip_verify":
+			if _, ok := val.(bool); !ok {
+				return fmt.Errorf("第%d个服务商（%s）的%s必须是布尔值", idx, provType, key)
+			}
+		}
+	}
+	return nil
+}
+
+// POP3支持的处理后操作：没有标记与文件夹，seen记录到本地状态文件（开启delete_after_download时同时删除）
+var pop3Actions = map[string]bool{
+	domain.ActionSeen: true,
+	domain.ActionNone: true,
+}
+
+// 校验POP3服务商：有预设的服务商可省略pop3_addr与port；POP3没有搜索、文件夹与标记，不支持相关配置
+func validatePOP3Provider(prov config.ProviderConfig, provType string, preset ProviderPreset, idx int) error {
+	switch {
+	case prov.OAuth2 != nil:
+		return fmt.Errorf("第%d个服务商（%s）使用POP3，不支持oauth2", idx, provType)
+	case prov.Search != nil:
+		return fmt.Errorf("第%d个服务商（%s）使用POP3，不支持search（收取所有未处理的邮件）", idx, provType)
+	case strings.EqualFold(prov.Mode, WatchModeIdle):
+		return fmt.Errorf("第%d个服务商（%s）使用POP3，不支持idle模式", idx, provType)
+	}
+	for _, m := range prov.Mailboxes {
+		if !strings.EqualFold(strings.TrimSpace(m), DefaultMailbox) {
+			return fmt.Errorf("第%d个服务商（%s）使用POP3，mailboxes只能为%s", idx, provType, DefaultMailbox)
+		}
+	}
+	if postProcess, err := BuildPostProcessConfig(prov.PostProcess); err == nil {
+		for _, outcome := range []string{domain.OutcomeProcessed, domain.OutcomeUnroutable, domain.OutcomeFailed} {
+			for _, action := range postProcess.Actions(outcome) {
+				if !pop3Actions[action.Type] {
+					return fmt.Errorf("第%d个服务商（%s）使用POP3，post_process只支持%s", idx, provType, strings.Join(getMapKeys(pop3Actions), "/"))
+				}
+			}
+		}
+	}
+
+	requiredKeys := []string{"username", "password"}
+	if preset.Host == "" {
+		requiredKeys = append(requiredKeys, "pop3_addr")
+	}
+	for _, key := range requiredKeys {
+		if _, exists := prov.Config[key]; !exists {
+			return fmt.Errorf("第%d个服务商（%s）缺少必填项：%s", idx, provType, key)
+		}
+	}
+	for key, val := range prov.Config {
+		switch key {
+		case "pop3_addr", "username", "password", "state_file":
+			strVal, ok := val.(string)
+			if !ok || strVal == "" {
+				return fmt.Errorf("第%d个服务商（%s）的%s必须是非空字符串", idx, provType, key)
+			}
+		case "port":
+			portVal, ok := val.(int)
+			if !ok {
+				return fmt.Errorf("第%d个服务商（%s）的port必须是整数（如995）", idx, provType)
+			}
+			if portVal < 1 || portVal > 65535 {
+				return fmt.Errorf("第%d个服务商（%s）的port必须在1-65535之间", idx, provType)
+			}
+		case "tls_mode":
+			strVal, ok := val.(string)
+			if !ok || !supportedTLSModes[strings.ToLower(strVal)] {
+				return fmt.Errorf("第%d个服务商（%s）的tls_mode必须是%s之一", idx, provType, strings.Join(getMapKeys(supportedTLSModes), "/"))
+			}
+		case "insecure_skip_verify", "delete_after_download":
+			if _, ok := val.(bool); !ok {
+				return fmt.Errorf("第%d个服务商（%s）的%s必须是布尔值", idx, provType, key)
+			}
+		case "since":
+			if _, err := parsePOP3Since(val); err != nil {
+				return fmt.Errorf("第%d个服务商（%s）的since无效：%w", idx, provType, err)
+			}
+		case "max_messages_per_poll":
+			if n, ok := val.(int); !ok || n <= 0 {
+				return fmt.Errorf("第%d个服务商（%s）的max_messages_per_poll必须是正整数", idx, provType)
+			}
 		}
 	}
 	return nil
 }
 
+// 校验已处理邮件存储配置
+func validateProcessedStore(store *config.ProcessedStoreConfig) error {
+	if store == nil {
+		return nil
+	}
+	switch strings.ToLower(store.Type) {
+	case "", ProcessedStoreFile:
+		return nil
+	case ProcessedStoreMongo:
+		if store.MongoURI == "" || store.Database == "" {
+			return errors.New("已处理邮件存储为mongo时mongo_uri与database不能为空")
+		}
+		return nil
+	default:
+		return fmt.Errorf("已处理邮件存储类型不支持：%s（支持：%s/%s）", store.Type, ProcessedStoreFile, ProcessedStoreMongo)
+	}
+}
+
 // 检查路径是否可写（不存在则创建）
 func checkPathWritable(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -120,11 +320,12 @@ func checkPathWritable(path string) error {
 	return nil
 }
 
-// 获取map的key列表
-func getMapKeys(m map[string]bool) []string {
+// 获取map的key列表（排序后返回，保证错误信息稳定）
+func getMapKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
@@ -5,15 +5,21 @@ import (
 	"strings"
 
 	"easyHR/internal/email/email-attacher/domain"
-	qq "easyHR/internal/email/email-attacher/internal/adapter/qmail"
+	"easyHR/internal/email/email-attacher/internal/adapter/imapmail"
+	"easyHR/internal/email/email-attacher/internal/adapter/pop3mail"
+	"easyHR/internal/email/email-attacher/internal/config"
 )
 
 // NewEmailClient 根据服务商类型创建客户端实例
+// 除pop3外的服务商都通过IMAP接入，差异由config中的服务商预设处理
 func NewEmailClient(providerType string) (domain.EmailClient, error) {
-	switch strings.ToLower(providerType) {
-	case "qq":
-		return qq.NewQQEmailClient(), nil
-	default:
+	provType := strings.ToLower(providerType)
+	preset, ok := config.LookupPreset(provType)
+	if !ok {
 		return nil, fmt.Errorf("不支持的服务商类型：%s", providerType)
 	}
+	if preset.Protocol == config.ProtocolPOP3 {
+		return pop3mail.NewClient(provType), nil
+	}
+	return imapmail.NewClient(provType), nil
 }
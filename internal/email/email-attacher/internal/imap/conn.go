@@ -1,34 +1,118 @@
 package imap
 
 import (
+	"context"
 	"crypto/tls"
-	"strings"
+	"errors"
+	"fmt"
+	"net"
 
+	"easyHR/internal/email/email-attacher/internal/retry"
+	"easyHR/internal/email/mailauth"
+
+	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
 )
 
+// TLS模式
+const (
+	TLSModeTLS      = "tls"      // 隐式TLS，通常为993端口
+	TLSModeStartTLS = "starttls" // 明文连接后通过STARTTLS升级，通常为143端口
+	TLSModeNone     = "none"     // 不加密，仅用于本地或测试服务器
+)
+
+// Options IMAP连接选项
+type Options struct {
+	Addr     string // 完整地址（host:port）
+	Username string
+	Password string
+	// TokenSource 不为空时使用OAuth2（AuthMechanism指定的SASL机制）认证，忽略Password
+	TokenSource        mailauth.TokenSource
+	AuthMechanism      string // xoauth2/oauthbearer，为空时使用xoauth2
+	TLSMode            string // tls/starttls/none，为空时使用tls
+	InsecureSkipVerify bool   // 跳过证书校验，仅用于自签名证书的自建服务器
+	// ID 登录后发送的IMAP ID（RFC 2971），为空时不发送
+	// 网易邮箱要求客户端表明身份，否则SELECT返回"Unsafe Login"
+	ID *imap.IDData
+	// UnilateralDataHandler 处理服务器主动推送的数据（如IDLE期间的EXISTS），可为空
+	UnilateralDataHandler *imapclient.UnilateralDataHandler
+}
+
 // Connect 建立IMAP连接（通用逻辑，所有IMAP服务商复用）
 func Connect(addr, username, password string) (*imapclient.Client, error) {
-	// 连接IMAP服务器（启用TLS）
+	return Dial(Options{Addr: addr, Username: username, Password: password})
+}
+
+// Dial 按选项建立IMAP连接并登录
+func Dial(opts Options) (*imapclient.Client, error) {
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("IMAP地址无效：%w", err)
+	}
 	options := &imapclient.Options{
 		TLSConfig: &tls.Config{
-			InsecureSkipVerify: false,
-			ServerName:         addr[:strings.Index(addr, ":")], // 提取主机名用于证书验证
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+			ServerName:         host, // 主机名用于证书验证
 		},
+		UnilateralDataHandler: opts.UnilateralDataHandler,
+	}
+
+	var c *imapclient.Client
+	switch opts.TLSMode {
+	case TLSModeTLS, "":
+		c, err = imapclient.DialTLS(opts.Addr, options)
+	case TLSModeStartTLS:
+		c, err = imapclient.DialStartTLS(opts.Addr, options)
+	case TLSModeNone:
+		c, err = imapclient.DialInsecure(opts.Addr, options)
+	default:
+		return nil, fmt.Errorf("不支持的TLS模式：%s", opts.TLSMode)
 	}
-	c, err := imapclient.DialTLS(addr, options)
 	if err != nil {
 		return nil, err
 	}
 
 	// 登录
-	if err := c.Login(username, password).Wait(); err != nil {
+	if err := login(c, opts); err != nil {
+		_ = c.Close()
 		return nil, err
 	}
 
+	// 服务器支持ID扩展时才发送，避免不支持的服务器返回BAD
+	if opts.ID != nil && c.Caps().Has(imap.CapID) {
+		if _, err := c.ID(opts.ID).Wait(); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("发送IMAP ID失败：%w", err)
+		}
+	}
+
 	return c, nil
 }
 
+// login 使用密码或OAuth2访问令牌登录
+func login(c *imapclient.Client, opts Options) error {
+	if opts.TokenSource == nil {
+		return c.Login(opts.Username, opts.Password).Wait()
+	}
+	token, err := opts.TokenSource.Token(context.Background())
+	if errors.Is(err, mailauth.ErrTokenRejected) {
+		return retry.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	err = c.Authenticate(mailauth.NewSASLClient(opts.AuthMechanism, opts.Username, token))
+	// 服务器拒绝令牌（可能已被撤销）时丢弃缓存，重连时重新刷新
+	var imapErr *imap.Error
+	if errors.As(err, &imapErr) {
+		opts.TokenSource.Invalidate()
+	}
+	if err != nil {
+		return fmt.Errorf("OAuth2认证失败：%w", err)
+	}
+	return nil
+}
+
 // Close 关闭IMAP连接
 func Close(c *imapclient.Client) error {
 	if c == nil {
@@ -0,0 +1,283 @@
+package imap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"easyHR/internal/email/email-attacher/internal/retry"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+)
+
+// 连接状态
+const (
+	StateDisconnected = "disconnected" // 未连接或连接已断开，下次使用时重连
+	StateConnecting   = "connecting"   // 正在建立连接
+	StateConnected    = "connected"    // 已连接并登录
+	StateClosed       = "closed"       // 已主动关闭
+)
+
+// 连接管理默认值
+const (
+	DefaultKeepalive         = 5 * time.Minute
+	DefaultReconnectAttempts = 3
+	DefaultReconnectInterval = 2 * time.Second
+)
+
+// ManagerConfig 连接管理配置，零值字段使用默认值
+type ManagerConfig struct {
+	Keepalive         time.Duration // 连接空闲超过该时长时发送NOOP保活，小于0时不发送
+	ReconnectAttempts int           // 每次重连的最大尝试次数
+	ReconnectInterval time.Duration // 重连初始间隔，之后指数退避
+}
+
+// Status 连接状态快照，供健康检查使用
+type Status struct {
+	State       string    // 连接状态
+	Mailbox     string    // 当前选中的邮箱
+	ConnectedAt time.Time // 最近一次连接成功的时间
+	LastError   string    // 最近一次连接错误
+	LastErrorAt time.Time // 最近一次连接错误的时间
+	Reconnects  int       // 断线后重连成功的次数
+}
+
+// Manager IMAP连接管理器：检测断开的连接，按退避策略重连并重新选中邮箱，空闲时发送NOOP保活
+// 所有命令通过Do串行执行，同一时刻只有一个命令（或IDLE）占用连接
+type Manager struct {
+	opts     Options
+	cfg      ManagerConfig
+	mu       sync.Mutex // 串行化命令执行与重连
+	client   *imapclient.Client
+	mailbox  string    // 已选中的邮箱，重连后重新选中
+	lastUsed time.Time // 最近一次执行命令的时间
+
+	statusMu sync.RWMutex // 状态单独加锁，IDLE占用连接期间也能读取
+	status   Status
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewManager 创建连接管理器（不立即连接），Keepalive大于0时启动保活协程
+func NewManager(opts Options, cfg ManagerConfig) *Manager {
+	if cfg.Keepalive == 0 {
+		cfg.Keepalive = DefaultKeepalive
+	}
+	if cfg.ReconnectAttempts <= 0 {
+		cfg.ReconnectAttempts = DefaultReconnectAttempts
+	}
+	if cfg.ReconnectInterval <= 0 {
+		cfg.ReconnectInterval = DefaultReconnectInterval
+	}
+	m := &Manager{
+		opts:   opts,
+		cfg:    cfg,
+		status: Status{State: StateDisconnected},
+		stop:   make(chan struct{}),
+	}
+	if cfg.Keepalive > 0 {
+		m.wg.Add(1)
+		go m.keepalive()
+	}
+	return m
+}
+
+// Connect 建立连接（带重试），已连接时直接返回
+func (m *Manager) Connect(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ensure(ctx)
+}
+
+// Do 在可用的连接上执行fn；连接已断开或在执行中断开时，重连并重新选中邮箱后再执行一次
+// 服务器返回的NO/BAD等业务错误不会触发重连
+func (m *Manager) Do(ctx context.Context, fn func(c *imapclient.Client) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if err := m.ensure(ctx); err != nil {
+			return err
+		}
+		err := fn(m.client)
+		m.lastUsed = time.Now()
+		if err == nil || !m.broken(err) {
+			return err
+		}
+		m.drop(err)
+		if attempt > 0 || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// Select 选中邮箱并记录，重连后自动重新选中
+func (m *Manager) Select(ctx context.Context, mailbox string) (*imap.SelectData, error) {
+	var data *imap.SelectData
+	err := m.Do(ctx, func(c *imapclient.Client) error {
+		var err error
+		if data, err = c.Select(mailbox, nil).Wait(); err != nil {
+			return err
+		}
+		m.mailbox = mailbox
+		return nil
+	})
+	return data, err
+}
+
+// Status 返回连接状态快照
+func (m *Manager) Status() Status {
+	m.statusMu.RLock()
+	defer m.statusMu.RUnlock()
+	return m.status
+}
+
+// Close 停止保活并登出，之后不能再使用
+func (m *Manager) Close() error {
+	select {
+	case <-m.stop:
+		return nil
+	default:
+		close(m.stop)
+	}
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := Close(m.client)
+	m.client = nil
+	m.setStatus(func(s *Status) { s.State = StateClosed })
+	return err
+}
+
+// ensure 连接不可用时重连（调用方持有mu）
+func (m *Manager) ensure(ctx context.Context) error {
+	select {
+	case <-m.stop:
+		return errors.New("IMAP连接已关闭")
+	default:
+	}
+	if m.client != nil && m.client.State() != imap.ConnStateLogout {
+		return nil
+	}
+	if m.client != nil {
+		m.drop(errors.New("服务器已断开连接"))
+	}
+
+	m.setStatus(func(s *Status) { s.State = StateConnecting })
+	err := retry.Retry(ctx, m.cfg.ReconnectAttempts, m.cfg.ReconnectInterval, func() error {
+		client, err := Dial(m.opts)
+		if err != nil {
+			m.recordError(err)
+			return err
+		}
+		if m.mailbox != "" {
+			if _, err := client.Select(m.mailbox, nil).Wait(); err != nil {
+				_ = client.Close()
+				err = fmt.Errorf("重新选中邮箱%s失败：%w", m.mailbox, err)
+				m.recordError(err)
+				return err
+			}
+		}
+		m.client = client
+		return nil
+	})
+	if err != nil {
+		m.setStatus(func(s *Status) { s.State = StateDisconnected })
+		return fmt.Errorf("IMAP连接失败：%w", err)
+	}
+
+	m.lastUsed = time.Now()
+	m.setStatus(func(s *Status) {
+		if !s.ConnectedAt.IsZero() {
+			s.Reconnects++
+		}
+		s.State = StateConnected
+		s.Mailbox = m.mailbox
+		s.ConnectedAt = m.lastUsed
+	})
+	return nil
+}
+
+// broken 判断错误是否由连接断开引起
+func (m *Manager) broken(err error) bool {
+	if m.client.State() == imap.ConnStateLogout {
+		return true
+	}
+	var imapErr *imap.Error
+	if errors.As(err, &imapErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// drop 丢弃断开的连接（调用方持有mu）
+func (m *Manager) drop(err error) {
+	if m.client != nil {
+		_ = m.client.Close()
+		m.client = nil
+	}
+	m.recordError(err)
+	m.setStatus(func(s *Status) { s.State = StateDisconnected })
+}
+
+// keepalive 定期检查连接，空闲超过Keepalive时发送NOOP；连接断开时在后台重连
+// 连接正被占用（如IDLE）时跳过本次检查，IDLE本身即可保活
+func (m *Manager) keepalive() {
+	defer m.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(m.cfg.Keepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		if !m.mu.TryLock() {
+			continue
+		}
+		switch {
+		case m.client == nil:
+			// 之前重连失败，继续尝试
+			_ = m.ensure(ctx)
+		case time.Since(m.lastUsed) >= m.cfg.Keepalive:
+			err := m.client.Noop().Wait()
+			m.lastUsed = time.Now()
+			if err != nil && m.broken(err) {
+				m.drop(err)
+				_ = m.ensure(ctx)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *Manager) recordError(err error) {
+	m.setStatus(func(s *Status) {
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now()
+	})
+}
+
+func (m *Manager) setStatus(update func(s *Status)) {
+	m.statusMu.Lock()
+	update(&m.status)
+	m.statusMu.Unlock()
+}
@@ -0,0 +1,177 @@
+// Package linkfetch 下载邮件正文中的简历链接，只访问白名单内的主机
+package linkfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"easyHR/internal/email/email-attacher/domain"
+	"easyHR/internal/email/email-attacher/internal/body"
+	"easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/internal/email/email-attacher/internal/safety"
+)
+
+// maxRedirects 最多跟随的重定向次数
+const maxRedirects = 5
+
+var (
+	// ErrHostNotAllowed 链接主机不在白名单内
+	ErrHostNotAllowed = errors.New("链接主机不在白名单内")
+	// ErrNotFile 链接返回的是网页而非文件（网盘分享页通常需要登录或提取码）
+	ErrNotFile = errors.New("链接指向网页而非文件，需人工下载")
+	// ErrTooLarge 文件超过大小上限
+	ErrTooLarge = errors.New("链接文件超过大小上限")
+)
+
+// contentTypeExts 响应未给出文件名时按Content-Type补全扩展名
+var contentTypeExts = map[string]string{
+	"application/pdf":    ".pdf",
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"image/jpeg":                   ".jpg",
+	"image/png":                    ".png",
+	"application/zip":              ".zip",
+	"application/x-rar-compressed": ".rar",
+	"application/vnd.rar":          ".rar",
+	"application/x-7z-compressed":  ".7z",
+}
+
+// HTTPFetcher 通过HTTP(S)下载简历链接，重定向目标同样需要在白名单内
+type HTTPFetcher struct {
+	hosts   []string
+	maxSize int64
+	client  *http.Client
+}
+
+// NewHTTPFetcher 按正文解析配置创建下载器
+func NewHTTPFetcher(cfg config.BodyConfig) *HTTPFetcher {
+	f := &HTTPFetcher{
+		hosts:   cfg.LinkHosts,
+		maxSize: cfg.MaxLinkSize,
+	}
+	f.client = &http.Client{
+		Timeout: cfg.LinkTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("重定向次数过多")
+			}
+			if !f.Allowed(req.URL) {
+				return fmt.Errorf("重定向到%s：%w", req.URL.Host, ErrHostNotAllowed)
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// Allowed 判断链接是否可以下载：http(s)协议且主机在白名单内
+func (f *HTTPFetcher) Allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return body.MatchHost(u.Hostname(), f.hosts)
+}
+
+// Fetch 下载link并保存到savePath目录，写入临时文件后重命名
+func (f *HTTPFetcher) Fetch(ctx context.Context, link, savePath string) (domain.Attachment, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("链接无效：%w", err)
+	}
+	if !f.Allowed(u) {
+		return domain.Attachment{}, fmt.Errorf("%s：%w", u.Host, ErrHostNotAllowed)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.Attachment{}, fmt.Errorf("下载链接失败：HTTP %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "text/html") || mediaType == "application/xhtml+xml" {
+		return domain.Attachment{}, ErrNotFile
+	}
+	if resp.ContentLength > f.maxSize {
+		return domain.Attachment{}, ErrTooLarge
+	}
+
+	name := fileName(resp, mediaType)
+	if err := os.MkdirAll(savePath, 0755); err != nil {
+		return domain.Attachment{}, fmt.Errorf("创建保存路径失败: %w", err)
+	}
+	size, err := writeFile(filepath.Join(savePath, name), resp.Body, f.maxSize)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	return domain.Attachment{
+		ID:          link,
+		Name:        name,
+		ContentType: mediaType,
+		Size:        size,
+		URL:         link,
+	}, nil
+}
+
+// fileName 优先使用Content-Disposition中的文件名，其次为最终URL路径的最后一段
+func fileName(resp *http.Response, mediaType string) string {
+	var name string
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name, _ = url.PathUnescape(path.Base(resp.Request.URL.Path))
+	}
+	// 只保留文件名，避免路径穿越
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "resume"
+	}
+	name = safety.SanitizeName(name)
+	if filepath.Ext(name) == "" {
+		name += contentTypeExts[mediaType]
+	}
+	return name
+}
+
+// writeFile 写入临时文件，超过maxSize时放弃
+func writeFile(filePath string, r io.Reader, maxSize int64) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".part-*")
+	if err != nil {
+		return 0, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+
+	// 多读一个字节以判断是否超限
+	n, err := io.Copy(tmp, io.LimitReader(r, maxSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, fmt.Errorf("写入链接文件失败: %w", err)
+	}
+	if n > maxSize {
+		return n, ErrTooLarge
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), filePath)
+}
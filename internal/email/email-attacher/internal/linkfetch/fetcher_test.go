@@ -0,0 +1,76 @@
+package linkfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easyHR/internal/email/email-attacher/internal/config"
+)
+
+func TestHTTPFetcher(t *testing.T) {
+	pdf := []byte("%PDF-1.4 resume")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/cv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="../张三-简历.pdf"`)
+		w.Write(pdf)
+	})
+	mux.HandleFunc("/s/share", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>请输入提取码</html>"))
+	})
+	mux.HandleFunc("/large.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://example.com/cv.pdf", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	host, _ := url.Parse(srv.URL)
+	f := NewHTTPFetcher(config.BodyConfig{
+		LinkHosts:   []string{host.Hostname()},
+		MaxLinkSize: 1024,
+		LinkTimeout: 5 * time.Second,
+	})
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	att, err := f.Fetch(ctx, srv.URL+"/files/cv", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "张三-简历.pdf" || att.URL != srv.URL+"/files/cv" || att.Size != int64(len(pdf)) {
+		t.Fatalf("unexpected attachment %+v", att)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, att.Name)); err != nil || string(data) != string(pdf) {
+		t.Fatalf("unexpected content %q, %v", data, err)
+	}
+
+	// 网盘分享页、超限文件与白名单外的主机都不会被保存
+	for link, want := range map[string]error{
+		srv.URL + "/s/share":           ErrNotFile,
+		srv.URL + "/large.pdf":         ErrTooLarge,
+		srv.URL + "/away":              ErrHostNotAllowed,
+		"https://pan.baidu.com/s/1abc": ErrHostNotAllowed,
+		"file:///etc/passwd":           ErrHostNotAllowed,
+	} {
+		if _, err := f.Fetch(ctx, link, dir); !errors.Is(err, want) {
+			t.Errorf("%s: expected %v, got %v", link, want, err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the fetched resume in %s, got %d entries", dir, len(entries))
+	}
+}
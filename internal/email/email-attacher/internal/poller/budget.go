@@ -0,0 +1,42 @@
+package poller
+
+import "sync"
+
+// byteBudget 限制同时下载的附件总字节数，各服务商的轮询协程共享
+type byteBudget struct {
+	max   int64
+	inUse int64
+	mu    sync.Mutex
+	cond  *sync.Cond
+}
+
+func newByteBudget(max int64) *byteBudget {
+	b := &byteBudget{max: max}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire 占用n字节，额度不足时阻塞；超过上限的附件等其他下载全部结束后独占额度，返回实际占用的字节数
+func (b *byteBudget) acquire(n int64) int64 {
+	if n <= 0 {
+		n = 1
+	}
+	if n > b.max {
+		n = b.max
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.inUse+n > b.max {
+		b.cond.Wait()
+	}
+	b.inUse += n
+	return n
+}
+
+// release 归还acquire占用的字节
+func (b *byteBudget) release(n int64) {
+	b.mu.Lock()
+	b.inUse -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
@@ -2,35 +2,72 @@ package poller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"easyHR/internal/email/email-attacher/domain"
+	"easyHR/internal/email/email-attacher/internal/body"
 	"easyHR/internal/email/email-attacher/internal/config"
 	"easyHR/internal/email/email-attacher/internal/factory"
+	"easyHR/internal/email/email-attacher/internal/linkfetch"
 	"easyHR/internal/email/email-attacher/internal/retry"
+	"easyHR/internal/email/email-attacher/internal/safety"
 	"easyHR/internal/email/email-attacher/internal/storage"
 	"easyHR/pkg/logger"
+
+	"github.com/emersion/go-imap/v2"
 )
 
 // Poller 轮询调度器
 type Poller struct {
-	cfg                *config.InternalConfig     // 内部配置
-	clients            []domain.EmailClient       // 邮箱客户端列表
-	processedStorage   *storage.ProcessedStorage  // 已处理邮件存储
-	attachmentStorage  *storage.AttachmentStorage // 附件存储
-	retryCfg           config.RetryConfig         // 重试配置
-	attachmentCallback domain.AttachmentCallback  // 附件下载回调
-	errorCallback      domain.ErrorCallback       // 错误回调
-	logger             logger.LoggerV1            // 日志器
+	cfg                 *config.InternalConfig     // 内部配置
+	clients             []domain.EmailClient       // 邮箱客户端列表
+	processedStore      storage.ProcessedStore     // 已处理邮件存储
+	attachmentStorage   *storage.AttachmentStorage // 附件存储
+	quarantine          *storage.Quarantine        // 未通过安全检查的文件隔离目录
+	downloadBudget      *byteBudget                // 同时下载的附件字节上限
+	linkFetcher         domain.LinkFetcher         // 正文简历链接下载器（开启正文解析时使用）
+	retryCfg            config.RetryConfig         // 重试配置
+	attachmentCallback  domain.AttachmentCallback  // 附件下载回调
+	unsupportedCallback domain.UnsupportedCallback // 不支持的附件回调
+	errorCallback       domain.ErrorCallback       // 错误回调
+	logger              logger.LoggerV1            // 日志器
+}
+
+// legacyScopes 旧版记录只有UID且只可能来自QQ邮箱，迁移到legacy_account指定账号的收件箱，未指定时迁移到qq服务商的账号
+// 其他账号的同号UID是不同的邮件，不能视为已处理（POP3的UID由本地分配，同样与旧记录无关）
+func legacyScopes(cfg *config.InternalConfig) []storage.MailboxKey {
+	var scopes []storage.MailboxKey
+	for _, provCfg := range cfg.ProvidersConfig {
+		if provCfg.Protocol == config.ProtocolPOP3 {
+			continue
+		}
+		if account := cfg.StorageConfig.LegacyAccount; account != "" {
+			if !strings.EqualFold(provCfg.Account(), account) {
+				continue
+			}
+		} else if provCfg.Type != config.ProviderQQ {
+			continue
+		}
+		scopes = append(scopes, storage.MailboxKey{
+			Provider: provCfg.Type,
+			Account:  provCfg.Account(),
+			Mailbox:  config.DefaultMailbox,
+		})
+	}
+	return scopes
 }
 
 // NewPoller 初始化轮询器
 func NewPoller(internalCfg *config.InternalConfig, logger logger.LoggerV1) (*Poller, error) {
 	// 初始化已处理邮件存储
-	processedStorage, err := storage.NewProcessedStorage(internalCfg.StorageConfig.ProcessedEmailsPath, logger)
+	processedStore, err := storage.NewProcessedStore(internalCfg.StorageConfig, legacyScopes(internalCfg), logger)
 	if err != nil {
 		return nil, fmt.Errorf("已处理存储初始化失败：%w", err)
 	}
@@ -47,165 +84,521 @@ func NewPoller(internalCfg *config.InternalConfig, logger logger.LoggerV1) (*Pol
 	return &Poller{
 		cfg:               internalCfg,
 		clients:           clients,
-		processedStorage:  processedStorage,
+		processedStore:    processedStore,
 		attachmentStorage: attachmentStorage,
+		quarantine:        storage.NewQuarantine(internalCfg.SafetyConfig.QuarantinePath, logger),
+		downloadBudget:    newByteBudget(internalCfg.DownloadConfig.MaxInflightBytes),
+		linkFetcher:       linkfetch.NewHTTPFetcher(internalCfg.BodyConfig),
 		logger:            logger,
 		retryCfg:          internalCfg.RetryConfig,
 	}, nil
 }
 
-// Run 启动轮询（阻塞，需在goroutine中运行）
+// Run 启动轮询（阻塞，需在goroutine中运行），每个服务商按各自的收信模式独立监听
 func (p *Poller) Run(ctx context.Context) {
 	p.logger.Info("轮训器启动", logger.Field{
 		Key: "interval",
 		Val: p.cfg.PollerConfig.Interval.String(),
 	})
 
+	var wg sync.WaitGroup
+	for i, client := range p.clients {
+		wg.Add(1)
+		go func(client domain.EmailClient, provCfg config.ProviderConfig) {
+			defer wg.Done()
+			p.watch(ctx, client, provCfg)
+		}(client, p.cfg.ProvidersConfig[i])
+	}
+	wg.Wait()
+	p.logger.Info("轮询器收到停止信号，正在退出...")
+}
+
+// watch 监听单个服务商：idle模式且服务器支持IDLE时等待推送，否则按固定间隔轮询
+func (p *Poller) watch(ctx context.Context, client domain.EmailClient, provCfg config.ProviderConfig) {
+	watchCfg := provCfg.WatchConfig
 	// 首次执行一次
-	p.doPoll()
+	p.pollClient(client, provCfg)
+
+	if watchCfg.Mode == config.WatchModeIdle {
+		if watcher, ok := client.(domain.IdleWatcher); ok && watcher.SupportsIdle() {
+			p.idleLoop(ctx, client, provCfg, watcher, watchCfg.IdleTimeout)
+			return
+		}
+		p.logger.Warn("服务器不支持IDLE，回退为轮询", logger.Field{
+			Key: "provider",
+			Val: client.GetProvider(),
+		})
+	}
+	p.pollLoop(ctx, client, provCfg)
+}
 
-	// 定时轮询
+// pollLoop 按固定间隔轮询
+func (p *Poller) pollLoop(ctx context.Context, client domain.EmailClient, provCfg config.ProviderConfig) {
 	ticker := time.NewTicker(p.cfg.PollerConfig.Interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			p.logger.Info("轮询器收到停止信号，正在退出...")
 			return
 		case <-ticker.C:
-			p.doPoll()
+			p.pollClient(client, provCfg)
 		}
 	}
 }
 
-// doPoll 单次轮询逻辑
-func (p *Poller) doPoll() {
-	p.logger.Info("开始新一轮轮询")
-
-	for _, client := range p.clients {
-		provider := client.GetProvider()
-		p.logger.Info("开始处理服务商", logger.Field{
+// idleLoop 通过IDLE等待新邮件，到期后重新发起，避免被服务器超时断开
+func (p *Poller) idleLoop(ctx context.Context, client domain.EmailClient, provCfg config.ProviderConfig, watcher domain.IdleWatcher, timeout time.Duration) {
+	provider := client.GetProvider()
+	p.logger.Info("进入IDLE监听",
+		logger.Field{
 			Key: "provider",
 			Val: provider,
+		}, logger.Field{
+			Key: "idle_timeout",
+			Val: timeout.String(),
 		})
 
-		// 获取未读邮件（带重试）
-		var unreadEmails []domain.Email
-		err := retry.Retry(context.Background(), p.retryCfg.MaxAttempts, p.retryCfg.Interval, func() error {
-			emails, err := client.ListUnreadEmails()
-			if err != nil {
-				return err
+	for {
+		newMail, err := watcher.WaitForNewMail(ctx, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err != nil:
+			p.logger.Warn("IDLE监听失败，等待一个轮询间隔后重试",
+				logger.Field{
+					Key: "provider",
+					Val: provider,
+				}, logger.Field{
+					Key: "err",
+					Val: err.Error(),
+				})
+			if p.errorCallback != nil {
+				p.errorCallback(err, provider)
 			}
-			unreadEmails = emails
-			return nil
-		})
-		//emails, err := client.ListUnreadEmails()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.cfg.PollerConfig.Interval):
+			}
+		case newMail:
+			p.logger.Info("收到新邮件通知", logger.Field{
+				Key: "provider",
+				Val: provider,
+			})
+		}
+		// 收到通知、IDLE到期或出错恢复后都拉取一次，避免遗漏推送
+		p.pollClient(client, provCfg)
+	}
+}
+
+// pollClient 拉取并处理单个服务商的未读邮件
+func (p *Poller) pollClient(client domain.EmailClient, provCfg config.ProviderConfig) {
+	provider := client.GetProvider()
+	p.logger.Info("开始处理服务商", logger.Field{
+		Key: "provider",
+		Val: provider,
+	})
+
+	// 获取未读邮件（带重试）
+	var unreadEmails []domain.Email
+	err := retry.Retry(context.Background(), p.retryCfg.MaxAttempts, p.retryCfg.Interval, func() error {
+		emails, err := client.ListUnreadEmails()
 		if err != nil {
-			p.logger.Info("获取未读邮件失败", logger.Field{
+			return err
+		}
+		unreadEmails = emails
+		return nil
+	})
+	//emails, err := client.ListUnreadEmails()
+	if err != nil {
+		p.logger.Info("获取未读邮件失败", logger.Field{
+			Key: "provider",
+			Val: err.Error(),
+		})
+		if p.errorCallback != nil {
+			p.errorCallback(err, provider)
+		}
+		return
+	}
+	//unreadEmails = emails
+	if len(unreadEmails) == 0 {
+		p.logger.Info("发现未读邮件",
+			logger.Field{
 				Key: "provider",
-				Val: err.Error(),
+				Val: provider},
+			logger.Field{
+				Key: "count",
+				Val: len(unreadEmails),
 			})
-			if p.errorCallback != nil {
-				p.errorCallback(err, provider)
-			}
+	}
+	// 处理每封邮件
+	for _, email := range unreadEmails {
+		key := storage.MessageKey{
+			Provider:    provider,
+			Account:     provCfg.Account(),
+			Mailbox:     email.Mailbox,
+			UIDValidity: email.UIDValidity,
+			UID:         email.ID,
+		}
+		// UIDVALIDITY变化后旧UID全部失效，已读邮件不会再被搜索到，未读邮件会重新处理
+		reset, err := p.processedStore.SetUIDValidity(key.MailboxKey(), key.UIDValidity)
+		if err != nil {
+			p.reportError(err, provider, "记录UIDVALIDITY失败", email.ID)
 			continue
 		}
-		//unreadEmails = emails
-		if len(unreadEmails) == 0 {
-			p.logger.Info("发现未读邮件",
+		if reset {
+			p.logger.Warn("邮箱UIDVALIDITY已变化，旧的已处理记录已失效",
 				logger.Field{
 					Key: "provider",
-					Val: provider},
-				logger.Field{
-					Key: "count",
-					Val: len(unreadEmails),
+					Val: provider,
+				}, logger.Field{
+					Key: "mailbox",
+					Val: email.Mailbox,
 				})
 		}
-		// 处理每封邮件
-		for _, email := range unreadEmails {
-			// 过滤已处理邮件
-			if p.processedStorage.IsProcessed(email.ID) {
-				p.logger.Debug("邮件已处理，跳过", logger.Field{
+
+		// 过滤已处理邮件
+		processed, err := p.processedStore.IsProcessed(key)
+		if err != nil {
+			p.reportError(err, provider, "查询已处理记录失败", email.ID)
+			continue
+		}
+		if processed {
+			p.logger.Debug("邮件已处理，跳过", logger.Field{
+				Key: "email_id",
+				Val: email.ID,
+			})
+			p.recordProcessed(client, email)
+			continue
+		}
+
+		// 解析正文中的投递信息，回调中可通过email.Application获取
+		if p.cfg.BodyConfig.Enabled {
+			email.Application = p.readApplication(client, email)
+		}
+
+		// 处理结果：有附件或链接交给回调即为processed，有附件下载失败为failed，否则为unroutable（被隔离的文件不计入）
+		delivered, failed := 0, 0
+		emailDir := filepath.Join(p.attachmentStorage.GetBasePath(), p.emailDir(provCfg, email), safety.SanitizeName(email.Subject))
+		// 本封邮件已接收的附件字节数（按解码后大小估算），超过上限的附件不再下载
+		var emailBytes int64
+
+		// 附件名来自发件人，清理后才能用于保存路径
+		for i := range email.Attachments {
+			email.Attachments[i].Name = safety.SanitizeName(email.Attachments[i].Name)
+		}
+
+		// 下载附件
+		if len(email.Attachments) > 0 {
+			p.logger.Debug("开始下载附件",
+				logger.Field{
 					Key: "email_id",
 					Val: email.ID,
+				}, logger.Field{
+					Key: "attach_count",
+					Val: len(email.Attachments),
 				})
-				continue
-			}
-
-			// 下载附件
-			if len(email.Attachments) > 0 {
-				p.logger.Debug("开始下载附件",
-					logger.Field{
-						Key: "email_id",
-						Val: email.ID,
-					}, logger.Field{
-						Key: "attach_count",
-						Val: len(email.Attachments),
-					})
-				for _, att := range email.Attachments {
-					// 构建附件保存路径（服务商/邮件ID/邮件主题/附件名）
-					savePath := filepath.Join(p.attachmentStorage.GetBasePath(), provider, strconv.Itoa(int(email.ID)), email.Subject, att.Name)
-					err := p.attachmentStorage.SaveAttachment(client, att, savePath)
-					if err != nil {
-						p.logger.Debug("附件下载失败",
-							logger.Field{
-								Key: "attach_name",
-								Val: att.Name,
-							}, logger.Field{
-								Key: "err",
-								Val: err.Error(),
-							})
-						if p.errorCallback != nil {
-							p.errorCallback(err, provider)
-						}
-						continue
+			for _, att := range email.Attachments {
+				if !provCfg.Attachments.Allowed(att.Name) {
+					p.reportUnsupported(email, att, "附件类型不在下载范围内")
+					continue
+				}
+				size := safety.DecodedSize(att.Size, att.Encoding)
+				if reason := p.checkSize(size, emailBytes); reason != "" {
+					p.quarantineFile(provCfg, email, att, "", reason)
+					continue
+				}
+				emailBytes += size
+				// 构建附件保存路径（服务商/账号/[文件夹/]邮件ID/邮件主题/附件名）
+				savePath := filepath.Join(emailDir, att.Name)
+				if !safety.Within(p.attachmentStorage.GetBasePath(), savePath) {
+					p.quarantineFile(provCfg, email, att, "", "附件保存路径超出附件目录")
+					continue
+				}
+				// 按附件大小占用下载额度，避免积压时多个服务商同时下载大量附件
+				reserved := p.downloadBudget.acquire(att.Size)
+				err := p.attachmentStorage.SaveAttachment(client, att, savePath)
+				p.downloadBudget.release(reserved)
+				if err != nil {
+					p.logger.Debug("附件下载失败",
+						logger.Field{
+							Key: "attach_name",
+							Val: att.Name,
+						}, logger.Field{
+							Key: "err",
+							Val: err.Error(),
+						})
+					if p.errorCallback != nil {
+						p.errorCallback(err, provider)
 					}
+					failed++
+					continue
+				}
+				if !p.inspect(provCfg, email, att, savePath) {
+					continue
+				}
 
-					// 触发回调
-					if p.attachmentCallback != nil {
-						p.attachmentCallback(email, att, savePath)
-					}
+				// 触发回调
+				delivered++
+				if p.attachmentCallback != nil {
+					p.attachmentCallback(email, att, savePath)
 				}
 			}
+		}
 
-			// 标记邮件为已读（带重试）
-			err = retry.Retry(context.Background(), p.retryCfg.MaxAttempts, p.retryCfg.Interval, func() error {
-				return client.MarkAsRead(email.ID)
-			})
-			if err != nil {
-				p.logger.Debug("标记已读失败",
-					logger.Field{
-						Key: "email_id",
-						Val: email.ID,
-					}, logger.Field{
-						Key: "err",
-						Val: err.Error(),
-					})
-				if p.errorCallback != nil {
-					p.errorCallback(err, provider)
+		// 下载正文中的简历链接，与附件走同一回调
+		if email.Application != nil {
+			for i, link := range email.Application.Links {
+				savePath := filepath.Join(emailDir, fmt.Sprintf("link-%d", i+1))
+				if p.fetchLink(provCfg, email, link, savePath, &emailBytes) {
+					delivered++
 				}
-				continue
 			}
+		}
 
-			// 记录已处理
-			if err := p.processedStorage.MarkAsProcessed(email.ID); err != nil {
-				p.logger.Debug("记录已处理失败",
-					logger.Field{
-						Key: "email_id",
-						Val: email.ID,
-					}, logger.Field{
-						Key: "err",
-						Val: err.Error(),
-					})
-				if p.errorCallback != nil {
-					p.errorCallback(err, provider)
-				}
+		outcome := domain.OutcomeUnroutable
+		switch {
+		case failed > 0:
+			outcome = domain.OutcomeFailed
+		case delivered > 0:
+			outcome = domain.OutcomeProcessed
+		}
+		// 执行处理后操作，失败时不记录已处理，下一轮重新处理
+		if err := p.postProcess(client, email, provCfg.PostProcess.Actions(outcome)); err != nil {
+			p.reportError(err, provider, "处理后操作失败", email.ID)
+			continue
+		}
+
+		// 记录已处理
+		if err := p.processedStore.MarkAsProcessed(key); err != nil {
+			p.logger.Debug("记录已处理失败",
+				logger.Field{
+					Key: "email_id",
+					Val: email.ID,
+				}, logger.Field{
+					Key: "err",
+					Val: err.Error(),
+				})
+			if p.errorCallback != nil {
+				p.errorCallback(err, provider)
 			}
+			continue
 		}
+		p.recordProcessed(client, email)
 	}
 
-	p.logger.Info("本轮轮询结束")
+	p.logger.Info("本轮处理结束", logger.Field{
+		Key: "provider",
+		Val: provider,
+	})
+}
+
+// emailDir 邮件在附件目录与隔离目录下的相对目录（服务商/账号/[文件夹/]邮件ID）
+// UID只在同一账号的同一文件夹内唯一，同一服务商配置多个账号或收件箱以外的文件夹时分目录，避免互相覆盖
+func (p *Poller) emailDir(provCfg config.ProviderConfig, email domain.Email) string {
+	dir := safety.SanitizeName(provCfg.Type)
+	if account := provCfg.Account(); account != "" {
+		dir = filepath.Join(dir, safety.SanitizeName(account))
+	}
+	if email.Mailbox != "" && email.Mailbox != config.DefaultMailbox {
+		dir = filepath.Join(dir, safety.SanitizeName(email.Mailbox))
+	}
+	return filepath.Join(dir, strconv.Itoa(int(email.ID)))
+}
+
+// checkSize 检查单个文件与整封邮件的大小上限，超过时返回原因
+func (p *Poller) checkSize(size, emailBytes int64) string {
+	limits := p.cfg.SafetyConfig
+	if size > limits.MaxAttachmentSize {
+		return fmt.Sprintf("附件大小约%d字节，超过上限%d字节", size, limits.MaxAttachmentSize)
+	}
+	if emailBytes+size > limits.MaxEmailSize {
+		return fmt.Sprintf("邮件附件总大小超过上限%d字节", limits.MaxEmailSize)
+	}
+	return ""
+}
+
+// inspect 检查已保存到savePath目录的文件，未通过时移入隔离目录，返回是否可以交给回调
+func (p *Poller) inspect(provCfg config.ProviderConfig, email domain.Email, att domain.Attachment, savePath string) bool {
+	filePath := filepath.Join(savePath, att.Name)
+	err := safety.Check(filePath, p.cfg.SafetyConfig.MaxAttachmentSize)
+	if err == nil {
+		return true
+	}
+	var rejected *safety.RejectError
+	if errors.As(err, &rejected) {
+		p.quarantineFile(provCfg, email, att, filePath, rejected.Reason)
+	} else {
+		p.reportError(err, provCfg.Type, "附件安全检查失败", email.ID)
+		_ = os.Remove(filePath)
+	}
+	// 附件独占的保存目录已空
+	_ = os.Remove(savePath)
+	return false
+}
+
+// quarantineFile 将未通过检查的文件移入隔离目录（filePath为空时只记录原因）并按不支持的附件上报；
+// 隔离失败时删除文件，避免留在附件目录中
+func (p *Poller) quarantineFile(provCfg config.ProviderConfig, email domain.Email, att domain.Attachment, filePath, reason string) {
+	_, err := p.quarantine.Put(p.emailDir(provCfg, email), filePath, storage.QuarantineRecord{
+		Name:     att.Name,
+		Reason:   reason,
+		Provider: provCfg.Type,
+		Account:  provCfg.Account(),
+		Mailbox:  email.Mailbox,
+		EmailID:  uint32(email.ID),
+		Subject:  email.Subject,
+		From:     email.From,
+		Size:     att.Size,
+		URL:      att.URL,
+	})
+	if err != nil {
+		p.reportError(err, provCfg.Type, "隔离附件失败", email.ID)
+		if filePath != "" {
+			_ = os.Remove(filePath)
+		}
+	}
+	p.reportUnsupported(email, att, "已隔离："+reason)
+}
+
+// recordProcessed 通知在本地记录已读状态的客户端（POP3），避免处理后操作不含标记已读时每轮重新下载
+func (p *Poller) recordProcessed(client domain.EmailClient, email domain.Email) {
+	recorder, ok := client.(domain.ProcessedRecorder)
+	if !ok {
+		return
+	}
+	if err := recorder.RecordProcessed(email.ID); err != nil {
+		p.reportError(err, client.GetProvider(), "记录客户端已读状态失败", email.ID)
+	}
+}
+
+// postProcess 逐个执行处理后操作（各自带重试，已成功的复制不会重复执行）
+// 客户端不支持处理后操作时只能标记已读
+func (p *Poller) postProcess(client domain.EmailClient, email domain.Email, actions []domain.PostAction) error {
+	processor, ok := client.(domain.PostProcessor)
+	for _, action := range actions {
+		err := retry.Retry(context.Background(), p.retryCfg.MaxAttempts, p.retryCfg.Interval, func() error {
+			if ok {
+				return processor.ApplyActions(email, []domain.PostAction{action})
+			}
+			if action.Type == domain.ActionSeen {
+				return client.MarkAsRead(email.ID)
+			}
+			return fmt.Errorf("%s客户端不支持%s操作", client.GetProvider(), action.Type)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readApplication 读取正文并提取投递信息，客户端不支持读取正文或读取失败时返回nil
+func (p *Poller) readApplication(client domain.EmailClient, email domain.Email) *domain.Application {
+	reader, ok := client.(domain.BodyReader)
+	if !ok || len(email.TextParts) == 0 {
+		return nil
+	}
+	text, html, err := reader.ReadBody(email)
+	if err != nil {
+		p.reportError(err, client.GetProvider(), "读取邮件正文失败", email.ID)
+		return nil
+	}
+	app := body.Parse(text, html)
+	if app != nil {
+		p.logger.Debug("正文投递信息",
+			logger.Field{
+				Key: "email_id",
+				Val: email.ID,
+			}, logger.Field{
+				Key: "name",
+				Val: app.Name,
+			}, logger.Field{
+				Key: "links",
+				Val: len(app.Links),
+			})
+	}
+	return app
+}
+
+// fetchLink 下载正文中的简历链接，通过安全检查后触发附件回调，返回是否交给了回调；
+// 主机不在白名单或下载失败时按不支持的附件上报，emailBytes累计本封邮件已接收的字节数
+func (p *Poller) fetchLink(provCfg config.ProviderConfig, email domain.Email, link, savePath string, emailBytes *int64) bool {
+	reserved := p.downloadBudget.acquire(p.cfg.BodyConfig.MaxLinkSize)
+	defer p.downloadBudget.release(reserved)
+
+	att, err := p.linkFetcher.Fetch(context.Background(), link, savePath)
+	if err != nil {
+		p.reportUnsupported(email, domain.Attachment{ID: link, Name: link, URL: link}, "简历链接无法下载："+err.Error())
+		return false
+	}
+	if *emailBytes+att.Size > p.cfg.SafetyConfig.MaxEmailSize {
+		p.quarantineFile(provCfg, email, att, filepath.Join(savePath, att.Name), fmt.Sprintf("邮件附件总大小超过上限%d字节", p.cfg.SafetyConfig.MaxEmailSize))
+		_ = os.Remove(savePath)
+		return false
+	}
+	*emailBytes += att.Size
+	if !p.inspect(provCfg, email, att, savePath) {
+		return false
+	}
+	p.logger.Info("简历链接下载成功",
+		logger.Field{
+			Key: "url",
+			Val: link,
+		}, logger.Field{
+			Key: "path",
+			Val: savePath,
+		})
+	if p.attachmentCallback != nil {
+		p.attachmentCallback(email, att, savePath)
+	}
+	return true
+}
+
+// reportError 记录单封邮件处理中的错误并触发错误回调
+func (p *Poller) reportError(err error, provider, msg string, emailID imap.UID) {
+	p.logger.Warn(msg,
+		logger.Field{
+			Key: "email_id",
+			Val: emailID,
+		}, logger.Field{
+			Key: "err",
+			Val: err.Error(),
+		})
+	if p.errorCallback != nil {
+		p.errorCallback(err, provider)
+	}
+}
+
+// reportUnsupported 记录未下载的附件并触发回调，避免附件被静默丢弃
+func (p *Poller) reportUnsupported(email domain.Email, att domain.Attachment, reason string) {
+	p.logger.Warn("不支持的附件",
+		logger.Field{
+			Key: "email_id",
+			Val: email.ID,
+		}, logger.Field{
+			Key: "attach_name",
+			Val: att.Name,
+		}, logger.Field{
+			Key: "reason",
+			Val: reason,
+		})
+	if p.unsupportedCallback != nil {
+		p.unsupportedCallback(email, att, reason)
+	}
+}
+
+// ConnectionStatus 返回各服务商的连接状态
+func (p *Poller) ConnectionStatus() []domain.ConnectionStatus {
+	statuses := make([]domain.ConnectionStatus, 0, len(p.clients))
+	for _, client := range p.clients {
+		if reporter, ok := client.(domain.HealthReporter); ok {
+			statuses = append(statuses, reporter.ConnectionStatus())
+		}
+	}
+	return statuses
 }
 
 // SetAttachmentCallback 设置附件下载回调
@@ -213,6 +606,16 @@ func (p *Poller) SetAttachmentCallback(callback domain.AttachmentCallback) {
 	p.attachmentCallback = callback
 }
 
+// SetUnsupportedCallback 设置不支持的附件回调
+func (p *Poller) SetUnsupportedCallback(callback domain.UnsupportedCallback) {
+	p.unsupportedCallback = callback
+}
+
+// SetLinkFetcher 替换正文简历链接下载器
+func (p *Poller) SetLinkFetcher(fetcher domain.LinkFetcher) {
+	p.linkFetcher = fetcher
+}
+
 // SetErrorCallback 设置错误回调
 func (p *Poller) SetErrorCallback(callback domain.ErrorCallback) {
 	p.errorCallback = callback
@@ -0,0 +1,74 @@
+package poller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	extconfig "easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/internal/email/email-attacher/internal/storage"
+	"easyHR/pkg/logger"
+)
+
+func TestLegacyScopes(t *testing.T) {
+	newConfig := func(legacyAccount string) *config.InternalConfig {
+		cfg := &extconfig.AppConfig{
+			PollInterval:        60,
+			AttachmentSavePath:  t.TempDir(),
+			ProcessedEmailsPath: filepath.Join(t.TempDir(), "processed_emails.json"),
+			LegacyAccount:       legacyAccount,
+			Providers: []extconfig.ProviderConfig{
+				{Type: "163", Config: map[string]interface{}{"username": "hr@163.com", "password": "secret"}},
+				{Type: "qq", Config: map[string]interface{}{"username": "hr@qq.com", "password": "secret"}},
+			},
+		}
+		if err := config.ValidateConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		internalCfg, err := config.InitInternalConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internalCfg
+	}
+	qq := storage.MailboxKey{Provider: "qq", Account: "hr@qq.com", Mailbox: config.DefaultMailbox}
+	netease := storage.MailboxKey{Provider: "163", Account: "hr@163.com", Mailbox: config.DefaultMailbox}
+
+	// 旧版文件只迁移到QQ邮箱，163账号的同号UID仍未处理
+	internalCfg := newConfig("")
+	if err := os.WriteFile(internalCfg.StorageConfig.ProcessedEmailsPath, []byte(`{"42": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := storage.NewProcessedStore(internalCfg.StorageConfig, legacyScopes(internalCfg), logger.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, mailbox := range []storage.MailboxKey{qq, netease} {
+		if _, err := s.SetUIDValidity(mailbox, 7); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, _ := s.IsProcessed(storage.MessageKey{Provider: qq.Provider, Account: qq.Account, Mailbox: qq.Mailbox, UIDValidity: 7, UID: 42}); !ok {
+		t.Fatal("legacy uid should be processed for the qq account")
+	}
+	if ok, _ := s.IsProcessed(storage.MessageKey{Provider: netease.Provider, Account: netease.Account, Mailbox: netease.Mailbox, UIDValidity: 7, UID: 42}); ok {
+		t.Fatal("legacy uid must not be migrated to another account")
+	}
+
+	// legacy_account指定账号时只迁移到该账号
+	if scopes := legacyScopes(newConfig("HR@163.com")); len(scopes) != 1 || scopes[0] != netease {
+		t.Fatalf("unexpected scopes %+v", scopes)
+	}
+	bad := &extconfig.AppConfig{
+		PollInterval:        60,
+		AttachmentSavePath:  t.TempDir(),
+		ProcessedEmailsPath: filepath.Join(t.TempDir(), "processed_emails.json"),
+		LegacyAccount:       "old@qq.com",
+		Providers:           []extconfig.ProviderConfig{{Type: "qq", Config: map[string]interface{}{"username": "hr@qq.com", "password": "secret"}}},
+	}
+	if err := config.ValidateConfig(bad); err == nil {
+		t.Fatal("expected error for unknown legacy_account")
+	}
+}
@@ -0,0 +1,267 @@
+// Package pop3 POP3协议客户端（RFC 1939），只实现收取邮件所需的命令：STAT、UIDL、TOP、RETR、DELE、NOOP、QUIT，
+// 支持隐式TLS与STLS（RFC 2595）升级
+package pop3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS模式
+const (
+	TLSModeTLS      = "tls"      // 隐式TLS，通常为995端口
+	TLSModeStartTLS = "starttls" // 明文连接后通过STLS升级，通常为110端口
+	TLSModeNone     = "none"     // 不加密，仅用于本地或测试服务器
+)
+
+// DefaultTimeout 默认读写超时，每次收发数据后重新计时
+const DefaultTimeout = time.Minute
+
+// Options POP3连接选项
+type Options struct {
+	Addr               string        // 完整地址（host:port）
+	Username           string        // 账号
+	Password           string        // 密码/授权码
+	TLSMode            string        // tls/starttls/none，为空时使用tls
+	InsecureSkipVerify bool          // 跳过证书校验，仅用于自签名证书的自建服务器
+	Timeout            time.Duration // 读写超时，0使用默认值
+}
+
+// Error 服务器返回的-ERR响应
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "POP3服务器返回错误：" + e.Message
+}
+
+// MessageID UIDL返回的邮件编号与唯一标识，编号只在本次会话内有效
+type MessageID struct {
+	Number int
+	UID    string
+}
+
+// Conn 一次POP3会话。DELE只在QUIT后生效，未QUIT直接断开时服务器放弃删除
+type Conn struct {
+	conn net.Conn
+	text *textproto.Conn
+}
+
+// Dial 按选项建立POP3连接并登录（USER/PASS）
+func Dial(opts Options) (*Conn, error) {
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("POP3地址无效：%w", err)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		ServerName:         host, // 主机名用于证书验证
+	}
+
+	var nc net.Conn
+	switch opts.TLSMode {
+	case TLSModeTLS, "":
+		nc, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", opts.Addr, tlsConfig)
+	case TLSModeStartTLS, TLSModeNone:
+		nc, err = net.DialTimeout("tcp", opts.Addr, timeout)
+	default:
+		return nil, fmt.Errorf("不支持的TLS模式：%s", opts.TLSMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConn(&timeoutConn{Conn: nc, timeout: timeout})
+	if _, err := c.readResponse(); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("读取POP3问候失败：%w", err)
+	}
+	if opts.TLSMode == TLSModeStartTLS {
+		if err := c.startTLS(tlsConfig); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	if err := c.login(opts.Username, opts.Password); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func newConn(nc net.Conn) *Conn {
+	return &Conn{conn: nc, text: textproto.NewConn(nc)}
+}
+
+// startTLS 发送STLS并在同一连接上完成TLS握手
+func (c *Conn) startTLS(config *tls.Config) error {
+	if _, err := c.cmd("STLS"); err != nil {
+		return fmt.Errorf("STLS失败：%w", err)
+	}
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("TLS握手失败：%w", err)
+	}
+	c.conn = tlsConn
+	c.text = textproto.NewConn(tlsConn)
+	return nil
+}
+
+// login 使用USER/PASS登录
+func (c *Conn) login(username, password string) error {
+	if _, err := c.cmd("USER %s", username); err != nil {
+		return fmt.Errorf("登录失败：%w", err)
+	}
+	if _, err := c.cmd("PASS %s", password); err != nil {
+		return fmt.Errorf("登录失败：%w", err)
+	}
+	return nil
+}
+
+// Stat 返回邮件数与总大小（字节）
+func (c *Conn) Stat() (int, int64, error) {
+	line, err := c.cmd("STAT")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("STAT响应格式错误：%q", line)
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("STAT响应格式错误：%q", line)
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("STAT响应格式错误：%q", line)
+	}
+	return count, size, nil
+}
+
+// UIDL 返回所有邮件的编号与唯一标识（按编号升序），服务器不支持UIDL时返回错误
+func (c *Conn) UIDL() ([]MessageID, error) {
+	lines, err := c.cmdLines("UIDL")
+	if err != nil {
+		return nil, fmt.Errorf("UIDL失败（服务器需支持UIDL）：%w", err)
+	}
+	ids := make([]MessageID, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("UIDL响应格式错误：%q", line)
+		}
+		num, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("UIDL响应格式错误：%q", line)
+		}
+		ids = append(ids, MessageID{Number: num, UID: fields[1]})
+	}
+	return ids, nil
+}
+
+// Retr 获取邮件原文并写入w（已去除行首的"."转义，行尾为LF），返回写入的字节数
+// 写入失败时连接处于响应中途，调用方应关闭连接
+func (c *Conn) Retr(num int, w io.Writer) (int64, error) {
+	if _, err := c.cmd("RETR %d", num); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, c.text.DotReader())
+}
+
+// Top 获取邮件头与正文的前lines行并写入w（lines为0时只有邮件头），用于不下载整封邮件时读取Date等头部
+func (c *Conn) Top(num, lines int, w io.Writer) (int64, error) {
+	if _, err := c.cmd("TOP %d %d", num, lines); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, c.text.DotReader())
+}
+
+// Dele 标记删除邮件，QUIT后生效
+func (c *Conn) Dele(num int) error {
+	_, err := c.cmd("DELE %d", num)
+	return err
+}
+
+// Noop 空操作，用于检查连接
+func (c *Conn) Noop() error {
+	_, err := c.cmd("NOOP")
+	return err
+}
+
+// Quit 结束会话并关闭连接，服务器在此时执行DELE标记的删除
+func (c *Conn) Quit() error {
+	_, err := c.cmd("QUIT")
+	if closeErr := c.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Close 直接关闭连接（不发送QUIT，本次会话的DELE不生效）
+func (c *Conn) Close() error {
+	return c.text.Close()
+}
+
+// cmd 发送单行命令并读取单行响应，返回+OK之后的内容
+func (c *Conn) cmd(format string, args ...any) (string, error) {
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return "", err
+	}
+	return c.readResponse()
+}
+
+// cmdLines 发送命令并读取多行响应（以"."结束）
+func (c *Conn) cmdLines(format string, args ...any) ([]string, error) {
+	if _, err := c.cmd(format, args...); err != nil {
+		return nil, err
+	}
+	return c.text.ReadDotLines()
+}
+
+// readResponse 读取+OK/-ERR响应行
+func (c *Conn) readResponse() (string, error) {
+	line, err := c.text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(line, "+OK"):
+		return strings.TrimSpace(strings.TrimPrefix(line, "+OK")), nil
+	case strings.HasPrefix(line, "-ERR"):
+		return "", &Error{Message: strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))}
+	default:
+		return "", fmt.Errorf("无法识别的POP3响应：%q", line)
+	}
+}
+
+// timeoutConn 每次读写前重新设置超时，大邮件传输中只要有数据就不会超时
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -27,3 +27,8 @@ func Retry(ctx context.Context, maxAttempts int, interval time.Duration, fn func
 	// 执行重试
 	return backoff.Retry(fn, bo)
 }
+
+// Permanent 包装不可恢复的错误，Retry遇到后立即返回err，不再重试
+func Permanent(err error) error {
+	return backoff.Permanent(err)
+}
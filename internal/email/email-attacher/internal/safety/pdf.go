@@ -0,0 +1,149 @@
+package safety
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+)
+
+// pdfActions PDF中会自动执行或启动外部程序的名称对象
+var pdfActions = map[string]string{
+	"JavaScript": "PDF包含JavaScript",
+	"JS":         "PDF包含JavaScript",
+	"Launch":     "PDF包含启动外部程序的动作",
+}
+
+// maxInflatedStream 单个压缩流解压后最多扫描的字节数，防止压缩炸弹
+const maxInflatedStream = 16 << 20
+
+// ScanPDF 扫描PDF中的名称对象，发现JavaScript或Launch动作时拒绝
+// 名称中的#xx转义会先还原（如/J#61vaScript）；FlateDecode压缩的流（含PDF 1.5起的对象流ObjStm）解压后同样扫描
+func ScanPDF(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := scanPDF(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return scanStreams(data)
+}
+
+// scanStreams 逐个尝试以zlib解压stream关键字之后的数据并扫描解压结果
+// 不解析流字典与Length，无法解压的流（如JPEG图片）直接跳过，解压到一半出错时仍扫描已解压的部分
+func scanStreams(data []byte) error {
+	keyword := []byte("stream")
+	for off := 0; ; {
+		i := bytes.Index(data[off:], keyword)
+		if i < 0 {
+			return nil
+		}
+		start := off + i
+		off = start + len(keyword)
+		// 跳过endstream
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+		body := data[off:]
+		if len(body) > 0 && body[0] == '\r' {
+			body = body[1:]
+		}
+		if len(body) > 0 && body[0] == '\n' {
+			body = body[1:]
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		err = scanPDF(bufio.NewReader(io.LimitReader(zr, maxInflatedStream)))
+		zr.Close()
+		var rejected *RejectError
+		if errors.As(err, &rejected) {
+			return err
+		}
+	}
+}
+
+// scanPDF 逐个读取以"/"开头的名称对象，名称在分隔符或空白处结束
+func scanPDF(r io.ByteReader) error {
+	var (
+		name   bytes.Buffer
+		inName bool
+	)
+	check := func() error {
+		if reason, ok := pdfActions[decodeName(name.Bytes())]; ok {
+			return rejectf("%s", reason)
+		}
+		return nil
+	}
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			if inName {
+				return check()
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if inName && !isPDFDelimiter(c) && name.Len() < 64 {
+			name.WriteByte(c)
+			continue
+		}
+		if inName {
+			if err := check(); err != nil {
+				return err
+			}
+			inName = false
+		}
+		if c == '/' {
+			inName = true
+			name.Reset()
+		}
+	}
+}
+
+// isPDFDelimiter PDF的空白与分隔符
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ', '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// decodeName 还原名称中的#xx十六进制转义
+func decodeName(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) && isHex(b[i+1]) && isHex(b[i+2]) {
+			out = append(out, unhex(b[i+1])<<4|unhex(b[i+2]))
+			i += 2
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	default:
+		return c - 'a' + 10
+	}
+}
@@ -0,0 +1,170 @@
+// Package safety 附件安全检查：文件名与路径清理、大小估算、按扩展名校验文件内容、PDF脚本与启动动作检测
+package safety
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameBytes 文件名（含扩展名）的最大字节数，多数文件系统上限为255
+const maxNameBytes = 200
+
+// defaultName 清理后为空时使用的文件名
+const defaultName = "attachment"
+
+// RejectError 附件未通过安全检查，Reason为拒绝原因
+type RejectError struct {
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return "附件未通过安全检查：" + e.Reason
+}
+
+// rejectf 返回拒绝原因
+func rejectf(format string, args ...any) error {
+	return &RejectError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// SanitizeName 将邮件中的文件名、主题或文件夹名清理为单个安全的路径段：
+// 去掉路径分隔符与控制字符，不允许"."、".."及以"."开头，过长时保留扩展名截断
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '_'
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Bidi_Control, r):
+			// 无效编码、控制字符与双向覆盖字符（可伪装扩展名）
+			return -1
+		case strings.ContainsRune(`:*?"<>|`, r):
+			// Windows保留字符
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ". ")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return defaultName
+	}
+	return truncate(name, maxNameBytes)
+}
+
+// truncate 按字节截断文件名，保留扩展名且不截断多字节字符
+func truncate(name string, limit int) string {
+	if len(name) <= limit {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > limit/4 {
+		ext = ""
+	}
+	stem := name[:len(name)-len(ext)]
+	n := limit - len(ext)
+	for n > 0 && !utf8.RuneStart(stem[n]) {
+		n--
+	}
+	return stem[:n] + ext
+}
+
+// Within 判断path清理后是否位于base目录内
+func Within(base, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(base), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// DecodedSize 按传输编码估算附件解码后的大小（BODYSTRUCTURE给出的是编码后的大小）
+func DecodedSize(size int64, encoding string) int64 {
+	if strings.EqualFold(encoding, "base64") {
+		return size / 4 * 3
+	}
+	return size
+}
+
+// magic 各扩展名对应的文件头，满足其一即可
+var magic = map[string][][]byte{
+	".pdf":  {[]byte("%PDF-")},
+	".doc":  {{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}},
+	".docx": {[]byte("PK\x03\x04")},
+	".zip":  {[]byte("PK\x03\x04"), []byte("PK\x05\x06")},
+	".jpg":  {{0xFF, 0xD8, 0xFF}},
+	".jpeg": {{0xFF, 0xD8, 0xFF}},
+	".png":  {[]byte("\x89PNG\r\n\x1a\n")},
+	".rar":  {[]byte("Rar!\x1a\x07")},
+	".7z":   {{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}},
+}
+
+// executables 可执行文件头，任何扩展名都拒绝
+var executables = []struct {
+	header []byte
+	kind   string
+}{
+	{[]byte("MZ"), "Windows可执行文件"},
+	{[]byte("\x7fELF"), "ELF可执行文件"},
+	{[]byte("#!"), "脚本"},
+	{[]byte{0xCF, 0xFA, 0xED, 0xFE}, "Mach-O可执行文件"},
+	{[]byte{0xCA, 0xFE, 0xBA, 0xBE}, "Mach-O/Java可执行文件"},
+}
+
+// pdfHeaderWindow PDF文件头允许出现的范围（规范允许前面有少量数据）
+const pdfHeaderWindow = 1024
+
+// Sniff 读取文件头，拒绝可执行文件及内容与扩展名不符的文件；未登记文件头的扩展名只检查可执行文件
+func Sniff(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	head := make([]byte, pdfHeaderWindow)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head = head[:n]
+	if n == 0 {
+		return rejectf("文件为空")
+	}
+
+	for _, e := range executables {
+		if bytes.HasPrefix(head, e.header) {
+			return rejectf("文件内容为%s", e.kind)
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	headers, ok := magic[ext]
+	if !ok {
+		return nil
+	}
+	for _, h := range headers {
+		if bytes.HasPrefix(head, h) || (ext == ".pdf" && bytes.Contains(head, h)) {
+			return nil
+		}
+	}
+	return rejectf("文件内容与扩展名%s不符", ext)
+}
+
+// Check 对已保存的文件执行全部检查：大小上限（maxSize<=0时不限）、文件头与PDF动作
+func Check(path string, maxSize int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return rejectf("文件大小%d字节超过上限%d字节", info.Size(), maxSize)
+	}
+	if err := Sniff(path); err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return ScanPDF(path)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package safety
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"resume.pdf":             "resume.pdf",
+		"../../etc/cron.d/x":     "_.._etc_cron.d_x",
+		`..\..\Windows\evil.exe`: "_.._Windows_evil.exe",
+		"/etc/passwd":            "_etc_passwd",
+		"..":                     "attachment",
+		"":                       "attachment",
+		" .hidden ":              "hidden",
+		"张三\x00简历\r\n.pdf":       "张三简历.pdf",
+		"简历\u202efdp.exe":        "简历fdp.exe",
+		"2026校园招聘: 后端/张三 <13900000000>": "2026校园招聘_ 后端_张三 _13900000000_",
+	} {
+		if got := SanitizeName(name); got != want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	long := SanitizeName(strings.Repeat("张", 100) + ".pdf")
+	if len(long) > maxNameBytes || !strings.HasSuffix(long, ".pdf") || !utf8.ValidString(long) {
+		t.Fatalf("unexpected truncated name %q", long)
+	}
+
+	base := t.TempDir()
+	if !Within(base, filepath.Join(base, "qq", SanitizeName("../x"))) || Within(base, filepath.Join(base, "..", "x")) {
+		t.Fatal("unexpected Within result")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// 压缩的对象流（PDF 1.5+），动作字典只在解压后可见
+	objStm := func(dict string) string {
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		zw.Write([]byte("5 0 " + dict))
+		zw.Close()
+		return "%PDF-1.5\n1 0 obj<</Type/Catalog/OpenAction 5 0 R>>endobj\n" +
+			"2 0 obj<</Type/ObjStm/N 1/First 4/Filter/FlateDecode/Length " + strconv.Itoa(b.Len()) + ">>stream\n" +
+			b.String() + "\nendstream\nendobj\n"
+	}
+
+	for _, path := range []string{
+		write("resume.pdf", "%PDF-1.7\n1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj\n/F1<</BaseFont/JSFont>>"),
+		write("resume.docx", "PK\x03\x04word/document.xml"),
+		write("photo.jpg", "\xFF\xD8\xFF\xE0JFIF"),
+		write("notes.txt", "自我介绍"),
+		write("compressed.pdf", objStm("<</Type/Page/Contents 6 0 R>>")),
+	} {
+		if err := Check(path, 1024); err != nil {
+			t.Errorf("%s: unexpected error %v", filepath.Base(path), err)
+		}
+	}
+
+	for path, reason := range map[string]string{
+		write("empty.pdf", ""):                     "为空",
+		write("invoice.pdf", "MZ\x90\x00"):         "Windows可执行文件",
+		write("photo.png", "\xFF\xD8\xFF\xE0JFIF"): "扩展名.png不符",
+		write("resume.doc", "PK\x03\x04"):          "扩展名.doc不符",
+		write("js.pdf", "%PDF-1.4\n1 0 obj<</Type/Catalog/OpenAction<</S/JavaScript/JS(app.alert(1))>>>>"): "JavaScript",
+		write("hex.pdf", "%PDF-1.4\n<</OpenAction<</S/J#61vaScript/JS 5 0 R>>>>"):                          "JavaScript",
+		write("launch.pdf", "%PDF-1.4\n<</S/Launch/F(cmd.exe)>>"):                                          "启动外部程序",
+		write("objstm.pdf", objStm("<</S/JavaScript/JS(app.alert(1))>>")):                                  "JavaScript",
+		write("large.pdf", "%PDF-1.4"+strings.Repeat(" ", 2048)):                                           "超过上限",
+	} {
+		err := Check(path, 1024)
+		var rejected *RejectError
+		if !errors.As(err, &rejected) || !strings.Contains(rejected.Reason, reason) {
+			t.Errorf("%s: expected rejection %q, got %v", filepath.Base(path), reason, err)
+		}
+	}
+
+	if DecodedSize(4096, "BASE64") != 3072 || DecodedSize(4096, "7bit") != 4096 {
+		t.Fatal("unexpected decoded size")
+	}
+}
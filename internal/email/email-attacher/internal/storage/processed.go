@@ -1,91 +1,333 @@
 package storage
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 
+	"easyHR/internal/email/email-attacher/internal/config"
 	"easyHR/pkg/logger"
 
 	"github.com/emersion/go-imap/v2"
 )
 
-// ProcessedStorage 已处理邮件存储（基于文件）
-type ProcessedStorage struct {
-	filePath     string            // 存储文件路径
-	processedIDs map[imap.UID]bool // 已处理邮件ID缓存
-	mu           sync.RWMutex      // 读写锁（保证并发安全）
-	logger       logger.LoggerV1
+// MailboxKey 定位一个邮箱：服务商 + 账号 + 邮箱名
+type MailboxKey struct {
+	Provider string
+	Account  string
+	Mailbox  string
 }
 
-// NewProcessedStorage 创建已处理邮件存储实例
-func NewProcessedStorage(filePath string, logger logger.LoggerV1) (*ProcessedStorage, error) {
-	ps := &ProcessedStorage{
-		filePath:     filePath,
-		processedIDs: make(map[imap.UID]bool),
-		logger:       logger,
+// MessageKey 定位一封邮件。UID只在同一邮箱、同一UIDVALIDITY下唯一
+type MessageKey struct {
+	Provider    string
+	Account     string
+	Mailbox     string
+	UIDValidity uint32
+	UID         imap.UID
+}
+
+// MailboxKey 邮件所在的邮箱
+func (k MessageKey) MailboxKey() MailboxKey {
+	return MailboxKey{Provider: k.Provider, Account: k.Account, Mailbox: k.Mailbox}
+}
+
+// ProcessedStore 已处理邮件存储
+type ProcessedStore interface {
+	// IsProcessed 检查邮件是否已处理
+	IsProcessed(key MessageKey) (bool, error)
+	// MarkAsProcessed 标记邮件为已处理（并持久化）
+	MarkAsProcessed(key MessageKey) error
+	// SetUIDValidity 记录邮箱当前的UIDVALIDITY；与上次不同时旧记录全部失效并被清理，返回true
+	SetUIDValidity(mailbox MailboxKey, validity uint32) (bool, error)
+	// Close 释放文件句柄或数据库连接
+	Close() error
+}
+
+// NewProcessedStore 按配置创建已处理邮件存储
+// legacyScopes为旧版processed_emails.json中记录的归属邮箱：旧格式只有UID，无法区分账号，记录会复制到其中每个邮箱
+func NewProcessedStore(cfg config.StorageConfig, legacyScopes []MailboxKey, log logger.LoggerV1) (ProcessedStore, error) {
+	switch cfg.ProcessedStoreType {
+	case config.ProcessedStoreMongo:
+		return NewMongoProcessedStore(cfg.Mongo, cfg.ProcessedEmailsPath, legacyScopes, log)
+	default:
+		return NewFileProcessedStore(cfg.ProcessedEmailsPath, legacyScopes, log)
 	}
+}
+
+// processedRecord 日志文件中的一行记录，UID为0表示邮箱的UIDVALIDITY记录
+type processedRecord struct {
+	Provider    string   `json:"provider"`
+	Account     string   `json:"account"`
+	Mailbox     string   `json:"mailbox"`
+	UIDValidity uint32   `json:"uid_validity"`
+	UID         imap.UID `json:"uid,omitempty"`
+}
 
-	// 加载已处理记录
-	if err := ps.load(); err != nil {
+func (r processedRecord) key() MessageKey {
+	return MessageKey{Provider: r.Provider, Account: r.Account, Mailbox: r.Mailbox, UIDValidity: r.UIDValidity, UID: r.UID}
+}
+
+func newProcessedRecord(key MessageKey) processedRecord {
+	return processedRecord{Provider: key.Provider, Account: key.Account, Mailbox: key.Mailbox, UIDValidity: key.UIDValidity, UID: key.UID}
+}
+
+// FileProcessedStore 基于本地文件的已处理邮件存储
+// 文件为追加写入的JSON Lines日志，每次写入后fsync；压缩时先写临时文件再原子替换，进程崩溃不会损坏已有记录
+type FileProcessedStore struct {
+	filePath  string
+	file      *os.File                // 追加写入句柄
+	processed map[MessageKey]struct{} // 已处理邮件
+	validity  map[MailboxKey]uint32   // 各邮箱当前UIDVALIDITY，0表示尚未观察到
+	mu        sync.Mutex
+	logger    logger.LoggerV1
+}
+
+// NewFileProcessedStore 打开文件存储，旧版UID集合格式会被迁移，原文件备份为.bak
+func NewFileProcessedStore(filePath string, legacyScopes []MailboxKey, log logger.LoggerV1) (*FileProcessedStore, error) {
+	s := &FileProcessedStore{
+		filePath:  filePath,
+		processed: make(map[MessageKey]struct{}),
+		validity:  make(map[MailboxKey]uint32),
+		logger:    log,
+	}
+	dirty, err := s.load(legacyScopes)
+	if err != nil {
 		return nil, err
 	}
-
-	return ps, nil
+	// 迁移或存在损坏行时重写文件
+	if dirty {
+		if err := s.compact(); err != nil {
+			return nil, err
+		}
+	}
+	if s.file == nil {
+		if s.file, err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("已处理邮件记录加载成功", logger.Field{Key: "count", Val: len(s.processed)})
+	return s, nil
 }
 
 // IsProcessed 检查邮件是否已处理
-func (ps *ProcessedStorage) IsProcessed(emailID imap.UID) bool {
-	ps.mu.RLock()
-	defer ps.mu.RUnlock()
-	return ps.processedIDs[emailID]
+func (s *FileProcessedStore) IsProcessed(key MessageKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.processed[key]
+	return ok, nil
+}
+
+// MarkAsProcessed 标记邮件为已处理，只追加一行记录
+func (s *FileProcessedStore) MarkAsProcessed(key MessageKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.processed[key]; ok {
+		return nil
+	}
+	if err := s.append(newProcessedRecord(key)); err != nil {
+		return err
+	}
+	s.processed[key] = struct{}{}
+	return nil
 }
 
-// MarkAsProcessed 标记邮件为已处理（并持久化）
-func (ps *ProcessedStorage) MarkAsProcessed(emailID imap.UID) error {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
+// SetUIDValidity 记录邮箱当前的UIDVALIDITY
+// 迁移来的旧记录（UIDVALIDITY为0）在首次观察到时绑定到当前值；值变化时旧记录失效并压缩文件
+func (s *FileProcessedStore) SetUIDValidity(mailbox MailboxKey, validity uint32) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	ps.processedIDs[emailID] = true
-	return ps.save()
+	prev := s.validity[mailbox]
+	if prev == validity {
+		return false, nil
+	}
+	s.validity[mailbox] = validity
+
+	rewrite := false
+	for key := range s.processed {
+		if key.MailboxKey() != mailbox || key.UIDValidity == validity {
+			continue
+		}
+		delete(s.processed, key)
+		if key.UIDValidity == 0 {
+			key.UIDValidity = validity
+			s.processed[key] = struct{}{}
+		}
+		rewrite = true
+	}
+	if rewrite {
+		return prev != 0, s.compact()
+	}
+	return prev != 0, s.append(processedRecord{Provider: mailbox.Provider, Account: mailbox.Account, Mailbox: mailbox.Mailbox, UIDValidity: validity})
 }
 
-// load 从文件加载已处理记录
-func (ps *ProcessedStorage) load() error {
-	// 文件不存在则创建空文件
-	if _, err := os.Stat(ps.filePath); os.IsNotExist(err) {
-		return ps.save() // 保存空map，创建文件
+// Close 关闭文件
+func (s *FileProcessedStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
 	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
 
-	// 读取文件内容
-	data, err := os.ReadFile(ps.filePath)
+// load 读取记录文件，返回是否需要重写（迁移了旧格式或跳过了损坏行）
+func (s *FileProcessedStore) load(legacyScopes []MailboxKey) (bool, error) {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if legacy, ok := parseLegacyProcessed(data); ok {
+		for _, uid := range legacy {
+			for _, scope := range legacyScopes {
+				s.processed[MessageKey{Provider: scope.Provider, Account: scope.Account, Mailbox: scope.Mailbox, UID: uid}] = struct{}{}
+			}
+		}
+		if err := backupLegacyProcessed(s.filePath, data); err != nil {
+			return false, err
+		}
+		s.logger.Info("旧版已处理邮件记录已迁移",
+			logger.Field{Key: "uids", Val: len(legacy)},
+			logger.Field{Key: "mailboxes", Val: len(legacyScopes)})
+		return true, nil
+	}
+
+	dirty := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lines := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		var rec processedRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// 通常是写入过程中崩溃留下的半行
+			s.logger.Warn("跳过损坏的已处理记录", logger.Field{Key: "line", Val: string(line)})
+			dirty = true
+			continue
+		}
+		if rec.UID == 0 {
+			s.validity[rec.key().MailboxKey()] = rec.UIDValidity
+			continue
+		}
+		s.processed[rec.key()] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	// 重复的UIDVALIDITY记录较多时顺带压缩
+	if lines > len(s.processed)+len(s.validity) {
+		dirty = true
+	}
+	return dirty, nil
+}
+
+// append 追加一行记录并fsync
+func (s *FileProcessedStore) append(rec processedRecord) error {
+	line, err := json.Marshal(rec)
 	if err != nil {
 		return err
 	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// compact 只保留当前有效的记录，写入临时文件后原子替换
+func (s *FileProcessedStore) compact() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for mailbox, validity := range s.validity {
+		if err := enc.Encode(processedRecord{Provider: mailbox.Provider, Account: mailbox.Account, Mailbox: mailbox.Mailbox, UIDValidity: validity}); err != nil {
+			return err
+		}
+	}
+	for key := range s.processed {
+		if err := enc.Encode(newProcessedRecord(key)); err != nil {
+			return err
+		}
+	}
 
-	// 反序列化
-	if err := json.Unmarshal(data, &ps.processedIDs); err != nil {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+	if err := writeFileAtomic(s.filePath, buf.Bytes()); err != nil {
+		return fmt.Errorf("压缩已处理记录失败：%w", err)
+	}
+	file, err := os.OpenFile(s.filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
 		return err
 	}
-	ps.logger.Info("已处理邮件记录加载成功",
-		logger.Field{
-			Key: "count",
-			Val: len(ps.processedIDs),
-		})
+	s.file = file
 	return nil
 }
 
-// save 持久化已处理记录到文件
-func (ps *ProcessedStorage) save() error {
-	data, err := json.MarshalIndent(ps.processedIDs, "", "  ")
+// writeFileAtomic 写入同目录下的临时文件并fsync，再重命名覆盖目标文件
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
 
-	// 写入文件（覆盖）
-	if err := os.WriteFile(ps.filePath, data, 0644); err != nil {
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
 		return err
 	}
-
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// 同步目录项，确保重命名落盘
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
 	return nil
 }
+
+// parseLegacyProcessed 解析旧版格式：{"42": true, ...}
+func parseLegacyProcessed(data []byte) ([]imap.UID, bool) {
+	var legacy map[imap.UID]bool
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, false
+	}
+	uids := make([]imap.UID, 0, len(legacy))
+	for uid, processed := range legacy {
+		if processed {
+			uids = append(uids, uid)
+		}
+	}
+	return uids, true
+}
+
+// backupLegacyProcessed 迁移前备份旧文件
+func backupLegacyProcessed(path string, data []byte) error {
+	return writeFileAtomic(path+".bak", data)
+}
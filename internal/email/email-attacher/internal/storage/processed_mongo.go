@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// mongoTimeout 单次数据库操作超时
+const mongoTimeout = 5 * time.Second
+
+// processedDoc 已处理邮件文档，UID为0的文档记录邮箱当前的UIDVALIDITY
+type processedDoc struct {
+	Provider    string    `bson:"provider"`
+	Account     string    `bson:"account"`
+	Mailbox     string    `bson:"mailbox"`
+	UIDValidity uint32    `bson:"uid_validity"`
+	UID         uint32    `bson:"uid"`
+	ProcessedAt time.Time `bson:"processed_at"`
+}
+
+// MongoProcessedStore 基于MongoDB的已处理邮件存储，多实例部署时共享记录
+type MongoProcessedStore struct {
+	client   *mongo.Client
+	col      *mongo.Collection
+	validity map[MailboxKey]uint32 // UIDVALIDITY缓存，避免每封邮件都查询
+	mu       sync.Mutex
+	logger   logger.LoggerV1
+}
+
+// NewMongoProcessedStore 连接MongoDB；legacyPath存在旧版记录文件时导入后备份为.bak
+func NewMongoProcessedStore(cfg config.MongoStoreConfig, legacyPath string, legacyScopes []MailboxKey, log logger.LoggerV1) (*MongoProcessedStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	opts := options.Client().ApplyURI(cfg.URI)
+	if cfg.Username != "" {
+		opts.SetAuth(options.Credential{Username: cfg.Username, Password: cfg.Password})
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("连接MongoDB失败：%w", err)
+	}
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("MongoDB不可用：%w", err)
+	}
+
+	col := client.Database(cfg.Database).Collection(cfg.Collection)
+	_, err = col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "provider", Value: 1},
+			{Key: "account", Value: 1},
+			{Key: "mailbox", Value: 1},
+			{Key: "uid_validity", Value: 1},
+			{Key: "uid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("创建索引失败：%w", err)
+	}
+
+	s := &MongoProcessedStore{
+		client:   client,
+		col:      col,
+		validity: make(map[MailboxKey]uint32),
+		logger:   log,
+	}
+	if err := s.migrateLegacy(legacyPath, legacyScopes); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// IsProcessed 检查邮件是否已处理
+func (s *MongoProcessedStore) IsProcessed(key MessageKey) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	n, err := s.col.CountDocuments(ctx, keyFilter(key), options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// MarkAsProcessed 标记邮件为已处理（重复标记不报错）
+func (s *MongoProcessedStore) MarkAsProcessed(key MessageKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	return s.upsert(ctx, key)
+}
+
+// SetUIDValidity 记录邮箱当前的UIDVALIDITY，规则同FileProcessedStore
+func (s *MongoProcessedStore) SetUIDValidity(mailbox MailboxKey, validity uint32) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	prev, ok := s.validity[mailbox]
+	if !ok {
+		var doc processedDoc
+		err := s.col.FindOne(ctx, mailboxFilter(mailbox, bson.M{"uid": 0})).Decode(&doc)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return false, err
+		}
+		prev = doc.UIDValidity
+	}
+	if prev == validity {
+		s.validity[mailbox] = validity
+		return false, nil
+	}
+
+	// 绑定迁移来的旧记录
+	if _, err := s.col.UpdateMany(ctx,
+		mailboxFilter(mailbox, bson.M{"uid_validity": 0, "uid": bson.M{"$ne": 0}}),
+		bson.M{"$set": bson.M{"uid_validity": validity}}); err != nil {
+		return false, err
+	}
+	// 清理失效记录（含旧的UIDVALIDITY文档）
+	if _, err := s.col.DeleteMany(ctx, mailboxFilter(mailbox, bson.M{"uid_validity": bson.M{"$ne": validity}})); err != nil {
+		return false, err
+	}
+	if _, err := s.col.UpdateOne(ctx,
+		mailboxFilter(mailbox, bson.M{"uid": 0}),
+		bson.M{"$set": bson.M{"uid_validity": validity, "processed_at": time.Now()}},
+		options.Update().SetUpsert(true)); err != nil {
+		return false, err
+	}
+	s.validity[mailbox] = validity
+	return prev != 0, nil
+}
+
+// Close 断开数据库连接
+func (s *MongoProcessedStore) Close() error {
+	return s.client.Disconnect(context.Background())
+}
+
+// migrateLegacy 导入旧版processed_emails.json
+func (s *MongoProcessedStore) migrateLegacy(path string, legacyScopes []MailboxKey) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	legacy, ok := parseLegacyProcessed(data)
+	if !ok {
+		return nil
+	}
+
+	for _, uid := range legacy {
+		for _, scope := range legacyScopes {
+			ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+			err := s.upsert(ctx, MessageKey{Provider: scope.Provider, Account: scope.Account, Mailbox: scope.Mailbox, UID: uid})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("导入旧版已处理记录失败：%w", err)
+			}
+		}
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		return err
+	}
+	s.logger.Info("旧版已处理邮件记录已导入MongoDB",
+		logger.Field{Key: "uids", Val: len(legacy)},
+		logger.Field{Key: "mailboxes", Val: len(legacyScopes)})
+	return nil
+}
+
+func (s *MongoProcessedStore) upsert(ctx context.Context, key MessageKey) error {
+	_, err := s.col.UpdateOne(ctx, keyFilter(key),
+		bson.M{"$setOnInsert": bson.M{"processed_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func keyFilter(key MessageKey) bson.M {
+	return bson.M{
+		"provider":     key.Provider,
+		"account":      key.Account,
+		"mailbox":      key.Mailbox,
+		"uid_validity": key.UIDValidity,
+		"uid":          uint32(key.UID),
+	}
+}
+
+// mailboxFilter 限定在某个邮箱内的查询条件
+func mailboxFilter(mailbox MailboxKey, extra bson.M) bson.M {
+	filter := bson.M{"provider": mailbox.Provider, "account": mailbox.Account, "mailbox": mailbox.Mailbox}
+	for k, v := range extra {
+		filter[k] = v
+	}
+	return filter
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"easyHR/pkg/logger"
+)
+
+func TestFileProcessedStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed_emails.json")
+	// 旧版格式：只有UID
+	if err := os.WriteFile(path, []byte(`{"42": true, "43": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	qq := MailboxKey{Provider: "qq", Account: "hr@qq.com", Mailbox: "INBOX"}
+	netease := MailboxKey{Provider: "163", Account: "hr@163.com", Mailbox: "INBOX"}
+
+	s, err := NewFileProcessedStore(path, []MailboxKey{qq, netease}, logger.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Fatalf("legacy file not backed up: %v", err)
+	}
+
+	// 首次观察到UIDVALIDITY时旧记录绑定到该值，不视为变化
+	if reset, err := s.SetUIDValidity(qq, 7); err != nil || reset {
+		t.Fatalf("unexpected reset %v, %v", reset, err)
+	}
+	if ok, _ := s.IsProcessed(MessageKey{Provider: "qq", Account: "hr@qq.com", Mailbox: "INBOX", UIDValidity: 7, UID: 42}); !ok {
+		t.Fatal("migrated uid should be processed")
+	}
+
+	// 不同账号的同一UID互不影响
+	key := MessageKey{Provider: "163", Account: "hr@163.com", Mailbox: "INBOX", UIDValidity: 100, UID: 44}
+	if _, err := s.SetUIDValidity(netease, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkAsProcessed(key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.IsProcessed(MessageKey{Provider: "qq", Account: "hr@qq.com", Mailbox: "INBOX", UIDValidity: 7, UID: 44}); ok {
+		t.Fatal("uid 44 of another account should not be processed")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写入中途崩溃留下的半行
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"provider":"qq","acc`)
+	_ = f.Close()
+
+	s, err = NewFileProcessedStore(path, nil, logger.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ok, _ := s.IsProcessed(key); !ok {
+		t.Fatal("record lost after reopen")
+	}
+
+	// UIDVALIDITY变化后旧记录失效
+	if reset, err := s.SetUIDValidity(qq, 8); err != nil || !reset {
+		t.Fatalf("expected reset, got %v, %v", reset, err)
+	}
+	if ok, _ := s.IsProcessed(MessageKey{Provider: "qq", Account: "hr@qq.com", Mailbox: "INBOX", UIDValidity: 7, UID: 42}); ok {
+		t.Fatal("stale record should be dropped")
+	}
+	if ok, _ := s.IsProcessed(key); !ok {
+		t.Fatal("other mailbox should keep its records")
+	}
+}
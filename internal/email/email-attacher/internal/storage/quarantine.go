@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"easyHR/pkg/logger"
+)
+
+// QuarantineRecord 被拒绝文件的说明，保存为隔离目录中的<文件名>.reason.json
+type QuarantineRecord struct {
+	Name          string    `json:"name"`
+	Reason        string    `json:"reason"`
+	Provider      string    `json:"provider"`
+	Account       string    `json:"account"`
+	Mailbox       string    `json:"mailbox"`
+	EmailID       uint32    `json:"email_id"`
+	Subject       string    `json:"subject"`
+	From          string    `json:"from"`
+	Size          int64     `json:"size"`
+	URL           string    `json:"url,omitempty"`
+	Stored        bool      `json:"stored"` // 文件是否已移入隔离目录（超过大小上限的附件不下载）
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// Quarantine 隔离目录：未通过安全检查的文件移到这里，不交给下游处理
+type Quarantine struct {
+	basePath string          // 隔离目录根目录
+	logger   logger.LoggerV1 // 日志器
+}
+
+// NewQuarantine 创建隔离目录实例
+func NewQuarantine(basePath string, logger logger.LoggerV1) *Quarantine {
+	return &Quarantine{
+		basePath: basePath,
+		logger:   logger,
+	}
+}
+
+// Put 将filePath移入隔离目录下的dir子目录并写入原因说明，返回隔离后的路径；
+// filePath为空时（文件未下载）只写入原因说明。dir与rec.Name需已清理
+func (q *Quarantine) Put(dir, filePath string, rec QuarantineRecord) (string, error) {
+	target := filepath.Join(q.basePath, dir)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return "", fmt.Errorf("创建隔离目录失败：%w", err)
+	}
+	target = availablePath(filepath.Join(target, rec.Name))
+
+	if filePath != "" {
+		if err := moveFile(filePath, target); err != nil {
+			return "", fmt.Errorf("移入隔离目录失败：%w", err)
+		}
+		rec.Stored = true
+	}
+	rec.QuarantinedAt = time.Now()
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(target+".reason.json", data, 0644); err != nil {
+		return "", fmt.Errorf("写入隔离原因失败：%w", err)
+	}
+
+	q.logger.Warn("附件已隔离",
+		logger.Field{
+			Key: "name",
+			Val: rec.Name,
+		}, logger.Field{
+			Key: "reason",
+			Val: rec.Reason,
+		}, logger.Field{
+			Key: "path",
+			Val: target,
+		})
+	return target, nil
+}
+
+// availablePath 同名文件或说明已存在时追加序号
+func availablePath(path string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		_, err := os.Stat(path)
+		_, reasonErr := os.Stat(path + ".reason.json")
+		if errors.Is(err, os.ErrNotExist) && errors.Is(reasonErr, os.ErrNotExist) {
+			return path
+		}
+		path = stem + "-" + strconv.Itoa(i) + ext
+	}
+}
+
+// moveFile 重命名文件，跨文件系统时复制后删除源文件
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
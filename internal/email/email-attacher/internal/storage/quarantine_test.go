@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"easyHR/pkg/logger"
+)
+
+func TestQuarantine(t *testing.T) {
+	q := NewQuarantine(filepath.Join(t.TempDir(), "_quarantine"), logger.NewNopLogger())
+	src := filepath.Join(t.TempDir(), "resume.pdf")
+	if err := os.WriteFile(src, []byte("MZ"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rec := QuarantineRecord{Name: "resume.pdf", Reason: "文件内容为Windows可执行文件", Provider: "qq", EmailID: 42}
+
+	path, err := q.Put(filepath.Join("qq", "42"), src, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("source file should be moved, got %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "MZ" {
+		t.Fatalf("unexpected quarantined file %q, %v", data, err)
+	}
+	var saved QuarantineRecord
+	data, _ := os.ReadFile(path + ".reason.json")
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Reason != rec.Reason || !saved.Stored || saved.QuarantinedAt.IsZero() {
+		t.Fatalf("unexpected record %+v, %v", saved, err)
+	}
+
+	// 未下载的附件只记录原因，同名时追加序号
+	rec.Reason = "附件大小约30000000字节，超过上限20971520字节"
+	second, err := q.Put(filepath.Join("qq", "42"), "", rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(second) != "resume-1.pdf" {
+		t.Fatalf("unexpected path %s", second)
+	}
+	if _, err := os.Stat(second); !os.IsNotExist(err) {
+		t.Fatalf("skipped attachment should not be stored, got %v", err)
+	}
+	if _, err := os.Stat(second + ".reason.json"); err != nil {
+		t.Fatal(err)
+	}
+}
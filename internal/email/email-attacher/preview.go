@@ -0,0 +1,61 @@
+package emailattacher
+
+import (
+	"fmt"
+	"io"
+
+	"easyHR/internal/email/email-attacher/config"
+	"easyHR/internal/email/email-attacher/domain"
+	internalConfig "easyHR/internal/email/email-attacher/internal/config"
+	"easyHR/internal/email/email-attacher/internal/factory"
+)
+
+// PreviewResult 单个服务商的搜索预览结果
+type PreviewResult struct {
+	Provider string         // 服务商类型
+	Username string         // 邮箱账号
+	Emails   []domain.Email // 匹配的邮件（不含附件）
+	Err      error          // 连接或搜索失败的原因
+}
+
+// Preview 按各服务商配置的搜索条件列出匹配的邮件，不下载附件、不标记已读，用于上线前验证搜索条件
+// 每个服务商最多返回limit封（最新的邮件优先），limit<=0时不限
+func Preview(cfg *config.AppConfig, limit int) ([]PreviewResult, error) {
+	if err := internalConfig.ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	internalCfg, err := internalConfig.InitInternalConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PreviewResult, 0, len(internalCfg.ProvidersConfig))
+	for _, provCfg := range internalCfg.ProvidersConfig {
+		res := PreviewResult{
+			Provider: provCfg.Type,
+			Username: provCfg.Account(),
+		}
+		res.Emails, res.Err = previewProvider(provCfg, limit)
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// previewProvider 连接单个服务商并执行搜索
+func previewProvider(provCfg internalConfig.ProviderConfig, limit int) ([]domain.Email, error) {
+	client, err := factory.NewEmailClient(provCfg.Type)
+	if err != nil {
+		return nil, err
+	}
+	previewer, ok := client.(domain.SearchPreviewer)
+	if !ok {
+		return nil, fmt.Errorf("服务商%s不支持搜索预览", provCfg.Type)
+	}
+	if err := client.Init(provCfg); err != nil {
+		return nil, fmt.Errorf("服务商%s初始化失败：%w", provCfg.Type, err)
+	}
+	if closer, ok := client.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return previewer.PreviewSearch(limit)
+}
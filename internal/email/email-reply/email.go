@@ -4,6 +4,8 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/smtp"
+
+	"easyHR/internal/email/mailauth"
 )
 
 // sendEmail 发送邮件
@@ -33,6 +35,9 @@ func sendEmail(to, subject, body string) error {
 
 	// 认证信息
 	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPServer)
+	if tokenSource != nil {
+		auth = mailauth.SMTPAuth(cfg.OAuth2.Mechanism, cfg.Username, tokenSource)
+	}
 
 	// 发送邮件
 	if cfg.UseTLS {
@@ -3,6 +3,8 @@ package emailreply
 import (
 	"errors"
 	"fmt"
+
+	"easyHR/internal/email/mailauth"
 )
 
 // Config 存储SMTP服务器连接参数和认证信息
@@ -10,10 +12,12 @@ type Config struct {
 	SMTPServer string // SMTP服务器地址
 	SMTPPort   int    // SMTP服务器端口
 	Username   string // 邮箱用户名
-	Password   string // 邮箱密码
+	Password   string // 邮箱密码（配置OAuth2时可为空）
 	UseTLS     bool   // 是否使用TLS
 	FromEmail  string // 发件人邮箱
 	FromName   string // 发件人名称
+	// OAuth2 使用OAuth2访问令牌（XOAUTH2/OAUTHBEARER）代替密码认证，可为空
+	OAuth2 *mailauth.OAuth2Config
 }
 
 // ReplyParams 存储邮件回复模板所需的动态数据
@@ -31,6 +35,8 @@ type ReplyParams struct {
 var (
 	// config 存储包的全局配置
 	config *Config
+	// tokenSource 配置OAuth2时的访问令牌来源
+	tokenSource mailauth.TokenSource
 	// ErrNotInitialized 表示包未初始化错误
 	ErrNotInitialized = errors.New("email-reply package not initialized")
 )
@@ -58,8 +64,8 @@ func Init(cfg *Config) error {
 	if cfg.Username == "" {
 		return errors.New("Username is required")
 	}
-	if cfg.Password == "" {
-		return errors.New("Password is required")
+	if cfg.Password == "" && cfg.OAuth2 == nil {
+		return errors.New("Password or OAuth2 is required")
 	}
 	if cfg.FromEmail == "" {
 		return errors.New("FromEmail is required")
@@ -68,8 +74,21 @@ func Init(cfg *Config) error {
 		return errors.New("FromName is required")
 	}
 
-	// 保存配置
-	config = cfg
+	// 保存配置（OAuth2配置补全默认值后保存副本）
+	c := *cfg
+	var source mailauth.TokenSource
+	if cfg.OAuth2 != nil {
+		oauth2Cfg, err := cfg.OAuth2.Complete()
+		if err != nil {
+			return fmt.Errorf("invalid OAuth2 config: %w", err)
+		}
+		if source, err = mailauth.NewTokenSource(oauth2Cfg); err != nil {
+			return fmt.Errorf("invalid OAuth2 config: %w", err)
+		}
+		c.OAuth2 = &oauth2Cfg
+	}
+	config = &c
+	tokenSource = source
 	return nil
 }
 
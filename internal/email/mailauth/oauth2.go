@@ -0,0 +1,219 @@
+// Package mailauth 邮件服务器的OAuth2认证：刷新令牌管理与XOAUTH2/OAUTHBEARER SASL机制，IMAP收信与SMTP发信共用
+package mailauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// SASL机制
+const (
+	MechanismXOAuth2     = "xoauth2"     // Gmail与Microsoft 365均支持
+	MechanismOAuthBearer = "oauthbearer" // RFC 7628，Gmail支持
+)
+
+// ErrTokenRejected 令牌地址拒绝刷新令牌（已撤销或过期），需重新授权，重试无意义
+var ErrTokenRejected = errors.New("刷新令牌被拒绝，需重新授权")
+
+// OAuth2服务商预设
+const (
+	ProviderGoogle    = "google"
+	ProviderMicrosoft = "microsoft"
+)
+
+// providerPreset 服务商的令牌地址与默认权限
+type providerPreset struct {
+	TokenURL string
+	Scopes   []string
+}
+
+var providerPresets = map[string]providerPreset{
+	ProviderGoogle: {
+		TokenURL: "https://oauth2.googleapis.com/token",
+		Scopes:   []string{"https://mail.google.com/"},
+	},
+	ProviderMicrosoft: {
+		TokenURL: "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+		Scopes: []string{
+			"https://outlook.office.com/IMAP.AccessAsUser.All",
+			"https://outlook.office.com/SMTP.Send",
+			"offline_access",
+		},
+	},
+}
+
+// OAuth2Config OAuth2认证配置，使用已授权的刷新令牌换取访问令牌
+type OAuth2Config struct {
+	Provider     string   `yaml:"provider"`      // google/microsoft，填写后可省略token_url与scopes
+	ClientID     string   `yaml:"client_id"`     // 必填
+	ClientSecret string   `yaml:"client_secret"` // 公共客户端可为空
+	TokenURL     string   `yaml:"token_url"`     // 令牌地址，未填写provider时必填
+	Scopes       []string `yaml:"scopes"`        // 刷新时请求的权限（可选）
+	RefreshToken string   `yaml:"refresh_token"` // 初始刷新令牌，token_file中已保存令牌时以文件为准
+	TokenFile    string   `yaml:"token_file"`    // 令牌保存文件（可选），刷新后写回，服务商轮换刷新令牌后重启仍可使用
+	Mechanism    string   `yaml:"mechanism"`     // SASL机制：xoauth2（默认）/oauthbearer
+}
+
+// Complete 补全服务商预设与默认机制并校验
+func (c OAuth2Config) Complete() (OAuth2Config, error) {
+	c.Provider = strings.ToLower(strings.TrimSpace(c.Provider))
+	c.Mechanism = strings.ToLower(strings.TrimSpace(c.Mechanism))
+	if c.Provider != "" {
+		preset, ok := providerPresets[c.Provider]
+		if !ok {
+			return c, fmt.Errorf("oauth2.provider不支持：%s（支持：%s/%s）", c.Provider, ProviderGoogle, ProviderMicrosoft)
+		}
+		if c.TokenURL == "" {
+			c.TokenURL = preset.TokenURL
+		}
+		if len(c.Scopes) == 0 {
+			c.Scopes = preset.Scopes
+		}
+	}
+	if c.Mechanism == "" {
+		c.Mechanism = MechanismXOAuth2
+	}
+
+	switch {
+	case c.Mechanism != MechanismXOAuth2 && c.Mechanism != MechanismOAuthBearer:
+		return c, fmt.Errorf("oauth2.mechanism不支持：%s（支持：%s/%s）", c.Mechanism, MechanismXOAuth2, MechanismOAuthBearer)
+	case c.ClientID == "":
+		return c, errors.New("oauth2.client_id不能为空")
+	case c.TokenURL == "":
+		return c, errors.New("oauth2.token_url不能为空（或填写provider）")
+	case c.RefreshToken == "" && c.TokenFile == "":
+		return c, errors.New("oauth2.refresh_token与token_file不能同时为空")
+	}
+	return c, nil
+}
+
+// TokenSource 提供访问令牌
+type TokenSource interface {
+	// Token 返回有效的访问令牌，过期时自动刷新
+	Token(ctx context.Context) (string, error)
+	// Invalidate 服务器拒绝令牌时调用，下次获取时强制刷新
+	Invalidate()
+}
+
+// RefreshTokenSource 用刷新令牌换取访问令牌，缓存到过期前，刷新后写回token_file
+type RefreshTokenSource struct {
+	mu    sync.Mutex
+	conf  *oauth2.Config
+	file  string
+	token *oauth2.Token
+}
+
+// NewTokenSource 按配置创建令牌源，token_file存在时从中恢复令牌
+func NewTokenSource(cfg OAuth2Config) (*RefreshTokenSource, error) {
+	cfg, err := cfg.Complete()
+	if err != nil {
+		return nil, err
+	}
+	s := &RefreshTokenSource{
+		conf: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: cfg.TokenURL},
+			Scopes:       cfg.Scopes,
+		},
+		file:  cfg.TokenFile,
+		token: &oauth2.Token{RefreshToken: cfg.RefreshToken},
+	}
+	if s.file != "" {
+		saved, err := loadToken(s.file)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.RefreshToken != "" {
+			s.token = saved
+		}
+	}
+	if s.token.RefreshToken == "" {
+		return nil, fmt.Errorf("token_file %s中没有刷新令牌，且未配置refresh_token", s.file)
+	}
+	return s, nil
+}
+
+// Token 返回有效的访问令牌，过期（或即将过期）时用刷新令牌换取新令牌
+func (s *RefreshTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() {
+		return s.token.AccessToken, nil
+	}
+
+	// 只带刷新令牌，强制向令牌地址刷新；响应未返回新的刷新令牌时沿用旧的
+	token, err := s.conf.TokenSource(ctx, &oauth2.Token{RefreshToken: s.token.RefreshToken}).Token()
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.Response != nil &&
+		(retrieveErr.Response.StatusCode == http.StatusBadRequest || retrieveErr.Response.StatusCode == http.StatusUnauthorized) {
+		return "", fmt.Errorf("%w：%w", ErrTokenRejected, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("刷新OAuth2令牌失败：%w", err)
+	}
+	s.token = token
+	if s.file != "" {
+		if err := saveToken(s.file, token); err != nil {
+			return "", err
+		}
+	}
+	return token.AccessToken, nil
+}
+
+// Invalidate 丢弃缓存的访问令牌
+func (s *RefreshTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = &oauth2.Token{RefreshToken: s.token.RefreshToken}
+}
+
+// loadToken 读取保存的令牌，文件不存在时返回nil
+func loadToken(path string) (*oauth2.Token, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌文件失败：%w", err)
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("解析令牌文件%s失败：%w", path, err)
+	}
+	return &token, nil
+}
+
+// saveToken 写入临时文件后重命名，仅所有者可读写
+func saveToken(path string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建令牌目录失败：%w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("保存令牌失败：%w", err)
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("保存令牌失败：%w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
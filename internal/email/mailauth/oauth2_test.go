@@ -0,0 +1,147 @@
+package mailauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// startTokenServer 模拟令牌地址：校验刷新令牌，每次刷新签发新的访问令牌并轮换刷新令牌
+func startTokenServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		n := refreshes.Load()
+		if r.Form.Get("refresh_token") != fmt.Sprintf("rt-%d", n) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		n = refreshes.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("at-%d", n),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+			"refresh_token": fmt.Sprintf("rt-%d", n),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &refreshes
+}
+
+func TestRefreshTokenSource(t *testing.T) {
+	srv, refreshes := startTokenServer(t)
+	ctx := context.Background()
+	cfg := OAuth2Config{
+		ClientID:     "easyhr",
+		TokenURL:     srv.URL,
+		RefreshToken: "rt-0",
+		TokenFile:    filepath.Join(t.TempDir(), "tokens", "hr.json"),
+	}
+
+	s, err := NewTokenSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 首次获取时刷新，未过期前复用缓存
+	for range 2 {
+		if token, err := s.Token(ctx); err != nil || token != "at-1" {
+			t.Fatalf("unexpected token %q, %v", token, err)
+		}
+	}
+	if refreshes.Load() != 1 {
+		t.Fatalf("expected 1 refresh, got %d", refreshes.Load())
+	}
+	// 令牌被拒绝后强制刷新，使用轮换后的刷新令牌
+	s.Invalidate()
+	if token, err := s.Token(ctx); err != nil || token != "at-2" {
+		t.Fatalf("unexpected token %q, %v", token, err)
+	}
+
+	// 重启后从token_file恢复：配置中的旧刷新令牌已失效，仍可继续使用
+	if info, err := os.Stat(cfg.TokenFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected token file %v, %v", info, err)
+	}
+	s, err = NewTokenSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := s.Token(ctx); err != nil || token != "at-2" {
+		t.Fatalf("expected persisted token, got %q, %v", token, err)
+	}
+	s.Invalidate()
+	if token, err := s.Token(ctx); err != nil || token != "at-3" {
+		t.Fatalf("unexpected token %q, %v", token, err)
+	}
+
+	// 刷新令牌被撤销
+	cfg.TokenFile = ""
+	s, _ = NewTokenSource(cfg)
+	if _, err := s.Token(ctx); !errors.Is(err, ErrTokenRejected) || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected invalid_grant, got %v", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	cfg, err := OAuth2Config{Provider: "Microsoft", ClientID: "easyhr", RefreshToken: "rt"}.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TokenURL != providerPresets[ProviderMicrosoft].TokenURL || len(cfg.Scopes) != 3 || cfg.Mechanism != MechanismXOAuth2 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	for _, c := range []OAuth2Config{
+		{ClientID: "easyhr", RefreshToken: "rt"},
+		{Provider: "google", RefreshToken: "rt"},
+		{Provider: "google", ClientID: "easyhr"},
+		{Provider: "google", ClientID: "easyhr", RefreshToken: "rt", Mechanism: "plain"},
+	} {
+		if _, err := c.Complete(); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
+
+func TestSMTPAuth(t *testing.T) {
+	srv, _ := startTokenServer(t)
+	s, err := NewTokenSource(OAuth2Config{ClientID: "easyhr", TokenURL: srv.URL, RefreshToken: "rt-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := SMTPAuth(MechanismXOAuth2, "hr@example.com", s)
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com"}); err == nil {
+		t.Fatal("expected token to be refused over plaintext")
+	}
+	mech, ir, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true})
+	if err != nil || mech != "XOAUTH2" || string(ir) != "user=hr@example.com\x01auth=Bearer at-1\x01\x01" {
+		t.Fatalf("unexpected initial response %s %q, %v", mech, ir, err)
+	}
+	// 服务器以质询返回错误时回复空响应，并在下次认证时刷新令牌
+	if resp, err := auth.Next([]byte(`{"status":"401"}`), true); err != nil || len(resp) != 0 {
+		t.Fatalf("unexpected response %q, %v", resp, err)
+	}
+	if _, ir, _ = auth.Start(&smtp.ServerInfo{Name: "localhost"}); !strings.Contains(string(ir), "at-2") {
+		t.Fatalf("expected refreshed token, got %q", ir)
+	}
+
+	auth = SMTPAuth(MechanismOAuthBearer, "hr@example.com", s)
+	if mech, ir, err = auth.Start(&smtp.ServerInfo{Name: "localhost"}); err != nil || mech != "OAUTHBEARER" || !strings.Contains(string(ir), "auth=Bearer at-2") {
+		t.Fatalf("unexpected initial response %s %q, %v", mech, ir, err)
+	}
+}
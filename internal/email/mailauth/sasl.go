@@ -0,0 +1,71 @@
+package mailauth
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+
+	"github.com/emersion/go-sasl"
+)
+
+// NewSASLClient 创建OAuth2 SASL客户端，mechanism为空时使用xoauth2
+func NewSASLClient(mechanism, username, token string) sasl.Client {
+	if mechanism == MechanismOAuthBearer {
+		return sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{Username: username, Token: token})
+	}
+	return &xoauth2Client{username: username, token: token}
+}
+
+// xoauth2Client Google与Microsoft定义的XOAUTH2机制
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+func (c *xoauth2Client) Start() (string, []byte, error) {
+	return "XOAUTH2", []byte("user=" + c.username + "\x01auth=Bearer " + c.token + "\x01\x01"), nil
+}
+
+// Next 认证失败时服务器以JSON质询返回错误详情，回复空响应后服务器给出最终的失败结果
+func (c *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	return []byte{}, nil
+}
+
+// smtpAuth 将SASL客户端适配为net/smtp认证，每次认证时获取访问令牌
+type smtpAuth struct {
+	mechanism string
+	username  string
+	source    TokenSource
+	client    sasl.Client
+}
+
+// SMTPAuth 返回使用OAuth2访问令牌的SMTP认证
+func SMTPAuth(mechanism, username string, source TokenSource) smtp.Auth {
+	return &smtpAuth{mechanism: mechanism, username: username, source: source}
+}
+
+func (a *smtpAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// 与smtp.PlainAuth一致：令牌只在TLS连接或本机上发送
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("未加密的连接，拒绝发送OAuth2令牌")
+	}
+	token, err := a.source.Token(context.Background())
+	if err != nil {
+		return "", nil, err
+	}
+	a.client = NewSASLClient(a.mechanism, a.username, token)
+	return a.client.Start()
+}
+
+func (a *smtpAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	// OAuth2机制只在令牌被拒绝时返回质询，下次认证时重新刷新
+	a.source.Invalidate()
+	return a.client.Next(fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
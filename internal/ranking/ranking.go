@@ -0,0 +1,311 @@
+package ranking
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"easyHR/internal/agent"
+	"easyHR/internal/agent/config"
+	skillset "easyHR/internal/agent/skills"
+)
+
+// 硬性筛选未通过的原因
+const (
+	FilterNoPrimaryReview = "no_primary_review"
+	FilterPrimaryScore    = "min_primary_score"
+	FilterConsensus       = "min_consensus"
+	FilterSpread          = "max_spread"
+	FilterSkillPrefix     = "required_skill:"
+)
+
+// 平局比较字段
+const (
+	TieBreakPrimary     = "primary"
+	TieBreakConsensus   = "consensus"
+	TieBreakNormalized  = "normalized"
+	TieBreakSubmittedAt = "submitted_at"
+)
+
+var defaultTieBreak = []string{TieBreakPrimary, TieBreakConsensus, TieBreakSubmittedAt}
+
+// Entry 某岗位排名中的一位候选人
+type Entry struct {
+	CandidateID     string    `json:"candidate_id" bson:"candidate_id"`
+	JobID           string    `json:"job_id" bson:"job_id"`
+	File            string    `json:"file" bson:"file"`
+	PrimaryScore    float64   `json:"primary_score" bson:"primary_score"`       // 主评审评分均值
+	ConsensusScore  float64   `json:"consensus_score" bson:"consensus_score"`   // 次级评审原始评分均值
+	NormalizedScore float64   `json:"normalized_score" bson:"normalized_score"` // 次级评审百分位均值
+	Spread          float64   `json:"spread" bson:"spread"`
+	Score           float64   `json:"score" bson:"score"` // 加权综合分
+	Passed          bool      `json:"passed" bson:"passed"`
+	FailedFilters   []string  `json:"failed_filters,omitempty" bson:"failed_filters,omitempty"`
+	Rank            int       `json:"rank" bson:"rank"` // 从1开始
+	SubmittedAt     time.Time `json:"submitted_at" bson:"submitted_at"`
+	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// ChangeCallback 岗位排名变化时的回调
+type ChangeCallback func(jobID string, entry Entry)
+
+// Ranker 按岗位维护候选人排名，每收到一次分析结果只重新计算该候选人并调整其位置
+type Ranker struct {
+	cfg config.RankingConfig
+
+	mu       sync.RWMutex
+	boards   map[string][]*Entry          // jobID -> 有序排名
+	index    map[string]map[string]*Entry // jobID -> candidateID -> entry
+	onChange ChangeCallback
+}
+
+// NewRanker 创建排名服务
+func NewRanker(cfg config.RankingConfig) *Ranker {
+	if cfg.ShortlistSize <= 0 {
+		cfg.ShortlistSize = 10
+	}
+	if len(cfg.TieBreak) == 0 {
+		cfg.TieBreak = defaultTieBreak
+	}
+	if cfg.Weights == (config.RankingWeights{}) {
+		cfg.Weights.Primary = 1
+	}
+	return &Ranker{
+		cfg:    cfg,
+		boards: make(map[string][]*Entry),
+		index:  make(map[string]map[string]*Entry),
+	}
+}
+
+// OnChange 注册排名变化回调
+func (r *Ranker) OnChange(cb ChangeCallback) {
+	r.onChange = cb
+}
+
+// Update 计入一份简历的分析结果，同一候选人再次分析时覆盖之前的结果
+// candidateID为空时以文件路径区分候选人，result为nil时忽略
+func (r *Ranker) Update(candidateID, file string, result *agent.AnalysisResult) Entry {
+	if result == nil {
+		return Entry{}
+	}
+	if candidateID == "" {
+		candidateID = file
+	}
+	now := time.Now()
+	e := r.evaluate(result)
+	e.CandidateID = candidateID
+	e.File = file
+	e.UpdatedAt = now
+
+	r.mu.Lock()
+	if r.index[e.JobID] == nil {
+		r.index[e.JobID] = make(map[string]*Entry)
+	}
+	if old, ok := r.index[e.JobID][candidateID]; ok {
+		e.SubmittedAt = old.SubmittedAt
+		r.remove(e.JobID, old)
+	} else {
+		e.SubmittedAt = now
+	}
+	r.insert(e)
+	r.index[e.JobID][candidateID] = e
+	snapshot := *e
+	r.mu.Unlock()
+
+	if r.onChange != nil {
+		r.onChange(snapshot.JobID, snapshot)
+	}
+	return snapshot
+}
+
+// Restore 载入已保存的排名条目（如重启后从存储恢复），已有的同一候选人条目被覆盖
+// 条目按保存时的分数与筛选结果重新排序，不会触发OnChange回调
+func (r *Ranker) Restore(entries []Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range entries {
+		e := entry
+		if r.index[e.JobID] == nil {
+			r.index[e.JobID] = make(map[string]*Entry)
+		}
+		if old, ok := r.index[e.JobID][e.CandidateID]; ok {
+			r.remove(e.JobID, old)
+		}
+		r.insert(&e)
+		r.index[e.JobID][e.CandidateID] = &e
+	}
+}
+
+// Remove 将候选人移出某岗位的排名
+func (r *Ranker) Remove(jobID, candidateID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.index[jobID][candidateID]
+	if !ok {
+		return
+	}
+	r.remove(jobID, e)
+	delete(r.index[jobID], candidateID)
+}
+
+// Ranking 返回某岗位的完整排名
+func (r *Ranker) Ranking(jobID string) []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	board := r.boards[jobID]
+	out := make([]Entry, len(board))
+	for i, e := range board {
+		out[i] = *e
+	}
+	return out
+}
+
+// Shortlist 返回某岗位通过硬性筛选的前n名，n<=0时使用配置的名单人数
+func (r *Ranker) Shortlist(jobID string, n int) []Entry {
+	if n <= 0 {
+		n = r.cfg.ShortlistSize
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Entry
+	for _, e := range r.boards[jobID] {
+		if len(out) == n {
+			break
+		}
+		if e.Passed {
+			out = append(out, *e)
+		}
+	}
+	return out
+}
+
+// Jobs 返回已有排名的岗位ID
+func (r *Ranker) Jobs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	jobs := make([]string, 0, len(r.boards))
+	for jobID := range r.boards {
+		jobs = append(jobs, jobID)
+	}
+	sort.Strings(jobs)
+	return jobs
+}
+
+// evaluate 根据分析结果计算各项分数与硬性筛选结果
+func (r *Ranker) evaluate(result *agent.AnalysisResult) *Entry {
+	e := &Entry{JobID: result.JobID}
+	e.ConsensusScore = result.Consensus.MeanScore
+	e.NormalizedScore = result.Consensus.MeanPercentile
+	e.Spread = result.Consensus.Spread
+
+	var n int
+	skills := make(map[string]bool)
+	for _, msg := range result.Primary {
+		if msg == nil || msg.Evaluation == nil {
+			continue
+		}
+		n++
+		e.PrimaryScore += float64(msg.Evaluation.MatchScore)
+		for _, s := range msg.Evaluation.Skills {
+			skills[skillset.Key(s)] = true
+		}
+	}
+	if n > 0 {
+		e.PrimaryScore /= float64(n)
+	}
+
+	w := r.cfg.Weights
+	if total := w.Primary + w.Consensus + w.Normalized; total > 0 {
+		e.Score = (w.Primary*e.PrimaryScore + w.Consensus*e.ConsensusScore + w.Normalized*e.NormalizedScore) / total
+	}
+
+	f := r.filters(e.JobID)
+	if n == 0 {
+		e.FailedFilters = append(e.FailedFilters, FilterNoPrimaryReview)
+	}
+	if f.MinPrimaryScore > 0 && e.PrimaryScore < f.MinPrimaryScore {
+		e.FailedFilters = append(e.FailedFilters, FilterPrimaryScore)
+	}
+	if f.MinConsensus > 0 && e.ConsensusScore < f.MinConsensus {
+		e.FailedFilters = append(e.FailedFilters, FilterConsensus)
+	}
+	if f.MaxSpread > 0 && e.Spread > f.MaxSpread {
+		e.FailedFilters = append(e.FailedFilters, FilterSpread)
+	}
+	for _, s := range f.RequiredSkills {
+		if !skills[skillset.Key(s)] {
+			e.FailedFilters = append(e.FailedFilters, FilterSkillPrefix+s)
+		}
+	}
+	e.Passed = len(e.FailedFilters) == 0
+	return e
+}
+
+// filters 岗位的硬性筛选条件，岗位未单独配置时使用通用条件
+func (r *Ranker) filters(jobID string) config.RankingFilters {
+	if f, ok := r.cfg.Jobs[jobID]; ok {
+		return f
+	}
+	return r.cfg.Filters
+}
+
+// less 排名比较：通过筛选的在前，其次综合分，再依次按平局字段比较
+func (r *Ranker) less(a, b *Entry) bool {
+	if a.Passed != b.Passed {
+		return a.Passed
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	for _, key := range r.cfg.TieBreak {
+		switch key {
+		case TieBreakPrimary:
+			if a.PrimaryScore != b.PrimaryScore {
+				return a.PrimaryScore > b.PrimaryScore
+			}
+		case TieBreakConsensus:
+			if a.ConsensusScore != b.ConsensusScore {
+				return a.ConsensusScore > b.ConsensusScore
+			}
+		case TieBreakNormalized:
+			if a.NormalizedScore != b.NormalizedScore {
+				return a.NormalizedScore > b.NormalizedScore
+			}
+		case TieBreakSubmittedAt:
+			if !a.SubmittedAt.Equal(b.SubmittedAt) {
+				return a.SubmittedAt.Before(b.SubmittedAt)
+			}
+		}
+	}
+	return a.CandidateID < b.CandidateID
+}
+
+// insert 二分查找插入位置并更新其后的名次，调用方持有写锁
+func (r *Ranker) insert(e *Entry) {
+	board := r.boards[e.JobID]
+	i := sort.Search(len(board), func(i int) bool { return r.less(e, board[i]) })
+	board = append(board, nil)
+	copy(board[i+1:], board[i:])
+	board[i] = e
+	r.boards[e.JobID] = board
+	renumber(board, i)
+}
+
+// remove 移除排名中的条目并更新其后的名次，调用方持有写锁
+func (r *Ranker) remove(jobID string, e *Entry) {
+	board := r.boards[jobID]
+	for i, x := range board {
+		if x == e {
+			board = append(board[:i], board[i+1:]...)
+			r.boards[jobID] = board
+			renumber(board, i)
+			return
+		}
+	}
+}
+
+func renumber(board []*Entry, from int) {
+	for i := from; i < len(board); i++ {
+		board[i].Rank = i + 1
+	}
+}
@@ -0,0 +1,95 @@
+package ranking
+
+import (
+	"testing"
+
+	"easyHR/internal/agent"
+	"easyHR/internal/agent/config"
+)
+
+const jobID = "SoftWareDeveloper_jobId"
+
+func result(primary int, consensus float64, skills ...string) *agent.AnalysisResult {
+	return &agent.AnalysisResult{
+		JobID: jobID,
+		Primary: []*agent.Message{{
+			Evaluation: &agent.CandidateEvaluation{MatchScore: primary, Skills: skills},
+		}},
+		Consensus: agent.Consensus{Reviewers: 2, MeanScore: consensus, MeanPercentile: consensus},
+	}
+}
+
+func ids(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.CandidateID
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRanker(t *testing.T) {
+	cfg := config.RankingConfig{
+		Weights:       config.RankingWeights{Primary: 1, Consensus: 1},
+		Filters:       config.RankingFilters{MinPrimaryScore: 60, RequiredSkills: []string{"go"}},
+		TieBreak:      []string{TieBreakPrimary, TieBreakSubmittedAt},
+		ShortlistSize: 2,
+	}
+	r := NewRanker(cfg)
+
+	r.Update("a", "a.pdf", result(80, 70, "Go"))
+	r.Update("b", "b.pdf", result(90, 60, "Go")) // 综合分与a相同，主评审分更高
+	r.Update("c", "c.pdf", result(95, 95))       // 缺少必备技能
+	r.Update("d", "d.pdf", result(70, 70, "Go"))
+	r.Update("e", "e.pdf", result(50, 90, "Go")) // 主评审分不达标
+
+	if got, want := ids(r.Ranking(jobID)), []string{"b", "a", "d", "c", "e"}; !equal(got, want) {
+		t.Fatalf("ranking = %v, want %v", got, want)
+	}
+	if got, want := ids(r.Shortlist(jobID, 0)), []string{"b", "a"}; !equal(got, want) {
+		t.Fatalf("shortlist = %v, want %v", got, want)
+	}
+
+	// d再次分析后分数提高，增量调整名次
+	e := r.Update("d", "d-v2.pdf", result(95, 90, "Go"))
+	if e.Rank != 1 {
+		t.Fatalf("expected d to move to rank 1, got %d", e.Rank)
+	}
+	ranking := r.Ranking(jobID)
+	if got, want := ids(ranking), []string{"d", "b", "a", "c", "e"}; !equal(got, want) {
+		t.Fatalf("ranking = %v, want %v", got, want)
+	}
+	for i, e := range ranking {
+		if e.Rank != i+1 {
+			t.Fatalf("entry %s has rank %d at position %d", e.CandidateID, e.Rank, i+1)
+		}
+	}
+	if ranking[3].Passed || ranking[3].FailedFilters[0] != FilterSkillPrefix+"go" {
+		t.Fatalf("unexpected filters for c: %+v", ranking[3])
+	}
+
+	// 重启后按保存的条目恢复，顺序与名次不变
+	restored := NewRanker(cfg)
+	saved := r.Ranking(jobID)
+	for i, j := 0, len(saved)-1; i < j; i, j = i+1, j-1 {
+		saved[i], saved[j] = saved[j], saved[i]
+	}
+	restored.Restore(saved)
+	if got, want := ids(restored.Ranking(jobID)), []string{"d", "b", "a", "c", "e"}; !equal(got, want) {
+		t.Fatalf("restored ranking = %v, want %v", got, want)
+	}
+	if got, want := ids(restored.Shortlist(jobID, 0)), []string{"d", "b"}; !equal(got, want) {
+		t.Fatalf("restored shortlist = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package ranking
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Storage 排名持久化使用的存储接口，由storage.Storage实现
+type Storage interface {
+	Insert(ctx context.Context, collection string, doc interface{}) error
+	FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error
+	Delete(ctx context.Context, collection string, filter interface{}) error
+}
+
+// MongoRepository 将排名条目保存在MongoDB集合中，每个岗位的每位候选人一条记录，重启后用于恢复排名
+type MongoRepository struct {
+	storage    Storage
+	collection string
+}
+
+// NewMongoRepository 创建排名存储，collection为空时使用rankings
+func NewMongoRepository(storage Storage, collection string) *MongoRepository {
+	if collection == "" {
+		collection = "rankings"
+	}
+	return &MongoRepository{storage: storage, collection: collection}
+}
+
+// SaveEntry 保存候选人在某岗位的最新条目，覆盖之前的记录
+func (m *MongoRepository) SaveEntry(ctx context.Context, e Entry) error {
+	if err := m.storage.Delete(ctx, m.collection, bson.M{"job_id": e.JobID, "candidate_id": e.CandidateID}); err != nil {
+		return err
+	}
+	return m.storage.Insert(ctx, m.collection, e)
+}
+
+// LoadEntries 读取所有岗位的排名条目
+func (m *MongoRepository) LoadEntries(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	if err := m.storage.FindMany(ctx, m.collection, bson.M{}, 0, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
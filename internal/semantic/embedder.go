@@ -0,0 +1,224 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Embedder turns texts into fixed-size vectors.
+type Embedder interface {
+	// Embed returns one vector per input text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Dimensions is the length of every vector returned by Embed.
+	Dimensions() int
+	// Name identifies the model; vectors from different models must not share an index.
+	Name() string
+}
+
+// HashEmbedder is a deterministic, dependency-free Embedder based on feature hashing
+// of character bigrams and words. It captures lexical overlap only, but needs no model.
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder creates a HashEmbedder with dims dimensions, defaulting to 256.
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = 256
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+func (h *HashEmbedder) Dimensions() int { return h.dims }
+
+func (h *HashEmbedder) Name() string { return fmt.Sprintf("hash-%d", h.dims) }
+
+func (h *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = h.embed(text)
+	}
+	return out, nil
+}
+
+func (h *HashEmbedder) embed(text string) []float32 {
+	vec := make([]float32, h.dims)
+	add := func(feature string) {
+		f := fnv.New64a()
+		f.Write([]byte(feature))
+		sum := f.Sum64()
+		idx := int(sum % uint64(h.dims))
+		// The top bit picks the sign so unrelated features tend to cancel out.
+		if sum>>63 == 1 {
+			vec[idx]--
+		} else {
+			vec[idx]++
+		}
+	}
+
+	for _, tok := range tokenize(text) {
+		runes := []rune(tok)
+		// Latin words are features on their own; Han text has no spaces, so use bigrams.
+		if !unicode.Is(unicode.Han, runes[0]) {
+			add("w:" + tok)
+			continue
+		}
+		if len(runes) == 1 {
+			add("c:" + tok)
+		}
+		for j := 0; j+1 < len(runes); j++ {
+			add("b:" + string(runes[j:j+2]))
+		}
+	}
+	normalize(vec)
+	return vec
+}
+
+// tokenize lowercases text and splits it into runs of Han characters and runs of other
+// letters or digits, so "熟悉Go语言" yields "熟悉", "go", "语言".
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		cur    []rune
+		curHan bool
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			tokens = append(tokens, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#' {
+			flush()
+			continue
+		}
+		han := unicode.Is(unicode.Han, r)
+		if len(cur) > 0 && han != curHan {
+			flush()
+		}
+		curHan = han
+		cur = append(cur, r)
+	}
+	flush()
+	return tokens
+}
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint.
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	dims    int
+	client  *http.Client
+}
+
+// NewOpenAIEmbedder creates an embedder for baseURL (e.g. "https://api.openai.com/v1").
+// dims must match the model's output size.
+func NewOpenAIEmbedder(baseURL, apiKey, model string, dims int) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		dims:    dims,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (o *OpenAIEmbedder) Dimensions() int { return o.dims }
+
+func (o *OpenAIEmbedder) Name() string { return o.model }
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (o *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(embeddingRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed embeddingResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || parsed.Error != nil {
+		msg := resp.Status
+		if parsed.Error != nil {
+			msg = parsed.Error.Message
+		}
+		return nil, fmt.Errorf("embedding request failed: %s", msg)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(parsed.Data))
+	}
+
+	out := make([][]float32, len(texts))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(out) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		if o.dims > 0 && len(d.Embedding) != o.dims {
+			return nil, fmt.Errorf("expected %d dimensions, got %d", o.dims, len(d.Embedding))
+		}
+		normalize(d.Embedding)
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
+
+// normalize scales vec to unit length in place so dot products are cosine similarities.
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i := range vec {
+		vec[i] *= inv
+	}
+}
@@ -0,0 +1,264 @@
+package semantic
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Kinds of indexed documents.
+const (
+	KindCV  = "cv"
+	KindJob = "job"
+)
+
+// ErrModelMismatch is returned when loading an index built with a different embedder.
+var ErrModelMismatch = errors.New("index was built with a different embedding model")
+
+// Hit is one search result.
+type Hit struct {
+	DocID string
+	Kind  string
+	Chunk int
+	Score float32 // cosine similarity
+}
+
+// entry is a stored vector quantized to int8 with a per-vector scale.
+type entry struct {
+	DocID  string
+	Kind   string
+	Chunk  int
+	Scale  float32
+	Vector []int8
+}
+
+// FlatIndex is an exact nearest-neighbour index over int8-quantized unit vectors.
+// A linear scan over a few hundred thousand chunks takes milliseconds, which is
+// plenty for a single HR team's resume pool, and keeps updates trivial.
+type FlatIndex struct {
+	mu      sync.RWMutex
+	model   string
+	dims    int
+	entries []entry
+	docs    map[string][]int // kind/docID -> entry positions
+}
+
+// NewFlatIndex creates an empty index for vectors of dims dimensions from model.
+func NewFlatIndex(model string, dims int) *FlatIndex {
+	return &FlatIndex{model: model, dims: dims, docs: make(map[string][]int)}
+}
+
+func docKey(kind, docID string) string { return kind + "/" + docID }
+
+// quantize maps a unit vector onto int8 using its largest component as the scale.
+func quantize(vec []float32) ([]int8, float32) {
+	var maxAbs float32
+	for _, v := range vec {
+		if a := float32(math.Abs(float64(v))); a > maxAbs {
+			maxAbs = a
+		}
+	}
+	q := make([]int8, len(vec))
+	if maxAbs == 0 {
+		return q, 0
+	}
+	scale := maxAbs / 127
+	for i, v := range vec {
+		q[i] = int8(math.Round(float64(v / scale)))
+	}
+	return q, scale
+}
+
+// Put replaces all chunks of a document with vectors.
+func (x *FlatIndex) Put(kind, docID string, vectors [][]float32) error {
+	for _, v := range vectors {
+		if len(v) != x.dims {
+			return fmt.Errorf("expected %d dimensions, got %d", x.dims, len(v))
+		}
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.deleteLocked(kind, docID)
+	key := docKey(kind, docID)
+	for i, v := range vectors {
+		q, scale := quantize(v)
+		x.docs[key] = append(x.docs[key], len(x.entries))
+		x.entries = append(x.entries, entry{DocID: docID, Kind: kind, Chunk: i, Scale: scale, Vector: q})
+	}
+	return nil
+}
+
+// Delete removes all chunks of a document.
+func (x *FlatIndex) Delete(kind, docID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.deleteLocked(kind, docID)
+}
+
+func (x *FlatIndex) deleteLocked(kind, docID string) {
+	key := docKey(kind, docID)
+	if _, ok := x.docs[key]; !ok {
+		return
+	}
+	kept := x.entries[:0]
+	for _, e := range x.entries {
+		if e.Kind != kind || e.DocID != docID {
+			kept = append(kept, e)
+		}
+	}
+	x.entries = kept
+	x.reindexLocked()
+}
+
+func (x *FlatIndex) reindexLocked() {
+	x.docs = make(map[string][]int, len(x.docs))
+	for i, e := range x.entries {
+		key := docKey(e.Kind, e.DocID)
+		x.docs[key] = append(x.docs[key], i)
+	}
+}
+
+// Vectors returns the dequantized chunk vectors of a document.
+func (x *FlatIndex) Vectors(kind, docID string) [][]float32 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var out [][]float32
+	for _, i := range x.docs[docKey(kind, docID)] {
+		e := x.entries[i]
+		v := make([]float32, len(e.Vector))
+		for j, q := range e.Vector {
+			v[j] = float32(q) * e.Scale
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// Len returns the number of stored chunks.
+func (x *FlatIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Search returns the k chunks of the given kind most similar to query.
+// An empty kind searches every document.
+func (x *FlatIndex) Search(query []float32, kind string, k int) []Hit {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	hits := make([]Hit, 0, len(x.entries))
+	for _, e := range x.entries {
+		if kind != "" && e.Kind != kind {
+			continue
+		}
+		hits = append(hits, Hit{DocID: e.DocID, Kind: e.Kind, Chunk: e.Chunk, Score: dot(query, e)})
+	}
+	return topK(hits, k)
+}
+
+// SearchDocs scores each document by its best-matching chunk against any of the
+// query vectors and returns the k best documents. Documents in exclude are skipped.
+func (x *FlatIndex) SearchDocs(queries [][]float32, kind string, k int, exclude map[string]bool) []Hit {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	best := make(map[string]Hit)
+	for _, e := range x.entries {
+		if (kind != "" && e.Kind != kind) || exclude[e.DocID] {
+			continue
+		}
+		key := docKey(e.Kind, e.DocID)
+		for _, q := range queries {
+			s := dot(q, e)
+			if h, ok := best[key]; !ok || s > h.Score {
+				best[key] = Hit{DocID: e.DocID, Kind: e.Kind, Chunk: e.Chunk, Score: s}
+			}
+		}
+	}
+	hits := make([]Hit, 0, len(best))
+	for _, h := range best {
+		hits = append(hits, h)
+	}
+	return topK(hits, k)
+}
+
+func dot(query []float32, e entry) float32 {
+	var sum float32
+	for i, q := range e.Vector {
+		sum += query[i] * float32(q)
+	}
+	return sum * e.Scale
+}
+
+func topK(hits []Hit, k int) []Hit {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].DocID < hits[j].DocID
+	})
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// indexFile is the on-disk format of a FlatIndex.
+type indexFile struct {
+	Model   string
+	Dims    int
+	Entries []entry
+}
+
+// Save writes the index to path atomically.
+func (x *FlatIndex) Save(path string) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	data := indexFile{Model: x.model, Dims: x.dims, Entries: x.entries}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(&data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFlatIndex reads an index saved by Save. A missing file yields an empty index.
+// The stored model and dimensions must match.
+func LoadFlatIndex(path, model string, dims int) (*FlatIndex, error) {
+	x := NewFlatIndex(model, dims)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var data indexFile
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if data.Model != model || data.Dims != dims {
+		return nil, fmt.Errorf("%w: %s/%d, want %s/%d", ErrModelMismatch, data.Model, data.Dims, model, dims)
+	}
+	x.entries = data.Entries
+	x.reindexLocked()
+	return x, nil
+}
@@ -0,0 +1,166 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easyHR/internal/agent/prompts/position"
+)
+
+func TestChunk(t *testing.T) {
+	text := strings.Repeat("一二三四五六七八九十\n", 30)
+	chunks := Chunk(text, 100, 10)
+	if len(chunks) < 3 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for _, c := range chunks {
+		if n := len([]rune(c)); n > 100 {
+			t.Fatalf("chunk has %d runes", n)
+		}
+	}
+	if got := Chunk("  ", 100, 10); len(got) != 0 {
+		t.Fatalf("expected no chunks for blank text, got %v", got)
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.gob")
+	svc, err := NewService(NewHashEmbedder(256), Config{IndexPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := map[string]string{
+		"go-1":   "熟悉Go语言，使用gRPC开发微服务，熟悉MySQL和Redis缓存设计",
+		"go-2":   "使用Go语言和gRPC构建微服务，负责Redis缓存与MySQL分库分表",
+		"design": "擅长Photoshop与Figma，负责App视觉设计和品牌插画",
+	}
+	for id, text := range docs {
+		if err := svc.IndexCV(ctx, id, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	similar := svc.SimilarCVs("go-1", 2)
+	if len(similar) != 2 || similar[0].DocID != "go-2" {
+		t.Fatalf("unexpected similar CVs: %+v", similar)
+	}
+
+	hits, err := svc.Search(ctx, "Go 微服务 Redis", KindCV, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].DocID == "design" {
+		t.Fatalf("unexpected search result: %+v", hits)
+	}
+
+	// 持久化后重新加载，结果一致
+	if err := svc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewService(NewHashEmbedder(256), Config{IndexPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Index().Len() != svc.Index().Len() {
+		t.Fatalf("loaded %d chunks, want %d", loaded.Index().Len(), svc.Index().Len())
+	}
+	if got := loaded.SimilarCVs("go-1", 1); len(got) != 1 || got[0].DocID != "go-2" {
+		t.Fatalf("unexpected similar CVs after reload: %+v", got)
+	}
+
+	// 模型不一致时拒绝加载
+	if _, err := NewService(NewHashEmbedder(128), Config{IndexPath: path}); !errors.Is(err, ErrModelMismatch) {
+		t.Fatalf("expected ErrModelMismatch, got %v", err)
+	}
+}
+
+func TestService_AutoSaveAndWeakMatch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.gob")
+	svc, err := NewService(NewHashEmbedder(256), Config{IndexPath: path, SaveInterval: 10 * time.Millisecond, MinSimilarity: 0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.Start(func(err error) { t.Error(err) })
+
+	job := &position.JobPosition{Title: "后端研发", Description: "使用Go语言和gRPC开发微服务", Requirements: position.JobRequirements{Skills: []string{"Go", "Redis", "MySQL"}}}
+	if err := svc.IndexJob(ctx, "backend", job); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.IndexCV(ctx, "go", "熟悉Go语言，使用gRPC开发微服务，熟悉MySQL和Redis缓存设计"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.IndexCV(ctx, "design", "擅长Photoshop与Figma，负责App视觉设计和品牌插画"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未关闭时也会定期保存，进程崩溃最多丢失一个周期的向量
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		loaded, err := NewService(NewHashEmbedder(256), Config{IndexPath: path})
+		if err == nil && loaded.Index().Len() == svc.Index().Len() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("index was not saved periodically: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := svc.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if best, weak := svc.WeakMatch("go"); weak || best.DocID != "backend" {
+		t.Fatalf("expected go CV to match backend, got %+v, weak=%t", best, weak)
+	}
+	if best, weak := svc.WeakMatch("design"); !weak {
+		t.Fatalf("expected design CV to be a weak match, got %+v", best)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		// 倒序返回，验证按index还原顺序
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float32{float32(i + 1), 0, 0}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEmbedder(srv.URL+"/v1/", "key", "test-model", 3)
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vecs) != 2 || vecs[0][0] != 1 || vecs[1][0] != 1 {
+		t.Fatalf("unexpected vectors: %v", vecs)
+	}
+
+	bad := NewOpenAIEmbedder(srv.URL+"/v1", "wrong", "test-model", 3)
+	if _, err := bad.Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Fatalf("expected API error, got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"easyHR/internal/agent/prompts/position"
+)
+
+// Config configures embeddings and the vector index.
+type Config struct {
+	Enabled      bool   `yaml:"enabled"`
+	Provider     string `yaml:"provider"` // "openai" for an OpenAI-compatible API, "hash" (default) for the local stand-in
+	BaseURL      string `yaml:"base_url"`
+	APIKey       string `yaml:"api_key"`
+	Model        string `yaml:"model"`
+	Dimensions   int    `yaml:"dimensions"`
+	IndexPath    string `yaml:"index_path"`    // where the index is persisted; empty keeps it in memory only
+	ChunkSize    int    `yaml:"chunk_size"`    // runes per chunk, default 500
+	ChunkOverlap int    `yaml:"chunk_overlap"` // runes shared by neighbouring chunks, default 50
+	// SaveInterval is how often unsaved changes are written to IndexPath, 1m by default,
+	// so a crash loses at most one interval of embeddings.
+	SaveInterval time.Duration `yaml:"save_interval"`
+	// MinSimilarity marks CVs whose closest job scores below it as weak matches,
+	// which are reviewed after everything else. 0 disables pre-ranking.
+	MinSimilarity float64 `yaml:"min_similarity"`
+}
+
+// NewEmbedder builds the Embedder selected by cfg.
+func NewEmbedder(cfg Config) (Embedder, error) {
+	switch cfg.Provider {
+	case "", "hash":
+		return NewHashEmbedder(cfg.Dimensions), nil
+	case "openai":
+		if cfg.BaseURL == "" || cfg.Model == "" || cfg.Dimensions <= 0 {
+			return nil, errors.New("openai embedder requires base_url, model and dimensions")
+		}
+		return NewOpenAIEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", cfg.Provider)
+	}
+}
+
+// Chunk splits text into pieces of at most size runes, preferring to break at line
+// ends, with overlap runes repeated at the start of each following chunk.
+func Chunk(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = 500
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			end = len(runes)
+		} else {
+			// Back up to the last line break in the second half of the window.
+			for i := end; i > start+size/2; i-- {
+				if runes[i-1] == '\n' {
+					end = i
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// JobText renders the parts of a job position that matter for matching.
+func JobText(job *position.JobPosition) string {
+	var sb strings.Builder
+	sb.WriteString(job.Title)
+	sb.WriteString("\n")
+	sb.WriteString(job.Description)
+	sb.WriteString("\n")
+	for _, r := range job.Responsibilities {
+		sb.WriteString(r)
+		sb.WriteString("\n")
+	}
+	sb.WriteString(job.Requirements.Education)
+	sb.WriteString("\n")
+	sb.WriteString(job.Requirements.Experience)
+	sb.WriteString("\n")
+	sb.WriteString(strings.Join(job.Requirements.Skills, "\n"))
+	return sb.String()
+}
+
+// Service embeds CVs and job positions and answers similarity queries.
+type Service struct {
+	embedder Embedder
+	index    *FlatIndex
+	cfg      Config
+	saveMu   sync.Mutex
+	dirty    atomic.Bool // the index has changes not yet saved
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewService creates a Service, loading the persisted index from cfg.IndexPath if present.
+func NewService(embedder Embedder, cfg Config) (*Service, error) {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = 500
+	}
+	if cfg.ChunkOverlap <= 0 {
+		cfg.ChunkOverlap = 50
+	}
+	if cfg.SaveInterval <= 0 {
+		cfg.SaveInterval = time.Minute
+	}
+	index := NewFlatIndex(embedder.Name(), embedder.Dimensions())
+	if cfg.IndexPath != "" {
+		var err error
+		if index, err = LoadFlatIndex(cfg.IndexPath, embedder.Name(), embedder.Dimensions()); err != nil {
+			return nil, err
+		}
+	}
+	return &Service{embedder: embedder, index: index, cfg: cfg}, nil
+}
+
+// Index returns the underlying vector index.
+func (s *Service) Index() *FlatIndex {
+	return s.index
+}
+
+// IndexCV embeds every chunk of a CV's text, replacing any earlier vectors for cvID.
+func (s *Service) IndexCV(ctx context.Context, cvID, text string) error {
+	chunks := Chunk(text, s.cfg.ChunkSize, s.cfg.ChunkOverlap)
+	if len(chunks) == 0 || !hasLetters(text) {
+		return fmt.Errorf("cv %s has no text to embed", cvID)
+	}
+	vectors, err := s.embedder.Embed(ctx, chunks)
+	if err != nil {
+		return err
+	}
+	if err := s.index.Put(KindCV, cvID, vectors); err != nil {
+		return err
+	}
+	s.dirty.Store(true)
+	return nil
+}
+
+// IndexJob embeds a job position as a single vector.
+func (s *Service) IndexJob(ctx context.Context, jobID string, job *position.JobPosition) error {
+	vectors, err := s.embedder.Embed(ctx, []string{JobText(job)})
+	if err != nil {
+		return err
+	}
+	if err := s.index.Put(KindJob, jobID, vectors); err != nil {
+		return err
+	}
+	s.dirty.Store(true)
+	return nil
+}
+
+// IndexJobs embeds every embedded job position.
+func (s *Service) IndexJobs(ctx context.Context) error {
+	ids, err := position.ListJobIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		job, err := position.LoadJobPosition(id)
+		if err != nil {
+			return err
+		}
+		if err := s.IndexJob(ctx, id, job); err != nil {
+			return fmt.Errorf("failed to index job %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// SimilarCVs returns up to k other CVs most similar to cvID.
+func (s *Service) SimilarCVs(cvID string, k int) []Hit {
+	vectors := s.index.Vectors(KindCV, cvID)
+	if len(vectors) == 0 {
+		return nil
+	}
+	return s.index.SearchDocs(vectors, KindCV, k, map[string]bool{cvID: true})
+}
+
+// WeakMatch pre-ranks an indexed CV before LLM review: it returns the CV's
+// closest job and whether that job is less similar than cfg.MinSimilarity.
+// It never reports a weak match when MinSimilarity is unset or no job is indexed.
+func (s *Service) WeakMatch(cvID string) (Hit, bool) {
+	jobs := s.MatchJobs(cvID, 1)
+	if len(jobs) == 0 {
+		return Hit{}, false
+	}
+	return jobs[0], s.cfg.MinSimilarity > 0 && float64(jobs[0].Score) < s.cfg.MinSimilarity
+}
+
+// MatchJobs returns up to k jobs most similar to cvID.
+func (s *Service) MatchJobs(cvID string, k int) []Hit {
+	vectors := s.index.Vectors(KindCV, cvID)
+	if len(vectors) == 0 {
+		return nil
+	}
+	return s.index.SearchDocs(vectors, KindJob, k, nil)
+}
+
+// Search embeds free text and returns the k most similar documents of kind.
+func (s *Service) Search(ctx context.Context, text, kind string, k int) ([]Hit, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return s.index.SearchDocs(vectors, kind, k, nil), nil
+}
+
+// Save persists the index to cfg.IndexPath, if configured.
+func (s *Service) Save() error {
+	if s.cfg.IndexPath == "" {
+		return nil
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.dirty.Store(false)
+	if err := s.index.Save(s.cfg.IndexPath); err != nil {
+		s.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// Start saves the index every cfg.SaveInterval while it has unsaved changes.
+// onError reports failed saves, which are retried on the next tick.
+func (s *Service) Start(onError func(error)) {
+	if s.cfg.IndexPath == "" || s.stop != nil {
+		return
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.SaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !s.dirty.Load() {
+					continue
+				}
+				if err := s.Save(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic save started by Start and saves any remaining changes.
+func (s *Service) Stop() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	if !s.dirty.Load() {
+		return nil
+	}
+	return s.Save()
+}
+
+func hasLetters(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -28,6 +28,8 @@ type Storage interface {
 	Insert(ctx context.Context, collection string, doc interface{}) error
 	// Find 查询文档
 	Find(ctx context.Context, collection string, filter interface{}) (interface{}, error)
+	// FindMany 查询多个文档并解码到results（切片指针），limit<=0表示不限制数量
+	FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error
 	// Update 更新文档
 	Update(ctx context.Context, collection string, filter interface{}, update interface{}) error
 	// Delete 删除文档
@@ -46,6 +46,20 @@ func (s *MongoStore) Find(ctx context.Context, collection string, filter interfa
 	return result, nil
 }
 
+// FindMany 查询多个文档
+func (s *MongoStore) FindMany(ctx context.Context, collection string, filter interface{}, limit int64, results interface{}) error {
+	coll := s.db.Collection(collection)
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
 // Update 更新文档
 func (s *MongoStore) Update(ctx context.Context, collection string, filter interface{}, update interface{}) error {
 	coll := s.db.Collection(collection)